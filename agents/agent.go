@@ -0,0 +1,48 @@
+package agents
+
+// DefaultMaxIterations bounds the tool-call loop when an agent doesn't
+// specify its own limit
+const DefaultMaxIterations = 6
+
+// Agent describes an LLM persona allowed to call a restricted set of tools
+//
+// Poля:
+//   - Name: идентификатор агента (используется в логах)
+//   - SystemPrompt: системный промпт, задающий роль агента
+//   - AllowedTools: имена инструментов, доступных агенту (пусто = все инструменты из Toolbox)
+//   - MaxIterations: ограничение на число раундов tool-calling перед тем, как вернуть последний ответ модели
+//   - Toolbox: реестр инструментов, из которого выбираются AllowedTools
+type Agent struct {
+	Name          string
+	SystemPrompt  string
+	AllowedTools  []string
+	MaxIterations int
+	Toolbox       *Toolbox
+}
+
+// NewAgent creates an agent bound to a toolbox with a restricted tool set
+func NewAgent(name, systemPrompt string, toolbox *Toolbox, allowedTools []string) *Agent {
+	return &Agent{
+		Name:          name,
+		SystemPrompt:  systemPrompt,
+		AllowedTools:  allowedTools,
+		MaxIterations: DefaultMaxIterations,
+		Toolbox:       toolbox,
+	}
+}
+
+// Tools returns the tools this agent is allowed to call
+func (a *Agent) Tools() []Tool {
+	if a == nil || a.Toolbox == nil {
+		return nil
+	}
+	return a.Toolbox.Filter(a.AllowedTools)
+}
+
+// Iterations returns the configured max-iterations, falling back to the default
+func (a *Agent) Iterations() int {
+	if a == nil || a.MaxIterations <= 0 {
+		return DefaultMaxIterations
+	}
+	return a.MaxIterations
+}
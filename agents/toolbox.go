@@ -0,0 +1,84 @@
+// Package agents provides tool-calling agents for LLM-driven game mastering
+package agents
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// ToolHandler executes a tool call with raw JSON arguments and returns the
+// result text that should be fed back to the model as a "tool" message
+type ToolHandler func(ctx context.Context, args json.RawMessage) (string, error)
+
+// Tool describes a single callable action exposed to an LLM provider
+//
+// Parameters holds the JSON-schema describing the tool's arguments, matching
+// the shape expected by openai.FunctionDefinition.Parameters
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage
+	Handler     ToolHandler
+}
+
+// Toolbox is a registry of tools keyed by name
+type Toolbox struct {
+	mu    sync.RWMutex
+	tools map[string]Tool
+}
+
+// NewToolbox creates an empty tool registry
+func NewToolbox() *Toolbox {
+	return &Toolbox{
+		tools: make(map[string]Tool),
+	}
+}
+
+// Register adds or replaces a tool in the toolbox
+func (tb *Toolbox) Register(tool Tool) {
+	tb.mu.Lock()
+	defer tb.mu.Unlock()
+	tb.tools[tool.Name] = tool
+}
+
+// Get returns a tool by name
+func (tb *Toolbox) Get(name string) (Tool, bool) {
+	tb.mu.RLock()
+	defer tb.mu.RUnlock()
+	tool, ok := tb.tools[name]
+	return tool, ok
+}
+
+// Call executes a registered tool by name
+func (tb *Toolbox) Call(ctx context.Context, name string, args json.RawMessage) (string, error) {
+	tool, ok := tb.Get(name)
+	if !ok {
+		return "", fmt.Errorf("unknown tool: %s", name)
+	}
+	return tool.Handler(ctx, args)
+}
+
+// Filter returns the subset of registered tools whose names are in allowed
+// If allowed is empty, all registered tools are returned
+func (tb *Toolbox) Filter(allowed []string) []Tool {
+	tb.mu.RLock()
+	defer tb.mu.RUnlock()
+
+	if len(allowed) == 0 {
+		tools := make([]Tool, 0, len(tb.tools))
+		for _, tool := range tb.tools {
+			tools = append(tools, tool)
+		}
+		return tools
+	}
+
+	tools := make([]Tool, 0, len(allowed))
+	for _, name := range allowed {
+		if tool, ok := tb.tools[name]; ok {
+			tools = append(tools, tool)
+		}
+	}
+	return tools
+}
@@ -0,0 +1,23 @@
+// Package chat abstracts the protocol a game.Session's players and GM
+// narration travel over, so game.SessionManager isn't hard-wired to
+// Telegram. telegram.Bot and xmpp.Bot both implement Transport, letting a
+// single session be attached to more than one at once (see
+// game.SessionManager.AttachTransport)
+package chat
+
+// Transport delivers text to one chat protocol. chatID identifies the
+// same game.Session across every Transport attached to it - Telegram uses
+// its own numeric chat ID, xmpp.RoomChatID derives an equivalent one from
+// a MUC room JID
+type Transport interface {
+	// Name identifies the transport for logging (e.g. "telegram", "xmpp")
+	Name() string
+
+	// SendMessage delivers text to chatID
+	SendMessage(chatID int64, text string) error
+
+	// SendReply delivers text to chatID as a reply to messageID, where the
+	// transport supports threaded replies. Transports that don't (e.g.
+	// XMPP groupchat) fall back to the same behavior as SendMessage
+	SendReply(messageID int, chatID int64, text string) error
+}
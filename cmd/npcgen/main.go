@@ -0,0 +1,118 @@
+// Command npcgen rolls a batch of NPCs straight to disk, skipping every
+// interactive prompt /newchar would otherwise ask - for GMs who need a
+// stack of throwaway stat blocks for a random encounter, not one carefully
+// built PC
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"wfrp-bot/config"
+	"wfrp-bot/game"
+	"wfrp-bot/game/content"
+	"wfrp-bot/llm"
+)
+
+func main() {
+	count := flag.Int("count", 1, "how many NPCs to generate")
+	race := flag.String("race", "any", "restrict to a race name, or \"any\"")
+	career := flag.String("career", "any", "restrict to a career class key (e.g. warrior), or \"any\"")
+	minStatus := flag.Int("min-status", 0, "minimum status level (0 = no floor)")
+	out := flag.String("out", "./npcs", "output directory")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig()
+	var llmProvider llm.LLMProvider
+	if err != nil {
+		log.Printf("Config not available (%v), NPCs will get fallback names", err)
+	} else {
+		providerCfg := cfg.Providers[cfg.DefaultProvider]
+		provider, perr := llm.NewProviderFromConfig(&llm.ProviderConfig{
+			Name:              providerCfg.Name,
+			APIKey:            providerCfg.APIKey,
+			BaseURL:           providerCfg.BaseURL,
+			Model:             providerCfg.Model,
+			RequestsPerMinute: providerCfg.RequestsPerMinute,
+		})
+		if perr != nil {
+			log.Printf("LLM provider unavailable (%v), NPCs will get fallback names", perr)
+		} else {
+			defer provider.Close()
+			llmProvider = provider
+		}
+	}
+
+	contentPath := os.Getenv("CONTENT_PATH")
+	if contentPath == "" {
+		contentPath = "./game/content/data"
+	}
+	contentProvider, err := content.NewFileContentLoader().Load(contentPath)
+	if err != nil {
+		log.Fatalf("Failed to load content data: %v", err)
+	}
+
+	cc := game.NewCharacterCreator("", contentProvider)
+	if llmProvider != nil {
+		cc.SetLLMProvider(llmProvider)
+	}
+
+	filter := game.NPCFilter{MinStatusLevel: *minStatus}
+	if *race != "any" {
+		filter.Race = *race
+	}
+	if *career != "any" {
+		filter.Class = *career
+	}
+
+	npcs, err := cc.GenerateBatch(context.Background(), *count, filter)
+	if err != nil {
+		log.Fatalf("Failed to generate NPCs: %v", err)
+	}
+
+	if err := os.MkdirAll(*out, 0755); err != nil {
+		log.Fatalf("Failed to create output directory %s: %v", *out, err)
+	}
+
+	for _, npc := range npcs {
+		filename := fmt.Sprintf("character_%s_%s.md", slugify(npc.Name), randomID())
+		path := filepath.Join(*out, filename)
+		markdown := (&game.CharacterCreator{Data: npc}).GenerateCharacterMarkdown()
+		if err := os.WriteFile(path, []byte(markdown), 0644); err != nil {
+			log.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	indexPath := filepath.Join(*out, "_index.md")
+	if err := os.WriteFile(indexPath, []byte(game.NPCsMarkdownTable(npcs)), 0644); err != nil {
+		log.Fatalf("Failed to write %s: %v", indexPath, err)
+	}
+
+	log.Printf("Generated %d NPC(s) in %s", len(npcs), *out)
+}
+
+// slugify turns an NPC name into a filesystem-safe fragment for its filename
+func slugify(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	name = strings.Map(func(r rune) rune {
+		if r == ' ' {
+			return '_'
+		}
+		return r
+	}, name)
+	if name == "" {
+		return "npc"
+	}
+	return name
+}
+
+// randomID returns a short hex tag to keep filenames unique across a batch
+func randomID() string {
+	return fmt.Sprintf("%08x", rand.Uint32())
+}
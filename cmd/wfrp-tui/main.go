@@ -0,0 +1,74 @@
+// Command wfrp-tui is an interactive terminal game-master console, built on
+// bubbletea/lipgloss, for running a WFRP session directly from a campaign
+// folder without going through the Telegram bot
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wfrp-bot/config"
+	"wfrp-bot/game"
+	"wfrp-bot/llm"
+	"wfrp-bot/storage"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: wfrp-tui <campaign>")
+		os.Exit(1)
+	}
+	campaign := os.Args[1]
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	providerCfg := cfg.Providers[cfg.DefaultProvider]
+	provider, err := llm.NewProviderFromConfig(&llm.ProviderConfig{
+		Name:              providerCfg.Name,
+		APIKey:            providerCfg.APIKey,
+		BaseURL:           providerCfg.BaseURL,
+		Model:             providerCfg.Model,
+		RequestsPerMinute: providerCfg.RequestsPerMinute,
+	})
+	if err != nil {
+		log.Fatalf("Failed to create LLM provider: %v", err)
+	}
+	defer provider.Close()
+
+	basePath := os.Getenv("BASE_PATH")
+	if basePath == "" {
+		basePath = "./storage"
+	}
+
+	campaignMgr := storage.NewCampaignManager(basePath)
+	if err := campaignMgr.Refresh(); err != nil {
+		log.Fatalf("Failed to load campaigns: %v", err)
+	}
+	if _, err := campaignMgr.GetCampaign(campaign); err != nil {
+		log.Fatalf("Failed to open campaign %q: %v", campaign, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	session := game.NewSession(ctx, 0, campaign, provider, campaignMgr)
+	session.Start()
+	defer session.Stop()
+
+	m, err := newModel(campaign, session, campaignMgr, basePath)
+	if err != nil {
+		log.Fatalf("Failed to initialize TUI: %v", err)
+	}
+
+	p := tea.NewProgram(m, tea.WithAltScreen())
+	if _, err := p.Run(); err != nil {
+		log.Fatalf("TUI exited with error: %v", err)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wfrp-bot/game"
+	"wfrp-bot/storage"
+)
+
+// mode is the vi-like modal state of the composer: normal mode drives
+// scrollback navigation, insert mode captures keystrokes into the input line
+type mode int
+
+const (
+	modeNormal mode = iota
+	modeInsert
+)
+
+// model is the bubbletea model backing the wfrp-tui session console
+type model struct {
+	campaign    string
+	session     *game.Session
+	campaignMgr *storage.CampaignManager
+	parser      *storage.MarkdownParser
+	basePath    string
+
+	sessions   []string
+	characters []string
+
+	transcript []string // rendered conversation lines, oldest first
+	scroll     int       // lines scrolled up from the bottom
+
+	mode  mode
+	input []rune
+
+	streaming   bool
+	streamChan  <-chan string
+	streamLine  strings.Builder
+	statusLine  string
+
+	width, height int
+}
+
+// newModel constructs the initial model and loads the campaign's sessions
+// and character list for the side panes
+func newModel(campaign string, session *game.Session, campaignMgr *storage.CampaignManager, basePath string) (*model, error) {
+	m := &model{
+		campaign:    campaign,
+		session:     session,
+		campaignMgr: campaignMgr,
+		parser:      storage.NewMarkdownParser(basePath),
+		basePath:    basePath,
+		mode:        modeNormal,
+	}
+
+	if sessions, err := campaignMgr.ListSessions(campaign); err == nil {
+		m.sessions = sessions
+	}
+	if characters, err := campaignMgr.ListCharacters(campaign); err == nil {
+		m.characters = characters
+	}
+
+	m.transcript = append(m.transcript, fmt.Sprintf("-- кампания %s --", campaign))
+
+	return m, nil
+}
+
+// Init starts the bubbletea program with no pending commands
+func (m *model) Init() tea.Cmd {
+	return nil
+}
+
+// streamChunkMsg carries one token from an in-flight StreamResponse, plus the
+// channel itself so Update can keep listening for the next one
+type streamChunkMsg struct {
+	chunk string
+	ch    <-chan string
+}
+
+// streamDoneMsg signals that the active stream's channel has closed
+type streamDoneMsg struct{}
+
+// rollResultMsg carries the result of a /roll command
+type rollResultMsg struct {
+	expr   string
+	result string
+	err    error
+}
+
+// editorDoneMsg carries the text composed in $EDITOR, ready to be sent the
+// same way as a typed line
+type editorDoneMsg struct {
+	text string
+	err  error
+}
+
+// listenForChunk returns a command that blocks for the next value on ch
+func listenForChunk(ch <-chan string) tea.Cmd {
+	return func() tea.Msg {
+		chunk, ok := <-ch
+		if !ok {
+			return streamDoneMsg{}
+		}
+		return streamChunkMsg{chunk: chunk, ch: ch}
+	}
+}
+
+// sendToGM starts streaming a GM response to text and returns the command
+// that begins listening for its first chunk
+func (m *model) sendToGM(text string) tea.Cmd {
+	m.transcript = append(m.transcript, "Игрок: "+text)
+
+	ch, err := m.session.StreamResponse(game.InputData{
+		Source:    "player",
+		Content:   text,
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		m.transcript = append(m.transcript, "Ошибка: "+err.Error())
+		return nil
+	}
+
+	m.streaming = true
+	m.streamChan = ch
+	m.streamLine.Reset()
+	m.transcript = append(m.transcript, "ГМ: ")
+
+	return listenForChunk(ch)
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"wfrp-bot/game"
+)
+
+// Update handles bubbletea messages: window resizes, the active stream, and
+// key input routed by the current mode (vi-like normal mode for scrollback,
+// insert mode for composing a line)
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		return m, nil
+
+	case streamChunkMsg:
+		m.streamLine.WriteString(msg.chunk)
+		m.transcript[len(m.transcript)-1] = "ГМ: " + m.streamLine.String()
+		return m, listenForChunk(msg.ch)
+
+	case streamDoneMsg:
+		m.streaming = false
+		m.streamChan = nil
+		return m, nil
+
+	case rollResultMsg:
+		if msg.err != nil {
+			m.transcript = append(m.transcript, fmt.Sprintf("Бросок %q не удался: %v", msg.expr, msg.err))
+		} else {
+			m.transcript = append(m.transcript, "Бросок: "+msg.result)
+		}
+		return m, nil
+
+	case editorDoneMsg:
+		if msg.err != nil {
+			m.statusLine = "редактор завершился с ошибкой: " + msg.err.Error()
+			return m, nil
+		}
+		text := strings.TrimSpace(msg.text)
+		if text == "" {
+			return m, nil
+		}
+		return m, m.sendToGM(text)
+
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+
+	return m, nil
+}
+
+// handleKey dispatches a key press according to the current mode
+func (m *model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.mode == modeInsert {
+		return m.handleInsertKey(msg)
+	}
+	return m.handleNormalKey(msg)
+}
+
+// handleNormalKey implements vi-like scrollback navigation plus the mode
+// switches into insert mode and the $EDITOR composer
+func (m *model) handleNormalKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "i":
+		m.mode = modeInsert
+		m.input = m.input[:0]
+		return m, nil
+	case "e":
+		return m, m.openEditor()
+	case "j", "down":
+		m.scrollDown(1)
+		return m, nil
+	case "k", "up":
+		m.scrollUp(1)
+		return m, nil
+	case "ctrl+d":
+		m.scrollDown(m.visibleLines() / 2)
+		return m, nil
+	case "ctrl+u":
+		m.scrollUp(m.visibleLines() / 2)
+		return m, nil
+	case "g":
+		m.scroll = len(m.transcript)
+		return m, nil
+	case "G":
+		m.scroll = 0
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// handleInsertKey accumulates a composed line, submitting it on Enter and
+// returning to normal mode on Esc
+func (m *model) handleInsertKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.mode = modeNormal
+		m.input = m.input[:0]
+		return m, nil
+	case tea.KeyEnter:
+		text := strings.TrimSpace(string(m.input))
+		m.input = m.input[:0]
+		m.mode = modeNormal
+		if text == "" {
+			return m, nil
+		}
+		return m, m.submitLine(text)
+	case tea.KeyBackspace:
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+		}
+		return m, nil
+	case tea.KeyRunes, tea.KeySpace:
+		m.input = append(m.input, msg.Runes...)
+		return m, nil
+	}
+
+	return m, nil
+}
+
+// submitLine interprets a composed line, dispatching slash-commands
+// (/roll, /save) and otherwise sending it to the GM as player input
+func (m *model) submitLine(text string) tea.Cmd {
+	switch {
+	case strings.HasPrefix(text, "/roll "):
+		expr := strings.TrimSpace(strings.TrimPrefix(text, "/roll "))
+		result, err := game.RollDice(expr)
+		return func() tea.Msg {
+			return rollResultMsg{expr: expr, result: result, err: err}
+		}
+	case strings.HasPrefix(text, "/save"):
+		name := strings.TrimSpace(strings.TrimPrefix(text, "/save"))
+		m.saveSession(name)
+		return nil
+	default:
+		return m.sendToGM(text)
+	}
+}
+
+// saveSession appends the in-memory transcript to sessions/<name>.md (or the
+// session's own ID if no name is given) via the shared MarkdownParser
+func (m *model) saveSession(name string) {
+	if name == "" {
+		name = m.session.ID
+	}
+
+	date := time.Now().Format("2006-01-02 15:04:05")
+	body, err := m.parser.BuildSessionLog(date, name, strings.Join(m.transcript, "\n"), nil)
+	if err != nil {
+		m.statusLine = "не удалось собрать лог сессии: " + err.Error()
+		return
+	}
+
+	path := fmt.Sprintf("%s/sessions/%s.md", m.campaign, name)
+	if err := m.parser.AppendFile(path, "\n"+body); err != nil {
+		m.statusLine = "не удалось сохранить сессию: " + err.Error()
+		return
+	}
+
+	m.statusLine = "сессия сохранена в " + path
+}
+
+// openEditor suspends the TUI and opens $EDITOR on a scratch file, feeding
+// its contents back as a composed line once the editor exits
+func (m *model) openEditor() tea.Cmd {
+	editor := os.Getenv("EDITOR")
+	if editor == "" {
+		editor = "vi"
+	}
+
+	tmpFile, err := os.CreateTemp("", "wfrp-tui-*.md")
+	if err != nil {
+		m.statusLine = "не удалось создать временный файл: " + err.Error()
+		return nil
+	}
+	tmpFile.Close()
+	path := tmpFile.Name()
+
+	cmd := exec.Command(editor, path)
+
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		defer os.Remove(path)
+		if err != nil {
+			return editorDoneMsg{err: err}
+		}
+
+		content, readErr := os.ReadFile(path)
+		if readErr != nil {
+			return editorDoneMsg{err: readErr}
+		}
+
+		return editorDoneMsg{text: string(content)}
+	})
+}
+
+// visibleLines returns how many transcript lines fit in the center pane
+func (m *model) visibleLines() int {
+	if m.height <= 4 {
+		return 1
+	}
+	return m.height - 4
+}
+
+// scrollUp scrolls the transcript view n lines toward the top (older lines)
+func (m *model) scrollUp(n int) {
+	m.scroll += n
+	if max := len(m.transcript); m.scroll > max {
+		m.scroll = max
+	}
+}
+
+// scrollDown scrolls the transcript view n lines toward the bottom (newer lines)
+func (m *model) scrollDown(n int) {
+	m.scroll -= n
+	if m.scroll < 0 {
+		m.scroll = 0
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	paneStyle = lipgloss.NewStyle().
+			Border(lipgloss.NormalBorder()).
+			Padding(0, 1)
+
+	titleStyle = lipgloss.NewStyle().Bold(true).Underline(true)
+
+	gmLineStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("205"))
+	playerLineStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("39"))
+
+	statusStyle = lipgloss.NewStyle().Faint(true)
+)
+
+// View renders the three-pane layout: campaigns/sessions on the left, the
+// live conversation in the center, character cards on the right, with a
+// status/input line along the bottom
+func (m *model) View() string {
+	if m.width == 0 {
+		return "загрузка..."
+	}
+
+	leftWidth := m.width / 5
+	rightWidth := m.width / 4
+	centerWidth := m.width - leftWidth - rightWidth - 6
+	paneHeight := m.height - 4
+
+	left := paneStyle.Width(leftWidth).Height(paneHeight).Render(m.renderLeftPane())
+	center := paneStyle.Width(centerWidth).Height(paneHeight).Render(m.renderCenterPane(centerWidth, paneHeight))
+	right := paneStyle.Width(rightWidth).Height(paneHeight).Render(m.renderRightPane())
+
+	panes := lipgloss.JoinHorizontal(lipgloss.Top, left, center, right)
+
+	return panes + "\n" + m.renderBottomLine()
+}
+
+// renderLeftPane lists the campaign's sessions
+func (m *model) renderLeftPane() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render(m.campaign))
+	b.WriteString("\n\n")
+	b.WriteString("Сессии:\n")
+	for _, s := range m.sessions {
+		b.WriteString("  " + s + "\n")
+	}
+	return b.String()
+}
+
+// renderCenterPane shows the scrolled window of the live conversation, with
+// GM and player lines styled differently
+func (m *model) renderCenterPane(width, height int) string {
+	lines := m.transcript
+	end := len(lines) - m.scroll
+	if end < 0 {
+		end = 0
+	}
+	if end > len(lines) {
+		end = len(lines)
+	}
+	start := end - height
+	if start < 0 {
+		start = 0
+	}
+
+	var b strings.Builder
+	for _, line := range lines[start:end] {
+		switch {
+		case strings.HasPrefix(line, "ГМ: "):
+			b.WriteString(gmLineStyle.Render(line))
+		case strings.HasPrefix(line, "Игрок: "):
+			b.WriteString(playerLineStyle.Render(line))
+		default:
+			b.WriteString(line)
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// renderRightPane lists character cards loaded for the campaign
+func (m *model) renderRightPane() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Персонажи"))
+	b.WriteString("\n\n")
+	for _, c := range m.characters {
+		b.WriteString("  " + c + "\n")
+	}
+	return b.String()
+}
+
+// renderBottomLine shows the input line in insert mode, or the current mode
+// and last status message in normal mode
+func (m *model) renderBottomLine() string {
+	if m.mode == modeInsert {
+		return fmt.Sprintf("> %s█", string(m.input))
+	}
+
+	mode := "NORMAL"
+	return statusStyle.Render(fmt.Sprintf("-- %s -- i: ввод, e: редактор, j/k: прокрутка, q: выход  %s", mode, m.statusLine))
+}
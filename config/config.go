@@ -6,7 +6,11 @@ import (
 	"log"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 	"syscall"
+
+	"wfrp-bot/providers"
 )
 
 // ProviderConfig представляет конфигурацию LLM провайдера
@@ -16,13 +20,15 @@ import (
 //   - APIKey: API ключ провайдера
 //   - BaseURL: базовый URL API провайдера
 //   - Model: название модели для использования
+//   - RequestsPerMinute: ограничение частоты запросов (0 отключает лимит)
 //   - Params: дополнительные параметры провайдера
 type ProviderConfig struct {
-	Name    string
-	APIKey  string
-	BaseURL string
-	Model   string
-	Params  map[string]string
+	Name              string
+	APIKey            string
+	BaseURL           string
+	Model             string
+	RequestsPerMinute int
+	Params            map[string]string
 }
 
 // BotConfig представляет конфигурацию бота, загруженную из переменных окружения
@@ -32,11 +38,15 @@ type ProviderConfig struct {
 //   - DefaultProvider: название LLM провайдера по умолчанию
 //   - Providers: карта всех зарегистрированных провайдеров
 //   - GroupID: идентификатор группы Telegram
+//   - FailoverChain: провайдеры (по имени, как в Providers), на которые
+//     стоит переключиться по очереди, если DefaultProvider недоступен -
+//     см. FAILOVER_CHAIN и llm.NewProviderWithFailover
 type BotConfig struct {
 	TelegramToken   string
 	DefaultProvider string
 	Providers       map[string]ProviderConfig
 	GroupID         string
+	FailoverChain   []string
 }
 
 // LoadConfig загружает конфигурацию бота из переменных окружения
@@ -50,7 +60,24 @@ type BotConfig struct {
 // - {PROVIDER}_API_KEY: API ключ
 // - {PROVIDER}_BASE_URL: базовый URL (по умолчанию OpenAI или соответствующий провайдеру)
 // - {PROVIDER}_MODEL: модель (по умолчанию gpt-4o или модель провайдера)
+//
+// APIKey/BaseURL/Model на самом деле читаются через
+// SecretsProvider (см. SECRETS_BACKEND в NewSecretsProvider), который по
+// умолчанию совпадает с os.Getenv, но может быть подменён на JSON-файл или
+// (в будущем) Vault/SSM без изменения этой функции - см. lookupSecret.
+// {PROVIDER}_REQUESTS_PER_MINUTE всегда читается напрямую из окружения
 func LoadConfig() (BotConfig, error) {
+	secrets, err := NewSecretsProvider()
+	if err != nil {
+		return BotConfig{}, fmt.Errorf("failed to initialize secrets provider: %w", err)
+	}
+	return loadConfigFrom(secrets)
+}
+
+// loadConfigFrom is LoadConfig's implementation, parameterized on the
+// SecretsProvider so ReloadConfig and tooling can reuse it with a
+// different backend than the process's default
+func loadConfigFrom(secrets SecretsProvider) (BotConfig, error) {
 	token := getEnv("TELEGRAM_BOT_TOKEN", "")
 	if token == "" {
 		return BotConfig{}, fmt.Errorf("TELEGRAM_BOT_TOKEN is required")
@@ -66,51 +93,93 @@ func LoadConfig() (BotConfig, error) {
 		return BotConfig{}, fmt.Errorf("TELEGRAM_GROUP_ID is required")
 	}
 
-	providers := make(map[string]ProviderConfig)
+	providerConfigs := make(map[string]ProviderConfig)
+	catalogue := providers.Catalogue()
 
-	// Parse provider configurations from environment
-	// z.ai provider
-	if apiKey := getEnv("ZAI_API_KEY", ""); apiKey != "" {
-		providers["zai"] = ProviderConfig{
-			Name:    "z.ai",
-			APIKey:  apiKey,
-			BaseURL: "https://api.z.ai/v1",
-			Model:   "claude-3-5-sonnet-20240228",
+	// Every known provider (z.ai, minimax, anthropic, gemini, openai) plus
+	// "custom" and any CUSTOM_PROVIDERS names are OpenAI-compatible at the
+	// transport level, differing only in their default BaseURL/Model -
+	// providers.Catalogue supplies those defaults, falling back to the
+	// plain OpenAI ones for names it doesn't recognize (custom/extra)
+	providerNames := append([]string{"zai", "minimax", "anthropic", "gemini", "openai", "custom"}, extraProviderNames()...)
+	for _, providerName := range providerNames {
+		envPrefix := strings.ToUpper(providerName)
+		apiKey := lookupSecret(secrets, envPrefix+"_API_KEY", "")
+		if apiKey == "" {
+			continue
 		}
-	}
 
-	// minimax provider
-	if apiKey := getEnv("MINIMAX_API_KEY", ""); apiKey != "" {
-		providers["minimax"] = ProviderConfig{
-			Name:    "minimax",
-			APIKey:  apiKey,
-			BaseURL: "https://api.minimax.chat/v1",
-			Model:   "minimax-text",
+		defaults := catalogue[providerName]
+		displayName := defaults.DisplayName
+		if displayName == "" {
+			displayName = providerName
+		}
+		defaultBaseURL := defaults.BaseURL
+		if defaultBaseURL == "" {
+			defaultBaseURL = "https://api.openai.com/v1"
+		}
+		defaultModel := defaults.Model
+		if defaultModel == "" {
+			defaultModel = "gpt-4o"
 		}
-	}
 
-	// OpenAI-compatible providers (e.g., open.ai, others using same API)
-	for _, providerName := range []string{"openai", "custom"} {
-		if apiKey := getEnv(fmt.Sprintf("%s_API_KEY", providerName), ""); apiKey != "" {
-			baseURL := getEnv(fmt.Sprintf("%s_BASE_URL", providerName), "https://api.openai.com/v1")
-			model := getEnv(fmt.Sprintf("%s_MODEL", providerName), "gpt-4o")
-			providers[providerName] = ProviderConfig{
-				Name:    providerName,
-				APIKey:  apiKey,
-				BaseURL: baseURL,
-				Model:   model,
-			}
+		providerConfigs[providerName] = ProviderConfig{
+			Name:              displayName,
+			APIKey:            apiKey,
+			BaseURL:           lookupSecret(secrets, envPrefix+"_BASE_URL", defaultBaseURL),
+			Model:             lookupSecret(secrets, envPrefix+"_MODEL", defaultModel),
+			RequestsPerMinute: getEnvInt(envPrefix+"_REQUESTS_PER_MINUTE", 0),
 		}
 	}
 
 	return BotConfig{
 		TelegramToken:   token,
 		DefaultProvider: defaultProvider,
-		Providers:       providers,
+		Providers:       providerConfigs,
 		GroupID:         groupID,
+		FailoverChain:   parseFailoverChain(),
 	}, nil
 }
 
+// parseFailoverChain parses FAILOVER_CHAIN ("anthropic,gemini") into an
+// ordered list of provider names (keys into BotConfig.Providers) to try, in
+// order, if DefaultProvider's health check fails or a request to it errors.
+// Empty/unset means no failover, preserving today's single-provider behavior
+func parseFailoverChain() []string {
+	raw := getEnv("FAILOVER_CHAIN", "")
+	if raw == "" {
+		return nil
+	}
+
+	var chain []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			chain = append(chain, name)
+		}
+	}
+	return chain
+}
+
+// extraProviderNames parses CUSTOM_PROVIDERS ("custom2,custom3") into a
+// list of additional OpenAI-compatible provider names to look for, on top
+// of the built-in zai, minimax, anthropic, gemini, openai and custom
+func extraProviderNames() []string {
+	raw := getEnv("CUSTOM_PROVIDERS", "")
+	if raw == "" {
+		return nil
+	}
+
+	var names []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
 // ReloadConfig перезагружает конфигурацию из переменных окружения
 //
 // Использует LoadConfig для получения обновленной конфигурации.
@@ -119,6 +188,88 @@ func ReloadConfig() (BotConfig, error) {
 	return LoadConfig()
 }
 
+// ProviderDiff describes how one provider's configuration changed between
+// two BotConfig loads, so callers (see BotConfig.Diff) only have to rebuild
+// the LLM clients that actually changed instead of every registered
+// provider on every reload
+type ProviderDiff struct {
+	Name    string
+	Added   bool // provider is present in the new config but wasn't before
+	Removed bool // provider was present before but has been dropped
+	Changed bool // provider exists in both but APIKey/BaseURL/Model/etc. differ
+}
+
+// ResolveProviderChain returns the ProviderConfig for cfg.DefaultProvider
+// followed by each name in cfg.FailoverChain, in order, so a caller can
+// build an llm.FallbackProvider (see llm.NewProviderWithFailover) out of it.
+// Names with no matching entry in cfg.Providers (unset API key, typo, ...)
+// are skipped rather than erroring, and the default provider is never
+// duplicated if it also appears in FailoverChain
+func (cfg BotConfig) ResolveProviderChain() []ProviderConfig {
+	seen := make(map[string]bool)
+	var chain []ProviderConfig
+
+	for _, name := range append([]string{cfg.DefaultProvider}, cfg.FailoverChain...) {
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+
+		provider, ok := cfg.Providers[name]
+		if !ok {
+			continue
+		}
+		chain = append(chain, provider)
+	}
+
+	return chain
+}
+
+// Diff compares cfg against previous and reports which providers were
+// added, removed, or had their credentials/URL/model/rate limit changed.
+// Providers that are identical in both configs are omitted from the result
+func (cfg BotConfig) Diff(previous BotConfig) []ProviderDiff {
+	var diffs []ProviderDiff
+
+	for name, provider := range cfg.Providers {
+		prevProvider, existed := previous.Providers[name]
+		switch {
+		case !existed:
+			diffs = append(diffs, ProviderDiff{Name: name, Added: true})
+		case !providerConfigEqual(provider, prevProvider):
+			diffs = append(diffs, ProviderDiff{Name: name, Changed: true})
+		}
+	}
+
+	for name := range previous.Providers {
+		if _, stillExists := cfg.Providers[name]; !stillExists {
+			diffs = append(diffs, ProviderDiff{Name: name, Removed: true})
+		}
+	}
+
+	return diffs
+}
+
+// providerConfigEqual compares two ProviderConfig values field by field.
+// ProviderConfig.Params is a map, so it isn't comparable with ==
+func providerConfigEqual(a, b ProviderConfig) bool {
+	if a.Name != b.Name || a.APIKey != b.APIKey || a.BaseURL != b.BaseURL ||
+		a.Model != b.Model || a.RequestsPerMinute != b.RequestsPerMinute {
+		return false
+	}
+
+	if len(a.Params) != len(b.Params) {
+		return false
+	}
+	for key, value := range a.Params {
+		if b.Params[key] != value {
+			return false
+		}
+	}
+
+	return true
+}
+
 // SetupConfigReload настраивает обработку сигналов для перезагрузки конфигурации
 //
 // Регистрирует обработчик сигнала SIGHUP (файл-дескриптор должен быть доступен)
@@ -159,3 +310,20 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getEnvInt retrieves an environment variable as an integer, falling back
+// to defaultValue if it is unset or not a valid integer
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		log.Printf("Invalid integer value for %s: %v, using default %d", key, err, defaultValue)
+		return defaultValue
+	}
+
+	return parsed
+}
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// OverlayPath returns the file /config set persists RuntimeConfig changes
+// to, honoring CONFIG_OVERLAY_FILE so changes survive a restart without
+// requiring operators to edit environment variables by hand
+func OverlayPath() string {
+	return getEnv("CONFIG_OVERLAY_FILE", "config_overlay.json")
+}
+
+// LoadOverlay reads the persisted key/value overrides from path. A missing
+// file is not an error - it just means no overrides have been saved yet
+func LoadOverlay(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config overlay %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("failed to parse config overlay %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// SaveOverlay writes values to path as indented JSON, creating or
+// overwriting it
+func SaveOverlay(path string, values map[string]string) error {
+	data, err := json.MarshalIndent(values, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode config overlay: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write config overlay %s: %w", path, err)
+	}
+	return nil
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// RuntimeConfig is the subset of a session's LLM settings that /config can
+// show and hot-swap without a restart (see telegram.CommandHandlers.ConfigCommand).
+// Unlike BotConfig, which is only read once at startup from the environment,
+// a RuntimeConfig is mutated in place as the caller issues /config set
+// <key> <value>, and a fresh llm.ProviderConfig is rebuilt from it on every
+// change
+type RuntimeConfig struct {
+	Provider     string  // key into BotConfig.Providers (e.g. "zai", "minimax")
+	Model        string
+	BaseURL      string
+	Temperature  float64
+	MaxTokens    int
+	SystemPrompt string
+}
+
+// RuntimeConfigKeys lists every key /config accepts, in display order
+var RuntimeConfigKeys = []string{"provider", "model", "base_url", "temperature", "max_tokens", "system_prompt"}
+
+// NewRuntimeConfig builds a RuntimeConfig from cfg's default provider
+func NewRuntimeConfig(cfg BotConfig) RuntimeConfig {
+	provider := cfg.Providers[cfg.DefaultProvider]
+	return RuntimeConfig{
+		Provider: cfg.DefaultProvider,
+		Model:    provider.Model,
+		BaseURL:  provider.BaseURL,
+	}
+}
+
+// Get returns key's current value formatted as a string, and whether key
+// was recognized. RuntimeConfig never carries an API key, so there is
+// nothing here that needs redacting
+func (rc RuntimeConfig) Get(key string) (string, bool) {
+	switch key {
+	case "provider":
+		return rc.Provider, true
+	case "model":
+		return rc.Model, true
+	case "base_url":
+		return rc.BaseURL, true
+	case "temperature":
+		return strconv.FormatFloat(rc.Temperature, 'f', -1, 64), true
+	case "max_tokens":
+		return strconv.Itoa(rc.MaxTokens), true
+	case "system_prompt":
+		return rc.SystemPrompt, true
+	default:
+		return "", false
+	}
+}
+
+// Set parses value for key and applies it, returning an error if key is
+// unrecognized or value doesn't parse for that key's type
+func (rc *RuntimeConfig) Set(key, value string) error {
+	switch key {
+	case "provider":
+		rc.Provider = value
+	case "model":
+		rc.Model = value
+	case "base_url":
+		rc.BaseURL = value
+	case "temperature":
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("temperature must be a number: %w", err)
+		}
+		rc.Temperature = parsed
+	case "max_tokens":
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("max_tokens must be an integer: %w", err)
+		}
+		rc.MaxTokens = parsed
+	case "system_prompt":
+		rc.SystemPrompt = value
+	default:
+		return fmt.Errorf("unknown config key %q", key)
+	}
+	return nil
+}
+
+// ToOverlay dumps rc as a flat key/value map suitable for SaveOverlay
+func (rc RuntimeConfig) ToOverlay() map[string]string {
+	overlay := make(map[string]string, len(RuntimeConfigKeys))
+	for _, key := range RuntimeConfigKeys {
+		value, _ := rc.Get(key)
+		overlay[key] = value
+	}
+	return overlay
+}
+
+// ApplyOverlay applies every recognized key in overlay on top of rc,
+// skipping keys that fail to parse (see LoadOverlay) rather than aborting
+// the whole load over one bad value
+func (rc RuntimeConfig) ApplyOverlay(overlay map[string]string) RuntimeConfig {
+	for _, key := range RuntimeConfigKeys {
+		if value, ok := overlay[key]; ok {
+			if err := rc.Set(key, value); err != nil {
+				continue
+			}
+		}
+	}
+	return rc
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+)
+
+// SecretsProvider abstracts where provider credentials (APIKey, BaseURL,
+// Model, ...) come from, so LoadConfig can pull them from process
+// environment variables, a JSON file operators edit in place, or (once
+// wired up) a real secrets manager like HashiCorp Vault or AWS SSM -
+// without changing any of the parsing logic in LoadConfig itself
+type SecretsProvider interface {
+	// Get returns the value stored under key and whether it was found
+	Get(key string) (string, bool)
+}
+
+// envSecretsProvider reads secrets straight from process environment
+// variables. This is the default backend and matches LoadConfig's
+// historical behaviour
+type envSecretsProvider struct{}
+
+func (envSecretsProvider) Get(key string) (string, bool) {
+	value := os.Getenv(key)
+	return value, value != ""
+}
+
+// fileSecretsProvider reads a flat key/value map from a JSON file
+// (SECRETS_FILE). Reload re-reads the file from disk, so operators can
+// rotate an API key by editing the file and sending SIGHUP instead of
+// restarting the bot with new environment variables
+type fileSecretsProvider struct {
+	path string
+
+	mu     sync.RWMutex
+	values map[string]string
+}
+
+func newFileSecretsProvider(path string) (*fileSecretsProvider, error) {
+	p := &fileSecretsProvider{path: path}
+	if err := p.Reload(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Reload re-reads the secrets file from disk, replacing the in-memory
+// key/value map used by Get
+func (p *fileSecretsProvider) Reload() error {
+	data, err := os.ReadFile(p.path)
+	if err != nil {
+		return fmt.Errorf("failed to read secrets file %s: %w", p.path, err)
+	}
+
+	values := make(map[string]string)
+	if err := json.Unmarshal(data, &values); err != nil {
+		return fmt.Errorf("failed to parse secrets file %s: %w", p.path, err)
+	}
+
+	p.mu.Lock()
+	p.values = values
+	p.mu.Unlock()
+
+	return nil
+}
+
+func (p *fileSecretsProvider) Get(key string) (string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	value, ok := p.values[key]
+	return value, ok
+}
+
+// notImplementedSecretsProvider stands in for backends this repo doesn't
+// carry a client for yet (Vault, AWS SSM). It reports every key as not
+// found rather than silently pretending to have a value, so LoadConfig
+// falls back to its other sources instead of booting with empty credentials
+type notImplementedSecretsProvider struct {
+	backend string
+}
+
+func (p notImplementedSecretsProvider) Get(key string) (string, bool) {
+	log.Printf("secrets backend %q is not wired up yet, ignoring key %s", p.backend, key)
+	return "", false
+}
+
+// NewSecretsProvider builds the SecretsProvider selected by SECRETS_BACKEND:
+//   - "env" (default): process environment variables
+//   - "file": a JSON key/value map at SECRETS_FILE
+//   - "vault", "ssm": reserved for HashiCorp Vault / AWS SSM; not
+//     implemented yet, so they resolve no keys and LoadConfig falls back
+//     to plain environment variables for everything
+func NewSecretsProvider() (SecretsProvider, error) {
+	switch backend := getEnv("SECRETS_BACKEND", "env"); backend {
+	case "env":
+		return envSecretsProvider{}, nil
+	case "file":
+		path := getEnv("SECRETS_FILE", "")
+		if path == "" {
+			return nil, fmt.Errorf("SECRETS_FILE is required when SECRETS_BACKEND=file")
+		}
+		return newFileSecretsProvider(path)
+	case "vault", "ssm":
+		return notImplementedSecretsProvider{backend: backend}, nil
+	default:
+		return nil, fmt.Errorf("unknown SECRETS_BACKEND %q", backend)
+	}
+}
+
+// lookupSecret tries secrets first and falls back to the environment
+// variable key (via getEnv's defaultValue semantics) if secrets doesn't
+// have it - so an "env" backend behaves exactly as before, and a
+// "file"/"vault"/"ssm" backend only needs to cover the keys it actually
+// manages
+func lookupSecret(secrets SecretsProvider, key, defaultValue string) string {
+	if value, ok := secrets.Get(key); ok {
+		return value
+	}
+	return getEnv(key, defaultValue)
+}
@@ -0,0 +1,168 @@
+// Package dice parses and evaluates WFRP 4e dice notation: plain rolls
+// ("d100", "2d10+5"), skill tests against a target number ("d100 vs WS 45",
+// "d100 vs 45"), and rolls that carry over a previous test's success levels
+// ("d10+SL"). It replaces the raw regex capture storage.MarkdownParser used
+// to previously only record a pattern and a trailing "= N"
+package dice
+
+import (
+	"fmt"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// exprPattern matches NdM, an optional "+mod" or "+SL" suffix, and an
+// optional "vs [Characteristic] Target" clause
+var exprPattern = regexp.MustCompile(`(?i)^(\d*)d(\d+)(?:([+-]\d+)|(\+sl))?(?:\s+vs\s+(?:([a-zа-я]+)\s+)?(\d+))?$`)
+
+// Target is the skill-test target number an Expr rolls against, optionally
+// named after the characteristic it was derived from (e.g. "WS" in
+// "d100 vs WS 45")
+type Target struct {
+	Characteristic string
+	Value          int
+}
+
+// Expr is a parsed dice expression, ready to be rolled with Roll or
+// resolved from an already-known total with Resolve
+type Expr struct {
+	Raw      string
+	NumDice  int
+	Sides    int
+	Modifier int  // flat modifier, e.g. +5 in "2d10+5"; ignored when UsesSL is set
+	UsesSL   bool // true for a "+SL" modifier: Roll takes the modifier from SkillContext.SL instead of Modifier
+	Target   *Target
+}
+
+// SkillContext carries state a roll may need beyond its own notation:
+// currently just the success levels of a preceding skill test, for an
+// expression like "d10+SL" that adds them as a damage bonus
+type SkillContext struct {
+	SL int
+}
+
+// Result is the outcome of rolling or resolving an Expr. SuccessLevels,
+// Success, Critical, Fumble and Astounding are only meaningful when Target
+// is set; Critical/Fumble/Astounding additionally require a single d100
+// roll, since they're defined by WFRP 4e's doubles rule (11, 22, ..., 100)
+type Result struct {
+	Expr          string
+	Rolls         []int
+	Total         int
+	Target        int
+	SuccessLevels int
+	Success       bool
+	Critical      bool // doubles were rolled (11, 22, ..., 100)
+	Fumble        bool // doubles on a failed test
+	Astounding    bool // doubles on a successful test
+}
+
+// Parse parses a dice expression such as "d100", "2d10+5", "d100 vs WS 45"
+// or "d10+SL"
+func Parse(expr string) (*Expr, error) {
+	trimmed := strings.TrimSpace(expr)
+
+	matches := exprPattern.FindStringSubmatch(trimmed)
+	if matches == nil {
+		return nil, fmt.Errorf("invalid dice expression: %s", expr)
+	}
+
+	e := &Expr{Raw: trimmed, NumDice: 1}
+
+	if matches[1] != "" {
+		n, err := strconv.Atoi(matches[1])
+		if err != nil || n < 1 || n > 100 {
+			return nil, fmt.Errorf("invalid number of dice in %s", expr)
+		}
+		e.NumDice = n
+	}
+
+	sides, err := strconv.Atoi(matches[2])
+	if err != nil || sides < 2 || sides > 100 {
+		return nil, fmt.Errorf("invalid number of sides in %s", expr)
+	}
+	e.Sides = sides
+
+	switch {
+	case matches[3] != "":
+		mod, err := strconv.Atoi(matches[3])
+		if err != nil {
+			return nil, fmt.Errorf("invalid modifier in %s", expr)
+		}
+		e.Modifier = mod
+	case matches[4] != "":
+		e.UsesSL = true
+	}
+
+	if matches[6] != "" {
+		target, err := strconv.Atoi(matches[6])
+		if err != nil {
+			return nil, fmt.Errorf("invalid target in %s", expr)
+		}
+		e.Target = &Target{Characteristic: strings.ToUpper(matches[5]), Value: target}
+	}
+
+	return e, nil
+}
+
+// Roll rolls e against rng, which is injectable so callers (and tests) can
+// get a deterministic sequence via rand.NewSource(seed). ctx supplies the
+// success levels a "+SL" modifier adds; it may be nil if e.UsesSL is false
+func (e *Expr) Roll(rng rand.Source, ctx *SkillContext) Result {
+	r := rand.New(rng)
+
+	rolls := make([]int, e.NumDice)
+	sum := 0
+	for i := range rolls {
+		rolls[i] = r.Intn(e.Sides) + 1
+		sum += rolls[i]
+	}
+
+	modifier := e.Modifier
+	if e.UsesSL && ctx != nil {
+		modifier = ctx.SL
+	}
+
+	return e.finish(rolls, sum, modifier)
+}
+
+// Resolve builds a Result from an already-known roll total rather than
+// simulating a new one. It's for recovering a roll someone already made and
+// wrote down - e.g. storage.MarkdownParser.parseLogEntry recovering
+// "d100 vs WS 45 = 32" from a session log - rather than rolling fresh
+func (e *Expr) Resolve(total int) Result {
+	return e.finish([]int{total}, total, 0)
+}
+
+// finish applies e.Target's skill-test rules to a roll already made (rolls,
+// summing to sum before modifier) and assembles the Result
+func (e *Expr) finish(rolls []int, sum, modifier int) Result {
+	result := Result{Expr: e.Raw, Rolls: rolls, Total: sum + modifier}
+
+	if e.Target == nil {
+		return result
+	}
+
+	result.Target = e.Target.Value
+	result.SuccessLevels = (e.Target.Value / 10) - (sum / 10)
+	result.Success = sum <= e.Target.Value
+
+	if e.Sides == 100 && e.NumDice == 1 {
+		tens, ones := sum/10, sum%10
+		if sum == 100 {
+			tens, ones = 0, 0
+		}
+		if tens == ones {
+			result.Critical = true
+			if result.Success {
+				result.Astounding = true
+			} else {
+				result.Fumble = true
+			}
+		}
+	}
+
+	return result
+}
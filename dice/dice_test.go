@@ -0,0 +1,154 @@
+package dice
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		expr    string
+		want    Expr
+		wantErr bool
+	}{
+		{name: "plain d100", expr: "d100", want: Expr{Raw: "d100", NumDice: 1, Sides: 100}},
+		{name: "numbered dice with modifier", expr: "2d10+5", want: Expr{Raw: "2d10+5", NumDice: 2, Sides: 10, Modifier: 5}},
+		{name: "negative modifier", expr: "d10-3", want: Expr{Raw: "d10-3", NumDice: 1, Sides: 10, Modifier: -3}},
+		{name: "plus SL", expr: "d10+SL", want: Expr{Raw: "d10+SL", NumDice: 1, Sides: 10, UsesSL: true}},
+		{name: "skill test vs characteristic", expr: "d100 vs WS 45", want: Expr{Raw: "d100 vs WS 45", NumDice: 1, Sides: 100, Target: &Target{Characteristic: "WS", Value: 45}}},
+		{name: "skill test vs bare target", expr: "d100 vs 45", want: Expr{Raw: "d100 vs 45", NumDice: 1, Sides: 100, Target: &Target{Value: 45}}},
+		{name: "invalid notation", expr: "not a roll", wantErr: true},
+		{name: "too many dice", expr: "200d10", wantErr: true},
+		{name: "too many sides", expr: "d1000", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.expr)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) expected an error, got none", tt.expr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q) unexpected error: %v", tt.expr, err)
+			}
+
+			if got.Raw != tt.want.Raw || got.NumDice != tt.want.NumDice || got.Sides != tt.want.Sides ||
+				got.Modifier != tt.want.Modifier || got.UsesSL != tt.want.UsesSL {
+				t.Fatalf("Parse(%q) = %+v, want %+v", tt.expr, got, tt.want)
+			}
+
+			switch {
+			case tt.want.Target == nil && got.Target != nil:
+				t.Fatalf("Parse(%q) Target = %+v, want nil", tt.expr, got.Target)
+			case tt.want.Target != nil && got.Target == nil:
+				t.Fatalf("Parse(%q) Target = nil, want %+v", tt.expr, tt.want.Target)
+			case tt.want.Target != nil && *got.Target != *tt.want.Target:
+				t.Fatalf("Parse(%q) Target = %+v, want %+v", tt.expr, got.Target, tt.want.Target)
+			}
+		})
+	}
+}
+
+func TestExprResolve(t *testing.T) {
+	tests := []struct {
+		name         string
+		expr         string
+		total        int
+		wantSL       int
+		wantSuccess  bool
+		wantCritical bool
+		wantFumble   bool
+		wantAstound  bool
+	}{
+		{name: "no target, just a total", expr: "d100", total: 45, wantSL: 0},
+		{name: "clean success", expr: "d100 vs WS 45", total: 32, wantSL: 1, wantSuccess: true},
+		{name: "clean failure", expr: "d100 vs WS 45", total: 67, wantSL: -2, wantSuccess: false},
+		{name: "critical success (doubles, astounding)", expr: "d100 vs WS 45", total: 11, wantSL: 3, wantSuccess: true, wantCritical: true, wantAstound: true},
+		{name: "critical failure (doubles, fumble)", expr: "d100 vs WS 45", total: 66, wantSL: -2, wantSuccess: false, wantCritical: true, wantFumble: true},
+		{name: "100 is always doubles", expr: "d100 vs WS 45", total: 100, wantSL: -6, wantSuccess: false, wantCritical: true, wantFumble: true},
+		{name: "non-d100 never gets critical/fumble", expr: "d10 vs 5", total: 5, wantSL: 0, wantSuccess: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Parse(tt.expr)
+			if err != nil {
+				t.Fatalf("Parse(%q) failed: %v", tt.expr, err)
+			}
+
+			result := e.Resolve(tt.total)
+
+			if result.SuccessLevels != tt.wantSL {
+				t.Errorf("SuccessLevels = %d, want %d", result.SuccessLevels, tt.wantSL)
+			}
+			if result.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v", result.Success, tt.wantSuccess)
+			}
+			if result.Critical != tt.wantCritical {
+				t.Errorf("Critical = %v, want %v", result.Critical, tt.wantCritical)
+			}
+			if result.Fumble != tt.wantFumble {
+				t.Errorf("Fumble = %v, want %v", result.Fumble, tt.wantFumble)
+			}
+			if result.Astounding != tt.wantAstound {
+				t.Errorf("Astounding = %v, want %v", result.Astounding, tt.wantAstound)
+			}
+		})
+	}
+}
+
+func TestExprRoll(t *testing.T) {
+	e, err := Parse("3d10+2")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	result := e.Roll(rand.NewSource(1), nil)
+
+	if len(result.Rolls) != 3 {
+		t.Fatalf("len(Rolls) = %d, want 3", len(result.Rolls))
+	}
+
+	sum := 0
+	for _, roll := range result.Rolls {
+		if roll < 1 || roll > 10 {
+			t.Fatalf("roll %d out of range [1,10]", roll)
+		}
+		sum += roll
+	}
+
+	if want := sum + 2; result.Total != want {
+		t.Fatalf("Total = %d, want %d", result.Total, want)
+	}
+}
+
+func TestExprRollUsesSL(t *testing.T) {
+	e, err := Parse("d10+SL")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	result := e.Roll(rand.NewSource(1), &SkillContext{SL: 3})
+
+	want := result.Rolls[0] + 3
+	if result.Total != want {
+		t.Fatalf("Total = %d, want %d (roll %d + SL 3)", result.Total, want, result.Rolls[0])
+	}
+}
+
+func TestExprRollUsesSLNilContext(t *testing.T) {
+	e, err := Parse("d10+SL")
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	result := e.Roll(rand.NewSource(1), nil)
+
+	if want := result.Rolls[0]; result.Total != want {
+		t.Fatalf("Total = %d, want %d (no SL applied without a context)", result.Total, want)
+	}
+}
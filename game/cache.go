@@ -0,0 +1,247 @@
+package game
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// dataCacheShardCount is the number of independently-locked shards
+// DataCache splits its entries across. Sharding replaces a single
+// package-wide mutex (CharacterManager's old cm.mu) with one lock per
+// shard, so two players loading or saving unrelated characters no longer
+// serialize behind each other
+const dataCacheShardCount = 16
+
+// characterCacheEntry wraps a cached character with its expiry
+type characterCacheEntry struct {
+	char      *Character
+	expiresAt time.Time
+}
+
+// characterShard is one RWMutex-guarded bucket of cached characters
+type characterShard struct {
+	mu      sync.RWMutex
+	entries map[string]*characterCacheEntry
+}
+
+// DataCache is an in-memory, write-through cache of parsed character
+// sheets. It exists to stop CharacterManager from re-reading and
+// re-parsing a card from disk on every command: once a sheet is loaded it
+// stays in RAM until its TTL expires, an explicit invalidation happens, or
+// a background sweep evicts it. storage.CampaignManager and
+// storage.HistoryManager already hold their records in memory after their
+// own Refresh/Index passes, so they don't suffer the same per-command
+// re-read cost and aren't wrapped here
+type DataCache struct {
+	shards [dataCacheShardCount]*characterShard
+	ttl    time.Duration
+
+	subMu       sync.Mutex
+	subscribers []chan string
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewDataCache creates a DataCache whose entries expire after ttl
+func NewDataCache(ttl time.Duration) *DataCache {
+	dc := &DataCache{ttl: ttl}
+	for i := range dc.shards {
+		dc.shards[i] = &characterShard{entries: make(map[string]*characterCacheEntry)}
+	}
+	return dc
+}
+
+// shardFor returns the shard responsible for playerID
+func (dc *DataCache) shardFor(playerID string) *characterShard {
+	return dc.shards[fnv32(playerID)%dataCacheShardCount]
+}
+
+// fnv32 is a small, dependency-free string hash used only to pick a shard
+func fnv32(s string) uint32 {
+	var h uint32 = 2166136261
+	for i := 0; i < len(s); i++ {
+		h ^= uint32(s[i])
+		h *= 16777619
+	}
+	return h
+}
+
+// Get returns the cached character for playerID, if present and not
+// expired
+func (dc *DataCache) Get(playerID string) (*Character, bool) {
+	shard := dc.shardFor(playerID)
+
+	shard.mu.RLock()
+	entry, ok := shard.entries[playerID]
+	shard.mu.RUnlock()
+
+	if !ok || time.Now().After(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.char, true
+}
+
+// Set stores char for playerID and resets its TTL. Callers use this both
+// to populate the cache after a disk read and to write through after a
+// save, so a successful write is immediately visible to the next read
+func (dc *DataCache) Set(playerID string, char *Character) {
+	shard := dc.shardFor(playerID)
+
+	shard.mu.Lock()
+	shard.entries[playerID] = &characterCacheEntry{char: char, expiresAt: time.Now().Add(dc.ttl)}
+	shard.mu.Unlock()
+}
+
+// Invalidate evicts playerID's cached character, if any, and notifies
+// subscribers so they can react (e.g. a hot-reload log line)
+func (dc *DataCache) Invalidate(playerID string) {
+	shard := dc.shardFor(playerID)
+
+	shard.mu.Lock()
+	delete(shard.entries, playerID)
+	shard.mu.Unlock()
+
+	dc.publish(playerID)
+}
+
+// All returns every currently-cached, non-expired character
+func (dc *DataCache) All() []*Character {
+	now := time.Now()
+
+	var chars []*Character
+	for _, shard := range dc.shards {
+		shard.mu.RLock()
+		for _, entry := range shard.entries {
+			if now.Before(entry.expiresAt) {
+				chars = append(chars, entry.char)
+			}
+		}
+		shard.mu.RUnlock()
+	}
+	return chars
+}
+
+// Subscribe returns a channel that receives the playerID of every
+// character invalidated from now on, whether by TTL sweep, an explicit
+// Invalidate, or a hot-reload triggered by an on-disk change
+func (dc *DataCache) Subscribe() <-chan string {
+	ch := make(chan string, 8)
+
+	dc.subMu.Lock()
+	dc.subscribers = append(dc.subscribers, ch)
+	dc.subMu.Unlock()
+
+	return ch
+}
+
+// publish notifies all subscribers of an invalidated playerID, dropping
+// the notification for any subscriber whose buffer is full rather than
+// blocking the cache
+func (dc *DataCache) publish(playerID string) {
+	dc.subMu.Lock()
+	defer dc.subMu.Unlock()
+
+	for _, ch := range dc.subscribers {
+		select {
+		case ch <- playerID:
+		default:
+		}
+	}
+}
+
+// StartSweeper launches a background goroutine that evicts expired
+// entries every interval, until Stop is called
+func (dc *DataCache) StartSweeper(interval time.Duration) {
+	dc.stop = make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-dc.stop:
+				return
+			case <-ticker.C:
+				dc.sweep()
+			}
+		}
+	}()
+}
+
+// Stop shuts down the background sweeper started by StartSweeper
+func (dc *DataCache) Stop() {
+	dc.stopOnce.Do(func() {
+		if dc.stop != nil {
+			close(dc.stop)
+		}
+	})
+}
+
+// sweep evicts every expired entry across all shards
+func (dc *DataCache) sweep() {
+	now := time.Now()
+	var evicted []string
+	for _, shard := range dc.shards {
+		shard.mu.Lock()
+		for id, entry := range shard.entries {
+			if now.After(entry.expiresAt) {
+				delete(shard.entries, id)
+				evicted = append(evicted, id)
+			}
+		}
+		shard.mu.Unlock()
+	}
+
+	for _, id := range evicted {
+		dc.publish(id)
+	}
+}
+
+// watchCharacterFiles starts an fsnotify watch on dir so that external
+// edits to a character's .md file (e.g. a GM editing a sheet by hand)
+// invalidate the cached copy without requiring a bot restart. resolve maps
+// a changed file's path back to the playerID to invalidate; paths it
+// doesn't recognize are ignored
+func watchCharacterFiles(dc *DataCache, dir string, resolve func(path string) (playerID string, tracked bool)) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Remove|fsnotify.Rename) == 0 {
+					continue
+				}
+				playerID, tracked := resolve(event.Name)
+				if !tracked {
+					continue
+				}
+				log.Printf("[CHARACTER] Detected external change to %s, invalidating cache", event.Name)
+				dc.Invalidate(playerID)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				log.Printf("[CHARACTER] File watcher error: %v", err)
+			}
+		}
+	}()
+
+	return watcher, nil
+}
@@ -6,28 +6,103 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/fsnotify/fsnotify"
 )
 
-// CharacterManager handles character card operations
+// characterCacheTTL is how long a loaded character sheet stays in
+// DataCache before it's considered stale and re-read from disk
+const characterCacheTTL = 10 * time.Minute
+
+// characterCacheSweepInterval is how often DataCache evicts expired
+// character entries in the background
+const characterCacheSweepInterval = time.Minute
+
+// CharacterManager handles character card operations. Reads and writes go
+// through a DataCache so repeated commands against the same character
+// don't re-read and re-parse its markdown file from disk every time
 type CharacterManager struct {
 	campaignPath string
-	characters   map[string]*Character
-	mu           sync.RWMutex
+	cache        *DataCache
+	history      *SheetHistory
+
+	pathMu    sync.Mutex
+	pathIndex map[string]string // CardPath -> playerID, for fsnotify events
+
+	watcher *fsnotify.Watcher
 }
 
 // NewCharacterManager creates a new character manager
 func NewCharacterManager(campaignPath string) *CharacterManager {
+	cache := NewDataCache(characterCacheTTL)
+	cache.StartSweeper(characterCacheSweepInterval)
+
 	return &CharacterManager{
 		campaignPath: campaignPath,
-		characters:   make(map[string]*Character),
+		cache:        cache,
+		history:      NewSheetHistory(filepath.Join(campaignPath, "characters", ".history")),
+		pathIndex:    make(map[string]string),
+	}
+}
+
+// WatchForChanges starts an fsnotify watch on the campaign's characters
+// directory, so a sheet edited on disk (e.g. by a GM outside the bot) has
+// its cached copy invalidated without requiring a restart. It is a no-op
+// to call this more than once
+func (cm *CharacterManager) WatchForChanges() error {
+	if cm.watcher != nil {
+		return nil
+	}
+
+	dir := filepath.Join(cm.campaignPath, "characters")
+	watcher, err := watchCharacterFiles(cm.cache, dir, cm.resolvePath)
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+
+	cm.watcher = watcher
+	return nil
+}
+
+// resolvePath maps a character file path back to the playerID it belongs
+// to, for the fsnotify watcher
+func (cm *CharacterManager) resolvePath(path string) (string, bool) {
+	cm.pathMu.Lock()
+	defer cm.pathMu.Unlock()
+
+	playerID, tracked := cm.pathIndex[path]
+	return playerID, tracked
+}
+
+// trackPath records the file path a playerID was loaded from, so a later
+// fsnotify event on that path can be resolved back to the player
+func (cm *CharacterManager) trackPath(playerID, path string) {
+	cm.pathMu.Lock()
+	cm.pathIndex[path] = playerID
+	cm.pathMu.Unlock()
+}
+
+// Close stops the background cache sweeper and, if WatchForChanges was
+// called, the file watcher
+func (cm *CharacterManager) Close() error {
+	cm.cache.Stop()
+	if cm.watcher != nil {
+		return cm.watcher.Close()
 	}
+	return nil
 }
 
-// LoadCharacter loads a character from markdown file
+// LoadCharacter returns a character, serving it from the cache when
+// possible and only reading its markdown file from disk on a cache miss
 func (cm *CharacterManager) LoadCharacter(playerID, characterPath string) (*Character, error) {
+	if char, ok := cm.cache.Get(playerID); ok {
+		return char, nil
+	}
+
 	// Determine full path
 	var fullPath string
 	if filepath.IsAbs(characterPath) {
@@ -53,20 +128,22 @@ func (cm *CharacterManager) LoadCharacter(playerID, characterPath string) (*Char
 
 	log.Printf("[CHARACTER] Loaded character %s from %s", char.Name, fullPath)
 
-	// Add to manager
-	cm.mu.Lock()
-	cm.characters[playerID] = char
-	cm.mu.Unlock()
+	cm.cache.Set(playerID, char)
+	cm.trackPath(playerID, fullPath)
+
+	if existing, err := cm.history.Log(playerID); err == nil && len(existing) == 0 {
+		if _, err := cm.history.Record(playerID, char.Sheet, nil); err != nil {
+			log.Printf("[CHARACTER] Failed to record initial history for %s: %v", playerID, err)
+		}
+	}
 
 	return char, nil
 }
 
-// SaveCharacter updates a character card to file
+// SaveCharacter updates a character card to file and writes the updated
+// copy straight through to the cache, so the next read sees it immediately
 func (cm *CharacterManager) SaveCharacter(playerID string, updates map[string]interface{}) error {
-	cm.mu.RLock()
-	char, exists := cm.characters[playerID]
-	cm.mu.RUnlock()
-
+	char, exists := cm.cache.Get(playerID)
 	if !exists {
 		return fmt.Errorf("character not found for player %s", playerID)
 	}
@@ -79,44 +156,113 @@ func (cm *CharacterManager) SaveCharacter(playerID string, updates map[string]in
 		return fmt.Errorf("failed to write character file: %w", err)
 	}
 
-	// Update in-memory character
-	cm.mu.Lock()
-	char.Sheet = updatedSheet
-	char.LastUpdate = time.Now()
-	cm.mu.Unlock()
+	// Write through to the cache
+	updated := *char
+	updated.Sheet = updatedSheet
+	updated.LastUpdate = time.Now()
+	cm.cache.Set(playerID, &updated)
 
-	log.Printf("[CHARACTER] Saved character %s for player %s", char.Name, playerID)
+	if _, err := cm.history.Record(playerID, updatedSheet, nil); err != nil {
+		log.Printf("[CHARACTER] Failed to record history for %s: %v", playerID, err)
+	}
+
+	log.Printf("[CHARACTER] Saved character %s for player %s", updated.Name, playerID)
 
 	return nil
 }
 
-// GetCharacter returns a character by player ID
-func (cm *CharacterManager) GetCharacter(playerID string) (*Character, bool) {
-	cm.mu.RLock()
-	defer cm.mu.RUnlock()
+// ApplyUpdate applies a structured CharacterUpdate to a persisted
+// character, writing the result to disk, through the cache, and into its
+// revision history (unlike SaveCharacter's ad-hoc field updates, this
+// records the CharacterUpdate payload itself alongside the snapshot)
+func (cm *CharacterManager) ApplyUpdate(playerID string, update CharacterUpdate) (string, []string, error) {
+	char, exists := cm.cache.Get(playerID)
+	if !exists {
+		return "", nil, fmt.Errorf("character not found for player %s", playerID)
+	}
+
+	updatedSheet, warnings := ApplyCharacterUpdate(char.Sheet, update)
 
-	char, exists := cm.characters[playerID]
-	return char, exists
+	if err := os.WriteFile(char.CardPath, []byte(updatedSheet), 0644); err != nil {
+		return "", nil, fmt.Errorf("failed to write character file: %w", err)
+	}
+
+	updated := *char
+	updated.Sheet = updatedSheet
+	updated.LastUpdate = time.Now()
+	cm.cache.Set(playerID, &updated)
+
+	if _, err := cm.history.Record(playerID, updatedSheet, &update); err != nil {
+		log.Printf("[CHARACTER] Failed to record history for %s: %v", playerID, err)
+	}
+
+	log.Printf("[CHARACTER] Applied update to %s for player %s: %v", updated.Name, playerID, warnings)
+
+	return updatedSheet, warnings, nil
 }
 
-// GetAllCharacters returns all characters
-func (cm *CharacterManager) GetAllCharacters() []*Character {
-	cm.mu.RLock()
-	defer cm.mu.RUnlock()
+// History returns a character's sheet revision log, oldest first
+func (cm *CharacterManager) History(playerID string) ([]HistoryEntry, error) {
+	return cm.history.Log(playerID)
+}
+
+// DiffRevisions returns a unified-style diff between two revisions of a
+// character's sheet
+func (cm *CharacterManager) DiffRevisions(playerID, fromRev, toRev string) (string, error) {
+	return cm.history.Diff(playerID, fromRev, toRev)
+}
+
+// Blame returns, for each line of a character's current sheet, the
+// revision that last changed it
+func (cm *CharacterManager) Blame(playerID string) ([]BlameLine, error) {
+	return cm.history.Blame(playerID)
+}
+
+// Undo reverts a character to a previous revision, writing it back as the
+// current sheet. The revert itself is recorded as a new forward-pointing
+// revision, so history is never rewritten
+func (cm *CharacterManager) Undo(playerID, rev string) error {
+	char, exists := cm.cache.Get(playerID)
+	if !exists {
+		return fmt.Errorf("character not found for player %s", playerID)
+	}
+
+	content, err := cm.history.Revert(playerID, rev)
+	if err != nil {
+		return err
+	}
 
-	chars := make([]*Character, 0, len(cm.characters))
-	for _, char := range cm.characters {
-		chars = append(chars, char)
+	if err := os.WriteFile(char.CardPath, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write character file: %w", err)
 	}
-	return chars
+
+	updated := *char
+	updated.Sheet = content
+	updated.LastUpdate = time.Now()
+	cm.cache.Set(playerID, &updated)
+
+	if _, err := cm.history.Record(playerID, content, nil); err != nil {
+		log.Printf("[CHARACTER] Failed to record history for %s: %v", playerID, err)
+	}
+
+	log.Printf("[CHARACTER] Reverted %s to revision %s for player %s", updated.Name, rev, playerID)
+
+	return nil
 }
 
-// RemoveCharacter removes a character from manager
-func (cm *CharacterManager) RemoveCharacter(playerID string) error {
-	cm.mu.Lock()
-	defer cm.mu.Unlock()
+// GetCharacter returns a character by player ID
+func (cm *CharacterManager) GetCharacter(playerID string) (*Character, bool) {
+	return cm.cache.Get(playerID)
+}
 
-	char, exists := cm.characters[playerID]
+// GetAllCharacters returns all cached characters
+func (cm *CharacterManager) GetAllCharacters() []*Character {
+	return cm.cache.All()
+}
+
+// RemoveCharacter removes a character from the cache and deletes its file
+func (cm *CharacterManager) RemoveCharacter(playerID string) error {
+	char, exists := cm.cache.Get(playerID)
 	if !exists {
 		return fmt.Errorf("character not found for player %s", playerID)
 	}
@@ -126,7 +272,11 @@ func (cm *CharacterManager) RemoveCharacter(playerID string) error {
 		return fmt.Errorf("failed to delete character file: %w", err)
 	}
 
-	delete(cm.characters, playerID)
+	cm.cache.Invalidate(playerID)
+
+	cm.pathMu.Lock()
+	delete(cm.pathIndex, char.CardPath)
+	cm.pathMu.Unlock()
 
 	log.Printf("[CHARACTER] Removed character %s for player %s", char.Name, playerID)
 
@@ -147,20 +297,22 @@ func (cm *CharacterManager) UpdateCharacterStats(playerID string, statChanges ma
 func (cm *CharacterManager) ValidateCharacter(char *Character) []string {
 	var violations []string
 
-	sheet := char.Sheet
+	cs := ParseCharacterSheet(char.Sheet)
 
-	// Check for required sections
-	requiredSections := []string{"# Имя", "## Характеристики", "## Навыки"}
-	for _, section := range requiredSections {
-		if !strings.Contains(sheet, section) {
-			violations = append(violations, fmt.Sprintf("Missing section: %s", section))
+	if cs.Name == "" || cs.Name == "Unknown" {
+		violations = append(violations, "Missing section: # Имя")
+	}
+
+	for _, heading := range []string{SectionCharacteristics, SectionSkills} {
+		if cs.Section(heading) == nil {
+			violations = append(violations, fmt.Sprintf("Missing section: ## %s", heading))
 		}
 	}
 
-	// Check characteristic range (0-100 for most stats in WFRP)
-	if strings.Contains(sheet, "## Характеристики") {
-		// Basic validation - could be enhanced
-		if !strings.Contains(sheet, "В") && !strings.Contains(sheet, "Лов") {
+	if cs.Section(SectionCharacteristics) != nil {
+		_, hasWS := cs.Characteristic("WS")
+		_, hasAg := cs.Characteristic("Ag")
+		if !hasWS && !hasAg {
 			violations = append(violations, "Characteristics section incomplete")
 		}
 	}
@@ -168,23 +320,25 @@ func (cm *CharacterManager) ValidateCharacter(char *Character) []string {
 	return violations
 }
 
-// applyUpdates applies updates to character sheet
+// applyUpdates applies ad-hoc field updates, as produced by
+// UpdateCharacterStats, to a parsed character sheet
 func (cm *CharacterManager) applyUpdates(sheet string, updates map[string]interface{}) string {
-	// Simple implementation - replaces patterns in sheet
-	// A more sophisticated version would parse markdown structure
-
-	updated := sheet
+	cs := ParseCharacterSheet(sheet)
 
 	for key, value := range updates {
 		switch v := value.(type) {
 		case int:
-			updated = strings.ReplaceAll(updated, key+": XX", key+": "+fmt.Sprint(v))
+			if _, ok := cs.Characteristic(key); ok {
+				cs.SetCharacteristic(key, v)
+			} else {
+				cs.SetField(key, strconv.Itoa(v))
+			}
 		case string:
-			updated = strings.ReplaceAll(updated, key+": XX", key+": "+v)
+			cs.SetField(key, v)
 		}
 	}
 
-	return updated
+	return cs.Render()
 }
 
 // extractCharacterName extracts character name from markdown content
@@ -218,88 +372,82 @@ type CharacterStats struct {
 
 // ParseCharacterStats parses character statistics from markdown
 func ParseCharacterStats(sheet string) (*CharacterStats, error) {
-	stats := &CharacterStats{
-		Name:      extractCharacterName(sheet),
-		CurrentHP: 0,
-		MaxHP:     0,
-		XP:        0,
-	}
+	cs := ParseCharacterSheet(sheet)
 
-	lines := strings.Split(sheet, "\n")
-	for _, line := range lines {
-		// Parse characteristic lines like "В: 40"
-		if strings.Contains(line, ":") {
-			parts := strings.SplitN(line, ":", 2)
-			if len(parts) == 2 {
-				key := strings.TrimSpace(parts[0])
-				value := strings.TrimSpace(parts[1])
-
-				// Try to parse as integer
-				var intValue int
-				if _, err := fmt.Sscanf(value, "%d", &intValue); err == nil {
-					switch key {
-					case "В", "Weapon Skill":
-						stats.WS = intValue
-					case "BS", "Ballistic Skill":
-						stats.BS = intValue
-					case "S", "Strength":
-						stats.S = intValue
-					case "Ag", "Agility":
-						stats.Ag = intValue
-					case "Int", "Intelligence":
-						stats.Int = intValue
-					case "WP", "Will Power":
-						stats.WP = intValue
-					case "Fel", "Fellowship":
-						stats.Fel = intValue
-					}
-				}
-			}
-		}
+	stats := &CharacterStats{Name: cs.Name}
 
-		// Parse HP
-		if strings.Contains(line, "HP:") || strings.Contains(line, "Здоровье:") {
-			if _, err := fmt.Sscanf(line, "*%*HP: %d", &stats.MaxHP); err == nil {
-				stats.CurrentHP = stats.MaxHP
-			}
-		}
+	if v, ok := cs.Characteristic("WS"); ok {
+		stats.WS = v
+	}
+	if v, ok := cs.Characteristic("BS"); ok {
+		stats.BS = v
+	}
+	if v, ok := cs.Characteristic("S"); ok {
+		stats.S = v
+	}
+	if v, ok := cs.Characteristic("Ag"); ok {
+		stats.Ag = v
+	}
+	if v, ok := cs.Characteristic("Int"); ok {
+		stats.Int = v
+	}
+	if v, ok := cs.Characteristic("WP"); ok {
+		stats.WP = v
+	}
+	if v, ok := cs.Characteristic("Fel"); ok {
+		stats.Fel = v
+	}
 
-		// Parse XP
-		if strings.Contains(line, "XP:") || strings.Contains(line, "Опыт:") {
-			if _, err := fmt.Sscanf(line, "*%*XP: %d", &stats.XP); err == nil {
-				// XP parsed
-			}
-		}
+	if v, ok := cs.CurrentHP(); ok {
+		stats.CurrentHP = v
+	}
+	if v, ok := cs.MaxHP(); ok {
+		stats.MaxHP = v
+	}
+	if v, ok := cs.XP(); ok {
+		stats.XP = v
 	}
 
+	stats.Experience = cs.EnsureSection(SectionExperience).Items()
+
 	return stats, nil
 }
 
 // CharacterUpdate represents changes to apply to a character
 type CharacterUpdate struct {
-	HPChange      int            // Damage or healing
-	MaxHPChange   int            // Permanent HP change
-	XPChange      int            // Experience gained
-	StatsChanges  map[string]int // Statistic changes (WS, S, Ag, etc.)
-	SkillsAdded   []string
-	SkillsRemoved []string
-	Conditions    []string // Conditions added/removed
+	HPChange        int            // Damage or healing
+	MaxHPChange     int            // Permanent HP change
+	XPChange        int            // Experience gained
+	AdvantageChange int            // Combat Advantage gained or lost (clamped to >= 0, see CharacterSheet.SetAdvantage)
+	StatsChanges    map[string]int // Statistic changes (WS, S, Ag, etc.)
+	SkillsAdded     []string
+	SkillsRemoved   []string
+	Conditions      []string // Conditions added/removed
 }
 
-// ApplyCharacterUpdate applies changes to a character sheet according to WFRP rules
+// ApplyCharacterUpdate applies changes to a character sheet according to
+// WFRP rules. The update is validated against the character's current
+// stats first; if it violates WFRP rules, it is rejected outright and the
+// sheet is returned unchanged
 func ApplyCharacterUpdate(sheet string, update CharacterUpdate) (string, []string) {
-	var warnings []string
-	updated := sheet
-
-	// Parse current stats for validation
 	stats, err := ParseCharacterStats(sheet)
 	if err != nil {
-		warnings = append(warnings, fmt.Sprintf("Failed to parse stats: %v", err))
+		return sheet, []string{fmt.Sprintf("Failed to parse stats: %v", err)}
+	}
+
+	if errs := ValidateUpdate(update, stats); len(errs) > 0 {
+		warnings := make([]string, len(errs))
+		for i, e := range errs {
+			warnings[i] = fmt.Sprintf("Rejected: %s", e)
+		}
+		return sheet, warnings
 	}
 
-	// Apply HP changes
+	var warnings []string
+	cs := ParseCharacterSheet(sheet)
+
 	if update.HPChange != 0 {
-		updated = applyHPChange(updated, update.HPChange, stats)
+		applyHPChange(cs, update.HPChange)
 		if update.HPChange < 0 {
 			warnings = append(warnings, fmt.Sprintf("Character took %d damage", -update.HPChange))
 		} else {
@@ -307,236 +455,110 @@ func ApplyCharacterUpdate(sheet string, update CharacterUpdate) (string, []strin
 		}
 	}
 
-	// Apply Max HP changes
 	if update.MaxHPChange != 0 {
-		updated = applyMaxHPChange(updated, update.MaxHPChange)
+		applyMaxHPChange(cs, update.MaxHPChange)
 	}
 
-	// Apply XP changes
 	if update.XPChange != 0 {
-		updated = applyXPChange(updated, update.XPChange)
+		applyXPChange(cs, update.XPChange)
 		warnings = append(warnings, fmt.Sprintf("Character gained %d XP", update.XPChange))
 	}
 
-	// Apply statistic changes
+	if update.AdvantageChange != 0 {
+		cs.SetAdvantage(cs.Advantage() + update.AdvantageChange)
+		warnings = append(warnings, fmt.Sprintf("Advantage changed by %d", update.AdvantageChange))
+	}
+
 	for stat, change := range update.StatsChanges {
-		updated = applyStatChange(updated, stat, change)
+		applyStatChange(cs, stat, change)
 		warnings = append(warnings, fmt.Sprintf("%s changed by %d", stat, change))
 	}
 
-	// Add skills
 	for _, skill := range update.SkillsAdded {
-		updated = addSkillToSheet(updated, skill)
+		addSkillToSheet(cs, skill)
 		warnings = append(warnings, fmt.Sprintf("Added skill: %s", skill))
 	}
 
-	// Add conditions
 	for _, cond := range update.Conditions {
-		updated = addConditionToSheet(updated, cond)
+		addConditionToSheet(cs, cond)
 		warnings = append(warnings, fmt.Sprintf("Condition added: %s", cond))
 	}
 
-	updated = fmt.Sprintf("%s\n\n*(Обновлено: %s)*", updated, time.Now().Format("15:04:05"))
+	updated := fmt.Sprintf("%s\n\n*(Обновлено: %s)*", cs.Render(), time.Now().Format("15:04:05"))
 
 	return updated, warnings
 }
 
-// applyHPChange applies HP damage or healing
-func applyHPChange(sheet string, change int, stats *CharacterStats) string {
-	if stats == nil {
-		return sheet
-	}
+// applyHPChange applies HP damage or healing, clamped to [0, MaxHP]
+func applyHPChange(cs *CharacterSheet, change int) {
+	current, _ := cs.CurrentHP()
+	max, hasMax := cs.MaxHP()
 
-	// Find current HP line and update it
-	newCurrentHP := stats.CurrentHP + change
-	if newCurrentHP < 0 {
-		newCurrentHP = 0
-	} else if stats.MaxHP > 0 && newCurrentHP > stats.MaxHP {
-		newCurrentHP = stats.MaxHP
+	newCurrent := current + change
+	if newCurrent < 0 {
+		newCurrent = 0
+	} else if hasMax && max > 0 && newCurrent > max {
+		newCurrent = max
 	}
 
-	// Replace HP line in sheet
-	replacer := strings.NewReplacer(
-		fmt.Sprintf("HP: %d", stats.CurrentHP),
-		fmt.Sprintf("HP: %d", newCurrentHP),
-		fmt.Sprintf("Здоровье: %d", stats.CurrentHP),
-		fmt.Sprintf("Здоровье: %d", newCurrentHP),
-	)
-
-	return replacer.Replace(sheet)
-}
-
-// applyMaxHPChange applies permanent Max HP change
-func applyMaxHPChange(sheet string, change int) string {
-	// This is for permanent changes like from "Toughened" talent
-	// Find Max HP line and update it
-	return sheet // Placeholder - needs full markdown parsing
+	cs.SetCurrentHP(newCurrent)
 }
 
-// applyXPChange applies experience change
-func applyXPChange(sheet string, change int) string {
-	// Parse current XP
-	var currentXP int
-	if idx := strings.Index(sheet, "XP:"); idx >= 0 {
-		if _, err := fmt.Sscanf(sheet[idx:], "XP: %d", &currentXP); err == nil {
-			newXP := currentXP + change
-			replacer := strings.NewReplacer(
-				fmt.Sprintf("XP: %d", currentXP),
-				fmt.Sprintf("XP: %d", newXP),
-			)
-			return replacer.Replace(sheet)
-		}
-	}
-	return sheet
+// applyMaxHPChange applies a permanent Max HP change, e.g. from the
+// "Toughened" talent
+func applyMaxHPChange(cs *CharacterSheet, change int) {
+	current, _ := cs.MaxHP()
+	cs.SetMaxHP(current + change)
 }
 
-// applyStatChange applies characteristic change
-func applyStatChange(sheet string, stat string, change int) string {
-	// Parse current stat value
-	var currentValue int
-	statMarker := fmt.Sprintf("%s:", stat)
-
-	if idx := strings.Index(sheet, statMarker); idx >= 0 {
-		if _, err := fmt.Sscanf(sheet[idx:], statMarker+" %d", &currentValue); err == nil {
-			newValue := currentValue + change
-			// WFRP stats max at 100 (without advances)
-			if newValue > 100 {
-				newValue = 100
-			}
-			if newValue < 0 {
-				newValue = 0
-			}
-			replacer := strings.NewReplacer(
-				fmt.Sprintf("%s %d", stat, currentValue),
-				fmt.Sprintf("%s %d", stat, newValue),
-			)
-			return replacer.Replace(sheet)
-		}
+// applyXPChange applies an experience change, never dropping below 0
+func applyXPChange(cs *CharacterSheet, change int) {
+	current, _ := cs.XP()
+	newXP := current + change
+	if newXP < 0 {
+		newXP = 0
 	}
-	return sheet
+	cs.SetXP(newXP)
 }
 
-// addSkillToSheet adds a new skill to the character sheet
-func addSkillToSheet(sheet string, skill string) string {
-	// Find the skills section and add the skill
-	skillsSection := "## Навыки"
-	if idx := strings.Index(sheet, skillsSection); idx >= 0 {
-		// Find end of section
-		endIdx := strings.Index(sheet[idx:], "##")
-		if endIdx == -1 {
-			endIdx = len(sheet[idx:])
-		}
-		insertPoint := idx + len(skillsSection)
+// applyStatChange applies a characteristic change, clamped to [0, 100]
+func applyStatChange(cs *CharacterSheet, stat string, change int) {
+	current, _ := cs.Characteristic(stat)
 
-		// Insert skill with proper formatting
-		newSkill := fmt.Sprintf("\n- %s", skill)
-		return sheet[:insertPoint] + newSkill + sheet[insertPoint:]
+	newValue := current + change
+	if newValue > 100 {
+		newValue = 100
 	}
-	return sheet
-}
-
-// addConditionToSheet adds a condition to the character sheet
-func addConditionToSheet(sheet string, condition string) string {
-	// Add to existing conditions or create new section
-	conditionsHeader := "## Состояния"
-	conditionsMarker := "### Психологические состояния"
-
-	var insertPoint int
-	var newCondition string
-
-	if idx := strings.Index(sheet, conditionsHeader); idx >= 0 {
-		// Add to existing section
-		if markerIdx := strings.Index(sheet, conditionsMarker); markerIdx > idx {
-			insertPoint = markerIdx
-			newCondition = fmt.Sprintf("\n- %s", condition)
-		} else {
-			// No marker, add after header
-			insertPoint = idx + len(conditionsHeader)
-			newCondition = fmt.Sprintf("\n\n%s\n- %s", conditionsMarker, condition)
-		}
-	} else {
-		// Create new conditions section
-		insertPoint = len(sheet)
-		newCondition = fmt.Sprintf("\n\n%s\n\n%s\n- %s", conditionsHeader, conditionsMarker, condition)
+	if newValue < 0 {
+		newValue = 0
 	}
 
-	return sheet[:insertPoint] + newCondition + sheet[insertPoint:]
+	cs.SetCharacteristic(stat, newValue)
 }
 
-// ParseCharacterUpdateFromResponse parses LLM response for character updates
-func ParseCharacterUpdateFromResponse(response string) (playerID string, update *CharacterUpdate, err error) {
-	update = &CharacterUpdate{
-		StatsChanges: make(map[string]int),
-		SkillsAdded:  make([]string, 0),
-		Conditions:   make([]string, 0),
+// addSkillToSheet adds a new skill to the character sheet, unless it's
+// already there
+func addSkillToSheet(cs *CharacterSheet, skill string) {
+	section := cs.EnsureSection(SectionSkills)
+	if !section.HasItem(skill) {
+		section.AddItem(skill)
 	}
+}
 
-	lines := strings.Split(response, "\n")
-
-	for _, line := range lines {
-		lower := strings.ToLower(strings.TrimSpace(line))
-
-		// Parse HP changes
-		if strings.Contains(lower, "получил") || strings.Contains(lower, "took damage") {
-			var damage int
-			if _, err := fmt.Sscanf(line, "%*[damage ]*%d", &damage); err == nil {
-				update.HPChange -= damage
-			}
-		}
-
-		// Parse healing
-		if strings.Contains(lower, "вылечен") || strings.Contains(lower, "healed") {
-			var healing int
-			if _, err := fmt.Sscanf(line, "%*[healed ]*%d", &healing); err == nil {
-				update.HPChange += healing
-			}
-		}
-
-		// Parse XP gain
-		if strings.Contains(lower, "получил опыт") || strings.Contains(lower, "gained xp") {
-			var xp int
-			if _, err := fmt.Sscanf(line, "%*[xp ]*%d", &xp); err == nil {
-				update.XPChange += xp
-			}
-		}
-
-		// Parse skill gains
-		if strings.Contains(lower, "навык") || strings.Contains(lower, "skill") {
-			// Extract skill name from line
-			skillName := extractSkillFromLine(line)
-			if skillName != "" {
-				update.SkillsAdded = append(update.SkillsAdded, skillName)
-			}
-		}
-
-		// Parse conditions
-		if strings.Contains(lower, "ранение") || strings.Contains(lower, "wound") {
-			update.Conditions = append(update.Conditions, "Wounded")
-		}
-		if strings.Contains(lower, "кровотечение") || strings.Contains(lower, "bleeding") {
-			update.Conditions = append(update.Conditions, "Bleeding")
-		}
-		if strings.Contains(lower, "крит") || strings.Contains(lower, "critical") {
-			update.Conditions = append(update.Conditions, "Critical Wound")
-		}
+// addConditionToSheet adds a condition to the character sheet's
+// Психологические состояния subsection, unless it's already there
+func addConditionToSheet(cs *CharacterSheet, condition string) {
+	section := cs.EnsureSection(SectionConditions)
+	if !section.HasSubItem(psychologicalConditionsMarker, condition) {
+		section.AddSubItem(psychologicalConditionsMarker, condition)
 	}
-
-	return "", update, nil
 }
 
-// extractSkillFromLine extracts skill name from a line
-func extractSkillFromLine(line string) string {
-	// Simple extraction - could be enhanced
-	parts := strings.Fields(line)
-	for _, part := range parts {
-		if strings.HasSuffix(part, ":") || strings.HasSuffix(part, "-") {
-			continue
-		}
-		if len(part) > 2 {
-			return strings.TrimSpace(part)
-		}
-	}
-	return ""
+// removeConditionFromSheet removes a condition from the character sheet's
+// Психологические состояния subsection, if present
+func removeConditionFromSheet(cs *CharacterSheet, condition string) {
+	section := cs.EnsureSection(SectionConditions)
+	section.RemoveSubItem(psychologicalConditionsMarker, condition)
 }
 
 // ValidateUpdate checks if an update is valid per WFRP rules
@@ -3,14 +3,20 @@ package game
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
 	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
+
+	"wfrp-bot/agents"
+	"wfrp-bot/game/content"
+	"wfrp-bot/llm"
 )
 
 // CharacterCreationState represents the current step in character creation
@@ -22,6 +28,8 @@ const (
 	CC_Race
 	CC_Career
 	CC_Stats
+	CC_StatsManual
+	CC_StatsSwap
 	CC_Skills
 	CC_Talents
 	CC_Gear
@@ -49,11 +57,11 @@ var RussianStatsFullNames = map[string]string{
 	"–ë–ë": "–ë–æ–µ–≤–∞—è –ü—Ä–∏–≥–æ–¥–Ω–æ—Å—Ç—å",
 	"–î–ë": "–î–∏—Å—Ç–∞–Ω—Ü–∏—è –ë–æ—è",
 	"–°–°": "–°–∏–ª–∞",
-	"–ò":  "–ò–Ω–∏—Ü–∏–∞—Ç–∏–≤–∞",
-	"–õ":  "–õ–æ–≤–∫–æ—Å—Ç—å",
-	"–û":  "–û–±—â–µ–Ω–∏–µ",
+	"–ò":   "–ò–Ω–∏—Ü–∏–∞—Ç–∏–≤–∞",
+	"–õ":   "–õ–æ–≤–∫–æ—Å—Ç—å",
+	"–û":   "–û–±—â–µ–Ω–∏–µ",
 	"–°–¢": "–°—Ç–æ–π–∫–æ—Å—Ç—å",
-	"–ö":  "–ö–ª–∞—Å—Å–æ–≤–∞—è",
+	"–ö":   "–ö–ª–∞—Å—Å–æ–≤–∞—è",
 }
 
 // IsLLMQuestion detects if user input is a question for LLM
@@ -90,75 +98,83 @@ func GetRussianStatsMap(stats map[string]int) map[string]int {
 
 // CharacterCreationData holds all data during character creation
 type CharacterCreationData struct {
-	Name        string
-	Race        string
-	RaceBonusXP int
-	Class       string
-	Career      string
-	CareerRank  string
-	Status      string
-	StatusLevel int
-	CareerXP    int
+	Name        string `json:"name"`
+	Race        string `json:"race"`
+	RaceBonusXP int    `json:"race_bonus_xp"`
+	Class       string `json:"class"`
+	Career      string `json:"career"`
+	CareerRank  string `json:"career_rank"`
+	Status      string `json:"status"`
+	StatusLevel int    `json:"status_level"`
+	CareerXP    int    `json:"career_xp"`
 
 	// Characteristics
-	WS  int // Weapon Skill
-	BS  int // Ballistic Skill
-	S   int // Strength
-	T   int // Toughness
-	I   int // Initiative
-	Ag  int // Agility
-	Dex int // Dexterity
-	Int int // Intelligence
-	WP  int // Willpower
-	Fel int // Fellowship
+	WS  int `json:"ws"`  // Weapon Skill
+	BS  int `json:"bs"`  // Ballistic Skill
+	S   int `json:"s"`   // Strength
+	T   int `json:"t"`   // Toughness
+	I   int `json:"i"`   // Initiative
+	Ag  int `json:"ag"`  // Agility
+	Dex int `json:"dex"` // Dexterity
+	Int int `json:"int"` // Intelligence
+	WP  int `json:"wp"`  // Willpower
+	Fel int `json:"fel"` // Fellowship
 
 	// Secondary characteristics
-	HP         int
-	Fate       int
-	Fortune    int
-	Resilience int
-	Resolve    int
-	Movement   int
+	HP         int `json:"hp"`
+	Fate       int `json:"fate"`
+	Fortune    int `json:"fortune"`
+	Resilience int `json:"resilience"`
+	Resolve    int `json:"resolve"`
+	Movement   int `json:"movement"`
 
 	// Skills from race and career
-	Skills map[string]int // skillName -> rating
+	Skills map[string]int `json:"skills"` // skillName -> rating
 
 	// Talents from race and career
-	Talents []string
+	Talents []string `json:"talents"`
 
 	// Gear
-	Gear map[string]string // item -> source
+	Gear map[string]string `json:"gear"` // item -> source
 
 	// Money
-	Money int
+	Money int `json:"money"`
 
 	// Appearance
-	Age       int
-	Height    string
-	HairColor string
-	EyeColor  string
-	Features  string
+	Age       int    `json:"age"`
+	Height    string `json:"height"`
+	HairColor string `json:"hair_color"`
+	EyeColor  string `json:"eye_color"`
+	Features  string `json:"features"`
 
 	// Personality
-	Strengths  []string
-	Weaknesses []string
-	Background string
-	Motivation string
+	Strengths  []string `json:"strengths"`
+	Weaknesses []string `json:"weaknesses"`
+	Background string   `json:"background"`
+	Motivation string   `json:"motivation"`
+	DarkSecret string   `json:"dark_secret"`
+	Ambition   string   `json:"ambition"`
 
 	// XP tracking
-	TotalXP      int
-	XPFromRace   int
-	XPFromStats  int
-	XPFromCareer int
-	XPSpent      int
+	TotalXP      int `json:"total_xp"`
+	XPFromRace   int `json:"xp_from_race"`
+	XPFromStats  int `json:"xp_from_stats"`
+	XPFromCareer int `json:"xp_from_career"`
+	XPSpent      int `json:"xp_spent"`
 
 	// Creation options (how they chose)
-	StatsMethod  string // "random_no_swap", "random_swap", "manual"
-	CareerMethod string // "first_roll", "three_rolls", "manual"
-	RaceMethod   string // "manual", "random"
+	StatsMethod  string `json:"stats_method"`  // "random_no_swap", "random_swap", "manual"
+	CareerMethod string `json:"career_method"` // "first_roll", "three_rolls", "manual"
+	RaceMethod   string `json:"race_method"`   // "manual", "random"
+
+	// Swap audit trail for the "random with swap" stats method (CC_StatsSwap
+	// state, see processStatsSwap) - SwapLog entries look like "swap #1:
+	// WS<->BS" so a GM can check the player didn't exceed maxStatSwaps
+	SwapCount int      `json:"swap_count"`
+	SwapLog   []string `json:"swap_log,omitempty"`
 
 	// File path for history
-	BasePath string
+	BasePath string `json:"-"`
 }
 
 // CharacterCreator manages the character creation state machine
@@ -171,17 +187,44 @@ type CharacterCreator struct {
 
 	// LLM provider for questions
 	LLMProvider interface {
-		GenerateRequest(ctx context.Context, prompt string, characterCards []string) (string, error)
+		GenerateRequest(ctx context.Context, agent *agents.Agent, messages []llm.Message) (llm.GenerateResult, error)
 	}
 
+	// Races, career classes and their d100 tables, loaded from content.ContentProvider
+	content content.ContentProvider
+
 	// File path for history
 	BasePath string
+
+	// UserID identifies the player this creator belongs to, for auto-saving
+	// and restoring in-progress creation across a bot restart (see SaveState/
+	// LoadState). Left empty for headless uses (e.g. bulk NPC generation),
+	// which skips auto-save entirely
+	UserID string
+
+	// Career options rolled by the "three rolls, pick one" method, awaiting
+	// the player's choice. Persisted by SaveState so a restart doesn't lose
+	// the roll
+	pendingCareerOptions []string
+
+	// NoSuggest disables the career-fit hints from suggestedCareersText and
+	// careerFitText (the "--no-suggest" behavior), for GMs who'd rather their
+	// players pick blind
+	NoSuggest bool
+
+	// previousData is the last character finished from this basePath (see
+	// savePreviousData/loadPreviousData), offered at CC_Name as a "0"
+	// quick-start shortcut. Nil if no character has ever been finished here
+	previousData *CharacterCreationData
 }
 
-// NewCharacterCreator creates a new character creator instance
-func NewCharacterCreator(basePath string) *CharacterCreator {
-	return &CharacterCreator{
-		State: CC_Name,
+// NewCharacterCreator creates a new character creator instance. provider
+// supplies the races and careers available during creation, so new source
+// material or homebrew can be added without touching this package
+func NewCharacterCreator(basePath string, provider content.ContentProvider) *CharacterCreator {
+	cc := &CharacterCreator{
+		State:   CC_Name,
+		content: provider,
 		Data: &CharacterCreationData{
 			Skills:   make(map[string]int),
 			Talents:  []string{},
@@ -189,11 +232,19 @@ func NewCharacterCreator(basePath string) *CharacterCreator {
 			BasePath: basePath,
 		},
 	}
+
+	if prev, err := loadPreviousData(basePath); err != nil {
+		log.Printf("[CHARGEN] Failed to load previous character snapshot: %v", err)
+	} else {
+		cc.previousData = prev
+	}
+
+	return cc
 }
 
 // SetLLMProvider sets the LLM provider for character creation
 func (cc *CharacterCreator) SetLLMProvider(provider interface {
-	GenerateRequest(ctx context.Context, prompt string, characterCards []string) (string, error)
+	GenerateRequest(ctx context.Context, agent *agents.Agent, messages []llm.Message) (llm.GenerateResult, error)
 }) {
 	cc.LLMProvider = provider
 }
@@ -237,10 +288,11 @@ func (cc *CharacterCreator) AskLLM(question string) (string, error) {
 	log.Printf("[LLM] Question: %s", question)
 
 	ctx := context.Background()
-	answer, err := cc.LLMProvider.GenerateRequest(ctx, prompt, nil)
+	result, err := cc.LLMProvider.GenerateRequest(ctx, nil, []llm.Message{{Role: "user", Content: prompt}})
 	if err != nil {
 		return "", fmt.Errorf("–æ—à–∏–±–∫–∞ LLM: %v", err)
 	}
+	answer := result.Content
 
 	log.Printf("[LLM] Raw answer: %s", answer)
 
@@ -258,11 +310,16 @@ func (cc *CharacterCreator) AskLLM(question string) (string, error) {
 func (cc *CharacterCreator) GetPrompt() string {
 	switch cc.State {
 	case CC_Name:
-		return `–ö–∞–∫ —Ç–µ–±—è –∑–æ–≤—É—Ç, –≥–µ—Ä–æ–π? –ù–∞–ø–∏—à–∏ –∏–º—è –ø–µ—Ä—Å–æ–Ω–∞–∂–∞.
+		prompt := `–ö–∞–∫ —Ç–µ–±—è –∑–æ–≤—É—Ç, –≥–µ—Ä–æ–π? –ù–∞–ø–∏—à–∏ –∏–º—è –ø–µ—Ä—Å–æ–Ω–∞–∂–∞.
 
 üí° –ü–æ–¥—Å–∫–∞–∑–∫–∏:
 ‚Ä¢ –ü—Ä–æ—Å—Ç–æ –Ω–∞–ø–∏—à–∏ –∏–º—è (–Ω–∞–ø—Ä–∏–º–µ—Ä: –ò–≤–∞–Ω, –ú–∞—Ä–∏—è)
 ‚Ä¢ –ù–∞–ø–∏—à–∏ "—Å–≥–µ–Ω–µ—Ä–∏ –∏–º—è" –∏–ª–∏ "—Å–≥–µ–Ω–µ—Ä–∏ —Å–∞–º" - —è –ø—Ä–∏–¥—É–º–∞—é –∏–º—è —Å–∞–º`
+		if cc.previousData != nil {
+			prompt += "\n" + fmt.Sprintf("‚Ä¢ –ù–∞–ø–∏—à–∏ \"0\" - –†–æ–ª–∏—Ç—å –∫–∞–∫ –ø—Ä–æ—à–ª—ã–π —Ä–∞–∑ (%s, %s %s)",
+				cc.previousData.Race, cc.previousData.Class, cc.previousData.Career)
+		}
+		return prompt
 
 	case CC_Race:
 		return `–í—ã–±–µ—Ä–∏ —Ä–∞—Å—É:
@@ -291,6 +348,9 @@ func (cc *CharacterCreator) GetPrompt() string {
 –ù–∞–ø–∏—à–∏ –Ω–æ–º–µ—Ä –≤–∞—Ä–∏–∞–Ω—Ç–∞.
 –ü—Ä–∏–º–µ—á–∞–Ω–∏–µ: –º–∏–Ω–∏–º—É–º 4, –º–∞–∫—Å–∏–º—É–º 18 –Ω–∞ —Ö–∞—Ä–∞–∫—Ç–µ—Ä–∏—Å—Ç–∏–∫—É.`
 
+	case CC_StatsSwap:
+		return cc.statsSwapPrompt()
+
 	case CC_Skills:
 		return `–¢–µ–ø–µ—Ä—å –≤—ã–±–µ—Ä–∏–º –Ω–∞–≤—ã–∫–∏.
 
@@ -335,7 +395,9 @@ func (cc *CharacterCreator) GetPrompt() string {
 –ù–∞–ø–∏—à–∏:
 1. –î–≤–µ-—Ç—Ä–∏ —Å–∏–ª—å–Ω—ã–µ —Å—Ç–æ—Ä–æ–Ω—ã —Ö–∞—Ä–∞–∫—Ç–µ—Ä–∞ (—á–µ—Ä–µ–∑ –∑–∞–ø—è—Ç—É—é)
 2. –î–≤–µ-—Ç—Ä–∏ —Å–ª–∞–±—ã–µ —Å—Ç–æ—Ä–æ–Ω—ã (—á–µ—Ä–µ–∑ –∑–∞–ø—è—Ç—É—é)
-3. –ö—Ä–∞—Ç–∫–æ: –û—Ç–∫—É–¥–∞ –ø–µ—Ä—Å–æ–Ω–∞–∂ –∏ —á–µ–º –∑–∞–Ω–∏–º–∞–ª—Å—è –¥–æ —ç—Ç–æ–≥–æ?`
+3. –ö—Ä–∞—Ç–∫–æ: –û—Ç–∫—É–¥–∞ –ø–µ—Ä—Å–æ–Ω–∞–∂ –∏ —á–µ–º –∑–∞–Ω–∏–º–∞–ª—Å—è –¥–æ —ç—Ç–æ–≥–æ?
+
+–û—Å—Ç–∞–≤—å –ª—é–±—É—é —Å—Ç—Ä–æ–∫—É –ø—É—Å—Ç–æ–π, –∏ –æ–Ω–∞ –±—É–¥–µ—Ç –±—Ä–æ—à–µ–Ω–∞ —Å–ª—É—á–∞–π–Ω–æ (–≤–º–µ—Å—Ç–µ —Å –º–æ—Ç–∏–≤–∞—Ü–∏–µ–π, —Ç—ë–º–Ω–æ–π —Ç–∞–π–Ω–æ–π –∏ —Å—Ç—Ä–µ–º–ª–µ–Ω–∏–µ–º, –∫–æ—Ç–æ—Ä—ã–µ –≤—Å–µ–≥–¥–∞ –±—Ä–æ—Å–∞—é—Ç—Å—è). –ù–∞–ø–∏—à–∏ "–±—Ä–æ—Å–∏—Ç—å" –∏–ª–∏ –æ—Å—Ç–∞–≤—å –≤—Å—ë –ø–æ–ª–µ –ø—É—Å—Ç—ã–º, —á—Ç–æ–±—ã –±—Ä–æ—Å–∏—Ç—å –≤—Å—ë —Å—Ä–∞–∑—É.`
 
 	case CC_Review:
 		return cc.generateReview()
@@ -362,12 +424,13 @@ func (cc *CharacterCreator) generateName() (string, bool) {
 	log.Printf("[LLM] Requesting name generation")
 
 	ctx := context.Background()
-	name, err := cc.LLMProvider.GenerateRequest(ctx, prompt, nil)
+	genResult, err := cc.LLMProvider.GenerateRequest(ctx, nil, []llm.Message{{Role: "user", Content: prompt}})
 	if err != nil {
 		log.Printf("[LLM] Error from provider: %v", err)
 		// Return simple message without formatting
 		return "–ò–∑–≤–∏–Ω–∏, –Ω–µ –ø–æ–ª—É—á–∏–ª–æ—Å—å —Å–≥–µ–Ω–µ—Ä–∏—Ä–æ–≤–∞—Ç—å –∏–º—è. API LLM –Ω–µ–¥–æ—Å—Ç—É–ø–µ–Ω. –ù–∞–ø–∏—à–∏ –∏–º—è –≤—Ä—É—á–Ω—É—é.", false
 	}
+	name := genResult.Content
 
 	log.Printf("[LLM] Raw name: [%s]", name)
 
@@ -403,6 +466,7 @@ func (cc *CharacterCreator) processLLMQuestion(question string) (string, bool) {
 func (cc *CharacterCreator) ProcessInput(input string) (string, bool) {
 	cc.currentInput = input
 	cc.saveStep()
+	defer cc.autoSaveState()
 
 	// Check for "generate name" command (US3)
 	lowerInput := strings.ToLower(strings.TrimSpace(input))
@@ -431,6 +495,12 @@ func (cc *CharacterCreator) ProcessInput(input string) (string, bool) {
 	case CC_Stats:
 		return cc.processStats(input)
 
+	case CC_StatsManual:
+		return cc.processStatsManual(input)
+
+	case CC_StatsSwap:
+		return cc.processStatsSwap(input)
+
 	case CC_Skills:
 		cc.State = CC_Talents
 		return "–¢–∞–ª–∞–Ω—Ç—ã:\n" + cc.getTalentsList() + "\n\n–ù–∞–ø–∏—à–∏ '–¥–∞–ª–µ–µ' –¥–ª—è –ø—Ä–æ–¥–æ–ª–∂–µ–Ω–∏—è.", true
@@ -471,6 +541,10 @@ func (cc *CharacterCreator) ProcessInput(input string) (string, bool) {
 func (cc *CharacterCreator) processName(input string) (string, bool) {
 	inputLower := strings.ToLower(strings.TrimSpace(input))
 
+	if inputLower == "0" && cc.previousData != nil {
+		return cc.quickStartFromPrevious()
+	}
+
 	// Handle "–¥–∞" to accept generated name
 	if inputLower == "–¥–∞" || inputLower == "yes" || inputLower == "y" {
 		if cc.Data.Name != "" {
@@ -504,95 +578,99 @@ func (cc *CharacterCreator) processName(input string) (string, bool) {
 	return cc.GetPrompt(), true
 }
 
+// quickStartFromPrevious implements the CC_Name "0" shortcut: it copies
+// race, career and status straight from the last character finished under
+// this BasePath (see savePreviousData/loadPreviousData) and jumps to
+// CC_Stats for a fresh roll, skipping the race and career questions
+// entirely
+func (cc *CharacterCreator) quickStartFromPrevious() (string, bool) {
+	prev := cc.previousData
+	if prev == nil {
+		return "–ù–µ—Ç —Å–æ—Ö—Ä–∞–Ω—ë–Ω–Ω–æ–≥–æ –ø—Ä–æ—à–ª–æ–≥–æ –ø–µ—Ä—Å–æ–Ω–∞–∂–∞. –ù–∞–ø–∏—à–∏ –∏–º—è –ø–µ—Ä—Å–æ–Ω–∞–∂–∞.", false
+	}
+
+	cc.Data.Name = prev.Name
+	cc.Data.Race = prev.Race
+	cc.Data.RaceMethod = prev.RaceMethod
+	cc.Data.RaceBonusXP = prev.RaceBonusXP
+	cc.Data.Class = prev.Class
+	cc.Data.Career = prev.Career
+	cc.Data.CareerRank = prev.CareerRank
+	cc.Data.Status = prev.Status
+	cc.Data.StatusLevel = prev.StatusLevel
+	cc.Data.CareerMethod = prev.CareerMethod
+	cc.Data.CareerXP = prev.CareerXP
+	cc.Data.TotalXP = prev.RaceBonusXP + prev.CareerXP
+
+	if race, ok := cc.content.RaceByName(prev.Race); ok {
+		cc.applyRaceBonuses(race)
+	}
+
+	cc.State = CC_Stats
+	return fmt.Sprintf("–ü–æ–≤—Ç–æ—Ä—è—é –ø—Ä–æ—à–ª–æ–≥–æ –ø–µ—Ä—Å–æ–Ω–∞–∂–∞: %s, %s, %s\n\n%s",
+		cc.Data.Race, cc.Data.Class, cc.Data.Career, cc.GetPrompt()), true
+}
+
 // processRace handles race selection
 func (cc *CharacterCreator) processRace(input string) (string, bool) {
 	input = strings.TrimSpace(strings.ToLower(input))
+	races := cc.content.Races()
 
 	// Check for random roll
-	if input == "–±—Ä–æ—Å–∏—Ç—å" || input == "roll" || input == "random" {
+	if input == "бросить" || input == "roll" || input == "random" {
 		roll := rand.Intn(100) + 1
-		race := ""
-		switch {
-		case roll <= 90:
-			race = "–ß–µ–ª–æ–≤–µ–∫"
-			cc.Data.RaceBonusXP = 20
-		case roll <= 94:
-			race = "–ü–æ–ª—É—Ä–æ—Å–ª–∏–∫"
-			cc.Data.RaceBonusXP = 20
-		case roll <= 98:
-			race = "–ì–Ω–æ–º"
-			cc.Data.RaceBonusXP = 20
-		case roll == 99:
-			race = "–í—ã—Å—à–∏–π —ç–ª—å—Ñ"
-			cc.Data.RaceBonusXP = 20
-		default:
-			race = "–õ–µ—Å–Ω–æ–π —ç–ª—å—Ñ"
-			cc.Data.RaceBonusXP = 20
+		race, ok := cc.content.RaceByRoll(roll)
+		if !ok {
+			return fmt.Sprintf("Нет расы для броска d100 = %d. Проверь файлы содержимого.", roll), false
 		}
-		cc.Data.Race = race
+
+		cc.Data.Race = race.Name
+		cc.Data.RaceBonusXP = race.BonusXP
 		cc.Data.RaceMethod = "random"
-		cc.Data.TotalXP += cc.Data.RaceBonusXP
-		cc.applyRaceBonuses()
+		cc.Data.TotalXP += race.BonusXP
+		cc.applyRaceBonuses(race)
 		cc.State = CC_Career
-		return fmt.Sprintf("(d100 = %d) ‚Üí %s!\n+20 XP (–≤—Å–µ–≥–æ: %d)\n\n%s", roll, race, cc.Data.TotalXP, cc.GetPrompt()), true
+		return fmt.Sprintf("(d100 = %d) → %s!\n+%d XP (всего: %d)\n\n%s%s", roll, race.Name, race.BonusXP, cc.Data.TotalXP, cc.GetPrompt(), cc.suggestedCareersText()), true
 	}
 
 	// Check for number selection
-	choice, err := strconv.Atoi(input)
-	if err == nil {
-		races := []string{"–ß–µ–ª–æ–≤–µ–∫", "–ü–æ–ª—É—Ä–æ—Å–ª–∏–∫", "–ì–Ω–æ–º", "–í—ã—Å—à–∏–π —ç–ª—å—Ñ", "–õ–µ—Å–Ω–æ–π —ç–ª—å—Ñ"}
+	if choice, err := strconv.Atoi(input); err == nil {
 		if choice >= 1 && choice <= len(races) {
-			cc.Data.Race = races[choice-1]
+			race := races[choice-1]
+			cc.Data.Race = race.Name
 			cc.Data.RaceMethod = "manual"
-			cc.applyRaceBonuses()
+			cc.applyRaceBonuses(race)
 			cc.State = CC_Career
-			return fmt.Sprintf("–í—ã–±—Ä–∞–ª: %s\n\n%s", cc.Data.Race, cc.GetPrompt()), true
+			return fmt.Sprintf("Выбрал: %s\n\n%s%s", cc.Data.Race, cc.GetPrompt(), cc.suggestedCareersText()), true
 		}
 	}
 
 	// Check for race name
-	races := map[string]string{
-		"—á–µ–ª–æ–≤–µ–∫": "–ß–µ–ª–æ–≤–µ–∫", "1": "–ß–µ–ª–æ–≤–µ–∫",
-		"–ø–æ–ª—É—Ä–æ—Å–ª–∏–∫": "–ü–æ–ª—É—Ä–æ—Å–ª–∏–∫", "2": "–ü–æ–ª—É—Ä–æ—Å–ª–∏–∫",
-		"–≥–Ω–æ–º": "–ì–Ω–æ–º", "3": "–ì–Ω–æ–º",
-		"–≤—ã—Å—à–∏–π —ç–ª—å—Ñ": "–í—ã—Å—à–∏–π —ç–ª—å—Ñ", "4": "–í—ã—Å—à–∏–π —ç–ª—å—Ñ",
-		"—ç–ª—å—Ñ":        "–í—ã—Å—à–∏–π —ç–ª—å—Ñ",
-		"–ª–µ—Å–Ω–æ–π —ç–ª—å—Ñ": "–õ–µ—Å–Ω–æ–π —ç–ª—å—Ñ", "5": "–õ–µ—Å–Ω–æ–π —ç–ª—å—Ñ",
-	}
-
-	if race, ok := races[input]; ok {
-		cc.Data.Race = race
-		cc.Data.RaceMethod = "manual"
-		cc.applyRaceBonuses()
-		cc.State = CC_Career
-		return fmt.Sprintf("–í—ã–±—Ä–∞–ª: %s\n\n%s", cc.Data.Race, cc.GetPrompt()), true
+	for _, race := range races {
+		if strings.ToLower(race.Name) == input {
+			cc.Data.Race = race.Name
+			cc.Data.RaceMethod = "manual"
+			cc.applyRaceBonuses(race)
+			cc.State = CC_Career
+			return fmt.Sprintf("Выбрал: %s\n\n%s%s", cc.Data.Race, cc.GetPrompt(), cc.suggestedCareersText()), true
+		}
 	}
 
-	return "–ù–µ –ø–æ–Ω—è–ª –≤—ã–±–æ—Ä. –ù–∞–ø–∏—à–∏ –Ω–æ–º–µ—Ä (1-5), —Ä–∞—Å—É –∏–ª–∏ '–±—Ä–æ—Å–∏—Ç—å' –¥–ª—è —Å–ª—É—á–∞–π–Ω–æ–≥–æ –≤—ã–±–æ—Ä–∞.", false
+	return fmt.Sprintf("Не понял выбор. Напиши номер (1-%d), расу или 'бросить' для случайного выбора.", len(races)), false
 }
 
-// applyRaceBonuses applies racial bonuses to characteristics
-func (cc *CharacterCreator) applyRaceBonuses() {
-	bonuses := map[string]map[string]int{
-		"–ß–µ–ª–æ–≤–µ–∫":     {"WS": 30, "BS": 30, "S": 20, "T": 20, "I": 30, "Ag": 30, "Dex": 30, "Int": 30, "WP": 30, "Fel": 30},
-		"–ü–æ–ª—É—Ä–æ—Å–ª–∏–∫":  {"WS": 20, "BS": 30, "S": 10, "T": 20, "I": 30, "Ag": 40, "Dex": 30, "Int": 30, "WP": 30, "Fel": 40},
-		"–ì–Ω–æ–º":        {"WS": 40, "BS": 30, "S": 30, "T": 40, "I": 20, "Ag": 20, "Dex": 30, "Int": 20, "WP": 40, "Fel": 20},
-		"–í—ã—Å—à–∏–π —ç–ª—å—Ñ": {"WS": 40, "BS": 40, "S": 20, "T": 20, "I": 40, "Ag": 40, "Dex": 40, "Int": 40, "WP": 30, "Fel": 30},
-		"–õ–µ—Å–Ω–æ–π —ç–ª—å—Ñ": {"WS": 30, "BS": 30, "S": 20, "T": 20, "I": 40, "Ag": 40, "Dex": 30, "Int": 30, "WP": 30, "Fel": 30},
-	}
-
-	if bonus, ok := bonuses[cc.Data.Race]; ok {
-		cc.Data.WS = bonus["WS"]
-		cc.Data.BS = bonus["BS"]
-		cc.Data.S = bonus["S"]
-		cc.Data.T = bonus["T"]
-		cc.Data.I = bonus["I"]
-		cc.Data.Ag = bonus["Ag"]
-		cc.Data.Dex = bonus["Dex"]
-		cc.Data.Int = bonus["Int"]
-		cc.Data.WP = bonus["WP"]
-		cc.Data.Fel = bonus["Fel"]
-	}
+// applyRaceBonuses applies a race's starting characteristics
+func (cc *CharacterCreator) applyRaceBonuses(race content.Race) {
+	cc.Data.WS = race.Characteristics["WS"]
+	cc.Data.BS = race.Characteristics["BS"]
+	cc.Data.S = race.Characteristics["S"]
+	cc.Data.T = race.Characteristics["T"]
+	cc.Data.I = race.Characteristics["I"]
+	cc.Data.Ag = race.Characteristics["Ag"]
+	cc.Data.Dex = race.Characteristics["Dex"]
+	cc.Data.Int = race.Characteristics["Int"]
+	cc.Data.WP = race.Characteristics["WP"]
+	cc.Data.Fel = race.Characteristics["Fel"]
 }
 
 // processCareer handles career selection
@@ -629,6 +707,7 @@ func (cc *CharacterCreator) processCareer(input string) (string, bool) {
 		msg += "\n–ö–∞–∫—É—é –≤—ã–±–∏—Ä–∞–µ—à—å? –ù–∞–ø–∏—à–∏ –Ω–æ–º–µ—Ä (1-3)."
 
 		// Store rolls for selection
+		cc.pendingCareerOptions = careers
 		cc.Data.Career = careers[0] // temporary
 		return msg, true
 
@@ -648,53 +727,196 @@ func (cc *CharacterCreator) processCareer(input string) (string, bool) {
 	return fmt.Sprintf("–ö–∞—Ä—å–µ—Ä–∞: %s\n+ %d XP (–≤—Å–µ–≥–æ: %d)\n\n%s", cc.Data.Career, cc.Data.CareerXP, cc.Data.TotalXP, cc.GetPrompt()), true
 }
 
-// getRandomCareer returns a career based on d100 roll
+// getRandomCareer returns a career name based on a d100 roll, picking its
+// career class by roll range and a random career within that class
 func (cc *CharacterCreator) getRandomCareer(roll int) string {
-	// Simplified career selection based on class
-	classes := []string{"–ê–∫–∞–¥–µ–º–∏–∫", "–ë—É—Ä–∂—É–∞", "–ü—Ä–∏–¥–≤–æ—Ä–Ω—ã–π", "–ö—Ä–µ—Å—Ç—å—è–Ω–∏–Ω", "–†–µ–π–Ω–¥–∂–µ—Ä", "–†–µ–º–µ—Å–ª–µ–Ω–Ω–∏–∫", "–£—á—ë–Ω—ã–π", "–í–æ–∏–Ω"}
-
-	// Use roll to pick class, then career
-	classIdx := (roll - 1) / 12
-	if classIdx >= len(classes) {
-		classIdx = len(classes) - 1
-	}
-
-	class := classes[classIdx]
-	careers := map[string][]string{
-		"–ê–∫–∞–¥–µ–º–∏–∫":    {"–£—á–µ–Ω–∏–∫", "–ü–∏—Å–∞—Ä—å", "–ê–ª—Ö–∏–º–∏–∫"},
-		"–ë—É—Ä–∂—É–∞":      {"–¢–æ—Ä–≥–æ–≤–µ—Ü", "–†–µ–º–µ—Å–ª–µ–Ω–Ω–∏–∫", "–ü–æ–¥–º–∞—Å—Ç–µ—Ä—å–µ"},
-		"–ü—Ä–∏–¥–≤–æ—Ä–Ω—ã–π":  {"–°–ª—É–≥–∞", "–û—Ä—É–∂–µ–Ω–æ—Å–µ—Ü", "–ú–µ–Ω–µ—Å—Ç—Ä–µ–ª—å"},
-		"–ö—Ä–µ—Å—Ç—å—è–Ω–∏–Ω":  {"–ü–æ–¥–µ–Ω—â–∏–∫", "–ö—Ä–µ–ø–æ—Å—Ç–Ω–æ–π", "–ü–∞—Å—Ç—É—Ö"},
-		"–†–µ–π–Ω–¥–∂–µ—Ä":    {"–û—Ö–æ—Ç–Ω–∏–∫", "–°–ª–µ–¥–æ–ø—ã—Ç", "–†–∞–∑–≤–µ–¥—á–∏–∫"},
-		"–†–µ–º–µ—Å–ª–µ–Ω–Ω–∏–∫": {"–ö—É–∑–Ω–µ—Ü", "–ü–ª–æ—Ç–Ω–∏–∫", "–¢–∫–∞—á"},
-		"–£—á—ë–Ω—ã–π":      {"–ê—Å—Ç—Ä–æ–ª–æ–≥", "–¶–µ–ª–∏—Ç–µ–ª—å", "–ü–∏–ª–æ—Ç"},
-		"–í–æ–∏–Ω":        {"–°—Ç—Ä–∞–∂–Ω–∏–∫", "–ù–∞—ë–º–Ω–∏–∫", "–û—Ö—Ä–∞–Ω–Ω–∏–∫"},
-	}
-
-	careerList := careers[class]
-	career := careerList[rand.Intn(len(careerList))]
-
-	cc.Data.Class = class
-	cc.Data.Career = career
-	cc.Data.CareerRank = "–†–∞–Ω–≥ 1"
-	cc.Data.Status = "–ú–µ–¥–Ω—ã–π"
+	class, ok := cc.content.CareerClassByRoll(roll)
+	if !ok || len(class.Careers) == 0 {
+		return "Неизвестная карьера"
+	}
+
+	career := class.Careers[rand.Intn(len(class.Careers))]
+
+	cc.Data.Class = class.Name
+	cc.Data.Career = career.Name
+	cc.Data.CareerRank = "Ранг 1"
+	cc.Data.Status = "Медный"
 	cc.Data.StatusLevel = 1
 
-	return fmt.Sprintf("%s ‚Üí %s", class, career)
+	return fmt.Sprintf("%s → %s", class.Name, career.Name)
 }
 
-// getCareerList returns list of available careers
+// getCareerList renders every career class and its careers, for manual
+// career selection
 func (cc *CharacterCreator) getCareerList() string {
-	return `
-–ê–∫–∞–¥–µ–º–∏–∫–∏: –£—á–µ–Ω–∏–∫, –ü–∏—Å–∞—Ä—å, –ê–ª—Ö–∏–º–∏–∫
-–ë—É—Ä–∂—É–∞: –¢–æ—Ä–≥–æ–≤–µ—Ü, –†–µ–º–µ—Å–ª–µ–Ω–Ω–∏–∫, –ü–æ–¥–º–∞—Å—Ç–µ—Ä—å–µ
-–ü—Ä–∏–¥–≤–æ—Ä–Ω—ã–µ: –°–ª—É–≥–∞, –û—Ä—É–∂–µ–Ω–æ—Å–µ—Ü, –ú–µ–Ω–µ—Å—Ç—Ä–µ–ª—å
-–ö—Ä–µ—Å—Ç—å—è–Ω–µ: –ü–æ–¥–µ–Ω—â–∏–∫, –ö—Ä–µ–ø–æ—Å—Ç–Ω–æ–π, –ü–∞—Å—Ç—É—Ö
-–†–µ–π–Ω–¥–∂–µ—Ä—ã: –û—Ö–æ—Ç–Ω–∏–∫, –°–ª–µ–¥–æ–ø—ã—Ç, –†–∞–∑–≤–µ–¥—á–∏–∫
-–†–µ–º–µ—Å–ª–µ–Ω–Ω–∏–∫–∏: –ö—É–∑–Ω–µ—Ü, –ü–ª–æ—Ç–Ω–∏–∫, –¢–∫–∞—á
-–£—á—ë–Ω—ã–µ: –ê—Å—Ç—Ä–æ–ª–æ–≥, –¶–µ–ª–∏—Ç–µ–ª—å, –ü–∏–ª–æ—Ç
-–í–æ–∏–Ω—ã: –°—Ç—Ä–∞–∂–Ω–∏–∫, –ù–∞—ë–º–Ω–∏–∫, –û—Ö—Ä–∞–Ω–Ω–∏–∫
-`
+	var builder strings.Builder
+	builder.WriteString("\n")
+	for _, class := range cc.content.CareerClasses() {
+		names := make([]string, len(class.Careers))
+		for i, career := range class.Careers {
+			names[i] = career.Name
+		}
+		builder.WriteString(fmt.Sprintf("%s: %s\n", class.Name, strings.Join(names, ", ")))
+	}
+	return builder.String()
+}
+
+// CareerScore is one career's suitability score for a character's current
+// characteristics, as computed by RecommendCareer and SuggestCareers. Reason
+// names the characteristics that drove the score, e.g. "высокие WS+T"
+type CareerScore struct {
+	Class  string
+	Career string
+	Score  int
+	Reason string
+}
+
+// characteristics returns the character's current characteristic values by
+// their WFRP stat code, for scoring against a career's weight vector
+func characteristics(data *CharacterCreationData) map[string]int {
+	return map[string]int{
+		"WS": data.WS, "BS": data.BS, "S": data.S, "T": data.T, "I": data.I,
+		"Ag": data.Ag, "Dex": data.Dex, "Int": data.Int, "WP": data.WP, "Fel": data.Fel,
+	}
+}
+
+// RecommendCareer scores every career by summing the character's
+// characteristics weighted by the career's weight vector (equal weights
+// when a career specifies none), and returns the top 3 highest-scoring
+// careers. Ties are broken in favour of the rarer career class (the
+// narrower its d100 roll range, the rarer it is)
+func (cc *CharacterCreator) RecommendCareer(data *CharacterCreationData) []CareerScore {
+	return cc.rankCareers(data, 3)
+}
+
+// SuggestCareers is RecommendCareer against the creator's own data, with a
+// caller-chosen cutoff instead of a fixed top 3. Meant to be called after
+// rollStats, once the character actually has characteristics to judge a
+// career fit against (cc.Data.Career, picked back at CC_Career, may or may
+// not be among the top picks - see careerFitText)
+func (cc *CharacterCreator) SuggestCareers(topN int) []CareerScore {
+	return cc.rankCareers(cc.Data, topN)
+}
+
+// rankCareers is the shared scoring pass behind RecommendCareer and
+// SuggestCareers
+func (cc *CharacterCreator) rankCareers(data *CharacterCreationData, topN int) []CareerScore {
+	stats := characteristics(data)
+
+	var scores []CareerScore
+	for _, class := range cc.content.CareerClasses() {
+		for _, career := range class.Careers {
+			score := 0
+			for stat, value := range stats {
+				weight := 1
+				if len(career.Weights) > 0 {
+					weight = career.Weights[stat]
+				}
+				score += value * weight
+			}
+			scores = append(scores, CareerScore{Class: class.Name, Career: career.Name, Score: score, Reason: careerFitReason(career.Weights)})
+		}
+	}
+
+	rarity := make(map[string]int)
+	for _, class := range cc.content.CareerClasses() {
+		rarity[class.Name] = class.Rarity()
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Score != scores[j].Score {
+			return scores[i].Score > scores[j].Score
+		}
+		return rarity[scores[i].Class] < rarity[scores[j].Class]
+	})
+
+	if topN > 0 && len(scores) > topN {
+		scores = scores[:topN]
+	}
+	return scores
+}
+
+// careerFitReason names the characteristics a career weights the most
+// (e.g. "высокие WS+T"), for explaining why SuggestCareers picked it. Empty
+// when the career has no weights of its own (every characteristic counts
+// equally, so no single one stands out)
+func careerFitReason(weights map[string]int) string {
+	if len(weights) == 0 {
+		return ""
+	}
+
+	max := 0
+	for _, w := range weights {
+		if w > max {
+			max = w
+		}
+	}
+
+	var top []string
+	for _, stat := range []string{"WS", "BS", "S", "T", "I", "Ag", "Dex", "Int", "WP", "Fel"} {
+		if weights[stat] == max {
+			top = append(top, stat)
+		}
+	}
+
+	return "высокие " + strings.Join(top, "+")
+}
+
+// suggestedCareersText renders RecommendCareer's top picks as a short
+// auto-suggest hint, useful for players who will roll their stats
+// randomly rather than pick a career by name
+func (cc *CharacterCreator) suggestedCareersText() string {
+	if cc.NoSuggest {
+		return ""
+	}
+
+	top := cc.RecommendCareer(cc.Data)
+	if len(top) == 0 {
+		return ""
+	}
+
+	var builder strings.Builder
+	builder.WriteString("\n💡 По твоим характеристикам подойдут: ")
+	names := make([]string, len(top))
+	for i, score := range top {
+		names[i] = fmt.Sprintf("%s (%s)", score.Career, score.Class)
+	}
+	builder.WriteString(strings.Join(names, ", "))
+	return builder.String()
+}
+
+// careerFitText reports how well the already-chosen career fits the
+// characteristics just rolled by rollStats. Career is picked at CC_Career,
+// which comes before CC_Stats in this state machine, so there's no menu
+// left to reorder by the time stats exist - this is the honest equivalent:
+// tell the player whether their roll happened to favour their career, and
+// what would have fit better if not
+func (cc *CharacterCreator) careerFitText() string {
+	if cc.NoSuggest {
+		return ""
+	}
+
+	top := cc.SuggestCareers(3)
+	if len(top) == 0 {
+		return ""
+	}
+
+	for i, score := range top {
+		if score.Career == cc.Data.Career {
+			if i == 0 {
+				return fmt.Sprintf("\n\n🎯 Бросок удачно лёг под твою карьеру «%s» (%s) — счёт %d.", score.Career, score.Class, score.Score)
+			}
+			return fmt.Sprintf("\n\n🎯 Твоя карьера «%s» (%s) — счёт %d, %d-е место по броску.", score.Career, score.Class, score.Score, i+1)
+		}
+	}
+
+	best := top[0]
+	reason := best.Reason
+	if reason != "" {
+		reason = " (" + reason + ")"
+	}
+	return fmt.Sprintf("\n\n🎯 По этому броску лучше подошла бы карьера «%s» (%s)%s — счёт %d против твоего выбора «%s».",
+		best.Career, best.Class, reason, best.Score, cc.Data.Career)
 }
 
 // processStats handles characteristic generation
@@ -720,12 +942,16 @@ func (cc *CharacterCreator) processStats(input string) (string, bool) {
 		cc.Data.StatsMethod = "random_swap"
 		cc.Data.XPFromStats = 25
 		cc.rollStats(true)
+		cc.Data.TotalXP += cc.Data.XPFromStats
+		cc.State = CC_StatsSwap
+		return fmt.Sprintf("%s\n%s\n\n+ %d XP (–≤—Å–µ–≥–æ: %d)\n\n%s",
+			"–•–∞—Ä–∞–∫—Ç–µ—Ä–∏—Å—Ç–∏–∫–∏ (–±—Ä–æ—Å–æ–∫ 2d10, –±–æ–Ω—É—Å —Ä–∞—Å—ã –ø—Ä–∏–º–µ–Ω–∏—Ç—Å—è –ø–æ—Å–ª–µ –æ–±–º–µ–Ω–∞):", cc.getNumberedStatsList(), cc.Data.XPFromStats, cc.Data.TotalXP, cc.GetPrompt()), true
 
 	case 3:
 		// Manual - ask for values
 		cc.Data.StatsMethod = "manual"
 		cc.Data.XPFromStats = 0
-		cc.State = CC_Skills
+		cc.State = CC_StatsManual
 		return "–†–∞—Å–ø—Ä–µ–¥–µ–ª–∏ 100 –ø—É–Ω–∫—Ç–æ–≤ –º–µ–∂–¥—É 10 —Ö–∞—Ä–∞–∫—Ç–µ—Ä–∏—Å—Ç–∏–∫–∞–º–∏ (–º–∏–Ω–∏–º—É–º 4, –º–∞–∫—Å–∏–º—É–º 18 –Ω–∞ –∫–∞–∂–¥—É—é).\n\n–§–æ—Ä–º–∞—Ç: WS=XX BS=XX S=XX T=XX I=XX Ag=XX Dex=XX Int=XX WP=XX Fel=XX", true
 
 	default:
@@ -735,8 +961,138 @@ func (cc *CharacterCreator) processStats(input string) (string, bool) {
 	cc.Data.TotalXP += cc.Data.XPFromStats
 	cc.calculateSecondaryStats()
 	cc.State = CC_Skills
-	return fmt.Sprintf("–•–∞—Ä–∞–∫—Ç–µ—Ä–∏—Å—Ç–∏–∫–∏ (–±—Ä–æ—Å–æ–∫ 2d10 + –±–æ–Ω—É—Å —Ä–∞—Å—ã):\n%s\n\n+ %d XP (–≤—Å–µ–≥–æ: %d)\n\n%s",
-		cc.getStatsSummary(), cc.Data.XPFromStats, cc.Data.TotalXP, cc.GetPrompt()), true
+
+	fitText := ""
+	if choice == 1 {
+		fitText = cc.careerFitText()
+	}
+
+	return fmt.Sprintf("–•–∞—Ä–∞–∫—Ç–µ—Ä–∏—Å—Ç–∏–∫–∏ (–±—Ä–æ—Å–æ–∫ 2d10 + –±–æ–Ω—É—Å —Ä–∞—Å—ã):\n%s%s\n\n+ %d XP (–≤—Å–µ–≥–æ: %d)\n\n%s",
+		cc.getStatsSummary(), fitText, cc.Data.XPFromStats, cc.Data.TotalXP, cc.GetPrompt()), true
+}
+
+// manualStatKeys are the ten characteristics processStatsManual expects,
+// in canonical casing (as used in CharacterCreationData's JSON tags and the
+// "WS=XX BS=XX ..." prompt)
+var manualStatKeys = []string{"WS", "BS", "S", "T", "I", "Ag", "Dex", "Int", "WP", "Fel"}
+
+// maxStatSwaps is the WFRP4e-allowed number of swaps for the "random with
+// swap" stats method (processStats case 2, CC_StatsSwap)
+const maxStatSwaps = 3
+
+// processStatsManual parses the "WS=XX BS=XX ..." distribution promised by
+// processStats case 3: every one of the ten characteristics must appear
+// exactly once, as an integer between 4 and 18, and the ten values must sum
+// to exactly 100. On success it applies the race bonus the same way
+// rollStats does, so manual and random characters land in the same range
+func (cc *CharacterCreator) processStatsManual(input string) (string, bool) {
+	values := make(map[string]int, len(manualStatKeys))
+	seen := make(map[string]bool, len(manualStatKeys))
+
+	for _, token := range strings.Fields(input) {
+		parts := strings.SplitN(token, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Sprintf("–ù–µ –ø–æ–Ω—è–ª \"%s\". –§–æ—Ä–º–∞—Ç: WS=XX BS=XX S=XX T=XX I=XX Ag=XX Dex=XX Int=XX WP=XX Fel=XX", token), false
+		}
+
+		key := canonicalStatKey(parts[0])
+		if key == "" {
+			return fmt.Sprintf("–ù–µ–∏–∑–≤–µ—Å—Ç–Ω–∞—è —Ö–∞—Ä–∞–∫—Ç–µ—Ä–∏—Å—Ç–∏–∫–∞ \"%s\". –û–∂–∏–¥–∞—é—Ç—Å—è: %s", parts[0], strings.Join(manualStatKeys, ", ")), false
+		}
+		if seen[key] {
+			return fmt.Sprintf("–•–∞—Ä–∞–∫—Ç–µ—Ä–∏—Å—Ç–∏–∫–∞ %s —É–∫–∞–∑–∞–Ω–∞ –¥–≤–∞–∂–¥—ã.", key), false
+		}
+		seen[key] = true
+
+		value, err := strconv.Atoi(parts[1])
+		if err != nil {
+			return fmt.Sprintf("–ó–Ω–∞—á–µ–Ω–∏–µ %s –¥–æ–ª–∂–Ω–æ –±—ã—Ç—å —á–∏—Å–ª–æ–º, –ø–æ–ª—É—á–∏–ª \"%s\".", key, parts[1]), false
+		}
+		if value < 4 || value > 18 {
+			return fmt.Sprintf("%s = %d –≤–Ω–µ –¥–∏–∞–ø–∞–∑–æ–Ω–∞ (–º–∏–Ω–∏–º—É–º 4, –º–∞–∫—Å–∏–º—É–º 18).", key, value), false
+		}
+		values[key] = value
+	}
+
+	var missing []string
+	for _, key := range manualStatKeys {
+		if !seen[key] {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Sprintf("–ù–µ —Ö–≤–∞—Ç–∞–µ—Ç —Ö–∞—Ä–∞–∫—Ç–µ—Ä–∏—Å—Ç–∏–∫: %s.", strings.Join(missing, ", ")), false
+	}
+
+	sum := 0
+	for _, v := range values {
+		sum += v
+	}
+	if sum != 100 {
+		delta := sum - 100
+		if delta > 0 {
+			return fmt.Sprintf("–°—É–º–º–∞ %d, –Ω—É–∂–Ω–æ 100 (–ø–µ—Ä–µ–±–æ—Ä –Ω–∞ %d). –£–º–µ–Ω—å—à–∏ —Ä–∞—Å–ø—Ä–µ–¥–µ–ª–µ–Ω–∏–µ.", sum, delta), false
+		}
+		return fmt.Sprintf("–°—É–º–º–∞ %d, –Ω—É–∂–Ω–æ 100 (–Ω–µ —Ö–≤–∞—Ç–∞–µ—Ç %d). –£–≤–µ–ª–∏—á—å —Ä–∞—Å–ø—Ä–µ–¥–µ–ª–µ–Ω–∏–µ.", sum, -delta), false
+	}
+
+	bonus := raceStatBonus(cc.Data.Race)
+	cc.Data.WS = values["WS"] + bonus
+	cc.Data.BS = values["BS"] + bonus
+	cc.Data.S = values["S"] + bonus
+	cc.Data.T = values["T"] + bonus
+	cc.Data.I = values["I"] + bonus
+	cc.Data.Ag = values["Ag"] + bonus
+	cc.Data.Dex = values["Dex"] + bonus
+	cc.Data.Int = values["Int"] + bonus
+	cc.Data.WP = values["WP"] + bonus
+	cc.Data.Fel = values["Fel"] + bonus
+
+	cc.calculateSecondaryStats()
+	cc.State = CC_Skills
+
+	return fmt.Sprintf("–•–∞—Ä–∞–∫—Ç–µ—Ä–∏—Å—Ç–∏–∫–∏ (—Ä—É—á–Ω–æ–µ —Ä–∞—Å–ø—Ä–µ–¥–µ–ª–µ–Ω–∏–µ + –±–æ–Ω—É—Å —Ä–∞—Å—ã):\n%s\n\n%s",
+		cc.getStatsSummary(), cc.GetPrompt()), true
+}
+
+// canonicalStatKey matches key against manualStatKeys case-insensitively,
+// returning the canonical form ("ag" -> "Ag") or "" if key isn't one of them
+func canonicalStatKey(key string) string {
+	for _, canonical := range manualStatKeys {
+		if strings.EqualFold(key, canonical) {
+			return canonical
+		}
+	}
+	return ""
+}
+
+// statPointer returns a pointer to cc.Data's field for key (one of
+// manualStatKeys, case-insensitive), or nil if key isn't one of them
+func (cc *CharacterCreator) statPointer(key string) *int {
+	switch canonicalStatKey(key) {
+	case "WS":
+		return &cc.Data.WS
+	case "BS":
+		return &cc.Data.BS
+	case "S":
+		return &cc.Data.S
+	case "T":
+		return &cc.Data.T
+	case "I":
+		return &cc.Data.I
+	case "Ag":
+		return &cc.Data.Ag
+	case "Dex":
+		return &cc.Data.Dex
+	case "Int":
+		return &cc.Data.Int
+	case "WP":
+		return &cc.Data.WP
+	case "Fel":
+		return &cc.Data.Fel
+	default:
+		return nil
+	}
 }
 
 // rollStats generates random characteristics
@@ -754,34 +1110,202 @@ func (cc *CharacterCreator) rollStats(allowSwap bool) {
 		rand.Intn(10) + rand.Intn(10) + 2,
 	}
 
-	// Apply race bonuses
-	raceBonus := map[string]int{
-		"–ß–µ–ª–æ–≤–µ–∫": 30, "–ü–æ–ª—É—Ä–æ—Å–ª–∏–∫": 20, "–ì–Ω–æ–º": 30,
-		"–í—ã—Å—à–∏–π —ç–ª—å—Ñ": 40, "–õ–µ—Å–Ω–æ–π —ç–ª—å—Ñ": 30,
+	cc.Data.WS = baseStats[0]
+	cc.Data.BS = baseStats[1]
+	cc.Data.S = baseStats[2]
+	cc.Data.T = baseStats[3]
+	cc.Data.I = baseStats[4]
+	cc.Data.Ag = baseStats[5]
+	cc.Data.Dex = baseStats[6]
+	cc.Data.Int = baseStats[7]
+	cc.Data.WP = baseStats[8]
+	cc.Data.Fel = baseStats[9]
+
+	if allowSwap {
+		// Race bonus is applied after the swap phase (see finishStatsSwap),
+		// not here, so the bonus stays on whichever characteristic the
+		// player finally settles the roll on
+		cc.Data.SwapCount = 0
+		cc.Data.SwapLog = nil
+		return
 	}
 
-	bonus := raceBonus[cc.Data.Race]
-	if bonus == 0 {
-		bonus = 30
+	bonus := raceStatBonus(cc.Data.Race)
+	cc.Data.WS += bonus
+	cc.Data.BS += bonus
+	cc.Data.S += bonus
+	cc.Data.T += bonus
+	cc.Data.I += bonus
+	cc.Data.Ag += bonus
+	cc.Data.Dex += bonus
+	cc.Data.Int += bonus
+	cc.Data.WP += bonus
+	cc.Data.Fel += bonus
+}
+
+// getNumberedStatsList renders the ten rolled characteristics as a numbered
+// list ("1. WS (ББ): 12", ...) for the swap/set commands in CC_StatsSwap
+// to reference by index or by key
+func (cc *CharacterCreator) getNumberedStatsList() string {
+	var b strings.Builder
+	for i, key := range manualStatKeys {
+		fmt.Fprintf(&b, "%d. %s (%s): %d\n", i+1, key, GetRussianStat(key), *cc.statPointer(key))
 	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// statsSwapPrompt renders the CC_StatsSwap instructions together with the
+// current (pre-bonus) characteristic values
+func (cc *CharacterCreator) statsSwapPrompt() string {
+	return fmt.Sprintf(`–¢–≤–æ–π –±—Ä–æ—Å–æ–∫ (–±–æ–Ω—É—Å —Ä–∞—Å—ã –¥–æ–±–∞–≤–∏—Ç—Å—è –ø–æ—Å–ª–µ –æ–±–º–µ–Ω–∞):
+%s
+
+–ú–æ–∂–Ω–æ –æ–±–º–µ–Ω—è—Ç—å –º–µ—Å—Ç–∞–º–∏ –∑–Ω–∞—á–µ–Ω–∏—è –ª—é–±—ã—Ö –¥–≤—É—Ö —Ö–∞—Ä–∞–∫—Ç–µ—Ä–∏—Å—Ç–∏–∫ (–¥–æ %d —Ä–∞–∑).
+–ö–æ–º–∞–Ω–¥—ã:
+- swap N M - –ø–æ–º–µ–Ω—è—Ç—å –º–µ—Å—Ç–∞–º–∏ —Ö–∞—Ä–∞–∫—Ç–µ—Ä–∏—Å—Ç–∏–∫–∏ —Å –Ω–æ–º–µ—Ä–∞–º–∏ N –∏ M
+- set –ö–õ–Æ–ß=–∑–Ω–∞—á–µ–Ω–∏–µ - –Ω–∞–ø—Ä–∏–º–µ—Ä set WS=42 (—Å—Ä–∞–±–æ—Ç–∞–µ—Ç —Ç–æ–ª—å–∫–æ –µ—Å–ª–∏ 42 —É–∂–µ –µ—Å—Ç—å —Å—Ä–µ–¥–∏ —Ç–µ–∫—É—â–∏—Ö –∑–Ω–∞—á–µ–Ω–∏–π - —ç—Ç–æ —Ç–æ–∂–µ –æ–±–º–µ–Ω, –∞ –Ω–µ —Å–≤–æ–±–æ–¥–Ω–æ–µ —Ä–µ–¥–∞–∫—Ç–∏—Ä–æ–≤–∞–Ω–∏–µ)
+- –¥–∞–ª–µ–µ - –∑–∞–∫–æ–Ω—á–∏—Ç—å –æ–±–º–µ–Ω—ã –∏ –ø—Ä–∏–º–µ–Ω–∏—Ç—å –±–æ–Ω—É—Å —Ä–∞—Å—ã
 
-	// Apply to characteristics
-	cc.Data.WS = baseStats[0] + bonus
-	cc.Data.BS = baseStats[1] + bonus
-	cc.Data.S = baseStats[2] + bonus
-	cc.Data.T = baseStats[3] + bonus
-	cc.Data.I = baseStats[4] + bonus
-	cc.Data.Ag = baseStats[5] + bonus
-	cc.Data.Dex = baseStats[6] + bonus
-	cc.Data.Int = baseStats[7] + bonus
-	cc.Data.WP = baseStats[8] + bonus
-	cc.Data.Fel = baseStats[9] + bonus
+–û—Å—Ç–∞–ª–æ—Å—å –æ–±–º–µ–Ω–æ–≤: %d`,
+		cc.getNumberedStatsList(), maxStatSwaps, maxStatSwaps-cc.Data.SwapCount)
+}
 
-	// Swap if allowed
-	if allowSwap && len(baseStats) > 0 {
-		// For simplicity, we'll just note that swap is possible
-		// In full implementation, player could reorder
+// processStatsSwap handles CC_StatsSwap input: "swap N M" swaps the two
+// characteristics at positions N and M in getNumberedStatsList, "set
+// KEY=value" swaps KEY with whichever characteristic currently holds value
+// (rejecting any value that isn't already rolled, so this stays a swap and
+// not a free edit), and "далее"/"done" applies the race bonus and moves on
+func (cc *CharacterCreator) processStatsSwap(input string) (string, bool) {
+	fields := strings.Fields(strings.TrimSpace(input))
+	if len(fields) == 0 {
+		return "–ù–µ –ø–æ–Ω—è–ª –∫–æ–º–∞–Ω–¥—É. –ù–∞–ø–∏—à–∏ \"swap N M\", \"set –ö–õ–Æ–ß=–∑–Ω–∞—á–µ–Ω–∏–µ\" –∏–ª–∏ \"–¥–∞–ª–µ–µ\".", false
 	}
+
+	switch strings.ToLower(fields[0]) {
+	case "далее", "done", "–¥–∞–ª–µ–µ":
+		return cc.finishStatsSwap()
+
+	case "swap":
+		if len(fields) != 3 {
+			return "–§–æ—Ä–º–∞—Ç: swap N M, –≥–¥–µ N –∏ M - –Ω–æ–º–µ—Ä–∞ —Ö–∞—Ä–∞–∫—Ç–µ—Ä–∏—Å—Ç–∏–∫ (1-10).", false
+		}
+		return cc.swapByIndex(fields[1], fields[2])
+
+	case "set":
+		if len(fields) != 2 {
+			return "–§–æ—Ä–º–∞—Ç: set –ö–õ–Æ–ß=–∑–Ω–∞—á–µ–Ω–∏–µ, –Ω–∞–ø—Ä–∏–º–µ—Ä set WS=42.", false
+		}
+		return cc.swapBySet(fields[1])
+	}
+
+	return "–ù–µ –ø–æ–Ω—è–ª –∫–æ–º–∞–Ω–¥—É. –ù–∞–ø–∏—à–∏ \"swap N M\", \"set –ö–õ–Æ–ß=–∑–Ω–∞—á–µ–Ω–∏–µ\" –∏–ª–∏ \"–¥–∞–ª–µ–µ\".", false
+}
+
+// swapByIndex swaps the characteristics at 1-based positions aStr and bStr
+// in manualStatKeys, enforcing maxStatSwaps
+func (cc *CharacterCreator) swapByIndex(aStr, bStr string) (string, bool) {
+	if cc.Data.SwapCount >= maxStatSwaps {
+		return fmt.Sprintf("–û–±–º–µ–Ω—ã –∏—Å—á–µ—Ä–ø–∞–Ω—ã (–º–∞–∫—Å–∏–º—É–º %d). –ù–∞–ø–∏—à–∏ \"–¥–∞–ª–µ–µ\", —á—Ç–æ–±—ã –ø—Ä–æ–¥–æ–ª–∂–∏—Ç—å.", maxStatSwaps), false
+	}
+
+	a, errA := strconv.Atoi(aStr)
+	b, errB := strconv.Atoi(bStr)
+	if errA != nil || errB != nil || a < 1 || a > len(manualStatKeys) || b < 1 || b > len(manualStatKeys) {
+		return fmt.Sprintf("–ù–æ–º–µ—Ä–∞ –¥–æ–ª–∂–Ω—ã –±—ã—Ç—å –æ—Ç 1 –¥–æ %d.", len(manualStatKeys)), false
+	}
+	if a == b {
+		return "–ù–µ–ª—å–∑—è –ø–æ–º–µ–Ω—è—Ç—å —Ö–∞—Ä–∞–∫—Ç–µ—Ä–∏—Å—Ç–∏–∫—É —Å–∞–º—É —Å —Å–æ–±–æ–π.", false
+	}
+
+	return cc.swapStats(manualStatKeys[a-1], manualStatKeys[b-1])
+}
+
+// swapBySet implements "set KEY=value": it only succeeds if value already
+// belongs to some other currently-rolled characteristic, in which case KEY
+// and that characteristic trade values - a swap addressed by value instead
+// of by position, never a free edit
+func (cc *CharacterCreator) swapBySet(token string) (string, bool) {
+	parts := strings.SplitN(token, "=", 2)
+	if len(parts) != 2 {
+		return "–§–æ—Ä–º–∞—Ç: set –ö–õ–Æ–ß=–∑–Ω–∞—á–µ–Ω–∏–µ, –Ω–∞–ø—Ä–∏–º–µ—Ä set WS=42.", false
+	}
+
+	key := canonicalStatKey(parts[0])
+	if key == "" {
+		return fmt.Sprintf("–ù–µ–∏–∑–≤–µ—Å—Ç–Ω–∞—è —Ö–∞—Ä–∞–∫—Ç–µ—Ä–∏—Å—Ç–∏–∫–∞ \"%s\".", parts[0]), false
+	}
+	value, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return fmt.Sprintf("–ó–Ω–∞—á–µ–Ω–∏–µ –¥–æ–ª–∂–Ω–æ –±—ã—Ç—å —á–∏—Å–ª–æ–º, –ø–æ–ª—É—á–∏–ª \"%s\".", parts[1]), false
+	}
+	if cc.Data.SwapCount >= maxStatSwaps {
+		return fmt.Sprintf("–û–±–º–µ–Ω—ã –∏—Å—á–µ—Ä–ø–∞–Ω—ã (–º–∞–∫—Å–∏–º—É–º %d). –ù–∞–ø–∏—à–∏ \"–¥–∞–ª–µ–µ\", —á—Ç–æ–±—ã –ø—Ä–æ–¥–æ–ª–∂–∏—Ç—å.", maxStatSwaps), false
+	}
+
+	otherKey := ""
+	for _, candidate := range manualStatKeys {
+		if candidate == key {
+			continue
+		}
+		if *cc.statPointer(candidate) == value {
+			otherKey = candidate
+			break
+		}
+	}
+	if otherKey == "" {
+		return fmt.Sprintf("%d –Ω–µ —Å–æ–≤–ø–∞–¥–∞–µ—Ç –Ω–∏ —Å –æ–¥–Ω–∏–º –∏–∑ —Ç–µ–∫—É—â–∏—Ö –∑–Ω–∞—á–µ–Ω–∏–π - —ç—Ç–æ –Ω–µ –æ–±–º–µ–Ω, –∞ —Å–≤–æ–±–æ–¥–Ω–æ–µ —Ä–µ–¥–∞–∫—Ç–∏—Ä–æ–≤–∞–Ω–∏–µ, –∫–æ—Ç–æ—Ä–æ–µ –∑–¥–µ—Å—å –∑–∞–ø—Ä–µ—â–µ–Ω–æ.", value), false
+	}
+
+	return cc.swapStats(key, otherKey)
+}
+
+// swapStats exchanges keyA's and keyB's rolled values and records the move
+// in cc.Data.SwapLog as an audit trail (see saveStep)
+func (cc *CharacterCreator) swapStats(keyA, keyB string) (string, bool) {
+	ptrA, ptrB := cc.statPointer(keyA), cc.statPointer(keyB)
+	*ptrA, *ptrB = *ptrB, *ptrA
+
+	cc.Data.SwapCount++
+	cc.Data.SwapLog = append(cc.Data.SwapLog, fmt.Sprintf("swap #%d: %s<->%s", cc.Data.SwapCount, keyA, keyB))
+
+	return fmt.Sprintf("–û–±–º–µ–Ω #%d: %s <-> %s (–æ—Å—Ç–∞–ª–æ—Å—å –æ–±–º–µ–Ω–æ–≤: %d)\n\n%s",
+		cc.Data.SwapCount, keyA, keyB, maxStatSwaps-cc.Data.SwapCount, cc.getNumberedStatsList()), true
+}
+
+// finishStatsSwap applies the race bonus (deferred until now, see rollStats)
+// and moves on to CC_Skills, same as the no-swap path in processStats
+func (cc *CharacterCreator) finishStatsSwap() (string, bool) {
+	bonus := raceStatBonus(cc.Data.Race)
+	cc.Data.WS += bonus
+	cc.Data.BS += bonus
+	cc.Data.S += bonus
+	cc.Data.T += bonus
+	cc.Data.I += bonus
+	cc.Data.Ag += bonus
+	cc.Data.Dex += bonus
+	cc.Data.Int += bonus
+	cc.Data.WP += bonus
+	cc.Data.Fel += bonus
+
+	cc.calculateSecondaryStats()
+	cc.State = CC_Skills
+
+	return fmt.Sprintf("–•–∞—Ä–∞–∫—Ç–µ—Ä–∏—Å—Ç–∏–∫–∏ (–ø–æ—Å–ª–µ –æ–±–º–µ–Ω–∞ + –±–æ–Ω—É—Å —Ä–∞—Å—ã):\n%s%s\n\n%s",
+		cc.getStatsSummary(), cc.careerFitText(), cc.GetPrompt()), true
+}
+
+// raceStatBonus is the flat bonus added to a 2d10 characteristic roll for
+// race (e.g. a Wood Elf's +30 on top of the 2-20 roll). Shared by rollStats
+// and processStatsManual so both paths land characteristics in the same range
+func raceStatBonus(race string) int {
+	bonus := map[string]int{
+		"–ß–µ–ª–æ–≤–µ–∫": 30, "–ü–æ–ª—É—Ä–æ—Å–ª–∏–∫": 20, "–ì–Ω–æ–º": 30,
+		"–í—ã—Å—à–∏–π —ç–ª—å—Ñ": 40, "–õ–µ—Å–Ω–æ–π —ç–ª—å—Ñ": 30,
+	}[race]
+	if bonus == 0 {
+		bonus = 30
+	}
+	return bonus
 }
 
 // calculateSecondaryStats calculates HP, Fate, etc.
@@ -795,9 +1319,9 @@ func (cc *CharacterCreator) calculateSecondaryStats() {
 
 	// Fate and Resilience based on race
 	fateResilience := map[string][2]int{
-		"–ß–µ–ª–æ–≤–µ–∫":     {2, 1},
+		"–ß–µ–ª–æ–≤–µ–∫":        {2, 1},
 		"–ü–æ–ª—É—Ä–æ—Å–ª–∏–∫":  {0, 2},
-		"–ì–Ω–æ–º":        {0, 2},
+		"–ì–Ω–æ–º":              {0, 2},
 		"–í—ã—Å—à–∏–π —ç–ª—å—Ñ": {0, 0},
 		"–õ–µ—Å–Ω–æ–π —ç–ª—å—Ñ": {0, 0},
 	}
@@ -848,12 +1372,22 @@ func (cc *CharacterCreator) getGearInfo() string {
 
 // processAppearance handles appearance generation
 func (cc *CharacterCreator) processAppearance(input string) (string, bool) {
-	// Generate random appearance
+	cc.rollAppearance()
+
+	cc.State = CC_Personality
+	return fmt.Sprintf("Внешность:\n- Волосы: %s\n- Глаза: %s\n- Рост: %s\n- Возраст: %d\n\n%s",
+		cc.Data.HairColor, cc.Data.EyeColor, cc.Data.Height, cc.Data.Age, cc.GetPrompt()), true
+}
+
+// rollAppearance rolls hair colour, eye colour, height and age the same way
+// processAppearance does, without touching cc.State - shared by
+// processAppearance and RerollAppearance
+func (cc *CharacterCreator) rollAppearance() {
 	hairRoll := rand.Intn(20) + 1
 	eyeRoll := rand.Intn(20) + 1
 
-	hairColors := []string{"—á—ë—Ä–Ω—ã–µ", "–∫–∞—à—Ç–∞–Ω–æ–≤—ã–µ", "—Ä—É—Å—ã–µ", "—Ä—ã–∂–∏–µ", "—Å–µ–¥—ã–µ", "–±–µ–ª—ã–µ"}
-	eyeColors := []string{"–∫–∞—Ä–∏–µ", "–≥–æ–ª—É–±—ã–µ", "–∑–µ–ª—ë–Ω—ã–µ", "—Å–µ—Ä—ã–µ", "—á—ë—Ä–Ω—ã–µ"}
+	hairColors := []string{"чёрные", "каштановые", "русые", "рыжие", "седые", "белые"}
+	eyeColors := []string{"карие", "голубые", "зелёные", "серые", "чёрные"}
 
 	if hairRoll > len(hairColors) {
 		hairRoll = len(hairColors)
@@ -866,7 +1400,7 @@ func (cc *CharacterCreator) processAppearance(input string) (string, bool) {
 	cc.Data.EyeColor = eyeColors[eyeRoll-1]
 
 	// Age: base + 2d10
-	ageBase := map[string]int{"–ß–µ–ª–æ–≤–µ–∫": 18, "–ü–æ–ª—É—Ä–æ—Å–ª–∏–∫": 30, "–ì–Ω–æ–º": 40, "–í—ã—Å—à–∏–π —ç–ª—å—Ñ": 100, "–õ–µ—Å–Ω–æ–π —ç–ª—å—Ñ": 50}
+	ageBase := map[string]int{"Человек": 18, "Полурослик": 30, "Гном": 40, "Высший эльф": 100, "Лесной эльф": 50}
 	base := ageBase[cc.Data.Race]
 	if base == 0 {
 		base = 18
@@ -874,32 +1408,152 @@ func (cc *CharacterCreator) processAppearance(input string) (string, bool) {
 	cc.Data.Age = base + rand.Intn(20) + 2
 
 	// Height (simplified)
-	cc.Data.Height = fmt.Sprintf("%d —Å–º", 150+rand.Intn(40))
+	cc.Data.Height = fmt.Sprintf("%d см", 150+rand.Intn(40))
+}
 
-	cc.State = CC_Personality
-	return fmt.Sprintf("–í–Ω–µ—à–Ω–æ—Å—Ç—å:\n- –í–æ–ª–æ—Å—ã: %s\n- –ì–ª–∞–∑–∞: %s\n- –†–æ—Å—Ç: %s\n- –í–æ–∑—Ä–∞—Å—Ç: %d\n\n%s",
-		cc.Data.HairColor, cc.Data.EyeColor, cc.Data.Height, cc.Data.Age, cc.GetPrompt()), true
+// RerollAppearance rerolls hair colour, eye colour, height and age without
+// forcing a state transition, so a player who doesn't like their appearance
+// can redo it from CC_Appearance, CC_Review or anywhere else without
+// walking back through the earlier steps
+func (cc *CharacterCreator) RerollAppearance() {
+	cc.rollAppearance()
+}
+
+// RerollStats rerolls characteristics in place, callable from any state so
+// a player can iterate on a roll they don't like. It's a no-op for the
+// manual distribution method, which has no random component to reroll
+func (cc *CharacterCreator) RerollStats() {
+	if cc.Data.StatsMethod == "manual" {
+		return
+	}
+	cc.rollStats(cc.Data.StatsMethod == "random_swap")
+	if cc.Data.StatsMethod == "random_swap" {
+		cc.State = CC_StatsSwap
+		return
+	}
+	cc.calculateSecondaryStats()
 }
 
-// processPersonality handles personality input
+// processPersonality handles personality input. Empty input (or the
+// "бросить" trigger, same word processRace already uses for a random
+// race) rolls every field via RollPersonality. Otherwise it's MixMode:
+// whatever lines the player actually filled in are kept, and RollPersonality
+// fills in anything still missing - strengths/weaknesses from a blank line,
+// motivation/dark secret/ambition always, since this free-text form never
+// asked for them. A blank background line leaves Background empty: there is
+// no background table to roll it from
 func (cc *CharacterCreator) processPersonality(input string) {
+	trimmed := strings.TrimSpace(input)
+	if trimmed == "" || strings.ToLower(trimmed) == "бросить" {
+		cc.RollPersonality()
+		return
+	}
+
 	lines := strings.Split(input, "\n")
-	if len(lines) >= 1 {
-		cc.Data.Strengths = strings.Split(lines[0], ",")
-		for i := range cc.Data.Strengths {
-			cc.Data.Strengths[i] = strings.TrimSpace(cc.Data.Strengths[i])
+	if len(lines) >= 1 && strings.TrimSpace(lines[0]) != "" {
+		cc.Data.Strengths = splitTrimmed(lines[0])
+	}
+	if len(lines) >= 2 && strings.TrimSpace(lines[1]) != "" {
+		cc.Data.Weaknesses = splitTrimmed(lines[1])
+	}
+	if len(lines) >= 3 && strings.TrimSpace(lines[2]) != "" {
+		cc.Data.Background = lines[2]
+	}
+
+	cc.RollPersonality()
+}
+
+// splitTrimmed splits a comma-separated line and trims whitespace from
+// each piece
+func splitTrimmed(line string) []string {
+	parts := strings.Split(line, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	return parts
+}
+
+// RollPersonality rolls any personality field the player hasn't already
+// filled in - strengths, weaknesses, motivation, dark secret and ambition -
+// from the weighted tables under game/content/data/personality, honouring
+// each entry's race/career restrictions. Motivation, dark secret and
+// ambition are always rolled: nothing upstream of CC_Personality ever asks
+// for them by hand
+func (cc *CharacterCreator) RollPersonality() {
+	tables := cc.content.Personality()
+
+	if len(cc.Data.Strengths) == 0 {
+		cc.Data.Strengths = pickPersonalityEntries(tables.Strengths, cc.Data.Race, cc.Data.Class, 1+rand.Intn(3))
+	}
+	if len(cc.Data.Weaknesses) == 0 {
+		cc.Data.Weaknesses = pickPersonalityEntries(tables.Weaknesses, cc.Data.Race, cc.Data.Class, 1+rand.Intn(3))
+	}
+	if cc.Data.Motivation == "" {
+		if picked := pickPersonalityEntries(tables.Motivations, cc.Data.Race, cc.Data.Class, 1); len(picked) > 0 {
+			cc.Data.Motivation = picked[0]
 		}
 	}
-	if len(lines) >= 2 {
-		cc.Data.Weaknesses = strings.Split(lines[1], ",")
-		for i := range cc.Data.Weaknesses {
-			cc.Data.Weaknesses[i] = strings.TrimSpace(cc.Data.Weaknesses[i])
+	if cc.Data.DarkSecret == "" {
+		if picked := pickPersonalityEntries(tables.DarkSecrets, cc.Data.Race, cc.Data.Class, 1); len(picked) > 0 {
+			cc.Data.DarkSecret = picked[0]
 		}
 	}
-	if len(lines) >= 3 {
-		cc.Data.Background = lines[2]
+	if cc.Data.Ambition == "" {
+		if picked := pickPersonalityEntries(tables.Ambitions, cc.Data.Race, cc.Data.Class, 1); len(picked) > 0 {
+			cc.Data.Ambition = picked[0]
+		}
+	}
+}
+
+// pickPersonalityEntries draws up to n distinct entries from table without
+// replacement, weighted by Weight (entries with Weight <= 0 count as 1),
+// after dropping any entry whose RaceRestrict/CareerRestrict doesn't match
+// race/class
+func pickPersonalityEntries(table []content.PersonalityEntry, race, class string, n int) []string {
+	pool := make([]content.PersonalityEntry, 0, len(table))
+	for _, entry := range table {
+		if len(entry.RaceRestrict) > 0 && !containsString(entry.RaceRestrict, race) {
+			continue
+		}
+		if len(entry.CareerRestrict) > 0 && !containsString(entry.CareerRestrict, class) {
+			continue
+		}
+		pool = append(pool, entry)
+	}
+	if n > len(pool) {
+		n = len(pool)
+	}
+
+	picked := make([]string, 0, n)
+	for i := 0; i < n; i++ {
+		total := 0
+		for _, entry := range pool {
+			total += personalityWeight(entry)
+		}
+		if total <= 0 {
+			break
+		}
+
+		roll := rand.Intn(total)
+		for idx, entry := range pool {
+			roll -= personalityWeight(entry)
+			if roll < 0 {
+				picked = append(picked, entry.Text)
+				pool = append(pool[:idx], pool[idx+1:]...)
+				break
+			}
+		}
+	}
+	return picked
+}
+
+// personalityWeight treats a non-positive Weight as 1 so an entry with no
+// weight set still has a chance to be picked
+func personalityWeight(entry content.PersonalityEntry) int {
+	if entry.Weight <= 0 {
+		return 1
 	}
-	cc.Data.Motivation = "–°—Ç–∞—Ç—å –∏—Å–∫–∞—Ç–µ–ª–µ–º –ø—Ä–∏–∫–ª—é—á–µ–Ω–∏–π"
+	return entry.Weight
 }
 
 // generateReview generates character review
@@ -924,6 +1578,9 @@ HP: %d | –°—É–¥—å–±–∞: %d | –î–≤–∏–∂–µ–Ω
 **–•–∞—Ä–∞–∫—Ç–µ—Ä:**
 –°–∏–ª—å–Ω—ã–µ: %s
 –°–ª–∞–±—ã–µ: %s
+–ú–æ—Ç–∏–≤–∞—Ü–∏—è: %s
+–¢—ë–º–Ω–∞—è —Ç–∞–π–Ω–∞: %s
+–°—Ç—Ä–µ–º–ª–µ–Ω–∏–µ: %s
 
 **–û–ø—ã—Ç:** %d –≤—Å–µ–≥–æ
 
@@ -936,6 +1593,7 @@ HP: %d | –°—É–¥—å–±–∞: %d | –î–≤–∏–∂–µ–Ω
 		cc.Data.Age, cc.Data.Height, cc.Data.HairColor, cc.Data.EyeColor,
 		strings.Join(cc.Data.Strengths, ", "),
 		strings.Join(cc.Data.Weaknesses, ", "),
+		cc.Data.Motivation, cc.Data.DarkSecret, cc.Data.Ambition,
 		cc.Data.TotalXP)
 }
 
@@ -950,6 +1608,7 @@ func (cc *CharacterCreator) saveStep() {
 		CC_Race:        "02_race",
 		CC_Career:      "03_career",
 		CC_Stats:       "04_stats",
+		CC_StatsSwap:   "04b_stats_swap",
 		CC_Skills:      "05_skills",
 		CC_Talents:     "06_talents",
 		CC_Gear:        "07_gear",
@@ -977,12 +1636,30 @@ func (cc *CharacterCreator) saveStep() {
 	content += fmt.Sprintf("- –†–∞—Å–∞: %s\n", cc.Data.Race)
 	content += fmt.Sprintf("- –ö–∞—Ä—å–µ—Ä–∞: %s\n", cc.Data.Career)
 
+	if len(cc.Data.SwapLog) > 0 {
+		content += "\n## –û–±–º–µ–Ω—ã —Ö–∞—Ä–∞–∫—Ç–µ—Ä–∏—Å—Ç–∏–∫:\n"
+		for _, entry := range cc.Data.SwapLog {
+			content += "- " + entry + "\n"
+		}
+	}
+
 	os.WriteFile(filename, []byte(content), 0644)
 }
 
 // GenerateCharacterMarkdown generates final character file
 func (cc *CharacterCreator) GenerateCharacterMarkdown() string {
-	return fmt.Sprintf(`# %s
+	return fmt.Sprintf(`---
+name: %s
+race: %s
+class: %s
+career: %s
+status: %s
+status_level: %d
+total_xp: %d
+created: %s
+---
+
+# %s
 
 **–î–∞—Ç–∞ —Å–æ–∑–¥–∞–Ω–∏—è:** %s  
 **–†–∞—Å–∞:** %s  
@@ -1031,14 +1708,25 @@ func (cc *CharacterCreator) GenerateCharacterMarkdown() string {
 
 ## –•–ê–†–ê–ö–¢–ï–†
 
-**–°–∏–ª—å–Ω—ã–µ —Å—Ç–æ—Ä–æ–Ω—ã:** %s  
-**–°–ª–∞–±—ã–µ —Å—Ç–æ—Ä–æ–Ω—ã:** %s  
+**–°–∏–ª—å–Ω—ã–µ —Å—Ç–æ—Ä–æ–Ω—ã:** %s
+**–°–ª–∞–±—ã–µ —Å—Ç–æ—Ä–æ–Ω—ã:** %s
+**–ú–æ—Ç–∏–≤–∞—Ü–∏—è:** %s
+**–¢—ë–º–Ω–∞—è —Ç–∞–π–Ω–∞:** %s
+**–°—Ç—Ä–µ–º–ª–µ–Ω–∏–µ:** %s
 **–ü—Ä–æ—à–ª–æ–µ:** %s
 
 ---
 
 **–ü–ï–†–°–û–ù–ê–ñ –ì–û–¢–û–í –ö –ò–ì–†–ï!**
 `,
+		cc.Data.Name,
+		cc.Data.Race,
+		cc.Data.Class,
+		cc.Data.Career,
+		cc.Data.Status,
+		cc.Data.StatusLevel,
+		cc.Data.TotalXP,
+		time.Now().Format("2006-01-02"),
 		cc.Data.Name,
 		time.Now().Format("2006-01-02"),
 		cc.Data.Race,
@@ -1065,6 +1753,9 @@ func (cc *CharacterCreator) GenerateCharacterMarkdown() string {
 		cc.Data.EyeColor,
 		strings.Join(cc.Data.Strengths, ", "),
 		strings.Join(cc.Data.Weaknesses, ", "),
+		cc.Data.Motivation,
+		cc.Data.DarkSecret,
+		cc.Data.Ambition,
 		cc.Data.Background,
 	)
 }
@@ -1076,7 +1767,57 @@ func (cc *CharacterCreator) SaveToFile(basePath string) error {
 	}
 
 	filename := filepath.Join(basePath, fmt.Sprintf("character_%s.md", cc.Data.Name))
-	return os.WriteFile(filename, []byte(cc.GenerateCharacterMarkdown()), 0644)
+	if err := os.WriteFile(filename, []byte(cc.GenerateCharacterMarkdown()), 0644); err != nil {
+		return err
+	}
+
+	if err := savePreviousData(basePath, cc.Data); err != nil {
+		log.Printf("[CHARGEN] Failed to save previous character snapshot: %v", err)
+	}
+	return nil
+}
+
+// previousDataPath returns basePath/previous.json, where the last finished
+// character is snapshotted for the "0" quick-start shortcut at CC_Name
+func previousDataPath(basePath string) string {
+	return filepath.Join(basePath, "previous.json")
+}
+
+// loadPreviousData reads the snapshot left by savePreviousData, if any. A
+// missing file is not an error - it just means no character has been
+// finished from basePath yet
+func loadPreviousData(basePath string) (*CharacterCreationData, error) {
+	if basePath == "" {
+		basePath = "./characters"
+	}
+
+	raw, err := os.ReadFile(previousDataPath(basePath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read previous character snapshot: %w", err)
+	}
+
+	var data CharacterCreationData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, fmt.Errorf("failed to parse previous character snapshot: %w", err)
+	}
+	return &data, nil
+}
+
+// savePreviousData snapshots data as basePath/previous.json, overwriting
+// whatever character was finished there before
+func savePreviousData(basePath string, data *CharacterCreationData) error {
+	raw, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode previous character snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(basePath, 0755); err != nil {
+		return fmt.Errorf("failed to create characters directory: %w", err)
+	}
+	return os.WriteFile(previousDataPath(basePath), raw, 0644)
 }
 
 // GetState returns current state
@@ -1084,6 +1825,72 @@ func (cc *CharacterCreator) GetState() CharacterCreationState {
 	return cc.State
 }
 
+// ResetTo rewinds the creator to state, clearing every field a step at or
+// after state would have filled in, and sets cc.State to state. Used when a
+// player jumps back to redo an earlier choice (e.g. race) without starting
+// the whole creation over from CC_Name
+func (cc *CharacterCreator) ResetTo(state CharacterCreationState) {
+	switch {
+	case state <= CC_Name:
+		cc.Data.Name = ""
+		fallthrough
+
+	case state <= CC_Race:
+		cc.Data.Race = ""
+		cc.Data.RaceMethod = ""
+		cc.Data.RaceBonusXP = 0
+		fallthrough
+
+	case state <= CC_Career:
+		cc.Data.Class = ""
+		cc.Data.Career = ""
+		cc.Data.CareerRank = ""
+		cc.Data.CareerMethod = ""
+		cc.Data.CareerXP = 0
+		cc.Data.Status = ""
+		cc.Data.StatusLevel = 0
+		cc.pendingCareerOptions = nil
+		fallthrough
+
+	case state <= CC_StatsSwap:
+		cc.Data.WS, cc.Data.BS, cc.Data.S, cc.Data.T = 0, 0, 0, 0
+		cc.Data.I, cc.Data.Ag, cc.Data.Dex, cc.Data.Int = 0, 0, 0, 0
+		cc.Data.WP, cc.Data.Fel = 0, 0
+		cc.Data.StatsMethod = ""
+		cc.Data.XPFromStats = 0
+		cc.Data.SwapCount = 0
+		cc.Data.SwapLog = nil
+		cc.Data.HP, cc.Data.Fate, cc.Data.Fortune = 0, 0, 0
+		cc.Data.Resilience, cc.Data.Resolve, cc.Data.Movement = 0, 0, 0
+		fallthrough
+
+	case state <= CC_Talents:
+		cc.Data.Skills = make(map[string]int)
+		cc.Data.Talents = []string{}
+		fallthrough
+
+	case state <= CC_Gear:
+		cc.Data.Gear = make(map[string]string)
+		cc.Data.Money = 0
+		fallthrough
+
+	case state <= CC_Personality:
+		cc.Data.HairColor = ""
+		cc.Data.EyeColor = ""
+		cc.Data.Height = ""
+		cc.Data.Age = 0
+		cc.Data.Strengths = nil
+		cc.Data.Weaknesses = nil
+		cc.Data.Background = ""
+		cc.Data.Motivation = ""
+		cc.Data.DarkSecret = ""
+		cc.Data.Ambition = ""
+	}
+
+	cc.Data.TotalXP = cc.Data.RaceBonusXP + cc.Data.CareerXP + cc.Data.XPFromStats
+	cc.State = state
+}
+
 // IsComplete returns true if character creation is complete
 func (cc *CharacterCreator) IsComplete() bool {
 	return cc.State == CC_Complete || cc.State == CC_Save
@@ -0,0 +1,87 @@
+package game
+
+import (
+	"strings"
+	"testing"
+)
+
+// validManualStats is a happy-path "WS=XX BS=XX ..." distribution: one
+// entry per manualStatKeys, summing to exactly 100
+const validManualStats = "WS=10 BS=10 S=10 T=10 I=10 Ag=10 Dex=10 Int=10 WP=10 Fel=10"
+
+func TestProcessStatsManual(t *testing.T) {
+	tests := []struct {
+		name       string
+		input      string
+		wantOK     bool
+		wantSubstr string
+	}{
+		{
+			name:   "happy path",
+			input:  validManualStats,
+			wantOK: true,
+		},
+		{
+			name:       "missing key",
+			input:      "WS=10 BS=10 S=10 T=10 I=10 Ag=10 Dex=10 Int=10 WP=10",
+			wantOK:     false,
+			wantSubstr: "Fel",
+		},
+		{
+			name:       "duplicate key",
+			input:      "WS=10 WS=10 S=10 T=10 I=10 Ag=10 Dex=10 Int=10 WP=10 Fel=10",
+			wantOK:     false,
+			wantSubstr: "WS",
+		},
+		{
+			name:       "out-of-range value",
+			input:      "WS=19 BS=10 S=10 T=10 I=10 Ag=10 Dex=10 Int=10 WP=10 Fel=1",
+			wantOK:     false,
+			wantSubstr: "WS",
+		},
+		{
+			name:       "sum is not 100",
+			input:      "WS=15 BS=10 S=10 T=10 I=10 Ag=10 Dex=10 Int=10 WP=10 Fel=10",
+			wantOK:     false,
+			wantSubstr: "100",
+		},
+		{
+			name:       "non-integer value",
+			input:      "WS=ten BS=10 S=10 T=10 I=10 Ag=10 Dex=10 Int=10 WP=10 Fel=10",
+			wantOK:     false,
+			wantSubstr: "WS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := NewCharacterCreator(t.TempDir(), nil)
+
+			msg, ok := cc.processStatsManual(tt.input)
+			if ok != tt.wantOK {
+				t.Fatalf("processStatsManual(%q) ok = %v, want %v (message: %s)", tt.input, ok, tt.wantOK, msg)
+			}
+			if tt.wantSubstr != "" && !strings.Contains(msg, tt.wantSubstr) {
+				t.Fatalf("processStatsManual(%q) message = %q, want it to contain %q", tt.input, msg, tt.wantSubstr)
+			}
+			if tt.wantOK && cc.State != CC_Skills {
+				t.Fatalf("processStatsManual(%q) State = %v, want CC_Skills", tt.input, cc.State)
+			}
+		})
+	}
+}
+
+func TestProcessStatsManualAppliesRaceBonus(t *testing.T) {
+	cc := NewCharacterCreator(t.TempDir(), nil)
+
+	if _, ok := cc.processStatsManual(validManualStats); !ok {
+		t.Fatalf("processStatsManual(%q) failed", validManualStats)
+	}
+
+	// An unrecognized (here, empty) race falls back to the default +30
+	// bonus - see raceStatBonus
+	bonus := raceStatBonus(cc.Data.Race)
+	if cc.Data.WS != 10+bonus {
+		t.Fatalf("WS = %d, want %d (10 + race bonus %d)", cc.Data.WS, 10+bonus, bonus)
+	}
+}
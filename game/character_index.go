@@ -0,0 +1,381 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CharacterSummary is the indexed view of one saved character, parsed from
+// the YAML front-matter GenerateCharacterMarkdown writes at the top of
+// every character_*.md file - enough to list, filter and look up a
+// character without re-parsing the whole sheet
+type CharacterSummary struct {
+	Name        string    `json:"name"`
+	Race        string    `json:"race"`
+	Class       string    `json:"class"`
+	Career      string    `json:"career"`
+	Status      string    `json:"status"`
+	StatusLevel int       `json:"status_level"`
+	TotalXP     int       `json:"total_xp"`
+	Created     string    `json:"created"`
+	Path        string    `json:"path"`
+	ModTime     time.Time `json:"mod_time"`
+}
+
+// IndexFilter narrows Query to characters matching every non-empty field.
+// Comparisons are case-insensitive, same as the repo's other race/career
+// name matching (see processRace)
+type IndexFilter struct {
+	Race   string
+	Class  string
+	Career string
+	Status string
+}
+
+// matches reports whether summary satisfies every non-empty field of f
+func (f IndexFilter) matches(summary *CharacterSummary) bool {
+	if f.Race != "" && !strings.EqualFold(f.Race, summary.Race) {
+		return false
+	}
+	if f.Class != "" && !strings.EqualFold(f.Class, summary.Class) {
+		return false
+	}
+	if f.Career != "" && !strings.EqualFold(f.Career, summary.Career) {
+		return false
+	}
+	if f.Status != "" && !strings.EqualFold(f.Status, summary.Status) {
+		return false
+	}
+	return true
+}
+
+// CharacterIndex is an in-memory index over every character_*.md file under
+// basePath, keyed by name with secondary lookups by race, class and status.
+// It's rebuilt from the cached characters/_index.json when nothing on disk
+// is newer, so scanning hundreds of characters stays sub-second. This
+// replaces the O(files) directory walks saveStep/SaveToFile used to do
+// ad-hoc
+type CharacterIndex struct {
+	mu       sync.RWMutex
+	basePath string
+	byName   map[string]*CharacterSummary
+	byRace   map[string][]*CharacterSummary
+	byClass  map[string][]*CharacterSummary
+	byStatus map[string][]*CharacterSummary
+}
+
+// NewCharacterIndex scans basePath and builds an index, using the cached
+// characters/_index.json if it's still fresh
+func NewCharacterIndex(basePath string) (*CharacterIndex, error) {
+	ci := &CharacterIndex{basePath: basePath}
+	if err := ci.Reload(); err != nil {
+		return nil, err
+	}
+	return ci, nil
+}
+
+// Reload rescans basePath, using the cached index if no character_*.md file
+// has changed since it was written
+func (ci *CharacterIndex) Reload() error {
+	entries, err := os.ReadDir(ci.basePath)
+	if os.IsNotExist(err) {
+		ci.rebuildFrom(nil)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read characters directory: %w", err)
+	}
+
+	var files []string
+	var latestMod time.Time
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), "character_") || filepath.Ext(entry.Name()) != ".md" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, entry.Name())
+		if info.ModTime().After(latestMod) {
+			latestMod = info.ModTime()
+		}
+	}
+
+	if cached, ok := loadIndexCache(ci.basePath, len(files), latestMod); ok {
+		ci.rebuildFrom(cached)
+		return nil
+	}
+
+	return ci.rescan(files)
+}
+
+// rescan unconditionally re-parses every file in files and rebuilds the
+// index from them, bypassing the mtime-based cache check. Delete and Rename
+// use this instead of Reload: they know exactly what just changed on disk,
+// and a fast delete+create pair can otherwise land on the same mtime as the
+// stale cache, causing Reload to serve pre-change data
+func (ci *CharacterIndex) rescan(files []string) error {
+	summaries := make([]*CharacterSummary, 0, len(files))
+	for _, name := range files {
+		path := filepath.Join(ci.basePath, name)
+		summary, err := parseCharacterSummary(path)
+		if err != nil {
+			log.Printf("[CHARINDEX] Skipping %s: %v", path, err)
+			continue
+		}
+		summaries = append(summaries, summary)
+	}
+
+	ci.rebuildFrom(summaries)
+	if err := saveIndexCache(ci.basePath, summaries); err != nil {
+		log.Printf("[CHARINDEX] Failed to write index cache: %v", err)
+	}
+	return nil
+}
+
+// forceReload re-reads basePath's character_*.md files and rebuilds the
+// index unconditionally, ignoring any cached characters/_index.json
+func (ci *CharacterIndex) forceReload() error {
+	entries, err := os.ReadDir(ci.basePath)
+	if os.IsNotExist(err) {
+		ci.rebuildFrom(nil)
+		return saveIndexCache(ci.basePath, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read characters directory: %w", err)
+	}
+
+	var files []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), "character_") || filepath.Ext(entry.Name()) != ".md" {
+			continue
+		}
+		files = append(files, entry.Name())
+	}
+
+	return ci.rescan(files)
+}
+
+// rebuildFrom replaces the index's maps with ones built from summaries
+func (ci *CharacterIndex) rebuildFrom(summaries []*CharacterSummary) {
+	byName := make(map[string]*CharacterSummary, len(summaries))
+	byRace := make(map[string][]*CharacterSummary)
+	byClass := make(map[string][]*CharacterSummary)
+	byStatus := make(map[string][]*CharacterSummary)
+
+	for _, summary := range summaries {
+		byName[summary.Name] = summary
+		byRace[summary.Race] = append(byRace[summary.Race], summary)
+		byClass[summary.Class] = append(byClass[summary.Class], summary)
+		byStatus[summary.Status] = append(byStatus[summary.Status], summary)
+	}
+
+	ci.mu.Lock()
+	defer ci.mu.Unlock()
+	ci.byName = byName
+	ci.byRace = byRace
+	ci.byClass = byClass
+	ci.byStatus = byStatus
+}
+
+// Find returns the summary for name, if indexed
+func (ci *CharacterIndex) Find(name string) (*CharacterSummary, bool) {
+	ci.mu.RLock()
+	defer ci.mu.RUnlock()
+	summary, ok := ci.byName[name]
+	return summary, ok
+}
+
+// Query returns every indexed character matching every non-empty field of f
+func (ci *CharacterIndex) Query(f IndexFilter) []*CharacterSummary {
+	ci.mu.RLock()
+	defer ci.mu.RUnlock()
+
+	var results []*CharacterSummary
+	for _, summary := range ci.byName {
+		if f.matches(summary) {
+			results = append(results, summary)
+		}
+	}
+	return results
+}
+
+// Delete removes name's character file and its creation/<name> step
+// directory, then reloads the index
+func (ci *CharacterIndex) Delete(name string) error {
+	summary, ok := ci.Find(name)
+	if !ok {
+		return fmt.Errorf("character not found: %s", name)
+	}
+
+	if err := os.Remove(summary.Path); err != nil {
+		return fmt.Errorf("failed to delete character file: %w", err)
+	}
+	if err := os.RemoveAll(filepath.Join(ci.basePath, "creation", name)); err != nil {
+		return fmt.Errorf("failed to delete creation steps for %s: %w", name, err)
+	}
+
+	return ci.forceReload()
+}
+
+// Rename renames oldName's character file and creation/<oldName> step
+// directory to newName, rewriting the file's front-matter name and title,
+// then reloads the index. The steps are not atomic: the new file is written
+// before the old one is removed, and a failure part-way can leave the
+// rename half done
+func (ci *CharacterIndex) Rename(oldName, newName string) error {
+	summary, ok := ci.Find(oldName)
+	if !ok {
+		return fmt.Errorf("character not found: %s", oldName)
+	}
+	if _, exists := ci.Find(newName); exists {
+		return fmt.Errorf("character already exists: %s", newName)
+	}
+
+	raw, err := os.ReadFile(summary.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read character file: %w", err)
+	}
+
+	renamed := strings.Replace(string(raw), "name: "+oldName+"\n", "name: "+newName+"\n", 1)
+	renamed = strings.Replace(renamed, "\n# "+oldName+"\n", "\n# "+newName+"\n", 1)
+
+	newPath := filepath.Join(ci.basePath, fmt.Sprintf("character_%s.md", newName))
+	if err := os.WriteFile(newPath, []byte(renamed), 0644); err != nil {
+		return fmt.Errorf("failed to write renamed character file: %w", err)
+	}
+	if err := os.Remove(summary.Path); err != nil {
+		return fmt.Errorf("failed to remove old character file: %w", err)
+	}
+
+	oldDir := filepath.Join(ci.basePath, "creation", oldName)
+	if _, err := os.Stat(oldDir); err == nil {
+		newDir := filepath.Join(ci.basePath, "creation", newName)
+		if err := os.Rename(oldDir, newDir); err != nil {
+			return fmt.Errorf("failed to rename creation steps: %w", err)
+		}
+	}
+
+	return ci.forceReload()
+}
+
+// parseCharacterSummary reads path's YAML front-matter (the block between
+// the opening and closing "---" lines) and builds a CharacterSummary from
+// it, hand-parsing "key: value" lines the same way storage.MarkdownParser
+// parses its own field pairs
+func parseCharacterSummary(path string) (*CharacterSummary, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read character file: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to stat character file: %w", err)
+	}
+
+	fields, err := parseFrontMatter(string(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	statusLevel, _ := strconv.Atoi(fields["status_level"])
+	totalXP, _ := strconv.Atoi(fields["total_xp"])
+
+	return &CharacterSummary{
+		Name:        fields["name"],
+		Race:        fields["race"],
+		Class:       fields["class"],
+		Career:      fields["career"],
+		Status:      fields["status"],
+		StatusLevel: statusLevel,
+		TotalXP:     totalXP,
+		Created:     fields["created"],
+		Path:        path,
+		ModTime:     info.ModTime(),
+	}, nil
+}
+
+// parseFrontMatter extracts the "key: value" lines between the opening and
+// closing "---" lines at the top of content
+func parseFrontMatter(content string) (map[string]string, error) {
+	lines := strings.Split(content, "\n")
+	if len(lines) == 0 || strings.TrimSpace(lines[0]) != "---" {
+		return nil, fmt.Errorf("missing front-matter")
+	}
+
+	fields := make(map[string]string)
+	for _, line := range lines[1:] {
+		if strings.TrimSpace(line) == "---" {
+			return fields, nil
+		}
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		fields[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+
+	return nil, fmt.Errorf("unterminated front-matter")
+}
+
+// indexCache is the on-disk shape of characters/_index.json
+type indexCache struct {
+	FileCount int                 `json:"file_count"`
+	LatestMod time.Time           `json:"latest_mod"`
+	Summaries []*CharacterSummary `json:"summaries"`
+}
+
+// indexCachePath returns basePath/_index.json
+func indexCachePath(basePath string) string {
+	return filepath.Join(basePath, "_index.json")
+}
+
+// loadIndexCache returns the cached summaries if the cache file exists and
+// matches fileCount/latestMod exactly - i.e. nothing has been added,
+// removed or modified since it was written
+func loadIndexCache(basePath string, fileCount int, latestMod time.Time) ([]*CharacterSummary, bool) {
+	raw, err := os.ReadFile(indexCachePath(basePath))
+	if err != nil {
+		return nil, false
+	}
+
+	var cache indexCache
+	if err := json.Unmarshal(raw, &cache); err != nil {
+		return nil, false
+	}
+
+	if cache.FileCount != fileCount || !cache.LatestMod.Equal(latestMod) {
+		return nil, false
+	}
+	return cache.Summaries, true
+}
+
+// saveIndexCache writes basePath/_index.json for loadIndexCache to pick up
+// on the next NewCharacterIndex/Reload
+func saveIndexCache(basePath string, summaries []*CharacterSummary) error {
+	var latestMod time.Time
+	for _, summary := range summaries {
+		if summary.ModTime.After(latestMod) {
+			latestMod = summary.ModTime
+		}
+	}
+
+	raw, err := json.MarshalIndent(indexCache{
+		FileCount: len(summaries),
+		LatestMod: latestMod,
+		Summaries: summaries,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode index cache: %w", err)
+	}
+
+	return os.WriteFile(indexCachePath(basePath), raw, 0644)
+}
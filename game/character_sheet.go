@@ -0,0 +1,454 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Known section headings on a WFRP 4e character sheet. These match the
+// headings checked by ValidateCharacter and produced by the character
+// creator
+const (
+	SectionCharacteristics = "Характеристики"
+	SectionSkills          = "Навыки"
+	SectionWeapons         = "Оружие"
+	SectionConditions      = "Состояния"
+	SectionExperience      = "Опыт"
+)
+
+// psychologicalConditionsMarker is the subsection within SectionConditions
+// that Psychology-type conditions (Wounded, Bleeding, Afraid, ...) are
+// filed under
+const psychologicalConditionsMarker = "### Психологические состояния"
+
+// characteristicAliases maps a canonical characteristic key to the
+// historical spellings it may appear under on an existing sheet
+var characteristicAliases = map[string][]string{
+	"WS":  {"WS", "В", "Weapon Skill"},
+	"BS":  {"BS", "Ballistic Skill"},
+	"S":   {"S", "Strength"},
+	"Ag":  {"Ag", "Agility"},
+	"Int": {"Int", "Intelligence"},
+	"WP":  {"WP", "Will Power"},
+	"Fel": {"Fel", "Fellowship"},
+}
+
+// Section is one "## Heading" block of a character sheet. Its body is kept
+// as raw lines so content CharacterSheet doesn't model - prose, nested
+// "### " subsections, anything a GM typed by hand - round-trips through
+// ParseCharacterSheet/Render unchanged
+type Section struct {
+	Heading string
+	Lines   []string
+}
+
+// CharacterSheet is a structured view of a character's markdown card. It
+// replaces scanning the raw sheet with strings.ReplaceAll/fmt.Sscanf: edits
+// go through typed accessors, and Render reproduces everything the sheet
+// doesn't understand byte for byte
+type CharacterSheet struct {
+	Name     string
+	Preamble []string
+	Sections []*Section
+}
+
+// ParseCharacterSheet parses a character's markdown sheet into a
+// CharacterSheet
+func ParseCharacterSheet(content string) *CharacterSheet {
+	lines := strings.Split(content, "\n")
+	sheet := &CharacterSheet{Name: extractCharacterName(content)}
+
+	i := 0
+	if i < len(lines) && strings.HasPrefix(lines[i], "# ") {
+		i++
+	}
+
+	for i < len(lines) && !strings.HasPrefix(lines[i], "## ") {
+		sheet.Preamble = append(sheet.Preamble, lines[i])
+		i++
+	}
+
+	var current *Section
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.HasPrefix(line, "## ") {
+			current = &Section{Heading: strings.TrimSpace(strings.TrimPrefix(line, "## "))}
+			sheet.Sections = append(sheet.Sections, current)
+			continue
+		}
+		if current != nil {
+			current.Lines = append(current.Lines, line)
+		}
+	}
+
+	return sheet
+}
+
+// Render reproduces the markdown sheet from its parsed structure
+func (cs *CharacterSheet) Render() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# Имя: %s\n", cs.Name)
+	for _, line := range cs.Preamble {
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+
+	for _, section := range cs.Sections {
+		fmt.Fprintf(&b, "## %s\n", section.Heading)
+		for _, line := range section.Lines {
+			b.WriteString(line)
+			b.WriteString("\n")
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// Section returns the named section, or nil if the sheet has none
+func (cs *CharacterSheet) Section(heading string) *Section {
+	for _, s := range cs.Sections {
+		if s.Heading == heading {
+			return s
+		}
+	}
+	return nil
+}
+
+// EnsureSection returns the named section, creating an empty one at the end
+// of the sheet if it doesn't already exist
+func (cs *CharacterSheet) EnsureSection(heading string) *Section {
+	if s := cs.Section(heading); s != nil {
+		return s
+	}
+	s := &Section{Heading: heading}
+	cs.Sections = append(cs.Sections, s)
+	return s
+}
+
+// splitField splits a "key: value" line. Bullets and nested headings are
+// not fields
+func splitField(line string) (key, value string, ok bool) {
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || strings.HasPrefix(trimmed, "-") || strings.HasPrefix(trimmed, "#") {
+		return "", "", false
+	}
+	parts := strings.SplitN(trimmed, ":", 2)
+	if len(parts) != 2 {
+		return "", "", false
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), true
+}
+
+// Field returns the value of a "key: value" line within section, ignoring
+// anything inside a nested "### " subsection
+func (s *Section) Field(key string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	inSub := false
+	for _, line := range s.Lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			inSub = true
+			continue
+		}
+		if inSub {
+			continue
+		}
+		if k, v, ok := splitField(line); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// IntField is the integer-parsing counterpart to Field
+func (s *Section) IntField(key string) (int, bool) {
+	v, ok := s.Field(key)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// SetField updates an existing "key: value" line within section, or
+// inserts a new one before any bullets/nested subsections
+func (s *Section) SetField(key, value string) {
+	for idx, line := range s.Lines {
+		if k, _, ok := splitField(line); ok && k == key {
+			s.Lines[idx] = fmt.Sprintf("%s: %s", key, value)
+			return
+		}
+	}
+
+	insertAt := len(s.Lines)
+	for idx, line := range s.Lines {
+		t := strings.TrimSpace(line)
+		if strings.HasPrefix(t, "#") || strings.HasPrefix(t, "-") {
+			insertAt = idx
+			break
+		}
+	}
+	s.Lines = append(s.Lines[:insertAt:insertAt], append([]string{fmt.Sprintf("%s: %s", key, value)}, s.Lines[insertAt:]...)...)
+}
+
+// Items returns the "- item" bullets directly under section, ignoring
+// anything nested inside a "### " subsection
+func (s *Section) Items() []string {
+	if s == nil {
+		return nil
+	}
+	var items []string
+	inSub := false
+	for _, line := range s.Lines {
+		t := strings.TrimSpace(line)
+		if strings.HasPrefix(t, "#") {
+			inSub = true
+			continue
+		}
+		if inSub {
+			continue
+		}
+		if strings.HasPrefix(t, "- ") {
+			items = append(items, strings.TrimPrefix(t, "- "))
+		}
+	}
+	return items
+}
+
+// HasItem reports whether item is already present among section's bullets
+func (s *Section) HasItem(item string) bool {
+	for _, existing := range s.Items() {
+		if existing == item {
+			return true
+		}
+	}
+	return false
+}
+
+// AddItem appends a "- item" bullet directly under section's own body,
+// before any nested "### " subsection
+func (s *Section) AddItem(item string) {
+	insertAt := len(s.Lines)
+	for idx, line := range s.Lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			insertAt = idx
+			break
+		}
+	}
+	s.Lines = append(s.Lines[:insertAt:insertAt], append([]string{"- " + item}, s.Lines[insertAt:]...)...)
+}
+
+// SubItems returns the "- item" bullets nested under a "### marker"
+// subsection within section
+func (s *Section) SubItems(marker string) []string {
+	if s == nil {
+		return nil
+	}
+	var items []string
+	in := false
+	for _, line := range s.Lines {
+		t := strings.TrimSpace(line)
+		if strings.HasPrefix(t, "#") {
+			in = t == marker
+			continue
+		}
+		if in && strings.HasPrefix(t, "- ") {
+			items = append(items, strings.TrimPrefix(t, "- "))
+		}
+	}
+	return items
+}
+
+// HasSubItem reports whether item is already present under marker
+func (s *Section) HasSubItem(marker, item string) bool {
+	for _, existing := range s.SubItems(marker) {
+		if existing == item {
+			return true
+		}
+	}
+	return false
+}
+
+// AddSubItem appends a "- item" bullet nested under a "### marker"
+// subsection within section, creating the subsection if it doesn't exist
+func (s *Section) AddSubItem(marker, item string) {
+	for idx, line := range s.Lines {
+		if strings.TrimSpace(line) != marker {
+			continue
+		}
+		end := len(s.Lines)
+		for j := idx + 1; j < len(s.Lines); j++ {
+			if strings.HasPrefix(strings.TrimSpace(s.Lines[j]), "#") {
+				end = j
+				break
+			}
+		}
+		s.Lines = append(s.Lines[:end:end], append([]string{"- " + item}, s.Lines[end:]...)...)
+		return
+	}
+	s.Lines = append(s.Lines, "", marker, "- "+item)
+}
+
+// RemoveSubItem deletes the "- item" bullet nested under a "### marker"
+// subsection within section, if present. It's a no-op if the marker or
+// item doesn't exist
+func (s *Section) RemoveSubItem(marker, item string) {
+	in := false
+	kept := s.Lines[:0:0]
+	for _, line := range s.Lines {
+		t := strings.TrimSpace(line)
+		if strings.HasPrefix(t, "#") {
+			in = t == marker
+			kept = append(kept, line)
+			continue
+		}
+		if in && t == "- "+item {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	s.Lines = kept
+}
+
+// FindField searches every section, then the preamble, for a "key: value"
+// line. HP and XP predate the typed section model and aren't guaranteed to
+// live in one of the named sections, so callers interested in those use
+// this instead of a single Section.Field lookup
+func (cs *CharacterSheet) FindField(key string) (string, bool) {
+	for _, s := range cs.Sections {
+		if v, ok := s.Field(key); ok {
+			return v, true
+		}
+	}
+	for _, line := range cs.Preamble {
+		if k, v, ok := splitField(line); ok && k == key {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// FindIntField is the integer-parsing counterpart to FindField
+func (cs *CharacterSheet) FindIntField(key string) (int, bool) {
+	v, ok := cs.FindField(key)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	return n, true
+}
+
+// SetField updates a "key: value" line wherever FindField would find it, or
+// appends it to the preamble if it isn't present anywhere yet
+func (cs *CharacterSheet) SetField(key, value string) {
+	for _, s := range cs.Sections {
+		if _, ok := s.Field(key); ok {
+			s.SetField(key, value)
+			return
+		}
+	}
+	for idx, line := range cs.Preamble {
+		if k, _, ok := splitField(line); ok && k == key {
+			cs.Preamble[idx] = fmt.Sprintf("%s: %s", key, value)
+			return
+		}
+	}
+	cs.Preamble = append(cs.Preamble, fmt.Sprintf("%s: %s", key, value))
+}
+
+// Characteristic reads a primary characteristic (WS, BS, S, Ag, Int, WP,
+// Fel) from the Характеристики section, accepting its historical aliases
+func (cs *CharacterSheet) Characteristic(stat string) (int, bool) {
+	section := cs.Section(SectionCharacteristics)
+	for _, alias := range characteristicAliasesOrSelf(stat) {
+		if v, ok := section.IntField(alias); ok {
+			return v, true
+		}
+	}
+	return 0, false
+}
+
+// SetCharacteristic writes a primary characteristic under its canonical
+// key, creating the Характеристики section if necessary
+func (cs *CharacterSheet) SetCharacteristic(stat string, value int) {
+	cs.EnsureSection(SectionCharacteristics).SetField(stat, strconv.Itoa(value))
+}
+
+func characteristicAliasesOrSelf(stat string) []string {
+	if aliases, ok := characteristicAliases[stat]; ok {
+		return aliases
+	}
+	return []string{stat}
+}
+
+// CurrentHP reads the sheet's current hit points. Sheets that predate the
+// HP/MaxHP split store a single legacy "HP:"/"Здоровье:" value, read here
+// as the current value
+func (cs *CharacterSheet) CurrentHP() (int, bool) {
+	if v, ok := cs.FindIntField("HP"); ok {
+		return v, true
+	}
+	return cs.FindIntField("Здоровье")
+}
+
+// SetCurrentHP writes the sheet's current hit points
+func (cs *CharacterSheet) SetCurrentHP(value int) {
+	cs.SetField("HP", strconv.Itoa(value))
+}
+
+// MaxHP reads the sheet's maximum hit points, falling back to CurrentHP for
+// sheets that only ever stored a single legacy HP value
+func (cs *CharacterSheet) MaxHP() (int, bool) {
+	if v, ok := cs.FindIntField("MaxHP"); ok {
+		return v, true
+	}
+	return cs.CurrentHP()
+}
+
+// SetMaxHP writes the sheet's maximum hit points
+func (cs *CharacterSheet) SetMaxHP(value int) {
+	cs.SetField("MaxHP", strconv.Itoa(value))
+}
+
+// Advantage reads the sheet's current combat Advantage, defaulting to 0
+// for sheets that have never tracked it
+func (cs *CharacterSheet) Advantage() int {
+	if v, ok := cs.FindIntField("Advantage"); ok {
+		return v
+	}
+	if v, ok := cs.FindIntField("Преимущество"); ok {
+		return v
+	}
+	return 0
+}
+
+// SetAdvantage writes the sheet's current combat Advantage, clamped to
+// never go below 0 per WFRP 4e rules
+func (cs *CharacterSheet) SetAdvantage(value int) {
+	if value < 0 {
+		value = 0
+	}
+	cs.SetField("Advantage", strconv.Itoa(value))
+}
+
+// XP reads the sheet's current experience total
+func (cs *CharacterSheet) XP() (int, bool) {
+	if v, ok := cs.FindIntField("XP"); ok {
+		return v, true
+	}
+	return cs.FindIntField("Опыт")
+}
+
+// SetXP writes the sheet's current experience total
+func (cs *CharacterSheet) SetXP(value int) {
+	cs.SetField("XP", strconv.Itoa(value))
+}
@@ -0,0 +1,196 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"wfrp-bot/llm"
+)
+
+// characterUpdateToolName is the tool the extractor model is asked to call
+// once per affected character, instead of describing changes in prose
+const characterUpdateToolName = "report_character_update"
+
+// characterUpdateToolSchema is the JSON schema for characterUpdateToolName's
+// arguments, matching characterUpdatePayload
+const characterUpdateToolSchema = `{"type":"object","properties":{` +
+	`"player":{"type":"string","description":"name of the affected character, as written on their sheet"},` +
+	`"hp_change":{"type":"integer","description":"HP lost (negative) or healed (positive)"},` +
+	`"max_hp_change":{"type":"integer","description":"permanent Max HP change, e.g. from a talent"},` +
+	`"xp_change":{"type":"integer","description":"experience points gained"},` +
+	`"skills_added":{"type":"array","items":{"type":"string"},"description":"new skills gained"},` +
+	`"conditions":{"type":"array","items":{"type":"string"},"description":"conditions gained, e.g. Wounded, Bleeding, Critical Wound"}` +
+	`},"required":["player"]}`
+
+// characterUpdateExtractionPrompt instructs the extractor model to report
+// every affected character via characterUpdateToolName rather than prose
+const characterUpdateExtractionPrompt = "Ты анализируешь повествовательный ответ GM и извлекаешь изменения персонажей. " +
+	"Для каждого затронутого персонажа вызови report_character_update ровно один раз с его именем и изменениями. " +
+	"Если персонаж не был затронут, не вызывай инструмент для него."
+
+// characterUpdatePayload is the decoded argument payload for one
+// report_character_update call
+type characterUpdatePayload struct {
+	Player      string   `json:"player"`
+	HPChange    int      `json:"hp_change"`
+	MaxHPChange int      `json:"max_hp_change"`
+	XPChange    int      `json:"xp_change"`
+	SkillsAdded []string `json:"skills_added"`
+	Conditions  []string `json:"conditions"`
+}
+
+// ExtractCharacterUpdates analyzes a GM narrative response and produces a
+// validated CharacterUpdate per affected character, keyed by playerID. It
+// replaces the old keyword/Sscanf heuristics that used to live in
+// ParseCharacterUpdateFromResponse.
+//
+// It first asks provider for a structured extraction via tool-calling (see
+// llm.RequestStructured). If the provider doesn't support tool-calling
+// or the model doesn't call the tool, it falls back
+// to extractCharacterUpdatesLocally, a regex+dictionary extractor
+func ExtractCharacterUpdates(ctx context.Context, provider llm.LLMProvider, narrative string, characters []*Character) map[string]CharacterUpdate {
+	calls, err := llm.RequestStructured(ctx, provider, characterUpdateExtractionPrompt,
+		[]llm.Message{{Role: "user", Content: narrative}},
+		characterUpdateToolName, "Records a WFRP character update extracted from the GM's narration",
+		json.RawMessage(characterUpdateToolSchema), 4)
+	if err != nil {
+		return extractCharacterUpdatesLocally(narrative, characters)
+	}
+
+	updates := make(map[string]CharacterUpdate)
+	for _, raw := range calls {
+		var payload characterUpdatePayload
+		if err := json.Unmarshal(raw, &payload); err != nil {
+			continue
+		}
+
+		playerID, ok := resolvePlayerByName(characters, payload.Player)
+		if !ok {
+			continue
+		}
+
+		updates[playerID] = CharacterUpdate{
+			HPChange:    payload.HPChange,
+			MaxHPChange: payload.MaxHPChange,
+			XPChange:    payload.XPChange,
+			SkillsAdded: payload.SkillsAdded,
+			Conditions:  payload.Conditions,
+		}
+	}
+
+	if len(updates) > 0 {
+		return updates
+	}
+
+	return extractCharacterUpdatesLocally(narrative, characters)
+}
+
+// damageVerbPattern, healVerbPattern and xpVerbPattern capture the numeric
+// amount attached to a ru/en damage, healing or XP verb
+var (
+	damageVerbPattern = regexp.MustCompile(`(?i)(?:получил|получила|takes?|took)\s+(\d+)\s*(?:урон[а-я]*|damage|dmg)`)
+	healVerbPattern   = regexp.MustCompile(`(?i)(?:вылечил[а-я]*|исцелил[а-я]*|heals?|healed)\D{0,10}(\d+)`)
+	xpVerbPattern     = regexp.MustCompile(`(?i)(?:получил|получила|gains?|gained)\s+(\d+)\s*(?:оч(?:ков|ко)? опыта|xp|experience)`)
+)
+
+// conditionDictionary maps ru/en condition keywords to the canonical
+// condition name added to the character sheet
+var conditionDictionary = map[string]string{
+	"ранение":      "Wounded",
+	"ранен":        "Wounded",
+	"wounded":      "Wounded",
+	"кровотечение": "Bleeding",
+	"bleeding":     "Bleeding",
+	"крит":         "Critical Wound",
+	"critical":     "Critical Wound",
+}
+
+// extractCharacterUpdatesLocally is the regex/dictionary fallback used when
+// the provider doesn't support tool-calling (or declines to use it),
+// scanning the narrative sentence by sentence and attributing each match to
+// a character mentioned by name in that sentence
+func extractCharacterUpdatesLocally(narrative string, characters []*Character) map[string]CharacterUpdate {
+	updates := make(map[string]CharacterUpdate)
+
+	for _, sentence := range splitSentences(narrative) {
+		playerID, ok := mentionedCharacter(sentence, characters)
+		if !ok {
+			continue
+		}
+
+		update := updates[playerID]
+
+		if m := damageVerbPattern.FindStringSubmatch(sentence); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil {
+				update.HPChange -= n
+			}
+		}
+		if m := healVerbPattern.FindStringSubmatch(sentence); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil {
+				update.HPChange += n
+			}
+		}
+		if m := xpVerbPattern.FindStringSubmatch(sentence); m != nil {
+			if n, err := strconv.Atoi(m[1]); err == nil {
+				update.XPChange += n
+			}
+		}
+
+		lower := strings.ToLower(sentence)
+		for keyword, condition := range conditionDictionary {
+			if strings.Contains(lower, keyword) && !containsString(update.Conditions, condition) {
+				update.Conditions = append(update.Conditions, condition)
+			}
+		}
+
+		updates[playerID] = update
+	}
+
+	return updates
+}
+
+// sentenceSplitPattern splits narrative text into sentences on ./!/?/newline
+var sentenceSplitPattern = regexp.MustCompile(`[.!?\n]+`)
+
+// splitSentences splits narrative text into non-empty sentences
+func splitSentences(narrative string) []string {
+	var sentences []string
+	for _, s := range sentenceSplitPattern.Split(narrative, -1) {
+		s = strings.TrimSpace(s)
+		if s != "" {
+			sentences = append(sentences, s)
+		}
+	}
+	return sentences
+}
+
+// mentionedCharacter returns the playerID of the character whose name is
+// mentioned in text, if any
+func mentionedCharacter(text string, characters []*Character) (string, bool) {
+	lower := strings.ToLower(text)
+	for _, char := range characters {
+		if char.Name != "" && strings.Contains(lower, strings.ToLower(char.Name)) {
+			return char.ID, true
+		}
+	}
+	return "", false
+}
+
+// resolvePlayerByName finds the playerID of the character whose name
+// matches (case-insensitively) the name reported by the extractor model
+func resolvePlayerByName(characters []*Character, name string) (string, bool) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		return "", false
+	}
+
+	for _, char := range characters {
+		if strings.ToLower(char.Name) == name {
+			return char.ID, true
+		}
+	}
+	return mentionedCharacter(name, characters)
+}
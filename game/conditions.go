@@ -0,0 +1,172 @@
+package game
+
+import (
+	"fmt"
+	"sync"
+)
+
+// Condition is a WFRP 4e condition or critical-wound state that can be
+// tracked on a character with a stack count
+type Condition string
+
+// WFRP 4e conditions (Core Rulebook condition list), plus Critical Wound
+// as the generic state for critical-wound-by-location tracking
+const (
+	ConditionAblaze        Condition = "Ablaze"
+	ConditionBleeding      Condition = "Bleeding"
+	ConditionBlinded       Condition = "Blinded"
+	ConditionBroken        Condition = "Broken"
+	ConditionDeafened      Condition = "Deafened"
+	ConditionEntangled     Condition = "Entangled"
+	ConditionFatigued      Condition = "Fatigued"
+	ConditionPoisoned      Condition = "Poisoned"
+	ConditionProne         Condition = "Prone"
+	ConditionStunned       Condition = "Stunned"
+	ConditionSurprised     Condition = "Surprised"
+	ConditionUnconscious   Condition = "Unconscious"
+	ConditionCriticalWound Condition = "Critical Wound"
+)
+
+// stackableConditions lists conditions whose severity is tracked as a
+// stack count (e.g. Bleeding 2 inflicts 2 wounds per round); every other
+// condition is a simple on/off state
+var stackableConditions = map[Condition]bool{
+	ConditionBleeding:      true,
+	ConditionPoisoned:      true,
+	ConditionFatigued:      true,
+	ConditionAblaze:        true,
+	ConditionCriticalWound: true,
+}
+
+// unconsciousDeathThreshold is how far below 0 HP an Unconscious character
+// can drop before dying outright
+const unconsciousDeathThreshold = -5
+
+// conditionStack is one character's current severity for one condition
+type conditionStack struct {
+	count int
+}
+
+// conditionState is the live condition stacks tracked for one character
+type conditionState struct {
+	stacks map[Condition]*conditionStack
+}
+
+// ConditionEngine tracks live WFRP condition stacks per character and
+// resolves their end-of-round effects (Bleeding damage, Ablaze escalation,
+// Unconscious death checks, ...). It is independent of a character sheet's
+// rendered ## Состояния section; Session.ApplyEndOfRound keeps the two in
+// sync by re-rendering the sheet after each resolution
+type ConditionEngine struct {
+	mu    sync.Mutex
+	state map[string]*conditionState // playerID -> live stacks
+}
+
+// NewConditionEngine creates an empty condition engine
+func NewConditionEngine() *ConditionEngine {
+	return &ConditionEngine{state: make(map[string]*conditionState)}
+}
+
+// character returns (creating if necessary) a playerID's condition state.
+// Callers must hold ce.mu
+func (ce *ConditionEngine) character(playerID string) *conditionState {
+	cs, ok := ce.state[playerID]
+	if !ok {
+		cs = &conditionState{stacks: make(map[Condition]*conditionStack)}
+		ce.state[playerID] = cs
+	}
+	return cs
+}
+
+// AddCondition inflicts a condition on a character. For stackable
+// conditions, count adds to any existing stack; for simple conditions,
+// count is ignored and the condition is just marked present
+func (ce *ConditionEngine) AddCondition(playerID string, condition Condition, count int) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	cs := ce.character(playerID)
+	stack, ok := cs.stacks[condition]
+	if !ok {
+		stack = &conditionStack{}
+		cs.stacks[condition] = stack
+	}
+
+	if stackableConditions[condition] {
+		stack.count += count
+	} else {
+		stack.count = 1
+	}
+}
+
+// RemoveCondition clears a condition entirely, regardless of stack count
+func (ce *ConditionEngine) RemoveCondition(playerID string, condition Condition) {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	if cs, ok := ce.state[playerID]; ok {
+		delete(cs.stacks, condition)
+	}
+}
+
+// Conditions returns a snapshot of a character's current condition stacks
+func (ce *ConditionEngine) Conditions(playerID string) map[Condition]int {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	result := make(map[Condition]int)
+	if cs, ok := ce.state[playerID]; ok {
+		for condition, stack := range cs.stacks {
+			result[condition] = stack.count
+		}
+	}
+	return result
+}
+
+// endOfRoundEffect is the net result of resolving one character's active
+// conditions for a single round
+type endOfRoundEffect struct {
+	hpChange int
+	logLines []string
+}
+
+// resolveEndOfRound computes the HP change and narrative log lines for one
+// round of a character's active conditions, without touching their sheet:
+//   - Bleeding N inflicts N wounds
+//   - Ablaze inflicts damage equal to its stack, then escalates by 1
+//     (it spreads unless put out, which is handled by RemoveCondition)
+//   - a character whose projected HP drops to 0 or below falls Unconscious;
+//     an already-Unconscious character who drops past
+//     unconsciousDeathThreshold dies instead
+func (ce *ConditionEngine) resolveEndOfRound(playerID string, currentHP int) endOfRoundEffect {
+	ce.mu.Lock()
+	defer ce.mu.Unlock()
+
+	cs := ce.character(playerID)
+	var effect endOfRoundEffect
+
+	if stack, ok := cs.stacks[ConditionBleeding]; ok && stack.count > 0 {
+		effect.hpChange -= stack.count
+		effect.logLines = append(effect.logLines, fmt.Sprintf("Bleeding inflicts %d wounds", stack.count))
+	}
+
+	if stack, ok := cs.stacks[ConditionAblaze]; ok && stack.count > 0 {
+		effect.hpChange -= stack.count
+		effect.logLines = append(effect.logLines, fmt.Sprintf("Ablaze inflicts %d wounds and spreads", stack.count))
+		stack.count++
+	}
+
+	projectedHP := currentHP + effect.hpChange
+	if projectedHP <= 0 {
+		if _, alreadyUnconscious := cs.stacks[ConditionUnconscious]; alreadyUnconscious {
+			if projectedHP <= unconsciousDeathThreshold {
+				effect.logLines = append(effect.logLines, "Character succumbs to their wounds and dies")
+			}
+		} else {
+			cs.stacks[ConditionUnconscious] = &conditionStack{count: 1}
+			effect.logLines = append(effect.logLines, "Character falls Unconscious")
+		}
+	}
+
+	return effect
+}
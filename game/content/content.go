@@ -0,0 +1,262 @@
+// Package content loads WFRP races, careers, and the d100 tables that pick
+// between them from external data files, so adding new source material
+// (Up in Arms, Archives of the Empire, homebrew races, translations) is a
+// matter of dropping in a JSON file rather than editing Go code
+package content
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// Race describes one playable race's starting characteristic bonuses and
+// the d100 range that selects it on a random roll
+type Race struct {
+	Name            string         `json:"name"`
+	RollMin         int            `json:"roll_min"`
+	RollMax         int            `json:"roll_max"`
+	BonusXP         int            `json:"bonus_xp"`
+	Characteristics map[string]int `json:"characteristics"`
+}
+
+// CareerClass is one of the eight WFRP career classes (Academic, Burgher,
+// ...), the careers within it, and the d100 range that selects it on a
+// random roll. Key is a stable ASCII identifier (e.g. "warrior") for code
+// that needs to refer to a class without matching its localized Name
+type CareerClass struct {
+	Name    string         `json:"name"`
+	Key     string         `json:"key,omitempty"`
+	RollMin int            `json:"roll_min"`
+	RollMax int            `json:"roll_max"`
+	Careers []CareerOption `json:"careers"`
+}
+
+// CareerOption is one career within a CareerClass, with an optional
+// per-characteristic weight vector used to recommend it from a
+// character's characteristics (e.g. a Warrior career weights WS/S/T
+// highly). An empty Weights map means every characteristic counts equally
+type CareerOption struct {
+	Name    string         `json:"name"`
+	Weights map[string]int `json:"weights,omitempty"`
+}
+
+// Rarity is the width of a CareerClass's d100 roll range: the smaller the
+// value, the rarer the class (a 5-point range is rarer than one spanning 20
+// points). Used to tie-break career recommendations that score equally
+func (c CareerClass) Rarity() int {
+	return c.RollMax - c.RollMin + 1
+}
+
+// PersonalityEntry is one entry in a personality table (a strength, a
+// weakness, a motivation, a dark secret or an ambition). RaceRestrict and
+// CareerRestrict, when non-empty, limit the entry to matching races or
+// career classes, so gnomish-only quirks don't show up on elves
+type PersonalityEntry struct {
+	Text           string   `json:"text"`
+	Weight         int      `json:"weight"`
+	RaceRestrict   []string `json:"race_restrict,omitempty"`
+	CareerRestrict []string `json:"career_restrict,omitempty"`
+}
+
+// PersonalityTables holds the weighted tables a character's personality is
+// rolled from
+type PersonalityTables struct {
+	Strengths   []PersonalityEntry
+	Weaknesses  []PersonalityEntry
+	Motivations []PersonalityEntry
+	DarkSecrets []PersonalityEntry
+	Ambitions   []PersonalityEntry
+}
+
+// ContentProvider is what CharacterCreator needs from the content
+// subsystem: the available races and career classes, and lookups by name
+// or d100 roll
+type ContentProvider interface {
+	Races() []Race
+	RaceByName(name string) (Race, bool)
+	RaceByRoll(roll int) (Race, bool)
+
+	CareerClasses() []CareerClass
+	CareerClassByRoll(roll int) (CareerClass, bool)
+
+	Personality() PersonalityTables
+}
+
+// ContentLoader loads a ContentProvider from a directory
+type ContentLoader interface {
+	Load(basePath string) (ContentProvider, error)
+}
+
+// staticContent is an in-memory ContentProvider over races and career
+// classes loaded once from disk
+type staticContent struct {
+	races         []Race
+	careerClasses []CareerClass
+	personality   PersonalityTables
+}
+
+func (c *staticContent) Races() []Race {
+	return c.races
+}
+
+func (c *staticContent) RaceByName(name string) (Race, bool) {
+	for _, race := range c.races {
+		if race.Name == name {
+			return race, true
+		}
+	}
+	return Race{}, false
+}
+
+func (c *staticContent) RaceByRoll(roll int) (Race, bool) {
+	for _, race := range c.races {
+		if roll >= race.RollMin && roll <= race.RollMax {
+			return race, true
+		}
+	}
+	return Race{}, false
+}
+
+func (c *staticContent) CareerClasses() []CareerClass {
+	return c.careerClasses
+}
+
+func (c *staticContent) CareerClassByRoll(roll int) (CareerClass, bool) {
+	for _, class := range c.careerClasses {
+		if roll >= class.RollMin && roll <= class.RollMax {
+			return class, true
+		}
+	}
+	return CareerClass{}, false
+}
+
+func (c *staticContent) Personality() PersonalityTables {
+	return c.personality
+}
+
+// FileContentLoader loads races and careers from "races/*.json" and
+// "careers/*.json" under a base directory, caching the result per
+// basePath so repeated CharacterCreator instances don't re-read disk
+type FileContentLoader struct {
+	mu    sync.Mutex
+	cache map[string]ContentProvider
+}
+
+// NewFileContentLoader creates a loader backed by JSON files on disk
+func NewFileContentLoader() *FileContentLoader {
+	return &FileContentLoader{cache: make(map[string]ContentProvider)}
+}
+
+// Load returns the ContentProvider for basePath, reading "races/*.json"
+// and "careers/*.json" from it on first use and serving cached results
+// afterwards
+func (l *FileContentLoader) Load(basePath string) (ContentProvider, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if cached, ok := l.cache[basePath]; ok {
+		return cached, nil
+	}
+
+	races, err := loadJSONDir[Race](filepath.Join(basePath, "races"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load races: %w", err)
+	}
+	sort.Slice(races, func(i, j int) bool { return races[i].RollMin < races[j].RollMin })
+
+	careerClasses, err := loadJSONDir[CareerClass](filepath.Join(basePath, "careers"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load careers: %w", err)
+	}
+	sort.Slice(careerClasses, func(i, j int) bool { return careerClasses[i].RollMin < careerClasses[j].RollMin })
+
+	personality, err := loadPersonalityTables(filepath.Join(basePath, "personality"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load personality tables: %w", err)
+	}
+
+	provider := &staticContent{races: races, careerClasses: careerClasses, personality: personality}
+	l.cache[basePath] = provider
+
+	return provider, nil
+}
+
+// loadPersonalityTables reads the five personality tables from
+// dir/{strengths,weaknesses,motivations,dark_secrets,ambitions}.json. Any
+// file that doesn't exist yields an empty table rather than an error, so
+// content directories predating the personality feature keep loading
+func loadPersonalityTables(dir string) (PersonalityTables, error) {
+	var tables PersonalityTables
+	var err error
+
+	if tables.Strengths, err = loadJSONFile[[]PersonalityEntry](filepath.Join(dir, "strengths.json")); err != nil {
+		return tables, err
+	}
+	if tables.Weaknesses, err = loadJSONFile[[]PersonalityEntry](filepath.Join(dir, "weaknesses.json")); err != nil {
+		return tables, err
+	}
+	if tables.Motivations, err = loadJSONFile[[]PersonalityEntry](filepath.Join(dir, "motivations.json")); err != nil {
+		return tables, err
+	}
+	if tables.DarkSecrets, err = loadJSONFile[[]PersonalityEntry](filepath.Join(dir, "dark_secrets.json")); err != nil {
+		return tables, err
+	}
+	if tables.Ambitions, err = loadJSONFile[[]PersonalityEntry](filepath.Join(dir, "ambitions.json")); err != nil {
+		return tables, err
+	}
+
+	return tables, nil
+}
+
+// loadJSONFile decodes path as a T, returning the zero value with no error
+// if path doesn't exist
+func loadJSONFile[T any](path string) (T, error) {
+	var item T
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return item, nil
+	}
+	if err != nil {
+		return item, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	if err := json.Unmarshal(data, &item); err != nil {
+		return item, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return item, nil
+}
+
+// loadJSONDir reads every *.json file in dir and decodes it as a T,
+// returning them in a stable (filename) order
+func loadJSONDir[T any](dir string) ([]T, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", dir, err)
+	}
+
+	var items []T
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", path, err)
+		}
+
+		var item T
+		if err := json.Unmarshal(data, &item); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+		}
+		items = append(items, item)
+	}
+
+	return items, nil
+}
@@ -0,0 +1,198 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Export formats supported by CharacterCreator.Export
+const (
+	ExportJSON     = "json"
+	ExportMarkdown = "markdown"
+	ExportFoundry  = "foundry"
+)
+
+// Export serializes the finished character into one of ExportJSON (the
+// canonical CharacterCreationData schema), ExportMarkdown (a human-readable
+// sheet for Telegram or a wiki) or ExportFoundry (a wfrp4e system actor
+// document ready to import into Foundry VTT)
+func (cc *CharacterCreator) Export(format string) ([]byte, error) {
+	switch strings.ToLower(format) {
+	case ExportJSON:
+		return json.MarshalIndent(cc.Data, "", "  ")
+	case ExportMarkdown:
+		return []byte(cc.exportMarkdown()), nil
+	case ExportFoundry:
+		return json.MarshalIndent(cc.exportFoundryActor(), "", "  ")
+	default:
+		return nil, fmt.Errorf("unknown export format: %s", format)
+	}
+}
+
+// exportMarkdown extends GenerateCharacterMarkdown with skills, talents,
+// gear and motivation, for a complete stand-alone character sheet
+func (cc *CharacterCreator) exportMarkdown() string {
+	var b strings.Builder
+	b.WriteString(cc.GenerateCharacterMarkdown())
+
+	b.WriteString("\n## НАВЫКИ\n\n")
+	if len(cc.Data.Skills) == 0 {
+		b.WriteString("(нет)\n")
+	}
+	for skill, rating := range cc.Data.Skills {
+		b.WriteString(fmt.Sprintf("- %s: %d\n", skill, rating))
+	}
+
+	b.WriteString("\n## ТАЛАНТЫ\n\n")
+	if len(cc.Data.Talents) == 0 {
+		b.WriteString("(нет)\n")
+	}
+	for _, talent := range cc.Data.Talents {
+		b.WriteString(fmt.Sprintf("- %s\n", talent))
+	}
+
+	b.WriteString("\n## СНАРЯЖЕНИЕ\n\n")
+	if len(cc.Data.Gear) == 0 {
+		b.WriteString("(нет)\n")
+	}
+	for item, source := range cc.Data.Gear {
+		b.WriteString(fmt.Sprintf("- %s (%s)\n", item, source))
+	}
+
+	b.WriteString(fmt.Sprintf("\n## МОТИВАЦИЯ\n\n%s\n", cc.Data.Motivation))
+
+	return b.String()
+}
+
+// foundryActor is a minimal representative subset of the wfrp4e system's
+// Actor document schema (https://github.com/moo-man/WFRP4e-FoundryVTT) -
+// enough for a character to be imported and fleshed out further in Foundry
+type foundryActor struct {
+	Name   string        `json:"name"`
+	Type   string        `json:"type"`
+	System foundrySystem `json:"system"`
+	Items  []foundryItem `json:"items"`
+}
+
+type foundrySystem struct {
+	Characteristics map[string]foundryAdvances `json:"characteristics"`
+	Status          foundryStatus              `json:"status"`
+	Details         foundryDetails             `json:"details"`
+	Money           foundryMoney               `json:"money"`
+}
+
+type foundryAdvances struct {
+	Initial  int `json:"initial"`
+	Advances int `json:"advances"`
+}
+
+type foundryStatus struct {
+	Fate       foundryValue  `json:"fate"`
+	Fortune    foundryValue  `json:"fortune"`
+	Resilience foundryValue  `json:"resilience"`
+	Resolve    foundryValue  `json:"resolve"`
+	Wounds     foundryWounds `json:"wounds"`
+}
+
+type foundryValue struct {
+	Value int `json:"value"`
+}
+
+type foundryWounds struct {
+	Value int `json:"value"`
+	Max   int `json:"max"`
+}
+
+type foundryDetails struct {
+	Species foundryText  `json:"species"`
+	Career  foundryText  `json:"career"`
+	Status  foundryRank  `json:"status"`
+	Age     foundryValue `json:"age"`
+	Height  foundryText  `json:"height"`
+	Hair    foundryText  `json:"hair"`
+	Eyes    foundryText  `json:"eyes"`
+}
+
+type foundryText struct {
+	Value string `json:"value"`
+}
+
+type foundryRank struct {
+	Value string `json:"value"`
+	Tier  int    `json:"tier"`
+}
+
+type foundryMoney struct {
+	GC int `json:"gc"`
+	SS int `json:"ss"`
+	BP int `json:"bp"`
+}
+
+type foundryItem struct {
+	Name   string             `json:"name"`
+	Type   string             `json:"type"`
+	System *foundryItemSystem `json:"system,omitempty"`
+}
+
+type foundryItemSystem struct {
+	Advances *foundryValue `json:"advances,omitempty"`
+	Source   string        `json:"source,omitempty"`
+}
+
+// exportFoundryActor builds a foundryActor from the character's data:
+// characteristics, status, money and details go into system, while skills,
+// talents and gear become items (the wfrp4e convention - skills/talents
+// aren't plain fields on the actor, they're embedded item documents)
+func (cc *CharacterCreator) exportFoundryActor() foundryActor {
+	d := cc.Data
+
+	items := make([]foundryItem, 0, len(d.Skills)+len(d.Talents)+len(d.Gear))
+	for skill, rating := range d.Skills {
+		items = append(items, foundryItem{
+			Name:   skill,
+			Type:   "skill",
+			System: &foundryItemSystem{Advances: &foundryValue{Value: rating}},
+		})
+	}
+	for _, talent := range d.Talents {
+		items = append(items, foundryItem{Name: talent, Type: "talent"})
+	}
+	for item, source := range d.Gear {
+		items = append(items, foundryItem{
+			Name:   item,
+			Type:   "trapping",
+			System: &foundryItemSystem{Source: source},
+		})
+	}
+
+	return foundryActor{
+		Name: d.Name,
+		Type: "character",
+		System: foundrySystem{
+			Characteristics: map[string]foundryAdvances{
+				"ws": {Initial: d.WS}, "bs": {Initial: d.BS}, "s": {Initial: d.S}, "t": {Initial: d.T},
+				"i": {Initial: d.I}, "ag": {Initial: d.Ag}, "dex": {Initial: d.Dex}, "int": {Initial: d.Int},
+				"wp": {Initial: d.WP}, "fel": {Initial: d.Fel},
+			},
+			Status: foundryStatus{
+				Fate:       foundryValue{Value: d.Fate},
+				Fortune:    foundryValue{Value: d.Fortune},
+				Resilience: foundryValue{Value: d.Resilience},
+				Resolve:    foundryValue{Value: d.Resolve},
+				Wounds:     foundryWounds{Value: d.HP, Max: d.HP},
+			},
+			Details: foundryDetails{
+				Species: foundryText{Value: d.Race},
+				Career:  foundryText{Value: d.Career},
+				Status:  foundryRank{Value: d.Status, Tier: d.StatusLevel},
+				Age:     foundryValue{Value: d.Age},
+				Height:  foundryText{Value: d.Height},
+				Hair:    foundryText{Value: d.HairColor},
+				Eyes:    foundryText{Value: d.EyeColor},
+			},
+			Money: foundryMoney{GC: d.Money},
+		},
+		Items: items,
+	}
+}
@@ -0,0 +1,428 @@
+// Package importer parses a pasted WFRP 4E character sheet - either the
+// Russian markdown the bot itself exports, or a plain English statblock
+// copied from another source - back into a game.CharacterCreationData.
+//
+// Like the rest of the repo, this is a hand-rolled line scanner rather than
+// a markdown AST: every line is checked against a handful of cheap rules
+// (section header, "label: value" field, section-specific line), and
+// anything that doesn't match gets reported as a ParseWarning instead of
+// aborting the import.
+package importer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"wfrp-bot/game"
+)
+
+// ParseWarning notes a line that fell inside a recognized section but
+// didn't match any rule for it, so it was skipped rather than imported
+type ParseWarning struct {
+	Line   int
+	Text   string
+	Reason string
+}
+
+// section tracks which part of the statblock the scanner is currently in
+type section int
+
+const (
+	sectionNone section = iota
+	sectionCharacteristics
+	sectionSkills
+	sectionTalents
+	sectionGear
+	sectionMoney
+)
+
+// sectionHeaders maps a lowercased, markup-stripped header line to the
+// section it opens - both the Russian headers the bot's own export uses
+// and the common English Cubicle 7 equivalents
+var sectionHeaders = map[string]section{
+	"характеристики":  sectionCharacteristics,
+	"характеристика":  sectionCharacteristics,
+	"characteristics": sectionCharacteristics,
+	"навыки":          sectionSkills,
+	"skills":          sectionSkills,
+	"таланты":         sectionTalents,
+	"talents":         sectionTalents,
+	"снаряжение":      sectionGear,
+	"gear":            sectionGear,
+	"trappings":       sectionGear,
+	"equipment":       sectionGear,
+	"деньги":          sectionMoney,
+	"money":           sectionMoney,
+}
+
+// fieldLabels maps a lowercased label to the CharacterCreationData field it
+// sets, for single "label: value" lines that aren't part of a section
+var fieldLabels = map[string]string{
+	"имя":     "name",
+	"name":    "name",
+	"раса":    "race",
+	"race":    "race",
+	"species": "race",
+	"карьера": "career",
+	"career":  "career",
+	"class":   "career",
+	"статус":  "status",
+	"status":  "status",
+}
+
+// russianStatCodes inverts game.RussianStatsMapping (English code ->
+// Russian code) so a Russian code read from a pasted sheet can be mapped
+// back. It is missing Dex and Int, same as the map it's built from - a
+// sheet using those Russian codes falls through to a ParseWarning
+var russianStatCodes = invertRussianStats()
+
+func invertRussianStats() map[string]string {
+	inverted := make(map[string]string, len(game.RussianStatsMapping))
+	for english, russian := range game.RussianStatsMapping {
+		inverted[russian] = english
+	}
+	return inverted
+}
+
+// englishStatCodes normalizes the case of the canonical English codes
+var englishStatCodes = map[string]string{
+	"ws": "WS", "bs": "BS", "s": "S", "t": "T", "i": "I",
+	"ag": "Ag", "dex": "Dex", "int": "Int", "wp": "WP", "fel": "Fel",
+}
+
+// ParseStatblock reads a pasted character sheet and returns the character
+// data it could recognize, plus a warning for every line it couldn't. It
+// only fails outright when no character name can be found at all
+func ParseStatblock(text string) (*game.CharacterCreationData, []ParseWarning, error) {
+	if strings.TrimSpace(text) == "" {
+		return nil, nil, fmt.Errorf("empty statblock")
+	}
+
+	data := &game.CharacterCreationData{
+		Skills:  make(map[string]int),
+		Talents: []string{},
+		Gear:    make(map[string]string),
+	}
+
+	var warnings []ParseWarning
+	sec := sectionNone
+	nameSeen := false
+
+	for i, raw := range strings.Split(text, "\n") {
+		line := strings.TrimSpace(raw)
+		if line == "" {
+			continue
+		}
+		lineNo := i + 1
+
+		if !nameSeen {
+			if name, ok := matchTitle(line); ok {
+				data.Name = name
+				nameSeen = true
+				continue
+			}
+		}
+
+		if newSec, ok := matchSection(line); ok {
+			sec = newSec
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			// An unrecognized heading closes whatever section we were in,
+			// so its body text isn't mistaken for the previous section's
+			sec = sectionNone
+			continue
+		}
+
+		if field, value, ok := matchField(line); ok {
+			applyField(data, field, value)
+			continue
+		}
+
+		switch sec {
+		case sectionCharacteristics:
+			if code, value, ok := parseCharacteristicLine(line); ok {
+				applyCharacteristic(data, code, value)
+				continue
+			}
+			warnings = append(warnings, ParseWarning{Line: lineNo, Text: line, Reason: "unrecognized characteristic line"})
+
+		case sectionSkills:
+			if name, rating, ok := matchSkillLine(line); ok {
+				data.Skills[name] = rating
+				continue
+			}
+			warnings = append(warnings, ParseWarning{Line: lineNo, Text: line, Reason: "unrecognized skill line"})
+
+		case sectionTalents:
+			if name, ok := matchListItem(line); ok {
+				data.Talents = append(data.Talents, name)
+				continue
+			}
+			warnings = append(warnings, ParseWarning{Line: lineNo, Text: line, Reason: "unrecognized talent line"})
+
+		case sectionGear:
+			if item, source, ok := matchGearLine(line); ok {
+				data.Gear[item] = source
+				continue
+			}
+			warnings = append(warnings, ParseWarning{Line: lineNo, Text: line, Reason: "unrecognized gear line"})
+
+		case sectionMoney:
+			if amount, ok := extractFirstInt(line); ok {
+				data.Money = amount
+				continue
+			}
+			warnings = append(warnings, ParseWarning{Line: lineNo, Text: line, Reason: "unrecognized money line"})
+		}
+		// Lines outside any recognized section (prose, decoration, fields
+		// we don't import) are left alone rather than warned about
+	}
+
+	if data.Name == "" {
+		return nil, warnings, fmt.Errorf("could not find a character name in the pasted text")
+	}
+
+	return data, warnings, nil
+}
+
+// stripMarkup removes the markdown decoration (bold, headings, bullets)
+// this package's callers need to see through to get at the plain text
+func stripMarkup(line string) string {
+	line = strings.ReplaceAll(line, "*", "")
+	line = strings.TrimSpace(line)
+	line = strings.TrimLeft(line, "#")
+	line = strings.TrimSpace(line)
+	return stripBullet(line)
+}
+
+// stripBullet removes a single leading list-bullet marker, if present
+func stripBullet(line string) string {
+	line = strings.TrimSpace(line)
+	for _, bullet := range []string{"-", "•"} {
+		if strings.HasPrefix(line, bullet) {
+			return strings.TrimSpace(strings.TrimPrefix(line, bullet))
+		}
+	}
+	return line
+}
+
+// matchTitle recognizes a markdown "# Name" line (but not "## Section")
+func matchTitle(line string) (string, bool) {
+	if !strings.HasPrefix(line, "#") || strings.HasPrefix(line, "##") {
+		return "", false
+	}
+	name := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+	if name == "" {
+		return "", false
+	}
+	return name, true
+}
+
+// matchSection recognizes a section header, in either markdown ("##
+// ХАРАКТЕРИСТИКИ") or plain ("Характеристики:") form
+func matchSection(line string) (section, bool) {
+	key := strings.ToLower(stripMarkup(line))
+	key = strings.TrimSuffix(key, ":")
+	sec, ok := sectionHeaders[key]
+	return sec, ok
+}
+
+// matchField recognizes a "Label: value" line for one of the single
+// top-level fields (Name, Race, Career, Status)
+func matchField(line string) (field, value string, ok bool) {
+	label, value, ok := splitLabel(line)
+	if !ok {
+		return "", "", false
+	}
+	field, ok = fieldLabels[strings.ToLower(label)]
+	if !ok {
+		return "", "", false
+	}
+	return field, value, true
+}
+
+// splitLabel splits a markup-stripped "Label: value" line on its first
+// colon
+func splitLabel(line string) (label, value string, ok bool) {
+	stripped := stripMarkup(line)
+	idx := strings.Index(stripped, ":")
+	if idx < 0 {
+		return "", "", false
+	}
+	label = strings.TrimSpace(stripped[:idx])
+	value = strings.TrimSpace(stripped[idx+1:])
+	if label == "" || value == "" {
+		return "", "", false
+	}
+	return label, value, true
+}
+
+// applyField writes a recognized top-level field into data
+func applyField(data *game.CharacterCreationData, field, value string) {
+	switch field {
+	case "name":
+		data.Name = value
+	case "race":
+		data.Race = value
+	case "career":
+		data.Career = value
+	case "status":
+		status, level := splitStatusLevel(value)
+		data.Status = status
+		data.StatusLevel = level
+	}
+}
+
+// splitStatusLevel splits a status line like "Серебряный 3" into the
+// status name and its numeric level, when the trailing token is a number
+func splitStatusLevel(value string) (string, int) {
+	parts := strings.Fields(value)
+	if len(parts) > 1 {
+		if level, err := strconv.Atoi(parts[len(parts)-1]); err == nil {
+			return strings.Join(parts[:len(parts)-1], " "), level
+		}
+	}
+	return value, 0
+}
+
+// parseCharacteristicLine recognizes a characteristic line in either the
+// markdown table form the bot's own export uses ("| ББ (...) | 30 | 3 |")
+// or a plain "Code: value" form
+func parseCharacteristicLine(line string) (code string, value int, ok bool) {
+	if strings.HasPrefix(line, "|") {
+		var cells []string
+		for _, cell := range strings.Split(line, "|") {
+			cell = strings.TrimSpace(cell)
+			if cell != "" {
+				cells = append(cells, cell)
+			}
+		}
+		if len(cells) < 2 {
+			return "", 0, false
+		}
+		label := strings.Fields(cells[0])
+		if len(label) == 0 {
+			return "", 0, false
+		}
+		n, err := strconv.Atoi(cells[1])
+		if err != nil {
+			return "", 0, false
+		}
+		code, ok = normalizeStatCode(label[0])
+		return code, n, ok
+	}
+
+	label, rawValue, ok := splitLabel(line)
+	if !ok {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(rawValue)
+	if err != nil {
+		return "", 0, false
+	}
+	code, ok = normalizeStatCode(label)
+	return code, n, ok
+}
+
+// normalizeStatCode maps either a canonical English code or a Russian code
+// to the canonical English code applyCharacteristic expects
+func normalizeStatCode(label string) (string, bool) {
+	if code, ok := englishStatCodes[strings.ToLower(label)]; ok {
+		return code, true
+	}
+	if code, ok := russianStatCodes[label]; ok {
+		return code, true
+	}
+	return "", false
+}
+
+// applyCharacteristic writes a normalized characteristic code and value
+// into data
+func applyCharacteristic(data *game.CharacterCreationData, code string, value int) {
+	switch code {
+	case "WS":
+		data.WS = value
+	case "BS":
+		data.BS = value
+	case "S":
+		data.S = value
+	case "T":
+		data.T = value
+	case "I":
+		data.I = value
+	case "Ag":
+		data.Ag = value
+	case "Dex":
+		data.Dex = value
+	case "Int":
+		data.Int = value
+	case "WP":
+		data.WP = value
+	case "Fel":
+		data.Fel = value
+	}
+}
+
+// matchSkillLine recognizes a skill list line, "- Skill: 30"
+func matchSkillLine(line string) (name string, rating int, ok bool) {
+	name, rawValue, ok := splitLabel(stripBullet(line))
+	if !ok {
+		return "", 0, false
+	}
+	n, err := strconv.Atoi(rawValue)
+	if err != nil {
+		return "", 0, false
+	}
+	return name, n, true
+}
+
+// matchListItem recognizes a plain bulleted list item, used for talents
+func matchListItem(line string) (string, bool) {
+	item := stripBullet(line)
+	if item == "" {
+		return "", false
+	}
+	return item, true
+}
+
+// matchGearLine recognizes a gear list line, "- Item (source)", with the
+// parenthetical source left off the item name when present
+func matchGearLine(line string) (item, source string, ok bool) {
+	body := stripBullet(line)
+	if body == "" {
+		return "", "", false
+	}
+	if strings.HasSuffix(body, ")") {
+		if idx := strings.LastIndex(body, "("); idx >= 0 {
+			name := strings.TrimSpace(body[:idx])
+			if name != "" {
+				return name, strings.TrimSpace(strings.TrimSuffix(body[idx+1:], ")")), true
+			}
+		}
+	}
+	return body, "", true
+}
+
+// extractFirstInt returns the first run of digits found in line
+func extractFirstInt(line string) (int, bool) {
+	start := -1
+	for i, r := range line {
+		if r >= '0' && r <= '9' {
+			if start == -1 {
+				start = i
+			}
+			continue
+		}
+		if start != -1 {
+			n, err := strconv.Atoi(line[start:i])
+			return n, err == nil
+		}
+	}
+	if start != -1 {
+		n, err := strconv.Atoi(line[start:])
+		return n, err == nil
+	}
+	return 0, false
+}
@@ -0,0 +1,210 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"wfrp-bot/llm"
+	"wfrp-bot/storage"
+)
+
+// lobbyMatchInterval is how often the background matcher re-scans the
+// queue for groups that can start a session
+const lobbyMatchInterval = 2 * time.Second
+
+// lobbyRelaxAfter is how long a queued player waits before the matcher
+// stops holding out for prefs.PartySize and starts a session with
+// whoever's queued for the same campaign instead
+const lobbyRelaxAfter = 30 * time.Second
+
+// lobbyEvictAfter is how long a queued player waits, unmatched, before
+// being evicted from the queue entirely
+const lobbyEvictAfter = 5 * time.Minute
+
+// LobbyPrefs are the preferences a queued player brings to matchmaking
+type LobbyPrefs struct {
+	PartySize int // desired number of players before a session starts; 0 means "whoever's available"
+}
+
+// LobbyResult is delivered on the channel Enqueue returns once the
+// matcher either starts a session for the queued player or evicts them
+// for waiting too long
+type LobbyResult struct {
+	SessionID string
+	Err       error
+}
+
+// queuedPlayer is one player's entry in the Lobby's queue
+type queuedPlayer struct {
+	playerID   string
+	campaign   string
+	prefs      LobbyPrefs
+	enqueuedAt time.Time
+	result     chan LobbyResult
+}
+
+// Lobby lets players queue for a campaign without a pre-formed Telegram
+// group: Enqueue parks them, a background matcher groups queued players by
+// campaign and party size and starts a Session for each group that fills,
+// and a queue-cleaner evicts anyone who's waited past lobbyEvictAfter.
+// This is what lets a campaign run as a public "drop-in" table instead of
+// only a pre-arranged group
+type Lobby struct {
+	mu    sync.Mutex
+	queue []*queuedPlayer
+
+	ctx         context.Context
+	provider    llm.LLMProvider
+	campaignMgr *storage.CampaignManager
+	sessionMgr  *SessionManager
+
+	matchInterval time.Duration
+	relaxAfter    time.Duration
+	evictAfter    time.Duration
+
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewLobby creates a Lobby that starts sessions via sessionMgr, built with
+// provider and campaignMgr, the same live resources NewSession needs
+func NewLobby(ctx context.Context, provider llm.LLMProvider, campaignMgr *storage.CampaignManager, sessionMgr *SessionManager) *Lobby {
+	return &Lobby{
+		ctx:           ctx,
+		provider:      provider,
+		campaignMgr:   campaignMgr,
+		sessionMgr:    sessionMgr,
+		matchInterval: lobbyMatchInterval,
+		relaxAfter:    lobbyRelaxAfter,
+		evictAfter:    lobbyEvictAfter,
+		stop:          make(chan struct{}),
+	}
+}
+
+// Enqueue queues playerID for campaign under prefs and returns a channel
+// that receives exactly one LobbyResult: a SessionID once the background
+// matcher groups playerID into a new session, or Err if the queue-cleaner
+// evicts playerID first. The channel is closed after that single send
+func (l *Lobby) Enqueue(playerID, campaign string, prefs LobbyPrefs) <-chan LobbyResult {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	result := make(chan LobbyResult, 1)
+	l.queue = append(l.queue, &queuedPlayer{
+		playerID:   playerID,
+		campaign:   campaign,
+		prefs:      prefs,
+		enqueuedAt: time.Now(),
+		result:     result,
+	})
+	log.Printf("[LOBBY] %s queued for campaign %s (party size %d)", playerID, campaign, prefs.PartySize)
+	return result
+}
+
+// StartMatching launches the background matcher/queue-cleaner goroutine.
+// Call StopMatching to shut it down
+func (l *Lobby) StartMatching() {
+	go func() {
+		ticker := time.NewTicker(l.matchInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-l.stop:
+				return
+			case <-ticker.C:
+				l.matchOnce()
+				l.evictExpired()
+			}
+		}
+	}()
+}
+
+// StopMatching shuts down the background goroutine started by StartMatching
+func (l *Lobby) StopMatching() {
+	l.stopOnce.Do(func() { close(l.stop) })
+}
+
+// matchOnce groups the queue by campaign, starting a session for every
+// group that reaches its party size - or, for anyone who's waited past
+// relaxAfter, a session with whoever else is queued for that campaign
+func (l *Lobby) matchOnce() {
+	l.mu.Lock()
+
+	byCampaign := make(map[string][]*queuedPlayer)
+	for _, qp := range l.queue {
+		byCampaign[qp.campaign] = append(byCampaign[qp.campaign], qp)
+	}
+
+	now := time.Now()
+	var matched, remaining []*queuedPlayer
+	for _, players := range byCampaign {
+		partySize := players[0].prefs.PartySize
+		if partySize <= 0 {
+			partySize = len(players)
+		}
+
+		for _, qp := range players {
+			if now.Sub(qp.enqueuedAt) >= l.relaxAfter && len(players) < partySize {
+				partySize = len(players)
+				break
+			}
+		}
+
+		if partySize > 0 && len(players) >= partySize {
+			matched = append(matched, players[:partySize]...)
+			remaining = append(remaining, players[partySize:]...)
+		} else {
+			remaining = append(remaining, players...)
+		}
+	}
+	l.queue = remaining
+	l.mu.Unlock()
+
+	groups := make(map[string][]*queuedPlayer)
+	for _, qp := range matched {
+		groups[qp.campaign] = append(groups[qp.campaign], qp)
+	}
+	for campaign, players := range groups {
+		l.startSession(campaign, players)
+	}
+}
+
+// startSession builds a new Session for campaign, registers it with
+// sessionMgr and hands every waiting player its ID
+func (l *Lobby) startSession(campaign string, players []*queuedPlayer) {
+	groupID := time.Now().UnixNano() // synthetic - a drop-in table has no pre-existing Telegram chat to key off
+	session := NewSession(l.ctx, groupID, campaign, l.provider, l.campaignMgr)
+	l.sessionMgr.AddSession(groupID, session)
+	log.Printf("[LOBBY] Matched %d player(s) into session %s for campaign %s", len(players), session.ID, campaign)
+
+	for _, qp := range players {
+		qp.result <- LobbyResult{SessionID: session.ID}
+		close(qp.result)
+	}
+}
+
+// evictExpired removes and notifies every queued player who's waited
+// longer than evictAfter without being matched
+func (l *Lobby) evictExpired() {
+	l.mu.Lock()
+	cutoff := time.Now().Add(-l.evictAfter)
+	var remaining, evicted []*queuedPlayer
+	for _, qp := range l.queue {
+		if qp.enqueuedAt.Before(cutoff) {
+			evicted = append(evicted, qp)
+		} else {
+			remaining = append(remaining, qp)
+		}
+	}
+	l.queue = remaining
+	l.mu.Unlock()
+
+	for _, qp := range evicted {
+		log.Printf("[LOBBY] Evicting %s from campaign %s queue after waiting past the threshold", qp.playerID, qp.campaign)
+		qp.result <- LobbyResult{Err: fmt.Errorf("no match found for campaign %s before the wait threshold", qp.campaign)}
+		close(qp.result)
+	}
+}
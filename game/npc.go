@@ -0,0 +1,338 @@
+package game
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"math/rand"
+	"strings"
+
+	"wfrp-bot/agents"
+	"wfrp-bot/game/content"
+	"wfrp-bot/llm"
+)
+
+// NPCOptions constrains GenerateNPCs' random rolls. A zero value means "no
+// constraint" for that field
+type NPCOptions struct {
+	Classes []string // restrict career class by content.CareerClass.Key (e.g. "warrior"); empty = any class
+	Status  string   // override the rolled status tier (e.g. "Серебряный"); empty = leave the default
+	MinAge  int      // re-roll age within this range (see constrainNPCAge); zero on both ends = don't constrain
+	MaxAge  int
+}
+
+var npcStrengthPool = []string{"Храбрость", "Верность", "Упорство", "Хитрость", "Честность"}
+var npcWeaknessPool = []string{"Вспыльчивость", "Жадность", "Трусость", "Болтливость", "Подозрительность"}
+
+// fallbackNPCNames is used when no LLM provider is available (or it
+// errors), so bulk generation never leaves an NPC nameless
+var fallbackNPCNames = []string{"Ганс", "Грета", "Освальд", "Миранда", "Дитер", "Хельга", "Бруно", "Ильза"}
+
+// NPCFilter constrains GenerateBatch's output by properties that can only
+// be checked after generation (unlike NPCOptions, which constrains the
+// rolls themselves): an exact race, a career class key, a minimum status
+// level, and a floor per characteristic (e.g. {"WS": 40} for "WS>=40").
+// A zero value accepts anything
+type NPCFilter struct {
+	Race           string
+	Class          string
+	MinStatusLevel int
+	StatFloor      map[string]int
+}
+
+// npcMatchesFilter reports whether npc satisfies filter's Race,
+// MinStatusLevel and StatFloor. Class is not checked here: GenerateBatch
+// applies it beforehand through NPCOptions.Classes
+func npcMatchesFilter(npc *CharacterCreationData, filter NPCFilter) bool {
+	if filter.Race != "" && !strings.EqualFold(npc.Race, filter.Race) {
+		return false
+	}
+	if filter.MinStatusLevel > 0 && npc.StatusLevel < filter.MinStatusLevel {
+		return false
+	}
+	stats := characteristics(npc)
+	for stat, floor := range filter.StatFloor {
+		if stats[stat] < floor {
+			return false
+		}
+	}
+	return true
+}
+
+// maxGenerateBatchAttempts caps how many rerolls GenerateBatch spends
+// trying to satisfy a narrow filter before giving up on one NPC
+const maxGenerateBatchAttempts = 50
+
+// GenerateBatch is GenerateNPCs with post-generation filtering: each NPC is
+// rolled via GenerateNPCs and rerolled (up to maxGenerateBatchAttempts
+// times) until it satisfies filter, so a GM can ask for e.g. "5 dwarves,
+// soldier class, WS>=40" without hand-picking through an unfiltered batch.
+// Uses cc's own content provider and LLM provider, same as the rest of the
+// headless NPC pipeline
+func (cc *CharacterCreator) GenerateBatch(ctx context.Context, count int, filter NPCFilter) ([]*CharacterCreationData, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("count must be positive, got %d", count)
+	}
+
+	opts := NPCOptions{}
+	if filter.Class != "" {
+		opts.Classes = []string{filter.Class}
+	}
+
+	npcs := make([]*CharacterCreationData, 0, count)
+	for len(npcs) < count {
+		var npc *CharacterCreationData
+		for attempt := 1; ; attempt++ {
+			rolled, err := GenerateNPCs(ctx, cc.content, cc.LLMProvider, 1, opts)
+			if err != nil {
+				return npcs, err
+			}
+			if npcMatchesFilter(rolled[0], filter) {
+				npc = rolled[0]
+				break
+			}
+			if attempt >= maxGenerateBatchAttempts {
+				return npcs, fmt.Errorf("could not roll an NPC matching the filter after %d attempts", maxGenerateBatchAttempts)
+			}
+		}
+		if cc.LLMProvider == nil {
+			// Each GenerateNPCs(..., 1, ...) call above starts its own
+			// fallback-name rotation at index 0; rotate across the whole
+			// batch instead so a filtered run doesn't name everyone the same
+			npc.Name = fallbackNPCNames[len(npcs)%len(fallbackNPCNames)]
+		}
+		npcs = append(npcs, npc)
+	}
+
+	return npcs, nil
+}
+
+// GenerateNPCs runs the full character creation pipeline headlessly for
+// count NPCs: a random race via the existing d100 table, a career
+// constrained by opts, auto-rolled stats and appearance, and an
+// LLM-generated name and one-line personality hook (falling back to a
+// canned pool if no LLM provider is set or it errors). It backs the
+// /npcs command, for GMs who need a tavern full of statted extras without
+// walking each one through /newchar by hand
+func GenerateNPCs(ctx context.Context, provider content.ContentProvider, llmProvider interface {
+	GenerateRequest(ctx context.Context, agent *agents.Agent, messages []llm.Message) (llm.GenerateResult, error)
+}, count int, opts NPCOptions) ([]*CharacterCreationData, error) {
+	if count <= 0 {
+		return nil, fmt.Errorf("count must be positive, got %d", count)
+	}
+
+	npcs := make([]*CharacterCreationData, 0, count)
+	for i := 0; i < count; i++ {
+		cc := NewCharacterCreator("", provider)
+		cc.SetLLMProvider(llmProvider)
+
+		if err := cc.rollNPCRace(); err != nil {
+			return npcs, err
+		}
+		if err := cc.rollNPCCareer(opts); err != nil {
+			return npcs, err
+		}
+
+		cc.Data.StatsMethod = "random_no_swap"
+		cc.Data.XPFromStats = 50
+		cc.rollStats(false)
+		cc.Data.TotalXP += cc.Data.XPFromStats
+		cc.calculateSecondaryStats()
+
+		cc.processAppearance("")
+		cc.constrainNPCAge(opts)
+		cc.rollNPCPersonality()
+		cc.rollNPCName(ctx, i)
+
+		npcs = append(npcs, cc.Data)
+	}
+
+	return npcs, nil
+}
+
+// rollNPCRace picks a random race via the same d100 table /newchar uses
+func (cc *CharacterCreator) rollNPCRace() error {
+	roll := rand.Intn(100) + 1
+	race, ok := cc.content.RaceByRoll(roll)
+	if !ok {
+		return fmt.Errorf("no race for d100 roll %d", roll)
+	}
+
+	cc.Data.Race = race.Name
+	cc.Data.RaceBonusXP = race.BonusXP
+	cc.Data.RaceMethod = "random"
+	cc.Data.TotalXP += race.BonusXP
+	cc.applyRaceBonuses(race)
+	return nil
+}
+
+// rollNPCCareer picks a random career, restricted to opts.Classes (by
+// content.CareerClass.Key) when given, and applies opts.Status when set
+func (cc *CharacterCreator) rollNPCCareer(opts NPCOptions) error {
+	classes := cc.content.CareerClasses()
+	if len(opts.Classes) > 0 {
+		classes = filterCareerClasses(classes, opts.Classes)
+		if len(classes) == 0 {
+			return fmt.Errorf("no career class matches %v", opts.Classes)
+		}
+	}
+
+	class := classes[rand.Intn(len(classes))]
+	if len(class.Careers) == 0 {
+		return fmt.Errorf("career class %s has no careers", class.Name)
+	}
+	career := class.Careers[rand.Intn(len(class.Careers))]
+
+	cc.Data.CareerMethod = "random"
+	cc.Data.CareerXP = 50
+	cc.Data.Class = class.Name
+	cc.Data.Career = career.Name
+	cc.Data.CareerRank = "Ранг 1"
+	cc.Data.Status = "Медный"
+	cc.Data.StatusLevel = 1
+	if opts.Status != "" {
+		cc.Data.Status = opts.Status
+	}
+	cc.Data.TotalXP += cc.Data.CareerXP
+	return nil
+}
+
+// filterCareerClasses keeps only the classes whose Key matches one of
+// wanted, case-insensitively
+func filterCareerClasses(classes []content.CareerClass, wanted []string) []content.CareerClass {
+	allowed := make(map[string]bool, len(wanted))
+	for _, w := range wanted {
+		allowed[strings.ToLower(w)] = true
+	}
+
+	var filtered []content.CareerClass
+	for _, class := range classes {
+		if allowed[strings.ToLower(class.Key)] {
+			filtered = append(filtered, class)
+		}
+	}
+	return filtered
+}
+
+// constrainNPCAge rerolls the age processAppearance picked into
+// [opts.MinAge, opts.MaxAge] when either bound is set
+func (cc *CharacterCreator) constrainNPCAge(opts NPCOptions) {
+	if opts.MinAge <= 0 && opts.MaxAge <= 0 {
+		return
+	}
+
+	min, max := opts.MinAge, opts.MaxAge
+	if min <= 0 {
+		min = 1
+	}
+	if max <= 0 || max < min {
+		max = min + 40
+	}
+	cc.Data.Age = min + rand.Intn(max-min+1)
+}
+
+// rollNPCPersonality fills in a minimal strengths/weaknesses/motivation
+// set so the NPC isn't blank in those fields; the one-line hook itself
+// comes from the LLM in rollNPCName's companion call, or a fallback
+func (cc *CharacterCreator) rollNPCPersonality() {
+	cc.Data.Strengths = []string{npcStrengthPool[rand.Intn(len(npcStrengthPool))]}
+	cc.Data.Weaknesses = []string{npcWeaknessPool[rand.Intn(len(npcWeaknessPool))]}
+	cc.Data.Motivation = "Выжить и прокормить себя"
+}
+
+// rollNPCName asks the LLM for a name and a one-line personality hook
+// (stored as Background, matching how /newchar's manual flow uses it),
+// falling back to a canned name and a generic hook if there's no LLM
+// provider or it errors
+func (cc *CharacterCreator) rollNPCName(ctx context.Context, index int) {
+	if cc.LLMProvider == nil {
+		cc.Data.Name = fallbackNPCNames[index%len(fallbackNPCNames)]
+		cc.Data.Background = fmt.Sprintf("%s из %s", cc.Data.Career, cc.Data.Race)
+		return
+	}
+
+	prompt := fmt.Sprintf(`Придумай одного NPC для Warhammer Fantasy Roleplay: %s, карьера %s.
+Ответь ровно двумя строками без форматирования и кавычек:
+Имя: <имя>
+Штрих: <одна фраза о характере или привычке>`, cc.Data.Race, cc.Data.Career)
+
+	result, err := cc.LLMProvider.GenerateRequest(ctx, nil, []llm.Message{{Role: "user", Content: prompt}})
+	if err != nil {
+		log.Printf("[NPC] LLM generation failed, falling back: %v", err)
+		cc.Data.Name = fallbackNPCNames[index%len(fallbackNPCNames)]
+		cc.Data.Background = fmt.Sprintf("%s из %s", cc.Data.Career, cc.Data.Race)
+		return
+	}
+
+	name, hook := parseNPCLLMReply(result.Content)
+	if name == "" {
+		name = fallbackNPCNames[index%len(fallbackNPCNames)]
+	}
+	if hook == "" {
+		hook = fmt.Sprintf("%s из %s", cc.Data.Career, cc.Data.Race)
+	}
+	cc.Data.Name = name
+	cc.Data.Background = hook
+}
+
+// parseNPCLLMReply pulls "Имя: ..." and "Штрих: ..." out of the LLM's
+// reply, tolerating missing lines or extra formatting
+func parseNPCLLMReply(reply string) (name, hook string) {
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.TrimSpace(strings.Trim(line, "*"))
+		switch {
+		case strings.HasPrefix(strings.ToLower(line), "имя:"):
+			name = strings.TrimSpace(line[len("имя:"):])
+		case strings.HasPrefix(strings.ToLower(line), "штрих:"):
+			hook = strings.TrimSpace(line[len("штрих:"):])
+		}
+	}
+	return name, hook
+}
+
+// NPCsMarkdownTable renders a batch of generated NPCs as a single Markdown
+// table, one row per character
+func NPCsMarkdownTable(npcs []*CharacterCreationData) string {
+	var b strings.Builder
+	b.WriteString("| Имя | Раса | Карьера | Статус | ББ | ДБ | СС | К | И | Л | О | СТ | HP | Возраст |\n")
+	b.WriteString("|---|---|---|---|---|---|---|---|---|---|---|---|---|---|\n")
+	for _, d := range npcs {
+		b.WriteString(fmt.Sprintf("| %s | %s | %s | %s %d | %d | %d | %d | %d | %d | %d | %d | %d | %d | %d |\n",
+			d.Name, d.Race, d.Career, d.Status, d.StatusLevel,
+			d.WS, d.BS, d.S, d.T, d.I, d.Ag, d.WP, d.Fel, d.HP, d.Age))
+	}
+	return b.String()
+}
+
+// NPCsJSONZip bundles every NPC's JSON export (via CharacterCreator.Export)
+// into a single zip archive, one "<name>.json" entry per character
+func NPCsJSONZip(npcs []*CharacterCreationData) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+
+	for i, d := range npcs {
+		data, err := (&CharacterCreator{Data: d}).Export(ExportJSON)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export NPC %d: %w", i, err)
+		}
+
+		name := d.Name
+		if name == "" {
+			name = fmt.Sprintf("npc_%d", i+1)
+		}
+		w, err := zw.Create(fmt.Sprintf("%s.json", name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to add %s to zip: %w", name, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to write %s to zip: %w", name, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to finalize zip: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -2,183 +2,1033 @@
 package game
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Direction restricts which side of the conversation a Pattern is allowed
+// to fire against, so a combat/skill pattern meant to nudge a player's
+// action doesn't also re-trigger on the GM's own narration of that fight
+type Direction int
+
+const (
+	DirectionAny Direction = iota
+	DirectionPlayerToGM
+	DirectionGMToPlayer
+)
+
+// matches reports whether content from source ("player", "gm", "system",
+// or "" for a query with no direction of its own, e.g. CheckRule) is in
+// scope for d
+func (d Direction) matches(source string) bool {
+	switch d {
+	case DirectionPlayerToGM:
+		return source == "player"
+	case DirectionGMToPlayer:
+		return source == "gm"
+	default:
+		return true
+	}
+}
+
+// PatternFlags mirrors the regexp flags a Pattern's Regex compiles with:
+// Caseless ~ (?i), DotAll ~ (?s), MultiLine ~ (?m). Utf8Mode is accepted
+// for compatibility with Hyperscan-style pattern packs but otherwise
+// unused, since Go's regexp is Unicode-aware by default
+type PatternFlags struct {
+	Caseless  bool `yaml:"caseless" json:"caseless"`
+	DotAll    bool `yaml:"dotall" json:"dotall"`
+	MultiLine bool `yaml:"multiline" json:"multiline"`
+	Utf8Mode  bool `yaml:"utf8mode" json:"utf8mode"`
+}
+
+// Pattern is one compiled rule-detection pattern: an ID, the rule text it
+// surfaces when it fires, the regex that detects it, and the constraints
+// on when a detection counts as a match
+type Pattern struct {
+	ID             string       `yaml:"id" json:"id"`
+	Rule           string       `yaml:"rule" json:"rule"`
+	Regex          string       `yaml:"regex" json:"regex"`
+	Payload        string       `yaml:"payload" json:"payload"` // composite AND/OR/NOT expression, see ParseRuleByPayload; when set, takes priority over Regex
+	Flags          PatternFlags `yaml:"flags" json:"flags"`
+	MinOccurrences int          `yaml:"min_occurrences" json:"min_occurrences"` // <=0 defaults to 1
+	MaxOccurrences int          `yaml:"max_occurrences" json:"max_occurrences"` // <=0 means unbounded
+	Direction      Direction    `yaml:"direction" json:"direction"`
+
+	compiled *regexp.Regexp
+	expr     RuleExpr // compiled from Payload, if set; MinOccurrences/MaxOccurrences don't apply to it
+}
+
+// compile builds p.compiled from p.Regex/p.Flags, or p.expr from p.Payload
+// when one is given instead of a plain regex
+func (p *Pattern) compile() error {
+	if p.Payload != "" {
+		expr, err := ParseRuleByPayload(p.Payload)
+		if err != nil {
+			return fmt.Errorf("pattern %q: invalid payload %q: %w", p.ID, p.Payload, err)
+		}
+		p.expr = expr
+		return nil
+	}
+
+	var flags string
+	if p.Flags.Caseless {
+		flags += "i"
+	}
+	if p.Flags.DotAll {
+		flags += "s"
+	}
+	if p.Flags.MultiLine {
+		flags += "m"
+	}
+
+	expr := p.Regex
+	if flags != "" {
+		expr = fmt.Sprintf("(?%s)%s", flags, expr)
+	}
+
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return fmt.Errorf("pattern %q: invalid regex %q: %w", p.ID, p.Regex, err)
+	}
+	p.compiled = re
+	return nil
+}
+
+// withinOccurrences reports whether count falls within p's
+// Min/MaxOccurrences bounds
+func (p *Pattern) withinOccurrences(count int) bool {
+	minCount := p.MinOccurrences
+	if minCount <= 0 {
+		minCount = 1
+	}
+	if count < minCount {
+		return false
+	}
+	if p.MaxOccurrences > 0 && count > p.MaxOccurrences {
+		return false
+	}
+	return true
+}
+
+// RuleExpr is a node in a composite AND/OR/NOT rule tree compiled by
+// ParseRuleByPayload, following the composable-rule design in Clash's
+// rules/logic package (AND/OR/NOT combining leaf rule matchers) - here the
+// leaves are regexes instead of Clash's DOMAIN/NETWORK/... rule types
+type RuleExpr interface {
+	Match(input InputData) bool
+}
+
+// PatternRule is a RuleExpr leaf: it matches when Regex (always
+// case-insensitive) is found anywhere in input.Content. Regex is compiled
+// once, by NewPatternRule/ParseRuleByPayload, not on every Match
+type PatternRule struct {
+	Regex    string
+	compiled *regexp.Regexp
+}
+
+// NewPatternRule compiles regex into a PatternRule leaf
+func NewPatternRule(regex string) (*PatternRule, error) {
+	compiled, err := regexp.Compile("(?i)" + regex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rule pattern %q: %w", regex, err)
+	}
+	return &PatternRule{Regex: regex, compiled: compiled}, nil
+}
+
+// Match implements RuleExpr
+func (p *PatternRule) Match(input InputData) bool {
+	return p.compiled != nil && p.compiled.MatchString(input.Content)
+}
+
+// AndRule is a RuleExpr matching only when every Child matches
+type AndRule struct {
+	Children []RuleExpr
+}
+
+// Match implements RuleExpr
+func (a *AndRule) Match(input InputData) bool {
+	if len(a.Children) == 0 {
+		return false
+	}
+	for _, c := range a.Children {
+		if !c.Match(input) {
+			return false
+		}
+	}
+	return true
+}
+
+// OrRule is a RuleExpr matching when any Child matches
+type OrRule struct {
+	Children []RuleExpr
+}
+
+// Match implements RuleExpr
+func (o *OrRule) Match(input InputData) bool {
+	for _, c := range o.Children {
+		if c.Match(input) {
+			return true
+		}
+	}
+	return false
+}
+
+// NotRule is a RuleExpr inverting Child
+type NotRule struct {
+	Child RuleExpr
+}
+
+// Match implements RuleExpr
+func (n *NotRule) Match(input InputData) bool {
+	return !n.Child.Match(input)
+}
+
+// ParseRuleByPayload compiles a composite rule expression such as
+// "AND,((combat_keyword),(NOT,skill_mentioned))" into a RuleExpr tree. A
+// leaf is any identifier with no top-level comma, compiled as a
+// case-insensitive regex (see NewPatternRule). AND/OR take a
+// parenthesized, comma-separated list of sub-expressions, each itself
+// wrapped in its own parens; NOT takes exactly one sub-expression. This is
+// the same "OP,(child),(child),..." payload convention used by Clash's
+// rules/logic package for its composite AND/OR/NOT rules
+func ParseRuleByPayload(payload string) (RuleExpr, error) {
+	return parseRuleExpr(strings.TrimSpace(payload))
+}
+
+// parseRuleExpr parses one expression: either a bare leaf identifier, or
+// an "OP,rest" node whose rest is interpreted according to OP
+func parseRuleExpr(expr string) (RuleExpr, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return nil, fmt.Errorf("empty rule expression")
+	}
+
+	idx := findTopLevelComma(expr)
+	if idx < 0 {
+		return NewPatternRule(expr)
+	}
+
+	op := strings.TrimSpace(expr[:idx])
+	rest := strings.TrimSpace(expr[idx+1:])
+
+	switch strings.ToUpper(op) {
+	case "AND", "OR":
+		groups, err := splitTopLevel(trimOuterParens(rest))
+		if err != nil {
+			return nil, fmt.Errorf("parsing %s rule: %w", op, err)
+		}
+		children := make([]RuleExpr, 0, len(groups))
+		for _, g := range groups {
+			child, err := parseRuleExpr(trimOuterParens(g))
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, child)
+		}
+		if len(children) == 0 {
+			return nil, fmt.Errorf("%s rule requires at least one child", op)
+		}
+		if strings.ToUpper(op) == "AND" {
+			return &AndRule{Children: children}, nil
+		}
+		return &OrRule{Children: children}, nil
+	case "NOT":
+		child, err := parseRuleExpr(trimOuterParens(rest))
+		if err != nil {
+			return nil, fmt.Errorf("parsing NOT rule: %w", err)
+		}
+		return &NotRule{Child: child}, nil
+	default:
+		return nil, fmt.Errorf("unknown rule operator %q", op)
+	}
+}
+
+// findTopLevelComma returns the index of the first comma in s that isn't
+// nested inside parentheses or a regex repetition quantifier like
+// "{2,4}", or -1 if there isn't one
+func findTopLevelComma(s string) int {
+	depth := 0
+	braceDepth := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case '{':
+			braceDepth++
+		case '}':
+			braceDepth--
+		case ',':
+			if depth == 0 && braceDepth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// splitTopLevel splits s on every comma not nested inside parentheses or a
+// regex repetition quantifier like "{2,4}", trimming whitespace from each
+// part. Each returned part keeps its own wrapping parens, if it has any
+func splitTopLevel(s string) ([]string, error) {
+	var parts []string
+	depth := 0
+	braceDepth := 0
+	start := 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses in %q", s)
+			}
+		case '{':
+			braceDepth++
+		case '}':
+			braceDepth--
+		case ',':
+			if depth == 0 && braceDepth == 0 {
+				parts = append(parts, strings.TrimSpace(s[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	if depth != 0 {
+		return nil, fmt.Errorf("unbalanced parentheses in %q", s)
+	}
+	parts = append(parts, strings.TrimSpace(s[start:]))
+	return parts, nil
+}
+
+// trimOuterParens strips a single layer of enclosing parentheses from s,
+// repeatedly, but only when the opening paren's matching close is the
+// final character - so "(a),(b)" (two sibling groups) is left alone while
+// "((a),(b))" (one group wrapping both) has its outer layer removed
+func trimOuterParens(s string) string {
+	s = strings.TrimSpace(s)
+	for len(s) >= 2 && s[0] == '(' && s[len(s)-1] == ')' {
+		depth := 0
+		matchesToEnd := true
+		for i, r := range s {
+			switch r {
+			case '(':
+				depth++
+			case ')':
+				depth--
+				if depth == 0 && i != len(s)-1 {
+					matchesToEnd = false
+				}
+			}
+			if !matchesToEnd {
+				break
+			}
+		}
+		if !matchesToEnd {
+			break
+		}
+		s = strings.TrimSpace(s[1 : len(s)-1])
+	}
+	return s
+}
+
+// RuleMatch represents a search result from rule lookup
+type RuleMatch struct {
+	Rule       string
+	Confidence float64
+	Source     string
+	PatternID  string
+}
+
+// Outcome is the effect a dynamic Rule (see Rule) has once it fires:
+// stricter outcomes win when several Rules fire on the same Check, so the
+// constants are ordered from most to least permissive
+type Outcome int
+
+const (
+	OutcomeAllow Outcome = iota
+	OutcomeWarn
+	OutcomeBlock
+)
+
+// Scope records how broadly a Rule is meant to apply: Session for a
+// temporary house rule that should only outlive the current table ("until
+// end of session"), Campaign for one meant to follow a campaign across
+// sessions, Global for a bot-wide house rule. Since a RuleChecker already
+// belongs to a single Session, Scope isn't enforced as a filter on Check -
+// it's bookkeeping for ListRules/a future cross-session rule store to key
+// off of, so a Campaign/Global rule can eventually be propagated to every
+// RuleChecker that should share it
+type Scope int
+
+const (
+	ScopeSession Scope = iota
+	ScopeCampaign
+	ScopeGlobal
 )
 
-// RuleChecker validates game actions against WFRP rules
+// Rule is one dynamically added, lifecycle-aware reconciliation rule,
+// modeled after snapd's requestrules "coexistence" design (PR #14581):
+// several Rules can legitimately fire on the same input at once, and are
+// only in conflict if their Outcomes can't coexist (see compatible),
+// rather than the last one registered simply overwriting the others
+type Rule struct {
+	ID          string
+	Pattern     string // case-insensitive substring match against InputData.Content; also the rule tree's variant key, see ruleVariant
+	Outcome     Outcome
+	Permissions []string
+	Expiry      *time.Time // nil means the rule never expires
+	Scope       Scope
+}
+
+// ruleVariant normalizes pattern into the key rc.ruleTree indexes rules
+// under, so "Bleeding" and "bleeding" share the same tree entry
+func ruleVariant(pattern string) string {
+	return strings.ToLower(strings.TrimSpace(pattern))
+}
+
+// expired reports whether r's Expiry has passed as of now
+func (r *Rule) expired(now time.Time) bool {
+	return r.Expiry != nil && now.After(*r.Expiry)
+}
+
+// compatible reports whether r and other can both fire on the same Check
+// without conflicting. Identical outcomes always coexist (e.g. two Warn
+// rules just both warn); otherwise they coexist unless one of them is an
+// outright OutcomeBlock, since a block can't be reconciled with anything
+// more permissive firing alongside it
+func (r *Rule) compatible(other *Rule) bool {
+	if r.Outcome == other.Outcome {
+		return true
+	}
+	return r.Outcome != OutcomeBlock && other.Outcome != OutcomeBlock
+}
+
+// RuleConflictError reports that two or more dynamic Rules fired on the
+// same input but have incompatible Outcomes (see Rule.compatible), so
+// Check can't produce a single reconciled decision
+type RuleConflictError struct {
+	RuleIDs []string
+}
+
+func (e *RuleConflictError) Error() string {
+	return fmt.Sprintf("conflicting rules fired: %s", strings.Join(e.RuleIDs, ", "))
+}
+
+// RuleDecision is the unified result of Check: every static Pattern
+// violation (unchanged from before), plus the Outcome and Permissions
+// merged from whichever dynamic Rules also fired (see AddRule)
+type RuleDecision struct {
+	Violations  []string
+	Outcome     Outcome
+	Permissions []string
+	FiredRules  []string // IDs of the dynamic Rules that fired, for audit/logging
+}
+
+// RuleListFilter narrows ListRules to rules matching every non-nil field.
+// Both fields are pointers (rather than bare Scope/Outcome) since their
+// zero values, ScopeSession and OutcomeAllow, are themselves meaningful
+// filter values, not an "any" sentinel
+type RuleListFilter struct {
+	Scope   *Scope
+	Outcome *Outcome
+}
+
+// RuleChecker validates game actions against WFRP rules using a set of
+// compiled Patterns (see Pattern) instead of an ad-hoc strings.Contains
+// scan over hardcoded keywords, so tuning or adding a rule is a data
+// change (LoadPatterns/ReloadPatterns) rather than a code change.
+// patterns is guarded by mu: LoadPatterns swaps in a whole new set, while
+// AddPattern/RemovePattern append to or splice the current one
+//
+// matchAll below runs each compiled Pattern over the text once per Check -
+// O(patterns*text). A true single-pass engine (e.g.
+// github.com/flier/gohs's Hyperscan bindings) would scan once for every
+// pattern via a shared scratch space, but needs cgo and the Hyperscan C
+// library, neither available in this environment; the occurrence-counting
+// and Direction filtering here are unaffected either way, so swapping in a
+// real multi-pattern scratch space later only touches matchAll
 type RuleChecker struct {
+	mu        sync.RWMutex
+	patterns  []*Pattern
 	ruleCache map[string]string
+	ragIndex  *RuleIndex         // optional; when set, SearchRules tries vector recall first, see SetRAGIndex
+	rootPath  string             // last path passed to LoadPatterns, so Reload doesn't need it repeated
+	sources   []string           // effective %include merge order of the current pattern set, see LayerSources
+	ruleTree  map[string][]*Rule // dynamic rules added via AddRule, keyed by ruleVariant, reconciled into Check alongside patterns
+
+	sweepStop     chan struct{}
+	sweepStopOnce sync.Once
 }
 
-// NewRuleChecker creates a new rule checker
+// NewRuleChecker creates a rule checker seeded with the built-in default
+// pattern set (see defaultPatterns). Use LoadPatterns to replace it with a
+// YAML/JSON pattern pack instead
 func NewRuleChecker() *RuleChecker {
-	return &RuleChecker{
-		ruleCache: make(map[string]string),
+	rc := &RuleChecker{ruleCache: make(map[string]string), ruleTree: make(map[string][]*Rule)}
+
+	for _, p := range defaultPatterns() {
+		pat := p
+		if err := pat.compile(); err != nil {
+			log.Printf("[RAG] skipping default pattern %q: %v", pat.ID, err)
+			continue
+		}
+		rc.patterns = append(rc.patterns, &pat)
 	}
+
+	return rc
 }
 
-// Check validates an action against WFRP rules
-func (rc *RuleChecker) Check(input InputData) ([]string, error) {
-	var violations []string
+// ruleLayerMaxDepth caps %include recursion so a cyclic or runaway include
+// chain can't hang LoadPatterns
+const ruleLayerMaxDepth = 32
 
-	// Check based on input content
-	content := strings.ToLower(input.Content)
+// loadLayer parses path's pattern file - a .yaml/.yml or .json list of
+// Pattern, optionally interspersed with directive lines in the style of
+// Mercurial's hgrc layer.rs: "%include <path>" recursively merges in
+// another pattern file first, and "%unset <id>" removes a pattern (by ID)
+// from the accumulated set. Directive lines are stripped before the
+// remainder is decoded as YAML/JSON, so they can appear on their own line
+// anywhere in the file. Layers merge by ID, later layer wins: path's own
+// %includes are merged in the order they appear, then path's own patterns
+// are applied on top, then path's own %unset directives prune the result -
+// so a small override file can pull in a base ruleset and both replace and
+// remove entries from it without forking it. visited guards against
+// include cycles across the whole recursion; depth enforces
+// ruleLayerMaxDepth. sources accumulates the file paths actually loaded,
+// innermost first, for LayerSources
+func loadLayer(path string, visited map[string]bool, depth int, sources *[]string) ([]Pattern, error) {
+	if depth > ruleLayerMaxDepth {
+		return nil, fmt.Errorf("pattern layer %s: exceeded max include depth %d", path, ruleLayerMaxDepth)
+	}
 
-	// Check for common rule violations
-	if rc.checkCombatRules(content, input) {
-		violations = append(violations, "Combat action needs proper skill check")
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("resolving pattern layer path %s: %w", path, err)
+	}
+	if visited[abs] {
+		return nil, fmt.Errorf("pattern layer %s: include cycle detected", path)
 	}
+	visited[abs] = true
+	defer delete(visited, abs)
 
-	if rc.checkSkillRules(content, input) {
-		violations = append(violations, "Skill check requires target characteristic")
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading pattern layer %s: %w", path, err)
 	}
 
-	// Log all violations for GM consideration
-	if len(violations) > 0 {
-		log.Printf("[RAG] Rule violations found: %v", violations)
+	var includes, unsets []string
+	var body strings.Builder
+	for _, line := range strings.Split(string(raw), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(trimmed, "%include "):
+			includes = append(includes, strings.TrimSpace(strings.TrimPrefix(trimmed, "%include ")))
+		case strings.HasPrefix(trimmed, "%unset "):
+			unsets = append(unsets, strings.TrimSpace(strings.TrimPrefix(trimmed, "%unset ")))
+		default:
+			body.WriteString(line)
+			body.WriteString("\n")
+		}
 	}
 
-	return violations, nil
+	merged := make(map[string]Pattern)
+	var order []string
+	merge := func(patterns []Pattern) {
+		for _, p := range patterns {
+			if _, exists := merged[p.ID]; !exists {
+				order = append(order, p.ID)
+			}
+			merged[p.ID] = p
+		}
+	}
+
+	for _, inc := range includes {
+		incPath := inc
+		if !filepath.IsAbs(incPath) {
+			incPath = filepath.Join(filepath.Dir(path), incPath)
+		}
+
+		layer, err := loadLayer(incPath, visited, depth+1, sources)
+		if err != nil {
+			return nil, err
+		}
+		merge(layer)
+	}
+
+	var own []Pattern
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal([]byte(body.String()), &own)
+	case ".json":
+		err = json.Unmarshal([]byte(body.String()), &own)
+	default:
+		return nil, fmt.Errorf("unrecognized pattern pack extension %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("decoding pattern layer %s: %w", path, err)
+	}
+	merge(own)
+
+	for _, id := range unsets {
+		delete(merged, id)
+	}
+
+	*sources = append(*sources, path)
+
+	result := make([]Pattern, 0, len(order))
+	for _, id := range order {
+		if p, ok := merged[id]; ok {
+			result = append(result, p)
+		}
+	}
+	return result, nil
 }
 
-// CheckRule looks up a specific rule
-func (rc *RuleChecker) CheckRule(query string) (string, error) {
-	queryLower := strings.ToLower(query)
+// LoadPatterns replaces rc's pattern set with the effective merge of
+// rootPath's pattern file, including any %include/%unset directives (see
+// loadLayer). Every pattern is compiled before the swap, so a bad file, a
+// missing include or an include cycle leaves the previous pattern set in
+// place
+func (rc *RuleChecker) LoadPatterns(rootPath string) error {
+	var sources []string
+	raw, err := loadLayer(rootPath, make(map[string]bool), 0, &sources)
+	if err != nil {
+		return err
+	}
 
-	// Check cache first
-	if cached, exists := rc.ruleCache[queryLower]; exists {
-		return cached, nil
+	compiled := make([]*Pattern, 0, len(raw))
+	for i := range raw {
+		if err := raw[i].compile(); err != nil {
+			return err
+		}
+		compiled = append(compiled, &raw[i])
 	}
 
-	// Try to match against known rule patterns
-	rule := rc.findRulePattern(queryLower)
-	if rule != "" {
-		rc.ruleCache[queryLower] = rule
-		return rule, nil
+	rc.mu.Lock()
+	rc.patterns = compiled
+	rc.ruleCache = make(map[string]string)
+	rc.rootPath = rootPath
+	rc.sources = sources
+	rc.mu.Unlock()
+
+	return nil
+}
+
+// ReloadPatterns re-reads path and replaces rc's pattern set, exactly like
+// LoadPatterns. It's the separate name callers reach for when refreshing
+// an already-loaded pack (e.g. from a /reload_rules admin command),
+// mirroring llm.ProviderManager.ReloadProvider's naming
+func (rc *RuleChecker) ReloadPatterns(path string) error {
+	return rc.LoadPatterns(path)
+}
+
+// Reload re-parses the pattern file last passed to LoadPatterns and
+// atomically swaps in the result, for refreshing an already-loaded layered
+// pack without the caller having to remember its root path
+func (rc *RuleChecker) Reload() error {
+	rc.mu.RLock()
+	rootPath := rc.rootPath
+	rc.mu.RUnlock()
+
+	if rootPath == "" {
+		return fmt.Errorf("no pattern file loaded yet")
 	}
+	return rc.LoadPatterns(rootPath)
+}
 
-	return "", fmt.Errorf("rule not found: %s", query)
+// LayerSources returns the file paths merged into rc's current pattern
+// set by the last LoadPatterns/Reload call, in merge order (innermost
+// %include first, rootPath last), so operators can see the effective
+// override order of a layered rule pack
+func (rc *RuleChecker) LayerSources() []string {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+	return append([]string(nil), rc.sources...)
 }
 
-// SearchRules searches for rules matching a query
-func (rc *RuleChecker) SearchRules(query string) []RuleMatch {
-	results := []RuleMatch{}
+// AddPattern compiles p and adds it to rc's pattern set
+func (rc *RuleChecker) AddPattern(p Pattern) error {
+	if err := p.compile(); err != nil {
+		return err
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.patterns = append(rc.patterns, &p)
+	return nil
+}
+
+// RemovePattern removes the pattern with the given ID, reporting whether
+// one was found
+func (rc *RuleChecker) RemovePattern(id string) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for i, p := range rc.patterns {
+		if p.ID == id {
+			rc.patterns = append(rc.patterns[:i], rc.patterns[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
 
-	// Simple keyword matching - could be enhanced with actual RAG
-	queryLower := strings.ToLower(query)
+// matchAll scans content against every pattern in scope for source (see
+// Direction.matches). A Pattern compiled from a Payload (see
+// ParseRuleByPayload) matches when its RuleExpr tree matches; otherwise a
+// Pattern matches when its regex's occurrence count satisfies
+// MinOccurrences/MaxOccurrences
+func (rc *RuleChecker) matchAll(content, source string) []RuleMatch {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
 
-	// Define known rule patterns
-	patterns := rc.getRulePatterns()
+	var matches []RuleMatch
+	for _, p := range rc.patterns {
+		if !p.Direction.matches(source) {
+			continue
+		}
 
-	for _, pattern := range patterns {
-		if strings.Contains(queryLower, pattern.keyword) {
-			results = append(results, RuleMatch{
-				Rule:     pattern.rule,
-				Confidence: 0.7, // Default confidence
-				Source:    "pattern-match",
+		if p.expr != nil {
+			if !p.expr.Match(InputData{Content: content, Source: source}) {
+				continue
+			}
+			matches = append(matches, RuleMatch{
+				Rule:       p.Rule,
+				Confidence: 0.9,
+				Source:     "composite-match",
+				PatternID:  p.ID,
 			})
+			continue
+		}
+
+		if p.compiled == nil {
+			continue
 		}
+
+		count := len(p.compiled.FindAllStringIndex(content, -1))
+		if !p.withinOccurrences(count) {
+			continue
+		}
+
+		matches = append(matches, RuleMatch{
+			Rule:       p.Rule,
+			Confidence: 0.9,
+			Source:     "pattern-match",
+			PatternID:  p.ID,
+		})
 	}
 
-	return results
+	return matches
 }
 
-// checkCombatRules validates combat-related actions
-func (rc *RuleChecker) checkCombatRules(content string, input InputData) bool {
-	combatKeywords := []string{
-		"атака", "attack", "бью", "hit", "удар",
-		"стреля", "shoot", "защита", "defend", "parry",
+// Check validates an action against WFRP rules: the static Pattern set
+// contributes Violations exactly as before, and any dynamic Rules (see
+// AddRule) that also match input are reconciled into a single Outcome and
+// merged Permissions set. Returns a *RuleConflictError if two or more
+// fired Rules have incompatible Outcomes (see Rule.compatible) - the
+// pattern-match Violations are still returned alongside that error, since
+// they're independent of the dynamic rule set
+func (rc *RuleChecker) Check(input InputData) (RuleDecision, error) {
+	matches := rc.matchAll(input.Content, input.Source)
+
+	violations := make([]string, 0, len(matches))
+	for _, m := range matches {
+		violations = append(violations, m.Rule)
+	}
+	if len(violations) > 0 {
+		log.Printf("[RAG] Rule violations found: %v", violations)
 	}
 
-	for _, keyword := range combatKeywords {
-		if strings.Contains(content, keyword) {
-			// Check if there's a characteristic/skill mentioned
-			hasSkill := strings.Contains(content, "WS") ||
-				strings.Contains(content, "BS") ||
-				strings.Contains(content, "В") ||
-				strings.Contains(content, "С") ||
-				strings.Contains(content, "Лов")
+	decision := RuleDecision{Violations: violations, Outcome: OutcomeAllow}
 
-			return !hasSkill
+	fired, err := rc.fireRules(input.Content)
+
+	permSet := make(map[string]bool)
+	for _, r := range fired {
+		if r.Outcome > decision.Outcome {
+			decision.Outcome = r.Outcome
+		}
+		for _, p := range r.Permissions {
+			permSet[p] = true
 		}
+		decision.FiredRules = append(decision.FiredRules, r.ID)
 	}
+	for p := range permSet {
+		decision.Permissions = append(decision.Permissions, p)
+	}
+	sort.Strings(decision.Permissions)
+	sort.Strings(decision.FiredRules)
 
-	return false
+	if err != nil {
+		return decision, err
+	}
+
+	return decision, nil
 }
 
-// checkSkillRules validates skill check actions
-func (rc *RuleChecker) checkSkillRules(content string, input InputData) bool {
-	skillKeywords := []string{
-		"проверка", "check", "проверить", "check it",
-		"навык", "skill", "способность",
+// fireRules returns every non-expired dynamic Rule whose variant is a
+// substring of content, first lazily sweeping expired rules out of every
+// tree entry (see sweepExpiredLocked). Returns a *RuleConflictError if any
+// two fired rules are incompatible (see Rule.compatible) - the caller
+// still gets the full fired set alongside that error, since the static
+// Pattern violations it's merged with are independent of the conflict
+func (rc *RuleChecker) fireRules(content string) ([]*Rule, error) {
+	lower := strings.ToLower(content)
+
+	rc.mu.Lock()
+	now := time.Now()
+	var fired []*Rule
+	for variant, rules := range rc.ruleTree {
+		kept := sweepExpiredLocked(rules, now)
+		if len(kept) == 0 {
+			delete(rc.ruleTree, variant)
+			continue
+		}
+		rc.ruleTree[variant] = kept
+
+		if strings.Contains(lower, variant) {
+			fired = append(fired, kept...)
+		}
 	}
+	rc.mu.Unlock()
 
-	for _, keyword := range skillKeywords {
-		if strings.Contains(content, keyword) {
-			// Check if a characteristic is mentioned
-			hasChar := strings.ContainsAny(content,
-				"В", "С", "Лов", "Инт", "ВН", "Об",
-				"WS", "BS", "S", "Ag", "Int", "WP", "Fel")
+	for i, a := range fired {
+		for _, b := range fired[i+1:] {
+			if !a.compatible(b) {
+				return fired, &RuleConflictError{RuleIDs: []string{a.ID, b.ID}}
+			}
+		}
+	}
+
+	return fired, nil
+}
 
-			return !hasChar
+// sweepExpiredLocked returns rules with every Rule whose Expiry has
+// passed as of now removed. Callers must hold rc.mu
+func sweepExpiredLocked(rules []*Rule, now time.Time) []*Rule {
+	kept := rules[:0]
+	for _, r := range rules {
+		if !r.expired(now) {
+			kept = append(kept, r)
 		}
 	}
+	return kept
+}
+
+// AddRule adds r to rc's rule tree, keyed by its Pattern's ruleVariant so
+// it coexists with any other live rule already sharing that variant (see
+// Rule.compatible). r.ID must be unique among currently live rules across
+// the whole tree; re-adding an existing ID removes the old rule and adds r
+// at the end of its own variant's entry
+func (rc *RuleChecker) AddRule(r Rule) error {
+	if r.ID == "" {
+		return fmt.Errorf("rule must have a non-empty ID")
+	}
+	if r.Pattern == "" {
+		return fmt.Errorf("rule %q must have a non-empty Pattern", r.ID)
+	}
 
+	variant := ruleVariant(r.Pattern)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for v, rules := range rc.ruleTree {
+		for i, existing := range rules {
+			if existing.ID == r.ID {
+				rc.ruleTree[v] = append(rules[:i], rules[i+1:]...)
+			}
+		}
+	}
+
+	rc.ruleTree[variant] = append(rc.ruleTree[variant], &r)
+	return nil
+}
+
+// RemoveRule removes the dynamic rule with the given ID from whichever
+// tree entry holds it, reporting whether one was found
+func (rc *RuleChecker) RemoveRule(id string) bool {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for variant, rules := range rc.ruleTree {
+		for i, r := range rules {
+			if r.ID == id {
+				rc.ruleTree[variant] = append(rules[:i], rules[i+1:]...)
+				return true
+			}
+		}
+	}
 	return false
 }
 
-// findRulePattern finds a matching rule pattern
-func (rc *RuleChecker) findRulePattern(query string) string {
-	patterns := rc.getRulePatterns()
+// ListRules returns every live (non-expired) dynamic rule across the tree
+// matching filter's non-nil fields
+func (rc *RuleChecker) ListRules(filter RuleListFilter) []Rule {
+	rc.mu.Lock()
+	now := time.Now()
+	var all []*Rule
+	for variant, rules := range rc.ruleTree {
+		kept := sweepExpiredLocked(rules, now)
+		if len(kept) == 0 {
+			delete(rc.ruleTree, variant)
+			continue
+		}
+		rc.ruleTree[variant] = kept
+		all = append(all, kept...)
+	}
+	rc.mu.Unlock()
 
-	for _, pattern := range patterns {
-		if strings.Contains(query, pattern.keyword) {
-			return pattern.rule
+	result := make([]Rule, 0, len(all))
+	for _, r := range all {
+		if filter.Outcome != nil && r.Outcome != *filter.Outcome {
+			continue
 		}
+		if filter.Scope != nil && r.Scope != *filter.Scope {
+			continue
+		}
+		result = append(result, *r)
 	}
+	return result
+}
 
-	return ""
+// StartRuleSweeper launches a background goroutine that evicts expired
+// dynamic rules from rc.ruleTree every interval, until StopRuleSweeper is
+// called. Expired rules are already swept lazily on fireRules/ListRules,
+// so this just bounds how long a rule with no further Check/ListRules
+// calls against it can linger, mirroring DataCache.StartSweeper
+func (rc *RuleChecker) StartRuleSweeper(interval time.Duration) {
+	rc.mu.Lock()
+	rc.sweepStop = make(chan struct{})
+	stop := rc.sweepStop
+	rc.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				rc.mu.Lock()
+				now := time.Now()
+				for variant, rules := range rc.ruleTree {
+					if kept := sweepExpiredLocked(rules, now); len(kept) == 0 {
+						delete(rc.ruleTree, variant)
+					} else {
+						rc.ruleTree[variant] = kept
+					}
+				}
+				rc.mu.Unlock()
+			}
+		}
+	}()
 }
 
-// getRulePatterns returns known rule patterns
-func (rc *RuleChecker) getRulePatterns() []rulePattern {
-	return []rulePattern{
-		// Combat rules
-		{"keyword": "инициатива", "rule": "Initiative is rolled at start of combat using Agility (Ag)"},
-		{"keyword": "атака", "rule": "Combat uses Weapon Skill (WS) against opponent's Parry (Ag)"},
-		{"keyword": "урон", "rule": "Damage is calculated from weapon damage minus enemy Toughness/Armor"},
+// StopRuleSweeper shuts down the background sweeper started by
+// StartRuleSweeper
+func (rc *RuleChecker) StopRuleSweeper() {
+	rc.sweepStopOnce.Do(func() {
+		rc.mu.RLock()
+		stop := rc.sweepStop
+		rc.mu.RUnlock()
+		if stop != nil {
+			close(stop)
+		}
+	})
+}
 
-		// Skill checks
-		{"keyword": "проверка навыка", "rule": "Skill checks use d100 + characteristic value vs difficulty"},
-		{"keyword": "провал проверки", "rule": "Failed check: result is higher than characteristic + skill"},
+// CheckRule looks up a specific rule matching query against rc's pattern
+// set, caching the result
+func (rc *RuleChecker) CheckRule(query string) (string, error) {
+	rc.mu.RLock()
+	cached, ok := rc.ruleCache[query]
+	rc.mu.RUnlock()
+	if ok {
+		return cached, nil
+	}
+
+	matches := rc.matchAll(query, "")
+	if len(matches) == 0 {
+		return "", fmt.Errorf("rule not found: %s", query)
+	}
+
+	rc.mu.Lock()
+	rc.ruleCache[query] = matches[0].Rule
+	rc.mu.Unlock()
 
-		// Character development
-		{"keyword": "опыт", "rule": "Experience (XP) is spent to advance characteristics and skills"},
-		{"keyword": "карьера", "rule": "Career advancement follows the scheme in КАРЬЕРЫ.md"},
+	return matches[0].Rule, nil
+}
 
-		// Conditions
-		{"keyword": "ранение", "rule": "Wounds reduce HP and may cause critical effects"},
-		{"keyword": "шок", "rule": "Critical wounds cause Bleeding, Broken, etc."},
+// SearchRules searches for rules matching a free-form query. When rc has a
+// RuleIndex attached (see SetRAGIndex), it's tried first: a vector recall
+// of the ingested rulebook chunks most similar to query, giving real
+// similarity scores and a file+heading Source instead of the pattern
+// match's fixed confidence. Falls back to the pattern set when no index is
+// attached, or the index returns nothing
+func (rc *RuleChecker) SearchRules(query string) []RuleMatch {
+	rc.mu.RLock()
+	idx := rc.ragIndex
+	rc.mu.RUnlock()
 
-		// Movement
-		{"keyword": "движение", "rule": "Movement rate (M) is derived from Agility (Ag)"},
+	if idx != nil {
+		matches, err := idx.Query(context.Background(), query, 0)
+		if err != nil {
+			log.Printf("[RAG] vector recall failed, falling back to pattern match: %v", err)
+		} else if len(matches) > 0 {
+			return matches
+		}
 	}
+
+	return rc.matchAll(query, "")
 }
 
-// RuleMatch represents a search result from rule lookup
-type RuleMatch struct {
-	Rule      string
-	Confidence float64
-	Source     string
+// SetRAGIndex attaches idx so SearchRules tries vector recall before
+// falling back to pattern matching. Passing nil (the default) disables it
+func (rc *RuleChecker) SetRAGIndex(idx *RuleIndex) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.ragIndex = idx
+}
+
+// GetRulesForContext returns the rule text of every distinct pattern
+// matching context
+func (rc *RuleChecker) GetRulesForContext(context string) []string {
+	matches := rc.matchAll(context, "")
+
+	var relevantRules []string
+	seen := make(map[string]bool)
+	for _, m := range matches {
+		if !seen[m.Rule] {
+			seen[m.Rule] = true
+			relevantRules = append(relevantRules, m.Rule)
+		}
+	}
+
+	return relevantRules
 }
 
 // ValidateRuleCheck validates a skill check format
 func (rc *RuleChecker) ValidateRuleCheck(characteristic, skill string, result int) error {
 	validChars := []string{"В", "С", "Лов", "Инт", "ВН", "Об"}
 
-	// Check if characteristic is valid
 	charValid := false
 	for _, vc := range validChars {
 		if characteristic == vc {
@@ -191,7 +1041,6 @@ func (rc *RuleChecker) ValidateRuleCheck(characteristic, skill string, result in
 		return fmt.Errorf("invalid characteristic: %s", characteristic)
 	}
 
-	// Check result range
 	if result < 0 || result > 200 {
 		return fmt.Errorf("invalid roll result: %d", result)
 	}
@@ -199,29 +1048,7 @@ func (rc *RuleChecker) ValidateRuleCheck(characteristic, skill string, result in
 	return nil
 }
 
-// GetRulesForContext returns relevant rules for a game context
-func (rc *RuleChecker) GetRulesForContext(context string) []string {
-	var relevantRules []string
-
-	// Extract keywords from context
-	words := strings.Fields(context)
-
-	// Find matching rules
-	for _, word := range words {
-		for _, pattern := range rc.getRulePatterns() {
-			if strings.Contains(word, pattern.keyword) {
-				ruleText := fmt.Sprintf("%s: %s", pattern.keyword, pattern.rule)
-				if !containsString(relevantRules, ruleText) {
-					relevantRules = append(relevantRules, ruleText)
-				}
-			}
-		}
-	}
-
-	return relevantRules
-}
-
-// containsString checks if a string exists in a slice
+// containsString reports whether item is present in slice
 func containsString(slice []string, item string) bool {
 	for _, s := range slice {
 		if s == item {
@@ -231,18 +1058,30 @@ func containsString(slice []string, item string) bool {
 	return false
 }
 
-// rulePattern represents a keyword to rule mapping
-type rulePattern struct {
-	keyword string
-	rule    string
-}
+// defaultPatterns is the built-in pattern set NewRuleChecker starts with.
+// The combat/skill-check patterns are scoped to DirectionPlayerToGM, since
+// they're meant to nudge a player's own action rather than re-fire on the
+// GM's narration of the same fight; the glossary-style entries apply in
+// either direction since CheckRule/SearchRules/GetRulesForContext query
+// them with no direction of their own
+func defaultPatterns() []Pattern {
+	return []Pattern{
+		{ID: "combat-action", Rule: "Combat action needs proper skill check", Regex: `атак|attack|бью|\bhit\b|удар|стреля|\bshoot`, Flags: PatternFlags{Caseless: true}, Direction: DirectionPlayerToGM},
+		{ID: "skill-check", Rule: "Skill check requires target characteristic", Regex: `провер(ка|ить)|\bcheck\b|навык|\bskill\b|способность`, Flags: PatternFlags{Caseless: true}, Direction: DirectionPlayerToGM},
 
-// stringsContainsAny checks if any of the substrings are in the main string
-func stringsContainsAny(s string, substrings []string) bool {
-	for _, sub := range substrings {
-		if strings.Contains(s, sub) {
-			return true
-		}
+		{ID: "rule-initiative", Rule: "Initiative is rolled at start of combat using Agility (Ag)", Regex: `инициатива`, Flags: PatternFlags{Caseless: true}},
+		{ID: "rule-attack", Rule: "Combat uses Weapon Skill (WS) against opponent's Parry (Ag)", Regex: `атака`, Flags: PatternFlags{Caseless: true}},
+		{ID: "rule-damage", Rule: "Damage is calculated from weapon damage minus enemy Toughness/Armor", Regex: `урон`, Flags: PatternFlags{Caseless: true}},
+
+		{ID: "rule-skill-test", Rule: "Skill checks use d100 + characteristic value vs difficulty", Regex: `проверка навыка`, Flags: PatternFlags{Caseless: true}},
+		{ID: "rule-failed-test", Rule: "Failed check: result is higher than characteristic + skill", Regex: `провал проверки`, Flags: PatternFlags{Caseless: true}},
+
+		{ID: "rule-experience", Rule: "Experience (XP) is spent to advance characteristics and skills", Regex: `опыт`, Flags: PatternFlags{Caseless: true}},
+		{ID: "rule-career", Rule: "Career advancement follows the scheme in КАРЬЕРЫ.md", Regex: `карьера`, Flags: PatternFlags{Caseless: true}},
+
+		{ID: "rule-wounds", Rule: "Wounds reduce HP and may cause critical effects", Regex: `ранение`, Flags: PatternFlags{Caseless: true}},
+		{ID: "rule-shock", Rule: "Critical wounds cause Bleeding, Broken, etc.", Regex: `шок`, Flags: PatternFlags{Caseless: true}},
+
+		{ID: "rule-movement", Rule: "Movement rate (M) is derived from Agility (Ag)", Regex: `движение`, Flags: PatternFlags{Caseless: true}},
 	}
-	return false
 }
@@ -0,0 +1,32 @@
+package game
+
+import "testing"
+
+func TestParseRuleByPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		wantErr bool
+	}{
+		{name: "simple leaf", payload: "combat_keyword"},
+		{name: "AND of two leaves", payload: "AND,((combat_keyword),(NOT,skill_mentioned))"},
+		{name: "leaf regex with a quantifier comma", payload: `attack\d{2,4}`},
+		{
+			name:    "composite expression with a quantifier comma in a leaf",
+			payload: `AND,((attack\d{2,4}),(NOT,skill_mentioned))`,
+		},
+		{name: "unknown operator", payload: "XOR,((a),(b))", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseRuleByPayload(tt.payload)
+			if tt.wantErr && err == nil {
+				t.Fatalf("ParseRuleByPayload(%q) expected an error, got none", tt.payload)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("ParseRuleByPayload(%q) unexpected error: %v", tt.payload, err)
+			}
+		})
+	}
+}
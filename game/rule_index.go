@@ -0,0 +1,419 @@
+package game
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sashabaranov/go-openai"
+
+	"wfrp-bot/rag"
+)
+
+// RAGClient embeds and, optionally, reranks text for RuleIndex. Concrete
+// implementations (OpenAIRAGClient, OllamaRAGClient, LocalRAGClient) mirror
+// rag.Embedder's pluggable-provider shape, but add Rerank since rulebook
+// lookup benefits from a second, query-aware pass over the vector recall's
+// candidates
+type RAGClient interface {
+	Embed(ctx context.Context, text string) ([]float32, error)
+	Rerank(ctx context.Context, query string, docs []string) ([]float64, error)
+}
+
+// embedRerank is a Rerank implementation shared by clients with no
+// dedicated cross-encoder reranker endpoint: it embeds query and every doc
+// and scores each by cosine similarity against the query embedding. A
+// client backed by a real reranker model/endpoint should implement Rerank
+// directly instead of calling this
+func embedRerank(ctx context.Context, embed func(context.Context, string) ([]float32, error), query string, docs []string) ([]float64, error) {
+	queryVec, err := embed(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("embedding query for rerank: %w", err)
+	}
+
+	scores := make([]float64, len(docs))
+	for i, doc := range docs {
+		docVec, err := embed(ctx, doc)
+		if err != nil {
+			return nil, fmt.Errorf("embedding doc %d for rerank: %w", i, err)
+		}
+		scores[i] = cosineSimilarity(queryVec, docVec)
+	}
+	return scores, nil
+}
+
+// OpenAIRAGClient embeds text via an OpenAI-compatible embeddings endpoint
+type OpenAIRAGClient struct {
+	client *openai.Client
+	model  string
+}
+
+// NewOpenAIRAGClient creates a client against apiBase (empty uses OpenAI's
+// default endpoint, so any OpenAI-compatible provider can be pointed at
+// via apiBase) using model (empty defaults to text-embedding-3-small)
+func NewOpenAIRAGClient(apiKey, apiBase, model string) (*OpenAIRAGClient, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("API key is required for OpenAI RAG client")
+	}
+	if model == "" {
+		model = "text-embedding-3-small"
+	}
+
+	cfg := openai.DefaultConfig(apiKey)
+	if apiBase != "" {
+		cfg.BaseURL = apiBase
+	}
+
+	return &OpenAIRAGClient{client: openai.NewClientWithConfig(cfg), model: model}, nil
+}
+
+// Embed sends text to the configured embeddings endpoint
+func (c *OpenAIRAGClient) Embed(ctx context.Context, text string) ([]float32, error) {
+	resp, err := c.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: []string{text},
+		Model: openai.EmbeddingModel(c.model),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI embedding request failed: %w", err)
+	}
+	if len(resp.Data) == 0 {
+		return nil, fmt.Errorf("OpenAI embedding request returned no data")
+	}
+
+	return resp.Data[0].Embedding, nil
+}
+
+// Rerank has no dedicated endpoint on OpenAI's classic API, so it falls
+// back to embedRerank
+func (c *OpenAIRAGClient) Rerank(ctx context.Context, query string, docs []string) ([]float64, error) {
+	return embedRerank(ctx, c.Embed, query, docs)
+}
+
+// OllamaRAGClient embeds text using a locally running Ollama embedding
+// model, mirroring rag.OllamaEmbedder's raw HTTP /api/embeddings call
+type OllamaRAGClient struct {
+	baseURL string
+	model   string
+	http    *http.Client
+}
+
+// NewOllamaRAGClient creates a client against baseURL (empty defaults to
+// http://localhost:11434) using model (empty defaults to "nomic-embed-text")
+func NewOllamaRAGClient(baseURL, model string) *OllamaRAGClient {
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+	if model == "" {
+		model = "nomic-embed-text"
+	}
+
+	return &OllamaRAGClient{baseURL: baseURL, model: model, http: &http.Client{}}
+}
+
+// Embed calls Ollama's /api/embeddings endpoint
+func (c *OllamaRAGClient) Embed(ctx context.Context, text string) ([]float32, error) {
+	body, err := json.Marshal(struct {
+		Model  string `json:"model"`
+		Prompt string `json:"prompt"`
+	}{Model: c.model, Prompt: text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Ollama embedding request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/api/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build Ollama embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Ollama embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Ollama embedding request returned status %d", resp.StatusCode)
+	}
+
+	var result struct {
+		Embedding []float32 `json:"embedding"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse Ollama embedding response: %w", err)
+	}
+
+	return result.Embedding, nil
+}
+
+// Rerank has no dedicated endpoint in Ollama's embeddings API, so it falls
+// back to embedRerank
+func (c *OllamaRAGClient) Rerank(ctx context.Context, query string, docs []string) ([]float64, error) {
+	return embedRerank(ctx, c.Embed, query, docs)
+}
+
+// localEmbeddingDims is the vector size LocalRAGClient's hashing-trick
+// embedding produces
+const localEmbeddingDims = 256
+
+// LocalRAGClient is a dependency-free fallback: it hashes each word of the
+// input into one of localEmbeddingDims buckets and L2-normalizes the
+// resulting bag-of-words vector. It's a real, working embedder - just not
+// a semantic one - for when no embedding provider is configured. A genuine
+// local ONNX/gguf model would need a runtime (onnxruntime/llama.cpp
+// bindings) this environment has no way to vendor or build against, so
+// it isn't wired in here
+type LocalRAGClient struct{}
+
+// NewLocalRAGClient creates the dependency-free fallback client
+func NewLocalRAGClient() *LocalRAGClient {
+	return &LocalRAGClient{}
+}
+
+// Embed hashes text into a fixed-size bag-of-words vector
+func (c *LocalRAGClient) Embed(_ context.Context, text string) ([]float32, error) {
+	vec := make([]float32, localEmbeddingDims)
+	for _, word := range strings.Fields(strings.ToLower(text)) {
+		h := fnv.New32a()
+		h.Write([]byte(word))
+		vec[h.Sum32()%localEmbeddingDims]++
+	}
+
+	var norm float64
+	for _, v := range vec {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return vec, nil
+	}
+	norm = math.Sqrt(norm)
+	for i := range vec {
+		vec[i] = float32(float64(vec[i]) / norm)
+	}
+
+	return vec, nil
+}
+
+// Rerank scores docs by cosine similarity of their hashed vectors against
+// the hashed query vector
+func (c *LocalRAGClient) Rerank(ctx context.Context, query string, docs []string) ([]float64, error) {
+	return embedRerank(ctx, c.Embed, query, docs)
+}
+
+// ruleIndexEntry is one ingested rulebook chunk, persisted alongside its
+// embedding so RuleIndex.Ingest can skip re-embedding unchanged chunks
+type ruleIndexEntry struct {
+	Source    string    `json:"source"`
+	Heading   string    `json:"heading"`
+	Text      string    `json:"text"`
+	Embedding []float32 `json:"embedding"`
+}
+
+// ruleIndexDefaultTopK is Query's default result count when k<=0
+const ruleIndexDefaultTopK = 5
+
+// RuleIndex is an in-process, flat-cosine vector index over rulebook
+// chunks. A true HNSW index (optional per the request this implements) is
+// skipped: at rulebook-corpus scale a flat scan over every entry is fast
+// enough, and it avoids carrying an approximate-search dependency for no
+// measurable benefit. Entries are persisted to Path as JSON keyed by a
+// hash of their text, so re-running Ingest after editing a rulebook file
+// only re-embeds the chunks that actually changed
+type RuleIndex struct {
+	mu      sync.RWMutex
+	client  RAGClient
+	path    string
+	entries map[string]ruleIndexEntry
+	topK    int
+}
+
+// NewRuleIndex creates a RuleIndex backed by client, persisting ingested
+// entries to persistPath (loaded immediately if it already exists; pass ""
+// to keep the index in-memory only). topK<=0 defaults to
+// ruleIndexDefaultTopK
+func NewRuleIndex(client RAGClient, persistPath string, topK int) *RuleIndex {
+	if topK <= 0 {
+		topK = ruleIndexDefaultTopK
+	}
+
+	idx := &RuleIndex{client: client, path: persistPath, entries: make(map[string]ruleIndexEntry), topK: topK}
+	idx.load()
+	return idx
+}
+
+// load reads idx's persisted entries from idx.path, if any. A missing,
+// unreadable or unparseable file just leaves idx empty rather than failing
+// construction
+func (idx *RuleIndex) load() {
+	if idx.path == "" {
+		return
+	}
+
+	data, err := os.ReadFile(idx.path)
+	if err != nil {
+		return
+	}
+
+	var entries map[string]ruleIndexEntry
+	if err := json.Unmarshal(data, &entries); err == nil {
+		idx.entries = entries
+	}
+}
+
+// persistLocked writes idx.entries to idx.path. Callers must hold idx.mu
+func (idx *RuleIndex) persistLocked() error {
+	if idx.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(idx.entries)
+	if err != nil {
+		return fmt.Errorf("encoding rule index: %w", err)
+	}
+	if err := os.WriteFile(idx.path, data, 0o644); err != nil {
+		return fmt.Errorf("writing rule index to %s: %w", idx.path, err)
+	}
+	return nil
+}
+
+// Ingest chunks the markdown file at path by heading (see rag.ChunkMarkdown)
+// and embeds any chunk not already present, keyed by a hash of its text, so
+// re-ingesting after a small edit only re-embeds the changed chunks
+func (idx *RuleIndex) Ingest(ctx context.Context, path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading rulebook file %s: %w", path, err)
+	}
+
+	chunks := rag.ChunkMarkdown(path, string(data))
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	changed := false
+	for _, chunk := range chunks {
+		hash := contentHash(chunk.Text)
+		if _, ok := idx.entries[hash]; ok {
+			continue
+		}
+
+		embedding, err := idx.client.Embed(ctx, chunk.Text)
+		if err != nil {
+			return fmt.Errorf("embedding chunk from %s (%s): %w", path, chunk.Heading, err)
+		}
+
+		idx.entries[hash] = ruleIndexEntry{Source: chunk.Source, Heading: chunk.Heading, Text: chunk.Text, Embedding: embedding}
+		changed = true
+	}
+
+	if !changed {
+		return nil
+	}
+	return idx.persistLocked()
+}
+
+// Query embeds text and returns the k most similar ingested chunks (k<=0
+// uses idx.topK) as RuleMatch, with Confidence set to their cosine
+// similarity and Source set to "path#heading"
+func (idx *RuleIndex) Query(ctx context.Context, text string, k int) ([]RuleMatch, error) {
+	queryVec, err := idx.client.Embed(ctx, text)
+	if err != nil {
+		return nil, fmt.Errorf("embedding query: %w", err)
+	}
+
+	if k <= 0 {
+		k = idx.topK
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	type scored struct {
+		entry ruleIndexEntry
+		score float64
+	}
+	candidates := make([]scored, 0, len(idx.entries))
+	for _, entry := range idx.entries {
+		candidates = append(candidates, scored{entry: entry, score: cosineSimilarity(queryVec, entry.Embedding)})
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	if len(candidates) > k {
+		candidates = candidates[:k]
+	}
+
+	matches := make([]RuleMatch, len(candidates))
+	for i, c := range candidates {
+		matches[i] = RuleMatch{
+			Rule:       c.entry.Text,
+			Confidence: c.score,
+			Source:     fmt.Sprintf("%s#%s", c.entry.Source, c.entry.Heading),
+		}
+	}
+	return matches, nil
+}
+
+// Rerank asks idx's client to re-score candidates against query, replacing
+// their Confidence and re-sorting by it descending
+func (idx *RuleIndex) Rerank(ctx context.Context, query string, candidates []RuleMatch) ([]RuleMatch, error) {
+	if len(candidates) == 0 {
+		return candidates, nil
+	}
+
+	docs := make([]string, len(candidates))
+	for i, c := range candidates {
+		docs[i] = c.Rule
+	}
+
+	scores, err := idx.client.Rerank(ctx, query, docs)
+	if err != nil {
+		return nil, fmt.Errorf("reranking: %w", err)
+	}
+
+	reranked := make([]RuleMatch, len(candidates))
+	copy(reranked, candidates)
+	for i := range reranked {
+		if i < len(scores) {
+			reranked[i].Confidence = scores[i]
+		}
+	}
+
+	sort.Slice(reranked, func(i, j int) bool { return reranked[i].Confidence > reranked[j].Confidence })
+	return reranked, nil
+}
+
+// contentHash is the key ruleIndexEntry is stored/looked-up under
+func contentHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// cosineSimilarity returns the cosine similarity of a and b, or 0 for
+// empty or mismatched-length vectors
+func cosineSimilarity(a, b []float32) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		normA += float64(a[i]) * float64(a[i])
+		normB += float64(b[i]) * float64(b[i])
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -0,0 +1,92 @@
+package game
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"wfrp-bot/rag"
+)
+
+// rulebookCollection is the rag.Indexer collection rulebook chunks are
+// embedded and searched under. It plays the same role as a campaign name
+// in rag.Indexer's per-campaign collections, but the rulebook is shared
+// across every campaign rather than indexed per-campaign
+const rulebookCollection = "wfrp-rulebook"
+
+// RuleSnippet is one retrieved chunk of the WFRP 4e rulebook, ready to be
+// spliced into a system prompt's "--- ПРАВИЛА ---" section
+type RuleSnippet struct {
+	Heading string
+	Text    string
+}
+
+// RulesRetriever finds the rulebook chunks most relevant to query, so
+// BuildSystemPrompt can splice in the specific pages needed for the
+// action at hand instead of dumping every rule added via PromptBuilder.AddRule
+// on every turn
+type RulesRetriever interface {
+	Retrieve(ctx context.Context, query string, k int) ([]RuleSnippet, error)
+}
+
+// RulebookRetriever is a RulesRetriever backed by a rag.Indexer: it embeds
+// query with the same Embedder the indexer was built with and returns the
+// topK chunks by cosine similarity, the same retrieval path Session
+// already uses for campaign lore (see buildLoreMessage)
+type RulebookRetriever struct {
+	indexer *rag.Indexer
+}
+
+// NewRulebookRetriever creates a RulebookRetriever over indexer. The
+// rulebook must already be indexed into rulebookCollection via IndexRulebook
+func NewRulebookRetriever(indexer *rag.Indexer) *RulebookRetriever {
+	return &RulebookRetriever{indexer: indexer}
+}
+
+// IndexRulebook chunks and embeds a rulebook source file into
+// rulebookCollection, so subsequent Retrieve calls can find it
+func (r *RulebookRetriever) IndexRulebook(ctx context.Context, path, content string) error {
+	return r.indexer.IndexFile(ctx, rulebookCollection, path, content)
+}
+
+// Retrieve returns the topK rulebook chunks most similar to query
+func (r *RulebookRetriever) Retrieve(ctx context.Context, query string, k int) ([]RuleSnippet, error) {
+	results, err := r.indexer.Search(ctx, rulebookCollection, query, k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search rulebook index: %w", err)
+	}
+
+	snippets := make([]RuleSnippet, 0, len(results))
+	for _, result := range results {
+		text := result.Payload["text"]
+		if text == "" {
+			continue
+		}
+		snippets = append(snippets, RuleSnippet{Heading: result.Payload["heading"], Text: text})
+	}
+	return snippets, nil
+}
+
+// actionCategoryPatterns classify player input by the kind of WFRP 4e
+// rules section it's most likely to need, in priority order - combat
+// phrasing wins over a skill check mentioned in passing, and so on down
+// to social and finally a lore catch-all
+var actionCategoryPatterns = []struct {
+	category string
+	pattern  *regexp.Regexp
+}{
+	{"combat", regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}_])(атак|удар|бь[юё]|бой|оружи|урон|парир|уклон|attack|strike|parry|dodge|wound|damage)\w*`)},
+	{"skill", regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}_])(провер|навык|характеристик|тест|skill check|characteristic test|skill|check)\w*`)},
+	{"social", regexp.MustCompile(`(?i)(?:^|[^\p{L}\p{N}_])(перегово|убежд|запуг|торгу|обман|charm|intimidat|haggle|persuade|bluff)\w*`)},
+}
+
+// classifyAction returns the WFRP rules category content's phrasing best
+// matches ("combat", "skill", "social"), or "lore" when none of them do
+func classifyAction(content string) string {
+	for _, candidate := range actionCategoryPatterns {
+		if candidate.pattern.MatchString(content) {
+			return candidate.category
+		}
+	}
+	return "lore"
+}
@@ -5,11 +5,16 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math/rand"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"wfrp-bot/agents"
 	"wfrp-bot/llm"
+	"wfrp-bot/rag"
+	"wfrp-bot/storage"
 )
 
 // Session represents an active game session
@@ -21,14 +26,38 @@ type Session struct {
 	State        SessionState
 	StartTime    time.Time
 	LastActivity time.Time
-
-	mu            sync.RWMutex
-	llmProvider   llm.LLMProvider
-	promptBuilder *PromptBuilder
-	ctx           context.Context
-	cancel        context.CancelFunc
+	Notes        []string // GM notes added via the add_session_note tool
+
+	mu             sync.RWMutex
+	llmProvider    llm.LLMProvider
+	promptBuilder  *PromptBuilder
+	gmAgent        *agents.Agent
+	ruleChecker    *RuleChecker
+	campaignMgr    *storage.CampaignManager
+	ragIndexer     *rag.Indexer     // optional; when set, player turns are augmented with retrieved lore
+	rulesRetriever RulesRetriever   // optional; when set, player turns are augmented with retrieved rulebook snippets, see retrieveRuleSnippets
+	conditions     *ConditionEngine // tracks Bleeding/Ablaze/Unconscious/etc. stacks between rounds
+	branchHead     string           // tip message ID of the conversation branch currently in use
+	ctx            context.Context
+	cancel         context.CancelFunc
+
+	turnOrder  []TurnEntry               // initiative order, highest first, see RollInitiative
+	turnIndex  int                       // index into turnOrder of whose turn it currently is
+	turnInputs map[string]chan InputData // per-player buffered queue an out-of-turn SubmitTurn parks into
+	turnOutput func(*GameOutput)         // optional; notified when a parked input is drained off-turn, see SetTurnOutputSink
+
+	transcript []TranscriptEntry  // rolling window of recent input/output pairs, see appendTranscriptLocked
+	persist    func(SessionRecord) // optional; notified with a fresh snapshot after every ProcessInput, see SetPersistSink
+
+	subMu       sync.Mutex
+	subscribers map[int]chan GameOutput // fan-out targets registered via Subscribe, keyed by an incrementing id so Unsubscribe can remove just one
+	nextSubID   int
 }
 
+// subscriberBufferSize bounds how many undelivered GameOutputs a Subscribe
+// channel holds before publish starts dropping rather than blocking
+const subscriberBufferSize = 16
+
 // SessionState represents of current state of game session
 type SessionState int
 
@@ -62,21 +91,37 @@ type GameOutput struct {
 	Content   string
 	IsAction  bool
 	Timestamp time.Time
+	Partial   bool // true for an in-progress StreamResponse chunk, published to Subscribe but never persisted (see publishStreamChunks)
 }
 
+// TurnEntry is one player's position in a session's rolled initiative
+// order (see RollInitiative)
+type TurnEntry struct {
+	PlayerID   string
+	Initiative int // d10 + the character's Initiative (I) characteristic
+}
+
+// turnQueueCapacity bounds how many out-of-turn inputs SubmitTurn will
+// park for a single player before it starts rejecting new ones
+const turnQueueCapacity = 8
+
 // PromptBuilder constructs LLM prompts
 type PromptBuilder struct {
-	campaign   string
-	scenario   string
-	characters []*Character
-	rules      []string
+	campaign             string
+	scenario             string
+	characters           []*Character
+	rules                []string
+	systemPromptOverride string // set via /config set system_prompt; replaces the default GM persona intro when non-empty
 }
 
 // NewSession creates a new game session
-func NewSession(ctx context.Context, groupID int64, campaign string, provider llm.LLMProvider) *Session {
+//
+// campaignMgr may be nil, in which case the session runs without persisted
+// conversation history (each turn is sent to the LLM on its own)
+func NewSession(ctx context.Context, groupID int64, campaign string, provider llm.LLMProvider, campaignMgr *storage.CampaignManager) *Session {
 	sessionCtx, cancel := context.WithCancel(ctx)
 
-	return &Session{
+	session := &Session{
 		ID:           fmt.Sprintf("%s_%d", campaign, groupID),
 		GroupID:      groupID,
 		Campaign:     campaign,
@@ -88,9 +133,23 @@ func NewSession(ctx context.Context, groupID int64, campaign string, provider ll
 		promptBuilder: &PromptBuilder{
 			campaign: campaign,
 		},
-		ctx:    sessionCtx,
-		cancel: cancel,
+		campaignMgr: campaignMgr,
+		conditions:  NewConditionEngine(),
+		ctx:         sessionCtx,
+		cancel:      cancel,
 	}
+
+	session.ruleChecker = NewRuleChecker()
+	session.gmAgent = BuildAgent("", session, session.ruleChecker)
+
+	if campaignMgr != nil {
+		if _, err := campaignMgr.NewConversation(campaign, session.ID); err != nil {
+			log.Printf("[SESSION] Failed to initialize conversation log for %s: %v", session.ID, err)
+		}
+		session.ragIndexer = campaignMgr.Indexer()
+	}
+
+	return session
 }
 
 // Start begins the game session
@@ -144,6 +203,30 @@ func (s *Session) GetCharacter(playerID string) (*Character, bool) {
 	return char, exists
 }
 
+// ExpireCondition removes one stack of condition from playerID's live
+// condition state and updates their sheet's rendered condition section to
+// match, without resolving any other end-of-round effects (see
+// ApplyEndOfRound for that). Used by a scheduled condition_expiry job to
+// end a status effect after its duration has passed
+func (s *Session) ExpireCondition(playerID string, condition Condition) (characterName string, ok bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	char, exists := s.Characters[playerID]
+	if !exists {
+		return "", false
+	}
+
+	s.conditions.RemoveCondition(playerID, condition)
+
+	cs := ParseCharacterSheet(char.Sheet)
+	removeConditionFromSheet(cs, string(condition))
+	char.Sheet = cs.Render()
+	char.LastUpdate = time.Now()
+
+	return char.Name, true
+}
+
 // UpdateActivity updates last activity timestamp
 func (s *Session) UpdateActivity() {
 	s.mu.Lock()
@@ -152,53 +235,404 @@ func (s *Session) UpdateActivity() {
 	s.LastActivity = time.Now()
 }
 
+// SetRAGIndexer attaches a RAG indexer used to retrieve relevant campaign
+// lore for each player turn. Passing nil (the default) disables retrieval
+func (s *Session) SetRAGIndexer(indexer *rag.Indexer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ragIndexer = indexer
+}
+
+// SetRulesRetriever attaches a RulesRetriever used to pull the specific
+// rulebook snippets a player's turn needs into the system prompt (see
+// retrieveRuleSnippets). Passing nil (the default) falls back to the static
+// PromptBuilder.AddRule list dumped by BuildSystemPrompt
+func (s *Session) SetRulesRetriever(retriever RulesRetriever) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rulesRetriever = retriever
+}
+
+// SetSystemPromptOverride replaces the session's default GM persona intro
+// with text, or restores the default when text is empty, for /config set
+// system_prompt (see PromptBuilder.SetSystemPromptOverride)
+func (s *Session) SetSystemPromptOverride(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.promptBuilder.SetSystemPromptOverride(text)
+}
+
+// SetAgent swaps the session's active agent to the one named by agentName
+// (see BuildAgent), for /start <campaign> --agent <name>. An empty or
+// unrecognized name falls back to the default GM agent, so a typo never
+// leaves the session without one
+func (s *Session) SetAgent(agentName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.gmAgent = BuildAgent(agentName, s, s.ruleChecker)
+}
+
+// buildRequestMessages assembles the system prompt, retrieved lore,
+// conversation history and the current player turn into the message list
+// sent to the LLM provider. If a campaign manager is attached, the player
+// turn is persisted first so it is included in the loaded conversation chain
+func (s *Session) buildRequestMessages(input InputData) []llm.Message {
+	characterSheets := s.GetAllCharacterSheets()
+	retrievedRules := s.retrieveRuleSnippets(input)
+
+	messages := []llm.Message{
+		{Role: "system", Content: s.promptBuilder.BuildSystemPrompt(characterSheets, retrievedRules)},
+	}
+
+	if loreMsg, ok := s.buildLoreMessage(input, characterSheets); ok {
+		messages = append(messages, loreMsg)
+	}
+
+	if s.campaignMgr == nil {
+		messages = append(messages, llm.Message{Role: "user", Content: s.promptBuilder.BuildInputContent(input)})
+		return messages
+	}
+
+	msg, err := s.campaignMgr.AppendMessage(s.Campaign, s.ID, s.branchHead, "user", s.promptBuilder.BuildInputContent(input), "")
+	if err != nil {
+		log.Printf("[SESSION] Failed to persist user message: %v", err)
+		messages = append(messages, llm.Message{Role: "user", Content: s.promptBuilder.BuildInputContent(input)})
+		return messages
+	}
+	s.branchHead = msg.ID
+
+	conv, err := s.campaignMgr.LoadConversation(s.Campaign, s.ID)
+	if err != nil {
+		log.Printf("[SESSION] Failed to load conversation for %s: %v", s.ID, err)
+		messages = append(messages, llm.Message{Role: msg.Role, Content: msg.Content})
+		return messages
+	}
+
+	for _, turn := range conv.Chain(s.branchHead) {
+		messages = append(messages, llm.Message{Role: turn.Role, Content: turn.Content})
+	}
+
+	return messages
+}
+
+// buildLoreMessage runs a similarity search over the campaign's indexed
+// notes for the current player input and returns a "--- RELEVANT LORE ---"
+// system message carrying the results, or false if retrieval is disabled,
+// failed, or found nothing worth adding. Chunks whose text already appears
+// verbatim in a character card are skipped to avoid sending duplicate
+// context
+func (s *Session) buildLoreMessage(input InputData, characterSheets []string) (llm.Message, bool) {
+	if s.ragIndexer == nil {
+		return llm.Message{}, false
+	}
+
+	results, err := s.ragIndexer.Search(s.ctx, s.Campaign, input.Content, 5)
+	if err != nil {
+		log.Printf("[SESSION] RAG search failed: %v", err)
+		return llm.Message{}, false
+	}
+
+	var lore strings.Builder
+	lore.WriteString("--- RELEVANT LORE ---\n")
+	found := false
+
+	for _, result := range results {
+		text := result.Payload["text"]
+		if text == "" || containsAny(characterSheets, text) {
+			continue
+		}
+
+		found = true
+		if heading := result.Payload["heading"]; heading != "" {
+			lore.WriteString(fmt.Sprintf("%s:\n", heading))
+		}
+		lore.WriteString(text)
+		lore.WriteString("\n\n")
+	}
+	lore.WriteString("--- END RELEVANT LORE ---")
+
+	if !found {
+		return llm.Message{}, false
+	}
+
+	return llm.Message{Role: "system", Content: lore.String()}, true
+}
+
+// retrieveRuleSnippets classifies input's action (combat/skill/social/lore,
+// see classifyAction) and retrieves the topK rulebook snippets most
+// relevant to it, for BuildSystemPrompt to splice into the "--- ПРАВИЛА
+// ---" section in place of the static PromptBuilder.AddRule list. Returns
+// nil if no RulesRetriever is attached, retrieval failed, or nothing came
+// back, in which case BuildSystemPrompt falls back to the static list
+func (s *Session) retrieveRuleSnippets(input InputData) []RuleSnippet {
+	if s.rulesRetriever == nil {
+		return nil
+	}
+
+	category := classifyAction(input.Content)
+	snippets, err := s.rulesRetriever.Retrieve(s.ctx, fmt.Sprintf("%s: %s", category, input.Content), 3)
+	if err != nil {
+		log.Printf("[SESSION] Rulebook retrieval failed: %v", err)
+		return nil
+	}
+
+	return snippets
+}
+
+// containsAny reports whether text already appears in any of cards
+func containsAny(cards []string, text string) bool {
+	for _, card := range cards {
+		if strings.Contains(card, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// ForkFrom rewinds the session onto an earlier message in its conversation:
+// subsequent turns branch off messageID instead of the current head,
+// leaving the original branch on disk untouched
+func (s *Session) ForkFrom(messageID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.campaignMgr == nil {
+		return fmt.Errorf("session %s has no persisted conversation to fork", s.ID)
+	}
+
+	if _, err := s.campaignMgr.ForkFrom(s.Campaign, s.ID, messageID); err != nil {
+		return err
+	}
+
+	s.branchHead = messageID
+	return nil
+}
+
+// ListBranches returns the tip message ID of every branch in the session's
+// persisted conversation
+func (s *Session) ListBranches() ([]string, error) {
+	if s.campaignMgr == nil {
+		return nil, fmt.Errorf("session %s has no persisted conversation", s.ID)
+	}
+
+	return s.campaignMgr.ListBranches(s.Campaign, s.ID)
+}
+
+// BranchPreview summarizes one tip of the conversation tree for /branches
+type BranchPreview struct {
+	TipID   string
+	Active  bool
+	Preview string // the tip message's content, truncated
+}
+
+// Branches lists every branch tip in the session's persisted conversation
+// alongside a short preview of its last message and whether it's the
+// branch the session is currently on
+func (s *Session) Branches() ([]BranchPreview, error) {
+	if s.campaignMgr == nil {
+		return nil, fmt.Errorf("session %s has no persisted conversation", s.ID)
+	}
+
+	s.mu.RLock()
+	activeTip := s.branchHead
+	s.mu.RUnlock()
+
+	conv, err := s.campaignMgr.LoadConversation(s.Campaign, s.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	previews := make([]BranchPreview, 0, len(conv.Branches()))
+	for _, tip := range conv.Branches() {
+		msg, _ := conv.Find(tip)
+		previews = append(previews, BranchPreview{
+			TipID:   tip,
+			Active:  tip == activeTip,
+			Preview: truncateText(msg.Content, 80),
+		})
+	}
+	return previews, nil
+}
+
+// truncateText shortens s to at most maxLen runes, adding an ellipsis if cut
+func truncateText(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen]) + "..."
+}
+
+// History returns the last n messages of the session's active branch,
+// root-first truncated to the tail (pass n <= 0 for the full chain)
+func (s *Session) History(n int) ([]storage.ConversationMessage, error) {
+	if s.campaignMgr == nil {
+		return nil, fmt.Errorf("session %s has no persisted conversation", s.ID)
+	}
+
+	s.mu.RLock()
+	activeTip := s.branchHead
+	s.mu.RUnlock()
+
+	conv, err := s.campaignMgr.LoadConversation(s.Campaign, s.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	chain := conv.Chain(activeTip)
+	if n > 0 && len(chain) > n {
+		chain = chain[len(chain)-n:]
+	}
+	return chain, nil
+}
+
+// Rewind moves the session's active branch pointer back n turns along its
+// current chain, without discarding anything on disk - a subsequent turn
+// appends a new sibling branch at that point
+func (s *Session) Rewind(n int) error {
+	if s.campaignMgr == nil {
+		return fmt.Errorf("session %s has no persisted conversation", s.ID)
+	}
+	if n <= 0 {
+		return fmt.Errorf("rewind count must be positive, got %d", n)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	conv, err := s.campaignMgr.LoadConversation(s.Campaign, s.ID)
+	if err != nil {
+		return err
+	}
+
+	chain := conv.Chain(s.branchHead)
+	if n >= len(chain) {
+		s.branchHead = ""
+		return nil
+	}
+
+	s.branchHead = chain[len(chain)-1-n].ID
+	return nil
+}
+
+// EditMessage rewrites msgID by appending a new sibling message onto its
+// parent with newText, then re-generates the GM's response from there -
+// the original message and everything after it stays on disk as an
+// untouched, still-reachable branch
+func (s *Session) EditMessage(msgID, newText string) (*GameOutput, error) {
+	if s.campaignMgr == nil {
+		return nil, fmt.Errorf("session %s has no persisted conversation", s.ID)
+	}
+
+	conv, err := s.campaignMgr.LoadConversation(s.Campaign, s.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	msg, ok := conv.Find(msgID)
+	if !ok {
+		return nil, fmt.Errorf("message not found: %s", msgID)
+	}
+
+	if err := s.ForkFrom(msg.ParentID); err != nil {
+		return nil, err
+	}
+
+	return s.ProcessInput(InputData{
+		Source:    "player",
+		Content:   newText,
+		Timestamp: time.Now(),
+	})
+}
+
 // ProcessInput processes player input and generates GM response
 func (s *Session) ProcessInput(input InputData) (*GameOutput, error) {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 
 	s.UpdateActivity()
 	s.State = StateProcessing
 
-	// Build prompt with context
-	prompt := s.promptBuilder.BuildGamePrompt(input, s.GetAllCharacterSheets())
-	// Check rules if needed
-	response, err := s.llmProvider.GenerateRequest(s.ctx, prompt, s.GetAllCharacterSheets())
+	if s.campaignMgr != nil {
+		if err := s.campaignMgr.CheckBudget(s.Campaign); err != nil {
+			s.State = StateActive
+			s.mu.Unlock()
+			return nil, err
+		}
+	}
+
+	messages := s.buildRequestMessages(input)
+
+	result, err := s.llmProvider.GenerateRequest(s.ctx, s.gmAgent, messages)
 	if err != nil {
 		s.State = StateActive
+		s.mu.Unlock()
 		return nil, fmt.Errorf("failed to generate response: %w", err)
 	}
+	response := result.Content
 
 	s.State = StateActive
 
-	// Parse character updates from response
-	_, charUpdate, err := ParseCharacterUpdateFromResponse(response)
-	if err != nil {
-		log.Printf("[SESSION] Failed to parse character update: %v", err)
-		// Continue without applying updates if parsing fails
+	if s.campaignMgr != nil {
+		if msg, err := s.campaignMgr.AppendMessage(s.Campaign, s.ID, s.branchHead, "assistant", response, result.Usage.Model); err != nil {
+			log.Printf("[SESSION] Failed to persist assistant message: %v", err)
+		} else {
+			s.branchHead = msg.ID
+		}
+
+		if err := s.campaignMgr.RecordUsage(s.Campaign, result.Usage); err != nil {
+			log.Printf("[SESSION] Failed to record usage: %v", err)
+		}
 	}
 
-	// Apply character updates if any
-	if charUpdate != nil {
-		for _, char := range s.Characters {
-			updatedSheet, warnings := ApplyCharacterUpdate(char.Sheet, *charUpdate)
-			for _, warning := range warnings {
-				log.Printf("[SESSION] Character update warning: %v", warning)
-			}
+	// Extract and apply per-character updates from the GM's narration
+	characters := make([]*Character, 0, len(s.Characters))
+	for _, char := range s.Characters {
+		characters = append(characters, char)
+	}
+	charUpdates := ExtractCharacterUpdates(s.ctx, s.llmProvider, response, characters)
+	for playerID, update := range charUpdates {
+		char, ok := s.Characters[playerID]
+		if !ok {
+			continue
+		}
+
+		updatedSheet, warnings := ApplyCharacterUpdate(char.Sheet, update)
+		for _, warning := range warnings {
+			log.Printf("[SESSION] Character update warning for %s: %v", char.Name, warning)
+		}
+
+		char.Sheet = updatedSheet
+		char.LastUpdate = time.Now()
+		log.Printf("[SESSION] Updated character %s after response", char.Name)
 
-			// Update in-memory character
-			char.Sheet = updatedSheet
-			char.LastUpdate = time.Now()
-			log.Printf("[SESSION] Updated character %s after response", char.Name)
+		for _, cond := range update.Conditions {
+			s.conditions.AddCondition(playerID, Condition(cond), 1)
 		}
 	}
 
-	return &GameOutput{
+	output := &GameOutput{
 		Source:    "gm",
 		Content:   response,
 		IsAction:  false,
 		Timestamp: time.Now(),
-	}, nil
+	}
+
+	s.appendTranscriptLocked(input, *output)
+	record := s.snapshotLocked()
+	persist := s.persist
+	s.mu.Unlock()
+
+	s.publish(*output)
+
+	if persist != nil {
+		persist(record)
+	}
+
+	return output, nil
 }
 
 // StreamResponse processes input and streams GM response
@@ -208,9 +642,18 @@ func (s *Session) StreamResponse(input InputData) (<-chan string, error) {
 	s.State = StateProcessing
 	s.mu.Unlock()
 
-	prompt := s.promptBuilder.BuildGamePrompt(input, s.GetAllCharacterSheets())
+	if s.campaignMgr != nil {
+		if err := s.campaignMgr.CheckBudget(s.Campaign); err != nil {
+			s.mu.Lock()
+			s.State = StateActive
+			s.mu.Unlock()
+			return nil, err
+		}
+	}
+
+	messages := s.buildRequestMessages(input)
 
-	stream, err := s.llmProvider.StreamRequest(s.ctx, prompt, nil)
+	upstream, err := s.llmProvider.StreamRequest(s.ctx, s.gmAgent, messages)
 	if err != nil {
 		s.mu.Lock()
 		s.State = StateActive
@@ -218,10 +661,58 @@ func (s *Session) StreamResponse(input InputData) (<-chan string, error) {
 		return nil, fmt.Errorf("failed to stream response: %w", err)
 	}
 
-	return stream, nil
+	return s.publishStreamChunks(s.recordStreamUsage(upstream)), nil
+}
+
+// publishStreamChunks wraps upstream so every chunk is also fanned out to
+// Subscribe's subscribers as a Partial GameOutput before being forwarded
+// to the caller - run after recordStreamUsage so usage-accounting chunks
+// never reach subscribers as narration
+func (s *Session) publishStreamChunks(upstream <-chan string) <-chan string {
+	ch := make(chan string)
+
+	go func() {
+		defer close(ch)
+
+		for chunk := range upstream {
+			s.publish(GameOutput{Source: "gm", Content: chunk, Partial: true, Timestamp: time.Now()})
+			ch <- chunk
+		}
+	}()
+
+	return ch
 }
 
-// checkInputsLoop periodically checks for new inputs
+// recordStreamUsage wraps upstream so the final usage-accounting chunk
+// every StreamRequest implementation sends (see llm.FormatUsageChunk) is
+// recorded against the session's campaign rather than forwarded to the
+// caller
+func (s *Session) recordStreamUsage(upstream <-chan string) <-chan string {
+	if s.campaignMgr == nil {
+		return upstream
+	}
+
+	ch := make(chan string)
+
+	go func() {
+		defer close(ch)
+
+		for chunk := range upstream {
+			if u, ok := llm.ParseUsageChunk(chunk); ok {
+				if err := s.campaignMgr.RecordUsage(s.Campaign, u); err != nil {
+					log.Printf("[SESSION] Failed to record usage: %v", err)
+				}
+				continue
+			}
+			ch <- chunk
+		}
+	}()
+
+	return ch
+}
+
+// checkInputsLoop periodically drains whichever player's turn queue holds
+// the current turn-holder's next parked input (see SubmitTurn)
 func (s *Session) checkInputsLoop() {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
@@ -231,36 +722,225 @@ func (s *Session) checkInputsLoop() {
 		case <-s.ctx.Done():
 			return
 		case <-ticker.C:
-			// Check for new player inputs every second
-			if hasInput, err := s.CheckInputs(); err != nil {
+			if err := s.ProcessNewInputs(); err != nil {
 				log.Printf("[SESSION] Input check error: %v", err)
-			} else if hasInput {
-				// Process new inputs if found
-				s.ProcessNewInputs()
 			}
 			s.checkTimeout()
 		}
 	}
 }
 
-// CheckInputs checks for new player inputs
-func (s *Session) CheckInputs() (bool, error) {
-	// This is a placeholder implementation
-	// In a real implementation, this would check:
-	// - Incoming Telegram messages
-	// - Character card updates
-	// - Game state changes
-	// Return true if new inputs are found
-	return false, nil
+// RollInitiative rolls a fresh initiative order for every character
+// currently in the session - d10 + their Initiative (I) characteristic,
+// highest first, per WFRP 4e combat setup - and resets CurrentTurn to the
+// top of it. A character with no parseable Initiative rolls as if it were
+// 0. Returns the rolled order
+func (s *Session) RollInitiative() []TurnEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := make([]TurnEntry, 0, len(s.Characters))
+	for playerID, char := range s.Characters {
+		cs := ParseCharacterSheet(char.Sheet)
+		initiative, _ := cs.Characteristic("I")
+		entries = append(entries, TurnEntry{PlayerID: playerID, Initiative: initiative + rand.Intn(10) + 1})
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Initiative > entries[j].Initiative })
+
+	s.turnOrder = entries
+	s.turnIndex = 0
+	if s.turnInputs == nil {
+		s.turnInputs = make(map[string]chan InputData)
+	}
+	for _, e := range entries {
+		if _, ok := s.turnInputs[e.PlayerID]; !ok {
+			s.turnInputs[e.PlayerID] = make(chan InputData, turnQueueCapacity)
+		}
+	}
+
+	return append([]TurnEntry(nil), entries...)
+}
+
+// CurrentTurn returns the character whose turn it currently is, or nil if
+// no initiative order has been rolled yet (see RollInitiative)
+func (s *Session) CurrentTurn() *Character {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.currentTurnCharacterLocked()
+}
+
+// currentTurnCharacterLocked is CurrentTurn's body; callers must hold s.mu
+func (s *Session) currentTurnCharacterLocked() *Character {
+	if len(s.turnOrder) == 0 {
+		return nil
+	}
+	return s.Characters[s.turnOrder[s.turnIndex].PlayerID]
+}
+
+// isCurrentTurnLocked reports whether playerID may act right now; callers
+// must hold s.mu. With no initiative order rolled, every player is always
+// "on turn" - RollInitiative is opt-in for scenes that need arbitration
+func (s *Session) isCurrentTurnLocked(playerID string) bool {
+	return len(s.turnOrder) == 0 || s.turnOrder[s.turnIndex].PlayerID == playerID
 }
 
-// ProcessNewInputs processes newly detected inputs
-func (s *Session) ProcessNewInputs() {
-	// Process queued inputs
-	// This would trigger ProcessInput for each detected input
+// AdvanceTurn moves to the next player in the initiative order, wrapping
+// back to the top once everyone has acted. A no-op if no order was rolled
+func (s *Session) AdvanceTurn() {
 	s.mu.Lock()
-	s.UpdateActivity()
+	defer s.mu.Unlock()
+
+	if len(s.turnOrder) == 0 {
+		return
+	}
+	s.turnIndex = (s.turnIndex + 1) % len(s.turnOrder)
+}
+
+// SetTurnOutputSink registers fn to receive the GameOutput produced when
+// checkInputsLoop later drains a parked out-of-turn input (see
+// SubmitTurn) - the caller that originally submitted it is long gone by
+// then, so it has no return value to deliver the GM's response through.
+// Passing nil (the default) makes such output reachable only through
+// Subscribe, since ProcessInput publishes it there either way
+func (s *Session) SetTurnOutputSink(fn func(*GameOutput)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.turnOutput = fn
+}
+
+// Subscribe registers a new fan-out target for every GameOutput s produces
+// - both the final output of ProcessInput and each partial chunk of a
+// StreamResponse (see publishStreamChunks) - so a spectator UI, transcript
+// logger or analytics sink can watch the session without competing with
+// the Telegram bot (or each other) for a single stream. Call the returned
+// func to unsubscribe and release the channel
+func (s *Session) Subscribe() (<-chan GameOutput, func()) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	if s.subscribers == nil {
+		s.subscribers = make(map[int]chan GameOutput)
+	}
+
+	id := s.nextSubID
+	s.nextSubID++
+	ch := make(chan GameOutput, subscriberBufferSize)
+	s.subscribers[id] = ch
+
+	return ch, func() {
+		s.subMu.Lock()
+		defer s.subMu.Unlock()
+		if ch, ok := s.subscribers[id]; ok {
+			delete(s.subscribers, id)
+			close(ch)
+		}
+	}
+}
+
+// publish fans output out to every current subscriber, dropping it for
+// any subscriber whose buffer is full rather than blocking the caller -
+// the same slow-consumer policy DataCache.publish uses for invalidations
+func (s *Session) publish(output GameOutput) {
+	s.subMu.Lock()
+	defer s.subMu.Unlock()
+
+	for _, ch := range s.subscribers {
+		select {
+		case ch <- output:
+		default:
+			log.Printf("[SESSION] Dropped output for a slow subscriber on session %s", s.ID)
+		}
+	}
+}
+
+// SetPersistSink registers fn to receive a fresh SessionRecord snapshot
+// after every completed ProcessInput, so a caller (SessionManager) can
+// write it through to a SessionStore without having to also persist from
+// every call site that happens to trigger a turn - including the ones
+// driven off-turn by checkInputsLoop. Passing nil (the default) disables it
+func (s *Session) SetPersistSink(fn func(SessionRecord)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.persist = fn
+}
+
+// SubmitTurn is the turn-arbitrated entry point for player input:
+// processes it immediately through ProcessInput when no initiative order
+// is active, it's playerID's turn, or input.Metadata["free_action"] is
+// true (a reaction/free action never has to wait its turn). Otherwise the
+// input is parked on playerID's buffered queue for checkInputsLoop to
+// drain once their turn comes around (delivered via SetTurnOutputSink),
+// and the caller immediately gets an "out of turn" GameOutput instead of
+// racing every player's messages straight into ProcessInput
+func (s *Session) SubmitTurn(playerID string, input InputData) (*GameOutput, error) {
+	freeAction, _ := input.Metadata["free_action"].(bool)
+
+	s.mu.Lock()
+	if freeAction || s.isCurrentTurnLocked(playerID) {
+		s.mu.Unlock()
+		return s.ProcessInput(input)
+	}
+
+	ch, ok := s.turnInputs[playerID]
+	if !ok {
+		ch = make(chan InputData, turnQueueCapacity)
+		s.turnInputs[playerID] = ch
+	}
+	currentName := "другого игрока"
+	if current := s.currentTurnCharacterLocked(); current != nil {
+		currentName = current.Name
+	}
 	s.mu.Unlock()
+
+	select {
+	case ch <- input:
+	default:
+		return &GameOutput{
+			Source:    "system",
+			Content:   "Очередь вашего хода переполнена, дождитесь своего хода.",
+			Timestamp: time.Now(),
+		}, nil
+	}
+
+	return &GameOutput{
+		Source:    "system",
+		Content:   fmt.Sprintf("Сейчас не ваш ход (ходит %s). Действие отложено до вашего хода.", currentName),
+		Timestamp: time.Now(),
+	}, nil
+}
+
+// ProcessNewInputs drains and processes one input parked for the current
+// turn-holder, if any, delivering the result via the turnOutput sink set
+// through SetTurnOutputSink. A no-op when no initiative order is active
+// or the current turn-holder has nothing queued
+func (s *Session) ProcessNewInputs() error {
+	s.mu.Lock()
+	playerID, hasTurn := "", len(s.turnOrder) > 0
+	if hasTurn {
+		playerID = s.turnOrder[s.turnIndex].PlayerID
+	}
+	ch := s.turnInputs[playerID]
+	sink := s.turnOutput
+	s.mu.Unlock()
+
+	if !hasTurn || ch == nil {
+		return nil
+	}
+
+	select {
+	case input := <-ch:
+		output, err := s.ProcessInput(input)
+		if err != nil {
+			return err
+		}
+		if sink != nil && output != nil {
+			sink(output)
+		}
+	default:
+	}
+
+	return nil
 }
 
 // checkTimeout checks if session has timed out
@@ -307,6 +987,56 @@ func (s *Session) GetAllCharacters() []*Character {
 	return chars
 }
 
+// ApplyEndOfRound resolves one round of condition effects (Bleeding
+// damage, Ablaze escalation, Unconscious death checks, ...) for a single
+// character, mutating their HP through applyHPChange and returning
+// narrative log lines describing what happened
+func (s *Session) ApplyEndOfRound(playerID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	char, ok := s.Characters[playerID]
+	if !ok {
+		return nil
+	}
+
+	cs := ParseCharacterSheet(char.Sheet)
+	currentHP, _ := cs.CurrentHP()
+
+	effect := s.conditions.resolveEndOfRound(playerID, currentHP)
+	if effect.hpChange != 0 {
+		applyHPChange(cs, effect.hpChange)
+	}
+
+	char.Sheet = cs.Render()
+	char.LastUpdate = time.Now()
+
+	for _, line := range effect.logLines {
+		log.Printf("[CONDITIONS] %s: %s", char.Name, line)
+	}
+
+	return effect.logLines
+}
+
+// AdvanceRound resolves end-of-round condition effects for every character
+// in the session, returning the narrative log lines per playerID
+func (s *Session) AdvanceRound() map[string][]string {
+	s.mu.RLock()
+	playerIDs := make([]string, 0, len(s.Characters))
+	for playerID := range s.Characters {
+		playerIDs = append(playerIDs, playerID)
+	}
+	s.mu.RUnlock()
+
+	results := make(map[string][]string)
+	for _, playerID := range playerIDs {
+		if lines := s.ApplyEndOfRound(playerID); len(lines) > 0 {
+			results[playerID] = lines
+		}
+	}
+	return results
+}
+
 // GetLLMProvider returns the LLM provider for the session
 func (s *Session) GetLLMProvider() llm.LLMProvider {
 	s.mu.RLock()
@@ -314,15 +1044,43 @@ func (s *Session) GetLLMProvider() llm.LLMProvider {
 	return s.llmProvider
 }
 
-// BuildGamePrompt constructs an LLM prompt from input and character sheets
-func (pb *PromptBuilder) BuildGamePrompt(input InputData, characterSheets []string) string {
+// SetLLMProvider swaps the session's active LLM provider, for /config set
+// hot-swapping a running session onto a different provider/model/params
+// without losing its conversation history. The previous provider is not
+// closed here - callers that built it solely for this swap are responsible
+// for that
+func (s *Session) SetLLMProvider(provider llm.LLMProvider) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.llmProvider = provider
+}
+
+// BuildSystemPrompt constructs the system-level instructions sent once per
+// request: game system context, campaign/scenario, character sheets and
+// rules. Per-turn player input is sent separately as a "user" message (see
+// BuildInputContent) rather than concatenated into this text.
+//
+// retrievedRules, when non-empty, is spliced in as the rules section
+// instead of the static PromptBuilder.AddRule list - they're scoped to
+// the specific action the current turn is about (see
+// Session.retrieveRuleSnippets), where the static list is the same on
+// every turn regardless of what's happening
+func (pb *PromptBuilder) BuildSystemPrompt(characterSheets []string, retrievedRules []RuleSnippet) string {
 	var prompt strings.Builder
 
-	// Add system context
-	prompt.WriteString("--- СИСТЕМА: WARHAMMER FANTASY ROLEPLAY 4E ---\n\n")
-	prompt.WriteString("Ты - Game Master (Гейм Мастер) для игры в WFRP 4e. ")
-	prompt.WriteString("Твоя задача - вести интересную и атмосферную игру, ")
-	prompt.WriteString("строго соблюдая правила WFRP 4th Edition.\n\n")
+	// Add system context - an operator-supplied override (see
+	// SetSystemPromptOverride) replaces the default GM persona intro
+	// entirely, so /config set system_prompt can reshape tone/style
+	// without losing the campaign/scenario/characters/rules sections below
+	if pb.systemPromptOverride != "" {
+		prompt.WriteString(pb.systemPromptOverride)
+		prompt.WriteString("\n\n")
+	} else {
+		prompt.WriteString("--- СИСТЕМА: WARHAMMER FANTASY ROLEPLAY 4E ---\n\n")
+		prompt.WriteString("Ты - Game Master (Гейм Мастер) для игры в WFRP 4e. ")
+		prompt.WriteString("Твоя задача - вести интересную и атмосферную игру, ")
+		prompt.WriteString("строго соблюдая правила WFRP 4th Edition.\n\n")
+	}
 
 	// Add campaign context
 	if pb.campaign != "" {
@@ -346,8 +1104,19 @@ func (pb *PromptBuilder) BuildGamePrompt(input InputData, characterSheets []stri
 		prompt.WriteString("\n--- КОНЕЦ ПЕРСОНАЖЕЙ ---\n\n")
 	}
 
-	// Add rules reference
-	if len(pb.rules) > 0 {
+	// Add rules reference - retrieved rulebook snippets take precedence
+	// over the static list (see retrievedRules' doc comment above)
+	if len(retrievedRules) > 0 {
+		prompt.WriteString("--- ПРАВИЛА (RETRIEVED) ---\n")
+		for _, snippet := range retrievedRules {
+			if snippet.Heading != "" {
+				prompt.WriteString(fmt.Sprintf("%s:\n", snippet.Heading))
+			}
+			prompt.WriteString(snippet.Text)
+			prompt.WriteString("\n\n")
+		}
+		prompt.WriteString("--- КОНЕЦ ПРАВИЛ ---\n\n")
+	} else if len(pb.rules) > 0 {
 		prompt.WriteString("--- ПРАВИЛА ---\n")
 		prompt.WriteString("Важно строго следовать правилам WFRP 4e. ")
 		prompt.WriteString("Для проверки механик используй:\n")
@@ -357,41 +1126,47 @@ func (pb *PromptBuilder) BuildGamePrompt(input InputData, characterSheets []stri
 		prompt.WriteString("--- КОНЕЦ ПРАВИЛ ---\n\n")
 	}
 
-	// Add input section
-	prompt.WriteString("--- ВВОД ИГРОКА ---\n")
-	prompt.WriteString(fmt.Sprintf("Источник: %s\n", input.Source))
-	prompt.WriteString(fmt.Sprintf("Содержание: %s\n", input.Content))
-	prompt.WriteString(fmt.Sprintf("Время: %s\n", input.Timestamp.Format("15:04:05")))
-
-	// Add metadata if present
-	if len(input.Metadata) > 0 {
-		prompt.WriteString("Метаданные:\n")
-		for key, value := range input.Metadata {
-			prompt.WriteString(fmt.Sprintf("  • %s: %v\n", key, value))
-		}
-	}
-
-	prompt.WriteString("--- КОНЕЦ ВВОДА ---\n\n")
-
 	// Add response instruction
 	prompt.WriteString("--- ИНСТРУКЦИЯ ---\n")
 	prompt.WriteString("Отвечай как Game Master. Веди игру атмосферно и интересно. ")
 	prompt.WriteString("При описании действий требуй проверок по правилам WFRP 4e. ")
 	prompt.WriteString("Если игрок пытается выполнить действие, требуй соответствующей проверки (Бой, Навык, Характеристика). ")
 	prompt.WriteString("Соблюдай все правила WFRP 4e, включая модификаторы, сложность и последствия провала/успеха.\n")
-	prompt.WriteString("--- КОНЕЦ ИНСТРУКЦИИ ---\n\n")
-
-	// Add separator for response
-	prompt.WriteString("GM RESPONSE:")
+	prompt.WriteString("--- КОНЕЦ ИНСТРУКЦИИ ---")
 
 	return prompt.String()
 }
 
+// BuildInputContent formats a turn of player input as the content of the
+// "user" message sent alongside the system prompt
+func (pb *PromptBuilder) BuildInputContent(input InputData) string {
+	var content strings.Builder
+
+	content.WriteString(fmt.Sprintf("Источник: %s\n", input.Source))
+	content.WriteString(fmt.Sprintf("Содержание: %s\n", input.Content))
+	content.WriteString(fmt.Sprintf("Время: %s\n", input.Timestamp.Format("15:04:05")))
+
+	if len(input.Metadata) > 0 {
+		content.WriteString("Метаданные:\n")
+		for key, value := range input.Metadata {
+			content.WriteString(fmt.Sprintf("  • %s: %v\n", key, value))
+		}
+	}
+
+	return content.String()
+}
+
 // SetScenario sets current scenario for prompt builder
 func (pb *PromptBuilder) SetScenario(scenario string) {
 	pb.scenario = scenario
 }
 
+// SetSystemPromptOverride replaces the default GM persona intro with text,
+// or restores the default when text is empty (see BuildSystemPrompt)
+func (pb *PromptBuilder) SetSystemPromptOverride(text string) {
+	pb.systemPromptOverride = text
+}
+
 // AddRule adds a rule reference to the prompt builder
 func (pb *PromptBuilder) AddRule(rule string) {
 	pb.rules = append(pb.rules, rule)
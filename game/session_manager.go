@@ -1,33 +1,218 @@
 package game
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"os"
+	"path/filepath"
 	"sync"
+	"time"
+
+	"wfrp-bot/chat"
+	"wfrp-bot/llm"
+	"wfrp-bot/storage"
 )
 
 // SessionManager manages multiple game sessions
 type SessionManager struct {
-	sessions map[int64]*Session
-	mu       sync.RWMutex
+	sessions       map[int64]*Session
+	mu             sync.RWMutex
+	store          SessionStore
+	basePath       string                     // root directory sessions/campaigns are persisted under, see transcriptLogPath
+	transports     map[int64][]chat.Transport // additional transports GM narration is broadcast to, see AttachTransport
+	ruleIndex      *RuleIndex                 // optional; attached to every session's RuleChecker, see SetRuleIndex
+	rulesRetriever RulesRetriever             // optional; attached to every session, see SetRulesRetriever
+	unsub          map[int64]func()           // Session.Subscribe unsubscribe funcs for subscribeTranscriptLog, keyed by chat ID
 }
 
-// NewSessionManager creates a new session manager
-func NewSessionManager() *SessionManager {
-	return &SessionManager{
-		sessions: make(map[int64]*Session),
+// NewSessionManager creates a new session manager backed by a
+// SessionStore persisted under basePath/sessions, so AddSession and
+// ProcessPlayerMessage survive a bot restart. If the store directory
+// can't be created, it falls back to an in-memory-only store and logs the
+// reason rather than failing startup
+func NewSessionManager(basePath string) *SessionManager {
+	store, err := newFileSessionStore(filepath.Join(basePath, "sessions"))
+	if err != nil {
+		log.Printf("[SESSION MANAGER] Persistent session store unavailable, sessions won't survive a restart: %v", err)
+		return &SessionManager{sessions: make(map[int64]*Session), store: newMemorySessionStore(), basePath: basePath, transports: make(map[int64][]chat.Transport), unsub: make(map[int64]func())}
 	}
+
+	return &SessionManager{sessions: make(map[int64]*Session), store: store, basePath: basePath, transports: make(map[int64][]chat.Transport), unsub: make(map[int64]func())}
 }
 
-// AddSession adds a session to the manager
-func (sm *SessionManager) AddSession(chatID int64, session *Session) {
+// AttachTransport additionally routes chatID's GM narration to t, so a
+// table that started on Telegram can also be followed from an XMPP MUC
+// room (or any future protocol) without replacing the session's original
+// transport. Not called anywhere by default - a single-protocol chat's
+// existing caller (e.g. telegram.Bot sending ProcessPlayerMessage's
+// return value itself) keeps working unchanged with zero transports
+// attached. Once a chat has transports attached, its caller should stop
+// also sending the return value itself, or players on that transport will
+// see every message twice
+func (sm *SessionManager) AttachTransport(chatID int64, t chat.Transport) {
 	sm.mu.Lock()
 	defer sm.mu.Unlock()
+	sm.transports[chatID] = append(sm.transports[chatID], t)
+	log.Printf("[SESSION MANAGER] Attached %s transport to chat %d", t.Name(), chatID)
+}
+
+// DetachTransport stops routing chatID's GM narration to t
+func (sm *SessionManager) DetachTransport(chatID int64, t chat.Transport) {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	remaining := sm.transports[chatID][:0]
+	for _, existing := range sm.transports[chatID] {
+		if existing != t {
+			remaining = append(remaining, existing)
+		}
+	}
+	sm.transports[chatID] = remaining
+}
+
+// broadcast delivers text to every transport attached to chatID via
+// AttachTransport. A no-op for chats with none attached
+func (sm *SessionManager) broadcast(chatID int64, text string) {
+	sm.mu.RLock()
+	transports := append([]chat.Transport{}, sm.transports[chatID]...)
+	sm.mu.RUnlock()
 
+	for _, t := range transports {
+		if err := t.SendMessage(chatID, text); err != nil {
+			log.Printf("[SESSION MANAGER] Failed to broadcast to %s transport for chat %d: %v", t.Name(), chatID, err)
+		}
+	}
+}
+
+// SetRuleIndex attaches idx to sm so every session it manages - present and
+// future, via AddSession and Rehydrate - gets vector recall on top of its
+// RuleChecker's pattern matching. Passing nil (the default) disables it
+func (sm *SessionManager) SetRuleIndex(idx *RuleIndex) {
+	sm.mu.Lock()
+	sm.ruleIndex = idx
+	sessions := make([]*Session, 0, len(sm.sessions))
+	for _, session := range sm.sessions {
+		sessions = append(sessions, session)
+	}
+	sm.mu.Unlock()
+
+	for _, session := range sessions {
+		session.ruleChecker.SetRAGIndex(idx)
+	}
+}
+
+// SetRulesRetriever attaches retriever to sm so every session it manages -
+// present and future, via AddSession and Rehydrate - splices retrieved
+// rulebook snippets into its system prompt instead of the static
+// PromptBuilder.AddRule list (see Session.SetRulesRetriever). Passing nil
+// (the default) disables it
+func (sm *SessionManager) SetRulesRetriever(retriever RulesRetriever) {
+	sm.mu.Lock()
+	sm.rulesRetriever = retriever
+	sessions := make([]*Session, 0, len(sm.sessions))
+	for _, session := range sm.sessions {
+		sessions = append(sessions, session)
+	}
+	sm.mu.Unlock()
+
+	for _, session := range sessions {
+		session.SetRulesRetriever(retriever)
+	}
+}
+
+// AddSession adds a session to the manager and persists its initial snapshot
+func (sm *SessionManager) AddSession(chatID int64, session *Session) {
+	sm.mu.Lock()
 	sm.sessions[chatID] = session
+	ruleIndex := sm.ruleIndex
+	rulesRetriever := sm.rulesRetriever
+	sm.mu.Unlock()
+
+	if ruleIndex != nil {
+		session.ruleChecker.SetRAGIndex(ruleIndex)
+	}
+	if rulesRetriever != nil {
+		session.SetRulesRetriever(rulesRetriever)
+	}
+
+	// A turn parked by SubmitTurn is drained later, off the caller that
+	// submitted it - broadcast is the only delivery path left for it, so
+	// at minimum any transport attached via AttachTransport still gets it
+	session.SetTurnOutputSink(func(output *GameOutput) {
+		sm.broadcast(chatID, output.Content)
+	})
+
+	// checkInputsLoop drives ProcessInput for parked turns off the caller
+	// that originally submitted them, so ProcessPlayerMessage's own
+	// sm.store.Save call below never runs for those - this sink is what
+	// persists them
+	session.SetPersistSink(func(record SessionRecord) {
+		if err := sm.store.Save(chatID, record); err != nil {
+			log.Printf("[SESSION MANAGER] Failed to persist session for chat %d: %v", chatID, err)
+		}
+	})
+
+	sm.subscribeTranscriptLog(chatID, session)
+
+	if err := sm.store.Save(chatID, session.Snapshot()); err != nil {
+		log.Printf("[SESSION MANAGER] Failed to persist session for chat %d: %v", chatID, err)
+	}
 	log.Printf("[SESSION MANAGER] Added session for chat %d", chatID)
 }
 
+// subscribeTranscriptLog registers a Subscribe consumer for session that
+// appends every non-Partial GameOutput to chatID's on-disk transcript log
+// (see transcriptLogPath) - a full, append-only narration history
+// independent of SessionStore's JSON snapshots, which only ever hold the
+// current state. The unsubscribe func is kept so RemoveSession can
+// release it
+func (sm *SessionManager) subscribeTranscriptLog(chatID int64, session *Session) {
+	ch, unsubscribe := session.Subscribe()
+
+	sm.mu.Lock()
+	sm.unsub[chatID] = unsubscribe
+	sm.mu.Unlock()
+
+	go func() {
+		for output := range ch {
+			if output.Partial {
+				continue
+			}
+			if err := sm.appendTranscriptLog(chatID, output); err != nil {
+				log.Printf("[SESSION MANAGER] Failed to append transcript log for chat %d: %v", chatID, err)
+			}
+		}
+	}()
+}
+
+// transcriptLogPath returns the path to chatID's append-only transcript
+// log: a flat "<chatID>_transcript.log" under basePath/sessions
+func (sm *SessionManager) transcriptLogPath(chatID int64) string {
+	return filepath.Join(sm.basePath, "sessions", fmt.Sprintf("%d_transcript.log", chatID))
+}
+
+// appendTranscriptLog appends one line describing output to chatID's
+// transcript log, creating it if necessary
+func (sm *SessionManager) appendTranscriptLog(chatID int64, output GameOutput) error {
+	path := sm.transcriptLogPath(chatID)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create transcript log directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open transcript log: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, "[%s] %s: %s\n", output.Timestamp.Format(time.RFC3339), output.Source, output.Content); err != nil {
+		return fmt.Errorf("failed to write transcript log: %w", err)
+	}
+	return nil
+}
+
 // GetSession retrieves a session by chat ID
 func (sm *SessionManager) GetSession(chatID int64) (*Session, bool) {
 	sm.mu.RLock()
@@ -37,12 +222,21 @@ func (sm *SessionManager) GetSession(chatID int64) (*Session, bool) {
 	return session, exists
 }
 
-// RemoveSession removes a session from the manager
+// RemoveSession removes a session from the manager and its persisted record
 func (sm *SessionManager) RemoveSession(chatID int64) {
 	sm.mu.Lock()
-	defer sm.mu.Unlock()
-
 	delete(sm.sessions, chatID)
+	unsubscribe, hadSub := sm.unsub[chatID]
+	delete(sm.unsub, chatID)
+	sm.mu.Unlock()
+
+	if hadSub {
+		unsubscribe()
+	}
+
+	if err := sm.store.Delete(chatID); err != nil {
+		log.Printf("[SESSION MANAGER] Failed to delete persisted session for chat %d: %v", chatID, err)
+	}
 	log.Printf("[SESSION MANAGER] Removed session for chat %d", chatID)
 }
 
@@ -58,7 +252,107 @@ func (sm *SessionManager) GetAllSessions() []*Session {
 	return sessions
 }
 
-// ProcessPlayerMessage processes a player message through the appropriate session
+// Rehydrate reconstructs every session found in the SessionManager's store
+// using provider and campaignMgr as the live resources NewSession needs,
+// so a bot restart doesn't lose character sheets, chat history or GM
+// state. Returns the chat IDs restored
+func (sm *SessionManager) Rehydrate(ctx context.Context, provider llm.LLMProvider, campaignMgr *storage.CampaignManager) ([]int64, error) {
+	records, err := sm.store.LoadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load persisted sessions: %w", err)
+	}
+
+	sm.mu.RLock()
+	ruleIndex := sm.ruleIndex
+	rulesRetriever := sm.rulesRetriever
+	sm.mu.RUnlock()
+
+	restored := make([]int64, 0, len(records))
+	for chatID, record := range records {
+		session := NewSession(ctx, record.GroupID, record.Campaign, provider, campaignMgr)
+		session.ApplyRecord(record)
+		if ruleIndex != nil {
+			session.ruleChecker.SetRAGIndex(ruleIndex)
+		}
+		if rulesRetriever != nil {
+			session.SetRulesRetriever(rulesRetriever)
+		}
+		session.SetTurnOutputSink(func(output *GameOutput) {
+			sm.broadcast(chatID, output.Content)
+		})
+		session.SetPersistSink(func(record SessionRecord) {
+			if err := sm.store.Save(chatID, record); err != nil {
+				log.Printf("[SESSION MANAGER] Failed to persist session for chat %d: %v", chatID, err)
+			}
+		})
+
+		sm.mu.Lock()
+		sm.sessions[chatID] = session
+		sm.mu.Unlock()
+
+		sm.subscribeTranscriptLog(chatID, session)
+
+		restored = append(restored, chatID)
+	}
+
+	if len(restored) > 0 {
+		log.Printf("[SESSION MANAGER] Rehydrated %d session(s) from persistent store", len(restored))
+	}
+	return restored, nil
+}
+
+// Restore is an alias for Rehydrate, matching the naming this feature was
+// originally requested under (see ReloadPatterns/Reload in rag.go for the
+// same alias precedent)
+func (sm *SessionManager) Restore(ctx context.Context, provider llm.LLMProvider, campaignMgr *storage.CampaignManager) ([]int64, error) {
+	return sm.Rehydrate(ctx, provider, campaignMgr)
+}
+
+// PruneIdle removes (and un-persists) every session whose LastActivity is
+// older than maxIdle, so abandoned sessions don't accumulate forever.
+// Returns the chat IDs that were pruned
+func (sm *SessionManager) PruneIdle(maxIdle time.Duration) []int64 {
+	cutoff := time.Now().Add(-maxIdle)
+
+	sm.mu.Lock()
+	var idle []int64
+	for chatID, session := range sm.sessions {
+		session.mu.RLock()
+		lastActivity := session.LastActivity
+		session.mu.RUnlock()
+
+		if lastActivity.Before(cutoff) {
+			idle = append(idle, chatID)
+			delete(sm.sessions, chatID)
+		}
+	}
+	sm.mu.Unlock()
+
+	for _, chatID := range idle {
+		if err := sm.store.Delete(chatID); err != nil {
+			log.Printf("[SESSION MANAGER] Failed to delete idle session %d: %v", chatID, err)
+		}
+		log.Printf("[SESSION MANAGER] Pruned idle session for chat %d", chatID)
+	}
+
+	return idle
+}
+
+// AdvanceRound resolves end-of-round condition effects (Bleeding damage,
+// Ablaze escalation, Unconscious death checks, ...) for every character in
+// the session running in chatID
+func (sm *SessionManager) AdvanceRound(chatID int64) (map[string][]string, error) {
+	session, exists := sm.GetSession(chatID)
+	if !exists {
+		return nil, fmt.Errorf("no active session for chat %d", chatID)
+	}
+
+	return session.AdvanceRound(), nil
+}
+
+// ProcessPlayerMessage processes a player message through the appropriate
+// session and persists the resulting session state (updated LastActivity,
+// new character sheets, GM notes, ...)
 func (sm *SessionManager) ProcessPlayerMessage(chatID int64, playerID string, text string) (*GameOutput, error) {
 	session, exists := sm.GetSession(chatID)
 	if !exists {
@@ -79,12 +373,21 @@ func (sm *SessionManager) ProcessPlayerMessage(chatID int64, playerID string, te
 		},
 	}
 
-	// Process input and get GM response
-	output, err := session.ProcessInput(input)
+	// Process input, arbitrated by the session's initiative order if one
+	// is active (see Session.SubmitTurn)
+	output, err := session.SubmitTurn(playerID, input)
 	if err != nil {
 		log.Printf("[SESSION MANAGER] Failed to process input: %v", err)
 		return nil, err
 	}
 
+	if err := sm.store.Save(chatID, session.Snapshot()); err != nil {
+		log.Printf("[SESSION MANAGER] Failed to persist session for chat %d: %v", chatID, err)
+	}
+
+	if output != nil {
+		sm.broadcast(chatID, output.Content)
+	}
+
 	return output, nil
 }
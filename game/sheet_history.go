@@ -0,0 +1,414 @@
+package game
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// HistoryEntry is one immutable revision of a character sheet: a
+// content-addressed snapshot (ContentHash, stored in objects/) plus a
+// pointer to its parent revision and the CharacterUpdate that produced it,
+// forming a git-style append-only chain. Update is nil for the initial
+// snapshot and for saves that didn't go through a typed CharacterUpdate
+type HistoryEntry struct {
+	Rev         string           `json:"rev"`
+	ParentRev   string           `json:"parent_rev,omitempty"`
+	ContentHash string           `json:"content_hash"`
+	Update      *CharacterUpdate `json:"update,omitempty"`
+	Timestamp   time.Time        `json:"timestamp"`
+}
+
+// computeRev derives an entry's content-addressed identity from everything
+// but its own Rev field
+func computeRev(e HistoryEntry) string {
+	e.Rev = ""
+	data, _ := json.Marshal(e)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashContent returns the content-addressed hash of a sheet's bytes
+func hashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// SheetHistory is a git-style object store of character sheet revisions,
+// rooted at <campaign>/characters/.history. Every playerID gets its own
+// objects/ directory (content-addressed sheet snapshots, deduplicated by
+// SHA-256) and an append-only log.jsonl of HistoryEntry records
+type SheetHistory struct {
+	basePath string
+}
+
+// NewSheetHistory creates a history store rooted at basePath (typically
+// <campaign>/characters/.history)
+func NewSheetHistory(basePath string) *SheetHistory {
+	return &SheetHistory{basePath: basePath}
+}
+
+func (h *SheetHistory) playerDir(playerID string) string {
+	return filepath.Join(h.basePath, playerID)
+}
+
+func (h *SheetHistory) objectPath(playerID, contentHash string) string {
+	return filepath.Join(h.playerDir(playerID), "objects", contentHash)
+}
+
+func (h *SheetHistory) logPath(playerID string) string {
+	return filepath.Join(h.playerDir(playerID), "log.jsonl")
+}
+
+// Record snapshots sheet as a new revision for playerID, caused by update
+// (nil if the change didn't come from a typed CharacterUpdate, e.g. the
+// very first snapshot), and returns the new revision's hash
+func (h *SheetHistory) Record(playerID, sheet string, update *CharacterUpdate) (string, error) {
+	entries, err := h.Log(playerID)
+	if err != nil {
+		return "", err
+	}
+
+	var parentRev string
+	if len(entries) > 0 {
+		parentRev = entries[len(entries)-1].Rev
+	}
+
+	contentHash := hashContent(sheet)
+	if err := h.writeObject(playerID, contentHash, sheet); err != nil {
+		return "", err
+	}
+
+	entry := HistoryEntry{
+		ParentRev:   parentRev,
+		ContentHash: contentHash,
+		Update:      update,
+		Timestamp:   time.Now(),
+	}
+	entry.Rev = computeRev(entry)
+
+	if err := h.appendLog(playerID, entry); err != nil {
+		return "", err
+	}
+
+	return entry.Rev, nil
+}
+
+// writeObject stores a content-addressed blob, skipping the write if it's
+// already on disk since objects are immutable and keyed by their own hash
+func (h *SheetHistory) writeObject(playerID, contentHash, content string) error {
+	path := h.objectPath(playerID, contentHash)
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history object dir: %w", err)
+	}
+
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Errorf("failed to write history object: %w", err)
+	}
+
+	return nil
+}
+
+// readObject reads a content-addressed blob by hash
+func (h *SheetHistory) readObject(playerID, contentHash string) (string, error) {
+	data, err := os.ReadFile(h.objectPath(playerID, contentHash))
+	if err != nil {
+		return "", fmt.Errorf("failed to read history object %s: %w", contentHash, err)
+	}
+	return string(data), nil
+}
+
+// appendLog appends entry to playerID's log.jsonl
+func (h *SheetHistory) appendLog(playerID string, entry HistoryEntry) error {
+	path := h.logPath(playerID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create history dir: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history log: %w", err)
+	}
+	defer file.Close()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode history entry: %w", err)
+	}
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to append history entry: %w", err)
+	}
+
+	return nil
+}
+
+// Log returns every revision recorded for playerID, oldest first. A
+// playerID with no history yet returns an empty slice and no error
+func (h *SheetHistory) Log(playerID string) ([]HistoryEntry, error) {
+	file, err := os.Open(h.logPath(playerID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to open history log: %w", err)
+	}
+	defer file.Close()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		var entry HistoryEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			return nil, fmt.Errorf("failed to decode history entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read history log: %w", err)
+	}
+
+	return entries, nil
+}
+
+// resolveRev resolves "HEAD" or an unambiguous revision prefix to a full
+// revision hash
+func (h *SheetHistory) resolveRev(playerID, rev string) (HistoryEntry, error) {
+	entries, err := h.Log(playerID)
+	if err != nil {
+		return HistoryEntry{}, err
+	}
+	if len(entries) == 0 {
+		return HistoryEntry{}, fmt.Errorf("no history for player %s", playerID)
+	}
+
+	if rev == "" || rev == "HEAD" {
+		return entries[len(entries)-1], nil
+	}
+
+	var match *HistoryEntry
+	for i := range entries {
+		if entries[i].Rev == rev {
+			return entries[i], nil
+		}
+		if strings.HasPrefix(entries[i].Rev, rev) {
+			if match != nil {
+				return HistoryEntry{}, fmt.Errorf("ambiguous revision prefix: %s", rev)
+			}
+			match = &entries[i]
+		}
+	}
+	if match != nil {
+		return *match, nil
+	}
+
+	return HistoryEntry{}, fmt.Errorf("revision not found: %s", rev)
+}
+
+// Content returns the sheet content at revision rev ("" or "HEAD" for the
+// latest revision)
+func (h *SheetHistory) Content(playerID, rev string) (string, error) {
+	entry, err := h.resolveRev(playerID, rev)
+	if err != nil {
+		return "", err
+	}
+	return h.readObject(playerID, entry.ContentHash)
+}
+
+// Diff renders a line-based diff between fromRev and toRev in unified-diff
+// style (no hunk collapsing, since character sheets are short). fromRev
+// may be "" to diff against an empty sheet, e.g. for a character's very
+// first revision
+func (h *SheetHistory) Diff(playerID, fromRev, toRev string) (string, error) {
+	var fromContent string
+	if fromRev != "" {
+		content, err := h.Content(playerID, fromRev)
+		if err != nil {
+			return "", err
+		}
+		fromContent = content
+	}
+
+	toContent, err := h.Content(playerID, toRev)
+	if err != nil {
+		return "", err
+	}
+
+	ops := diffLines(splitLines(fromContent), splitLines(toContent))
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", shortRev(fromRev), shortRev(toRev))
+	for _, op := range ops {
+		switch op.Type {
+		case diffEqual:
+			fmt.Fprintf(&b, " %s\n", op.Text)
+		case diffRemove:
+			fmt.Fprintf(&b, "-%s\n", op.Text)
+		case diffAdd:
+			fmt.Fprintf(&b, "+%s\n", op.Text)
+		}
+	}
+
+	return b.String(), nil
+}
+
+// Revert returns the sheet content as of rev. It does not mutate history
+// itself: the caller (CharacterManager.Undo) is expected to save the
+// restored content as a normal update, which records a new
+// forward-pointing revision through Record rather than erasing anything
+func (h *SheetHistory) Revert(playerID, rev string) (string, error) {
+	return h.Content(playerID, rev)
+}
+
+// BlameLine is one line of a character's current sheet, attributed to the
+// revision that last introduced or changed it
+type BlameLine struct {
+	Line int
+	Rev  string
+	Text string
+}
+
+// Blame replays playerID's history from the first revision forward,
+// tracking which revision last touched each line, and returns that
+// attribution for the current (HEAD) sheet content
+func (h *SheetHistory) Blame(playerID string) ([]BlameLine, error) {
+	entries, err := h.Log(playerID)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no history for player %s", playerID)
+	}
+
+	var prevLines, origins []string
+
+	for _, entry := range entries {
+		content, err := h.readObject(playerID, entry.ContentHash)
+		if err != nil {
+			return nil, err
+		}
+		lines := splitLines(content)
+
+		newOrigins := make([]string, len(lines))
+		for _, op := range diffLines(prevLines, lines) {
+			switch op.Type {
+			case diffEqual:
+				newOrigins[op.BIndex] = origins[op.AIndex]
+			case diffAdd:
+				newOrigins[op.BIndex] = entry.Rev
+			}
+		}
+
+		prevLines = lines
+		origins = newOrigins
+	}
+
+	blame := make([]BlameLine, len(prevLines))
+	for i, line := range prevLines {
+		blame[i] = BlameLine{Line: i + 1, Rev: origins[i], Text: line}
+	}
+
+	return blame, nil
+}
+
+// shortRev returns an 8-character prefix of rev for display, mirroring a
+// git short hash. Empty revs (diffing against "no sheet yet") render as "∅"
+func shortRev(rev string) string {
+	if rev == "" {
+		return "∅"
+	}
+	if len(rev) <= 8 {
+		return rev
+	}
+	return rev[:8]
+}
+
+// splitLines splits text into lines without keeping trailing newlines
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// diffOpType identifies one line-diff operation
+type diffOpType int
+
+const (
+	diffEqual diffOpType = iota
+	diffRemove
+	diffAdd
+)
+
+// diffOp is one line of a line-based diff between an old sequence (a) and
+// a new sequence (b). AIndex/BIndex index into whichever sequence the
+// operation is relevant for
+type diffOp struct {
+	Type   diffOpType
+	Text   string
+	AIndex int
+	BIndex int
+}
+
+// diffLines computes a line-based diff between a (old) and b (new) using
+// the standard longest-common-subsequence dynamic program. It's O(len(a) *
+// len(b)), which is fine for sheets of a few hundred lines
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{Type: diffEqual, Text: a[i], AIndex: i, BIndex: j})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{Type: diffRemove, Text: a[i], AIndex: i})
+			i++
+		default:
+			ops = append(ops, diffOp{Type: diffAdd, Text: b[j], BIndex: j})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{Type: diffRemove, Text: a[i], AIndex: i})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{Type: diffAdd, Text: b[j], BIndex: j})
+	}
+
+	return ops
+}
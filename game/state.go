@@ -0,0 +1,179 @@
+package game
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"wfrp-bot/game/content"
+)
+
+// chargenState is the on-disk shape SaveState/LoadState serialize: the
+// state machine's position, its data so far, the player's last raw input
+// (for saveStep-style diagnostics), and any career options rolled by the
+// "three rolls, pick one" method that are still awaiting a choice
+type chargenState struct {
+	State                CharacterCreationState `json:"state"`
+	Data                 *CharacterCreationData `json:"data"`
+	CurrentInput         string                 `json:"current_input"`
+	PendingCareerOptions []string               `json:"pending_career_options,omitempty"`
+}
+
+// SaveState serializes the creator's state machine to w, so LoadState can
+// rehydrate it later - used to resume an in-progress character across a
+// bot restart
+func (cc *CharacterCreator) SaveState(w io.Writer) error {
+	state := chargenState{
+		State:                cc.State,
+		Data:                 cc.Data,
+		CurrentInput:         cc.currentInput,
+		PendingCareerOptions: cc.pendingCareerOptions,
+	}
+	return json.NewEncoder(w).Encode(state)
+}
+
+// LoadState rebuilds a CharacterCreator from data written by SaveState.
+// provider supplies the races and careers, same as NewCharacterCreator
+func LoadState(r io.Reader, provider content.ContentProvider) (*CharacterCreator, error) {
+	var state chargenState
+	if err := json.NewDecoder(r).Decode(&state); err != nil {
+		return nil, fmt.Errorf("failed to decode character creator state: %w", err)
+	}
+
+	cc := NewCharacterCreator("", provider)
+	cc.State = state.State
+	cc.Data = state.Data
+	cc.currentInput = state.CurrentInput
+	cc.pendingCareerOptions = state.PendingCareerOptions
+
+	return cc, nil
+}
+
+// chargenStatePath returns basePath/chargen/<userID>.json, where an
+// in-progress character creation for userID is auto-saved
+func chargenStatePath(basePath, userID string) string {
+	return filepath.Join(basePath, "chargen", userID+".json")
+}
+
+// autoSaveState persists the creator's current step so /resume can pick up
+// exactly where the player left off. It's a no-op until UserID is set
+// (headless creators, like bulk NPC generation, never set it), and it
+// removes the saved state once creation is finished rather than leaving a
+// stale file behind
+func (cc *CharacterCreator) autoSaveState() {
+	if cc.UserID == "" {
+		return
+	}
+
+	basePath := cc.Data.BasePath
+	if basePath == "" {
+		basePath = "./characters"
+	}
+
+	if cc.IsComplete() {
+		if err := DeleteStateFile(basePath, cc.UserID); err != nil {
+			log.Printf("[CHARGEN] Failed to remove finished state for %s: %v", cc.UserID, err)
+		}
+		return
+	}
+
+	if err := cc.SaveStateToFile(basePath, cc.UserID); err != nil {
+		log.Printf("[CHARGEN] Failed to auto-save state for %s: %v", cc.UserID, err)
+	}
+}
+
+// SaveStateToFile writes the creator's state to basePath/chargen/<userID>.json,
+// creating the chargen directory if needed
+func (cc *CharacterCreator) SaveStateToFile(basePath, userID string) error {
+	path := chargenStatePath(basePath, userID)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create chargen directory: %w", err)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create chargen state file: %w", err)
+	}
+	defer file.Close()
+
+	return cc.SaveState(file)
+}
+
+// LoadStateFromFile reads an in-progress character creation previously
+// written by SaveStateToFile, for userID under basePath
+func LoadStateFromFile(basePath, userID string, provider content.ContentProvider) (*CharacterCreator, error) {
+	file, err := os.Open(chargenStatePath(basePath, userID))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return LoadState(file, provider)
+}
+
+// DeleteStateFile removes a user's saved chargen state, if any
+func DeleteStateFile(basePath, userID string) error {
+	err := os.Remove(chargenStatePath(basePath, userID))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// OutstandingChargenUsers lists the userIDs with a saved in-progress
+// character creation under basePath/chargen, so a bot can restore them on
+// startup
+func OutstandingChargenUsers(basePath string) ([]string, error) {
+	dir := filepath.Join(basePath, "chargen")
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read chargen directory: %w", err)
+	}
+
+	var userIDs []string
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		userIDs = append(userIDs, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	return userIDs, nil
+}
+
+// SweepExpiredChargenStates removes chargen state files older than maxAge,
+// returning how many were deleted. Abandoned creations the player never
+// returned to via /resume shouldn't accumulate forever
+func SweepExpiredChargenStates(basePath string, maxAge time.Duration) (int, error) {
+	dir := filepath.Join(basePath, "chargen")
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read chargen directory: %w", err)
+	}
+
+	cutoff := time.Now().Add(-maxAge)
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(dir, entry.Name())); err == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
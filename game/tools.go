@@ -0,0 +1,697 @@
+package game
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"wfrp-bot/agents"
+	"wfrp-bot/dice"
+)
+
+// globalRandSource adapts the package-level math/rand generator (already
+// used throughout this package, e.g. RollInitiative, character_creation.go)
+// to the rand.Source interface dice.Expr.Roll expects, so the dice package's
+// SL/critical/fumble math runs off the same RNG as everything else instead
+// of seeding a separate one per roll
+type globalRandSource struct{}
+
+func (globalRandSource) Int63() int64 { return rand.Int63() }
+func (globalRandSource) Seed(int64)   {}
+
+var diceExprPattern = regexp.MustCompile(`^(\d*)d(\d+)([+-]\d+)?$`)
+
+// parseDiceExpr parses a dice expression like "2d10+5" into its components
+func parseDiceExpr(expr string) (numDice, sides, modifier int, err error) {
+	matches := diceExprPattern.FindStringSubmatch(expr)
+	if matches == nil {
+		return 0, 0, 0, fmt.Errorf("invalid dice expression: %s", expr)
+	}
+
+	numDice = 1
+	if matches[1] != "" {
+		numDice, err = strconv.Atoi(matches[1])
+		if err != nil || numDice < 1 || numDice > 100 {
+			return 0, 0, 0, fmt.Errorf("invalid number of dice in %s", expr)
+		}
+	}
+
+	sides, err = strconv.Atoi(matches[2])
+	if err != nil || sides < 2 || sides > 100 {
+		return 0, 0, 0, fmt.Errorf("invalid number of sides in %s", expr)
+	}
+
+	if matches[3] != "" {
+		modifier, err = strconv.Atoi(matches[3])
+		if err != nil {
+			return 0, 0, 0, fmt.Errorf("invalid modifier in %s", expr)
+		}
+	}
+
+	return numDice, sides, modifier, nil
+}
+
+// gmSystemPrompt is the system prompt for the default GM agent
+const gmSystemPrompt = "Ты - Game Master для WFRP 4e. Используй доступные инструменты, чтобы бросать кубы, " +
+	"проверять правила, наносить урон персонажам, записывать заметки сессии, тратить очки судьбы и " +
+	"завершать боевые раунды (advance_round), вместо того чтобы просто описывать результат текстом. " +
+	"Для проверок навыков и характеристик используй skill_check, для состязательных проверок - " +
+	"opposed_test, для урона с учётом Toughness и брони - damage, для изменения Advantage - advantage_change. " +
+	"Никогда не придумывай результат броска или число SL сам - всегда вызывай соответствующий инструмент."
+
+// NewGMToolbox builds the toolbox of deterministic actions the GM agent can
+// invoke while narrating a session: roll_dice, lookup_rule, apply_damage,
+// add_session_note, spend_fate_point, advance_round, lookup_character,
+// list_campaign_files, save_scene_note, skill_check, opposed_test, damage,
+// advantage_change, roll_initiative and advance_turn
+func NewGMToolbox(session *Session, ruleChecker *RuleChecker) *agents.Toolbox {
+	toolbox := agents.NewToolbox()
+
+	toolbox.Register(agents.Tool{
+		Name:        "roll_dice",
+		Description: "Rolls a WFRP dice expression (e.g. \"d100\", \"2d10+5\") and returns the result",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"expr":{"type":"string","description":"dice expression, e.g. d100 or 2d10+5"}},"required":["expr"]}`),
+		Handler:     rollDiceTool,
+	})
+
+	toolbox.Register(agents.Tool{
+		Name:        "lookup_rule",
+		Description: "Looks up a WFRP 4e rule matching a query",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"query":{"type":"string","description":"keyword or question about a rule"}},"required":["query"]}`),
+		Handler:     lookupRuleTool(ruleChecker),
+	})
+
+	toolbox.Register(agents.Tool{
+		Name:        "apply_damage",
+		Description: "Applies damage (or healing, with a negative amount) to a character's HP",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"character":"string","amount":{"type":"integer"}},"required":["character","amount"]}`),
+		Handler:     applyDamageTool(session),
+	})
+
+	toolbox.Register(agents.Tool{
+		Name:        "add_session_note",
+		Description: "Appends a note to the current session's log",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"text":{"type":"string"}},"required":["text"]}`),
+		Handler:     addSessionNoteTool(session),
+	})
+
+	toolbox.Register(agents.Tool{
+		Name:        "spend_fate_point",
+		Description: "Spends one Fate Point for a character, failing if none remain",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"character":{"type":"string"}},"required":["character"]}`),
+		Handler:     spendFatePointTool(session),
+	})
+
+	toolbox.Register(agents.Tool{
+		Name:        "advance_round",
+		Description: "Ends the current combat round, resolving Bleeding, Ablaze and other end-of-round condition effects for every character",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
+		Handler:     advanceRoundTool(session),
+	})
+
+	toolbox.Register(agents.Tool{
+		Name:        "lookup_character",
+		Description: "Returns a character's current sheet by name",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"character":{"type":"string"}},"required":["character"]}`),
+		Handler:     lookupCharacterTool(session),
+	})
+
+	toolbox.Register(agents.Tool{
+		Name:        "list_campaign_files",
+		Description: "Lists the files stored on disk for the current campaign (character sheets, session logs, notes)",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
+		Handler:     listCampaignFilesTool(session),
+	})
+
+	toolbox.Register(agents.Tool{
+		Name:        "save_scene_note",
+		Description: "Persists a scene note to the campaign's session log on disk, surviving a restart (add_session_note is in-memory only and is lost on restart)",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"text":{"type":"string"}},"required":["text"]}`),
+		Handler:     saveSceneNoteTool(session),
+	})
+
+	toolbox.Register(agents.Tool{
+		Name:        "skill_check",
+		Description: "Rolls a d100 skill or characteristic test for a character, returning success/failure, success levels, and critical/fumble status per WFRP 4e's doubles rule",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"character":{"type":"string"},"skill":{"type":"string","description":"skill name, or a characteristic like WS/BS/S/T/Ag/Int/WP/Fel"},"modifier":{"type":"integer","description":"situational modifier applied to the target number"}},"required":["character","skill"]}`),
+		Handler:     skillCheckTool(session),
+	})
+
+	toolbox.Register(agents.Tool{
+		Name:        "opposed_test",
+		Description: "Resolves a WFRP 4e opposed test between two characters: both roll against their own skill/characteristic, and the higher success level wins (ties go to the defender)",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"attacker":{"type":"string"},"attacker_skill":{"type":"string"},"attacker_modifier":{"type":"integer"},"defender":{"type":"string"},"defender_skill":{"type":"string"},"defender_modifier":{"type":"integer"}},"required":["attacker","attacker_skill","defender","defender_skill"]}`),
+		Handler:     opposedTestTool(session),
+	})
+
+	toolbox.Register(agents.Tool{
+		Name:        "damage",
+		Description: "Applies weapon/effect damage to a character after soaking it with their Toughness Bonus and armor, per WFRP 4e damage rules (use apply_damage instead if the final HP loss is already known)",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"character":{"type":"string"},"amount":{"type":"integer","description":"raw damage before Toughness/armor soak"},"armor":{"type":"integer","description":"Armour Points covering the hit location, default 0"}},"required":["character","amount"]}`),
+		Handler:     damageTool(session),
+	})
+
+	toolbox.Register(agents.Tool{
+		Name:        "advantage_change",
+		Description: "Raises or lowers a character's combat Advantage (e.g. +1 for winning a round, reset to 0 on fleeing)",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{"character":{"type":"string"},"change":{"type":"integer"}},"required":["character","change"]}`),
+		Handler:     advantageChangeTool(session),
+	})
+
+	toolbox.Register(agents.Tool{
+		Name:        "roll_initiative",
+		Description: "Rolls a fresh initiative order (d10 + Initiative characteristic, highest first) for every character in the session, so turns are arbitrated instead of racing - call this at the start of a combat scene",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
+		Handler:     rollInitiativeTool(session),
+	})
+
+	toolbox.Register(agents.Tool{
+		Name:        "advance_turn",
+		Description: "Passes the turn to the next character in the initiative order rolled by roll_initiative, wrapping back to the top once everyone has acted",
+		Parameters:  json.RawMessage(`{"type":"object","properties":{}}`),
+		Handler:     advanceTurnTool(session),
+	})
+
+	return toolbox
+}
+
+// characterBuilderSystemPrompt is the system prompt for the more narrowly
+// scoped character-builder agent, selected via /start <campaign> --agent wfrp-character-builder
+const characterBuilderSystemPrompt = "Ты помогаешь создать или проверить персонажа WFRP 4e. " +
+	"Используй lookup_character, чтобы свериться с текущим листом персонажа, и roll_dice для генерации " +
+	"характеристик. Не изменяй состояние сессии и не описывай боевые сцены - это не твоя роль."
+
+// BuildAgent resolves agentName to an *agents.Agent bound to session's
+// toolbox. An empty or unrecognized name falls back to "gm", the default
+// Game Master agent with access to every registered tool
+func BuildAgent(agentName string, session *Session, ruleChecker *RuleChecker) *agents.Agent {
+	toolbox := NewGMToolbox(session, ruleChecker)
+
+	switch agentName {
+	case "wfrp-character-builder":
+		return agents.NewAgent("wfrp-character-builder", characterBuilderSystemPrompt, toolbox, []string{"roll_dice", "lookup_character"})
+	default:
+		return NewGMAgent(toolbox)
+	}
+}
+
+// NewGMAgent creates the default GM agent with access to every registered tool
+func NewGMAgent(toolbox *agents.Toolbox) *agents.Agent {
+	return agents.NewAgent("gm", gmSystemPrompt, toolbox, nil)
+}
+
+// rollDiceArgs is the decoded argument payload for roll_dice
+type rollDiceArgs struct {
+	Expr string `json:"expr"`
+}
+
+// rollDiceTool rolls a dice expression such as "2d10+5"
+func rollDiceTool(ctx context.Context, args json.RawMessage) (string, error) {
+	var a rollDiceArgs
+	if err := json.Unmarshal(args, &a); err != nil {
+		return "", fmt.Errorf("invalid roll_dice arguments: %w", err)
+	}
+
+	return RollDice(a.Expr)
+}
+
+// RollDice rolls a dice expression such as "2d10+5" and returns it formatted
+// as "expr = total". It is the exported entry point for callers outside the
+// GM agent's tool-calling loop (e.g. the /roll command in cmd/wfrp-tui)
+func RollDice(expr string) (string, error) {
+	numDice, sides, modifier, err := parseDiceExpr(expr)
+	if err != nil {
+		return "", err
+	}
+
+	total := modifier
+	for i := 0; i < numDice; i++ {
+		total += rand.Intn(sides) + 1
+	}
+
+	return fmt.Sprintf("%s = %d", expr, total), nil
+}
+
+// lookupRuleArgs is the decoded argument payload for lookup_rule
+type lookupRuleArgs struct {
+	Query string `json:"query"`
+}
+
+// lookupRuleTool looks up a rule via the session's RuleChecker
+func lookupRuleTool(ruleChecker *RuleChecker) agents.ToolHandler {
+	return func(ctx context.Context, args json.RawMessage) (string, error) {
+		var a lookupRuleArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", fmt.Errorf("invalid lookup_rule arguments: %w", err)
+		}
+
+		if ruleChecker == nil {
+			return "", fmt.Errorf("no rule checker available")
+		}
+
+		rule, err := ruleChecker.CheckRule(a.Query)
+		if err != nil {
+			return "", err
+		}
+
+		return rule, nil
+	}
+}
+
+// applyDamageArgs is the decoded argument payload for apply_damage
+type applyDamageArgs struct {
+	Character string `json:"character"`
+	Amount    int    `json:"amount"`
+}
+
+// applyDamageTool applies HP damage (or healing) to a character in the session
+func applyDamageTool(session *Session) agents.ToolHandler {
+	return func(ctx context.Context, args json.RawMessage) (string, error) {
+		var a applyDamageArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", fmt.Errorf("invalid apply_damage arguments: %w", err)
+		}
+
+		char, ok := session.findCharacterByName(a.Character)
+		if !ok {
+			return "", fmt.Errorf("character not found: %s", a.Character)
+		}
+
+		updated, warnings := ApplyCharacterUpdate(char.Sheet, CharacterUpdate{HPChange: -a.Amount})
+		session.mu.Lock()
+		char.Sheet = updated
+		session.mu.Unlock()
+
+		log.Printf("[AGENT] apply_damage %s %d: %v", a.Character, a.Amount, warnings)
+
+		return fmt.Sprintf("applied %d damage to %s", a.Amount, char.Name), nil
+	}
+}
+
+// addSessionNoteArgs is the decoded argument payload for add_session_note
+type addSessionNoteArgs struct {
+	Text string `json:"text"`
+}
+
+// addSessionNoteTool records a note on the session for later review
+func addSessionNoteTool(session *Session) agents.ToolHandler {
+	return func(ctx context.Context, args json.RawMessage) (string, error) {
+		var a addSessionNoteArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", fmt.Errorf("invalid add_session_note arguments: %w", err)
+		}
+
+		session.AddNote(a.Text)
+
+		return "note added", nil
+	}
+}
+
+// spendFatePointArgs is the decoded argument payload for spend_fate_point
+type spendFatePointArgs struct {
+	Character string `json:"character"`
+}
+
+// spendFatePointTool spends a Fate Point for a character
+func spendFatePointTool(session *Session) agents.ToolHandler {
+	return func(ctx context.Context, args json.RawMessage) (string, error) {
+		var a spendFatePointArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", fmt.Errorf("invalid spend_fate_point arguments: %w", err)
+		}
+
+		if _, ok := session.findCharacterByName(a.Character); !ok {
+			return "", fmt.Errorf("character not found: %s", a.Character)
+		}
+
+		// Fate Point tracking lives on the character sheet; the sheet is
+		// free-form markdown today so we can only acknowledge the spend
+		return fmt.Sprintf("%s spends a Fate Point", a.Character), nil
+	}
+}
+
+// advanceRoundTool resolves end-of-round condition effects for every
+// character in the session
+func advanceRoundTool(session *Session) agents.ToolHandler {
+	return func(ctx context.Context, args json.RawMessage) (string, error) {
+		results := session.AdvanceRound()
+		if len(results) == 0 {
+			return "round advanced, no condition effects triggered", nil
+		}
+
+		var lines []string
+		for playerID, charLines := range results {
+			name := playerID
+			if char, ok := session.GetCharacter(playerID); ok {
+				name = char.Name
+			}
+			for _, line := range charLines {
+				lines = append(lines, fmt.Sprintf("%s: %s", name, line))
+			}
+		}
+		return strings.Join(lines, "; "), nil
+	}
+}
+
+// findCharacterByName finds a session character by display name
+func (s *Session) findCharacterByName(name string) (*Character, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, char := range s.Characters {
+		if char.Name == name {
+			return char, true
+		}
+	}
+	return nil, false
+}
+
+// AddNote appends a GM note to the session's notes log
+func (s *Session) AddNote(text string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Notes = append(s.Notes, text)
+	log.Printf("[SESSION] Note added to %s: %s", s.ID, text)
+}
+
+// lookupCharacterArgs is the decoded argument payload for lookup_character
+type lookupCharacterArgs struct {
+	Character string `json:"character"`
+}
+
+// lookupCharacterTool returns a character's current sheet by name
+func lookupCharacterTool(session *Session) agents.ToolHandler {
+	return func(ctx context.Context, args json.RawMessage) (string, error) {
+		var a lookupCharacterArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", fmt.Errorf("invalid lookup_character arguments: %w", err)
+		}
+
+		char, ok := session.findCharacterByName(a.Character)
+		if !ok {
+			return "", fmt.Errorf("character not found: %s", a.Character)
+		}
+
+		return char.Sheet, nil
+	}
+}
+
+// listCampaignFilesTool lists the files stored on disk for the session's campaign
+func listCampaignFilesTool(session *Session) agents.ToolHandler {
+	return func(ctx context.Context, args json.RawMessage) (string, error) {
+		if session.campaignMgr == nil {
+			return "", fmt.Errorf("no campaign storage available")
+		}
+
+		files, err := session.campaignMgr.ListCampaignFiles(session.Campaign)
+		if err != nil {
+			return "", err
+		}
+		if len(files) == 0 {
+			return "campaign has no files yet", nil
+		}
+
+		return strings.Join(files, "\n"), nil
+	}
+}
+
+// saveSceneNoteArgs is the decoded argument payload for save_scene_note
+type saveSceneNoteArgs struct {
+	Text string `json:"text"`
+}
+
+// saveSceneNoteTool persists a scene note to the campaign's session log on
+// disk, unlike add_session_note which only keeps it in memory
+func saveSceneNoteTool(session *Session) agents.ToolHandler {
+	return func(ctx context.Context, args json.RawMessage) (string, error) {
+		var a saveSceneNoteArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", fmt.Errorf("invalid save_scene_note arguments: %w", err)
+		}
+
+		if session.campaignMgr == nil {
+			return "", fmt.Errorf("no campaign storage available")
+		}
+
+		if err := session.campaignMgr.AppendSceneNote(session.Campaign, session.ID, a.Text); err != nil {
+			return "", err
+		}
+
+		return "scene note saved", nil
+	}
+}
+
+// skillTarget resolves the target number a skill test rolls against: the
+// character's rating in the Skills section if they're trained in it,
+// falling back to the raw characteristic of the same name (WS, BS, S, T,
+// Ag, Int, WP, Fel) for an untrained basic-skill test
+func skillTarget(sheet *CharacterSheet, skill string) (int, bool) {
+	if v, ok := sheet.Section(SectionSkills).IntField(skill); ok {
+		return v, true
+	}
+	return sheet.Characteristic(skill)
+}
+
+// rollSkillTest rolls a single d100 against target using the package's
+// shared RNG (see globalRandSource), carrying over skillCtx's success
+// levels for a follow-up "+SL" roll if skillCtx is non-nil
+func rollSkillTest(label string, target int, skillCtx *dice.SkillContext) dice.Result {
+	expr := &dice.Expr{
+		Raw:     fmt.Sprintf("d100 vs %s %d", label, target),
+		NumDice: 1,
+		Sides:   100,
+		Target:  &dice.Target{Characteristic: label, Value: target},
+	}
+	return expr.Roll(globalRandSource{}, skillCtx)
+}
+
+// describeSkillResult labels result's outcome the way the GM agent should
+// narrate it
+func describeSkillResult(result dice.Result) string {
+	switch {
+	case result.Astounding:
+		return "astounding success"
+	case result.Success:
+		return "success"
+	case result.Fumble:
+		return "fumble"
+	case result.Critical:
+		return "critical failure"
+	default:
+		return "failure"
+	}
+}
+
+// skillCheckArgs is the decoded argument payload for skill_check
+type skillCheckArgs struct {
+	Character string `json:"character"`
+	Skill     string `json:"skill"`
+	Modifier  int    `json:"modifier"`
+}
+
+// skillCheckTool rolls a d100 test for character against their rating in
+// skill (or the matching raw characteristic), modified by a.Modifier
+func skillCheckTool(session *Session) agents.ToolHandler {
+	return func(ctx context.Context, args json.RawMessage) (string, error) {
+		var a skillCheckArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", fmt.Errorf("invalid skill_check arguments: %w", err)
+		}
+
+		char, ok := session.findCharacterByName(a.Character)
+		if !ok {
+			return "", fmt.Errorf("character not found: %s", a.Character)
+		}
+
+		sheet := ParseCharacterSheet(char.Sheet)
+		target, ok := skillTarget(sheet, a.Skill)
+		if !ok {
+			return "", fmt.Errorf("%s has no rating for %s", char.Name, a.Skill)
+		}
+
+		result := rollSkillTest(a.Skill, target+a.Modifier, nil)
+		return fmt.Sprintf("%s tests %s (d100 = %d vs %d): %s, SL %+d", char.Name, a.Skill, result.Total, result.Target, describeSkillResult(result), result.SuccessLevels), nil
+	}
+}
+
+// opposedTestArgs is the decoded argument payload for opposed_test
+type opposedTestArgs struct {
+	Attacker         string `json:"attacker"`
+	AttackerSkill    string `json:"attacker_skill"`
+	AttackerModifier int    `json:"attacker_modifier"`
+	Defender         string `json:"defender"`
+	DefenderSkill    string `json:"defender_skill"`
+	DefenderModifier int    `json:"defender_modifier"`
+}
+
+// opposedWinner picks the winning side of an opposed test by success
+// level. A tied SL goes to the defender per WFRP 4e's opposed-test
+// tie-break rule
+func opposedWinner(attackerName, defenderName string, attackerSL, defenderSL int) string {
+	if attackerSL > defenderSL {
+		return attackerName
+	}
+	return defenderName
+}
+
+// opposedTestTool resolves a WFRP 4e opposed test: both sides roll their
+// own skill_check, and whoever scores the higher success level wins (see
+// opposedWinner)
+func opposedTestTool(session *Session) agents.ToolHandler {
+	return func(ctx context.Context, args json.RawMessage) (string, error) {
+		var a opposedTestArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", fmt.Errorf("invalid opposed_test arguments: %w", err)
+		}
+
+		attacker, ok := session.findCharacterByName(a.Attacker)
+		if !ok {
+			return "", fmt.Errorf("character not found: %s", a.Attacker)
+		}
+		defender, ok := session.findCharacterByName(a.Defender)
+		if !ok {
+			return "", fmt.Errorf("character not found: %s", a.Defender)
+		}
+
+		attackerTarget, ok := skillTarget(ParseCharacterSheet(attacker.Sheet), a.AttackerSkill)
+		if !ok {
+			return "", fmt.Errorf("%s has no rating for %s", attacker.Name, a.AttackerSkill)
+		}
+		defenderTarget, ok := skillTarget(ParseCharacterSheet(defender.Sheet), a.DefenderSkill)
+		if !ok {
+			return "", fmt.Errorf("%s has no rating for %s", defender.Name, a.DefenderSkill)
+		}
+
+		attackerResult := rollSkillTest(a.AttackerSkill, attackerTarget+a.AttackerModifier, nil)
+		defenderResult := rollSkillTest(a.DefenderSkill, defenderTarget+a.DefenderModifier, nil)
+
+		winner := opposedWinner(attacker.Name, defender.Name, attackerResult.SuccessLevels, defenderResult.SuccessLevels)
+
+		return fmt.Sprintf(
+			"%s (%s): %d vs %d, SL %+d, %s | %s (%s): %d vs %d, SL %+d, %s | winner: %s",
+			attacker.Name, a.AttackerSkill, attackerResult.Total, attackerResult.Target, attackerResult.SuccessLevels, describeSkillResult(attackerResult),
+			defender.Name, a.DefenderSkill, defenderResult.Total, defenderResult.Target, defenderResult.SuccessLevels, describeSkillResult(defenderResult),
+			winner,
+		), nil
+	}
+}
+
+// damageArgs is the decoded argument payload for damage
+type damageArgs struct {
+	Character string `json:"character"`
+	Amount    int    `json:"amount"`
+	Armor     int    `json:"armor"`
+}
+
+// soakDamage applies a character's Toughness Bonus and armor to raw
+// incoming damage, per WFRP 4e: damage taken = max(0, amount - TB - armor)
+func soakDamage(amount, toughnessBonus, armor int) int {
+	taken := amount - toughnessBonus - armor
+	if taken < 0 {
+		taken = 0
+	}
+	return taken
+}
+
+// damageTool soaks raw damage with a character's Toughness Bonus and
+// armor before applying it (see soakDamage)
+func damageTool(session *Session) agents.ToolHandler {
+	return func(ctx context.Context, args json.RawMessage) (string, error) {
+		var a damageArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", fmt.Errorf("invalid damage arguments: %w", err)
+		}
+
+		char, ok := session.findCharacterByName(a.Character)
+		if !ok {
+			return "", fmt.Errorf("character not found: %s", a.Character)
+		}
+
+		sheet := ParseCharacterSheet(char.Sheet)
+		toughness, _ := sheet.Characteristic("T")
+		toughnessBonus := toughness / 10
+
+		taken := soakDamage(a.Amount, toughnessBonus, a.Armor)
+
+		updated, warnings := ApplyCharacterUpdate(char.Sheet, CharacterUpdate{HPChange: -taken})
+		session.mu.Lock()
+		char.Sheet = updated
+		session.mu.Unlock()
+
+		log.Printf("[AGENT] damage %s: %d raw, %d soaked (TB %d, armor %d): %v", a.Character, a.Amount, taken, toughnessBonus, a.Armor, warnings)
+
+		return fmt.Sprintf("%s takes %d damage (soaked %d of %d with Toughness Bonus %d and armor %d)", char.Name, taken, a.Amount-taken, a.Amount, toughnessBonus, a.Armor), nil
+	}
+}
+
+// advantageChangeArgs is the decoded argument payload for advantage_change
+type advantageChangeArgs struct {
+	Character string `json:"character"`
+	Change    int    `json:"change"`
+}
+
+// advantageChangeTool raises or lowers a character's combat Advantage,
+// clamped to never go below 0 (see CharacterSheet.SetAdvantage)
+func advantageChangeTool(session *Session) agents.ToolHandler {
+	return func(ctx context.Context, args json.RawMessage) (string, error) {
+		var a advantageChangeArgs
+		if err := json.Unmarshal(args, &a); err != nil {
+			return "", fmt.Errorf("invalid advantage_change arguments: %w", err)
+		}
+
+		char, ok := session.findCharacterByName(a.Character)
+		if !ok {
+			return "", fmt.Errorf("character not found: %s", a.Character)
+		}
+
+		updated, warnings := ApplyCharacterUpdate(char.Sheet, CharacterUpdate{AdvantageChange: a.Change})
+		session.mu.Lock()
+		char.Sheet = updated
+		session.mu.Unlock()
+
+		log.Printf("[AGENT] advantage_change %s %+d: %v", a.Character, a.Change, warnings)
+
+		sheet := ParseCharacterSheet(char.Sheet)
+		return fmt.Sprintf("%s's Advantage is now %d", char.Name, sheet.Advantage()), nil
+	}
+}
+
+// rollInitiativeTool rolls a fresh initiative order for the session (see
+// Session.RollInitiative)
+func rollInitiativeTool(session *Session) agents.ToolHandler {
+	return func(ctx context.Context, args json.RawMessage) (string, error) {
+		order := session.RollInitiative()
+		if len(order) == 0 {
+			return "no characters in session, nothing to roll initiative for", nil
+		}
+
+		lines := make([]string, 0, len(order))
+		for _, entry := range order {
+			name := entry.PlayerID
+			if char, ok := session.GetCharacter(entry.PlayerID); ok {
+				name = char.Name
+			}
+			lines = append(lines, fmt.Sprintf("%s (%d)", name, entry.Initiative))
+		}
+		return fmt.Sprintf("Initiative order: %s", strings.Join(lines, ", ")), nil
+	}
+}
+
+// advanceTurnTool moves to the next player in the session's initiative
+// order (see Session.AdvanceTurn)
+func advanceTurnTool(session *Session) agents.ToolHandler {
+	return func(ctx context.Context, args json.RawMessage) (string, error) {
+		session.AdvanceTurn()
+
+		char := session.CurrentTurn()
+		if char == nil {
+			return "no initiative order active, call roll_initiative first", nil
+		}
+		return fmt.Sprintf("It's now %s's turn", char.Name), nil
+	}
+}
@@ -0,0 +1,106 @@
+package game
+
+import (
+	"testing"
+
+	"wfrp-bot/dice"
+)
+
+func TestSkillTarget(t *testing.T) {
+	sheet := ParseCharacterSheet(`# Test Character
+
+## Характеристики
+WS: 45
+Ag: 38
+
+## Навыки
+Charm: 52
+`)
+
+	tests := []struct {
+		name      string
+		skill     string
+		wantValue int
+		wantOK    bool
+	}{
+		{name: "trained skill", skill: "Charm", wantValue: 52, wantOK: true},
+		{name: "falls back to raw characteristic", skill: "WS", wantValue: 45, wantOK: true},
+		{name: "characteristic alias", skill: "Ag", wantValue: 38, wantOK: true},
+		{name: "unknown skill and characteristic", skill: "Nonsense", wantValue: 0, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := skillTarget(sheet, tt.skill)
+			if ok != tt.wantOK || got != tt.wantValue {
+				t.Fatalf("skillTarget(%q) = (%d, %v), want (%d, %v)", tt.skill, got, ok, tt.wantValue, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDescribeSkillResult(t *testing.T) {
+	tests := []struct {
+		name   string
+		result dice.Result
+		want   string
+	}{
+		{name: "astounding success", result: dice.Result{Astounding: true, Success: true}, want: "astounding success"},
+		{name: "success", result: dice.Result{Success: true}, want: "success"},
+		{name: "fumble", result: dice.Result{Fumble: true}, want: "fumble"},
+		{name: "critical failure", result: dice.Result{Critical: true}, want: "critical failure"},
+		{name: "plain failure", result: dice.Result{}, want: "failure"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeSkillResult(tt.result); got != tt.want {
+				t.Fatalf("describeSkillResult(%+v) = %q, want %q", tt.result, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSoakDamage(t *testing.T) {
+	tests := []struct {
+		name           string
+		amount         int
+		toughnessBonus int
+		armor          int
+		want           int
+	}{
+		{name: "fully soaked by TB and armor", amount: 5, toughnessBonus: 3, armor: 2, want: 0},
+		{name: "partially soaked", amount: 10, toughnessBonus: 3, armor: 2, want: 5},
+		{name: "no soak", amount: 8, toughnessBonus: 0, armor: 0, want: 8},
+		{name: "soak exceeds amount never goes negative", amount: 2, toughnessBonus: 4, armor: 4, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := soakDamage(tt.amount, tt.toughnessBonus, tt.armor); got != tt.want {
+				t.Fatalf("soakDamage(%d, %d, %d) = %d, want %d", tt.amount, tt.toughnessBonus, tt.armor, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpposedWinner(t *testing.T) {
+	tests := []struct {
+		name       string
+		attackerSL int
+		defenderSL int
+		want       string
+	}{
+		{name: "attacker wins outright", attackerSL: 3, defenderSL: 1, want: "Grom"},
+		{name: "defender wins outright", attackerSL: 0, defenderSL: 2, want: "Elsa"},
+		{name: "tie goes to the defender", attackerSL: 2, defenderSL: 2, want: "Elsa"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := opposedWinner("Grom", "Elsa", tt.attackerSL, tt.defenderSL); got != tt.want {
+				t.Fatalf("opposedWinner(...) = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Alert severities used by ErrorNotifier when building an Alert
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+)
+
+// Alert is the payload handed to every registered AlertSink
+type Alert struct {
+	Severity  string
+	Provider  string // LLM provider name the alert concerns; used as the OpsGenie dedup alias
+	Kind      string // e.g. "provider_error", "api_error", "request_failed"
+	Message   string
+	Labels    map[string]string
+	Timestamp time.Time
+}
+
+// AlertSink delivers an Alert to some external system. ErrorNotifier fans
+// an Alert out to every registered sink concurrently, bounding each
+// delivery with a per-sink timeout so one slow sink can't delay the others
+type AlertSink interface {
+	Send(ctx context.Context, alert Alert) error
+}
+
+// WebhookSink posts an Alert as JSON to a configurable URL. Set HMACSecret
+// to sign the body (X-Signature-256: hex(hmac-sha256(body))) so the
+// receiving end can verify the request came from this bot. Delivery is
+// retried with the same exponential backoff as WithRetry; a zero-value
+// Retry falls back to DefaultRetryOptions
+type WebhookSink struct {
+	URL        string
+	Headers    map[string]string
+	HMACSecret string
+	Client     *http.Client
+	Retry      RetryOptions
+}
+
+// NewWebhookSink creates a WebhookSink posting to url with default retry
+// settings and http.DefaultClient
+func NewWebhookSink(url string) *WebhookSink {
+	return &WebhookSink{URL: url, Client: http.DefaultClient, Retry: DefaultRetryOptions}
+}
+
+// Send implements AlertSink
+func (w *WebhookSink) Send(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	opts := w.Retry
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = DefaultRetryOptions.MaxRetries
+	}
+	if opts.BaseDelay == 0 {
+		opts.BaseDelay = DefaultRetryOptions.BaseDelay
+	}
+	if opts.MaxDelay == 0 {
+		opts.MaxDelay = DefaultRetryOptions.MaxDelay
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepOrDone(ctx, backoffDelay(opts, attempt)); err != nil {
+				return lastErr
+			}
+		}
+
+		if err := w.deliver(ctx, body); err != nil {
+			lastErr = err
+			continue
+		}
+		return nil
+	}
+	return fmt.Errorf("webhook delivery to %s failed after %d attempts: %w", w.URL, opts.MaxRetries+1, lastErr)
+}
+
+func (w *WebhookSink) deliver(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range w.Headers {
+		req.Header.Set(key, value)
+	}
+	if w.HMACSecret != "" {
+		mac := hmac.New(sha256.New, []byte(w.HMACSecret))
+		mac.Write(body)
+		req.Header.Set("X-Signature-256", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	client := w.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// opsGenieAlert is the request body for POST /v2/alerts; see
+// https://docs.opsgenie.com/docs/alert-api
+type opsGenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description"`
+	Priority    string            `json:"priority"`
+	Details     map[string]string `json:"details,omitempty"`
+}
+
+// OpsGenieSink posts an Alert to OpsGenie's alert API, using Provider as
+// the alias so repeated alerts for the same provider deduplicate into one
+// OpsGenie alert instead of paging on every failure
+type OpsGenieSink struct {
+	APIKey  string
+	BaseURL string // defaults to https://api.opsgenie.com
+	Client  *http.Client
+}
+
+// NewOpsGenieSink creates an OpsGenieSink authenticating with apiKey
+func NewOpsGenieSink(apiKey string) *OpsGenieSink {
+	return &OpsGenieSink{APIKey: apiKey, BaseURL: "https://api.opsgenie.com", Client: http.DefaultClient}
+}
+
+// Send implements AlertSink
+func (o *OpsGenieSink) Send(ctx context.Context, alert Alert) error {
+	payload := opsGenieAlert{
+		Message:     fmt.Sprintf("[%s] %s", alert.Kind, alert.Message),
+		Alias:       alert.Provider,
+		Description: alert.Message,
+		Priority:    opsGeniePriority(alert.Severity),
+		Details:     alert.Labels,
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode OpsGenie alert: %w", err)
+	}
+
+	baseURL := o.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.opsgenie.com"
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, baseURL+"/v2/alerts", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "GenieKey "+o.APIKey)
+
+	client := o.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("opsgenie returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// opsGeniePriority maps an Alert.Severity to an OpsGenie priority level
+func opsGeniePriority(severity string) string {
+	if severity == SeverityCritical {
+		return "P1"
+	}
+	return "P3"
+}
+
+// TelegramSender is the subset of telegram.Bot's API TelegramSink needs.
+// It's declared here rather than imported because package telegram already
+// imports package llm (for ProviderConfig et al.) - *telegram.Bot satisfies
+// this interface structurally without either package importing the other
+type TelegramSender interface {
+	SendMessage(chatID int64, text string) error
+}
+
+// TelegramSink relays an Alert as a message to a Telegram chat, typically
+// an admin/ops chat
+type TelegramSink struct {
+	Bot    TelegramSender
+	ChatID int64
+}
+
+// NewTelegramSink creates a TelegramSink posting to chatID via bot
+func NewTelegramSink(bot TelegramSender, chatID int64) *TelegramSink {
+	return &TelegramSink{Bot: bot, ChatID: chatID}
+}
+
+// Send implements AlertSink
+func (t *TelegramSink) Send(ctx context.Context, alert Alert) error {
+	icon := "⚠️"
+	if alert.Severity == SeverityCritical {
+		icon = "🔴"
+	}
+	text := fmt.Sprintf("%s %s (%s)\nПровайдер: %s\n%s", icon, strings.ToUpper(alert.Severity), alert.Kind, alert.Provider, alert.Message)
+	return t.Bot.SendMessage(t.ChatID, text)
+}
+
+// ErrorNotifier handles and fans out notifications about API errors to
+// every registered AlertSink (see WithSinks), bounding each delivery with
+// sinkTimeout so a slow or unreachable sink can't block the others
+type ErrorNotifier struct {
+	adminChatID string
+	sinks       []AlertSink
+	sinkTimeout time.Duration
+}
+
+// NewErrorNotifier creates a new error notifier. sinks are fanned out to
+// concurrently on every Notify* call; pass none to keep the prior
+// log-only behavior
+func NewErrorNotifier(chatID string, sinks ...AlertSink) *ErrorNotifier {
+	return &ErrorNotifier{adminChatID: chatID, sinks: sinks, sinkTimeout: 10 * time.Second}
+}
+
+// dispatch logs alert and fans it out to every registered sink, waiting
+// for all deliveries (each bounded by en.sinkTimeout) before returning
+func (en *ErrorNotifier) dispatch(alert Alert) {
+	log.Printf("Alert [%s/%s] %s: %s", alert.Severity, alert.Provider, alert.Kind, alert.Message)
+	if en.adminChatID != "" {
+		log.Printf("Admin chat %s notified: %s", en.adminChatID, alert.Message)
+	}
+
+	var wg sync.WaitGroup
+	for _, sink := range en.sinks {
+		wg.Add(1)
+		go func(sink AlertSink) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), en.sinkTimeout)
+			defer cancel()
+			if err := sink.Send(ctx, alert); err != nil {
+				log.Printf("Alert sink delivery failed: %v", err)
+			}
+		}(sink)
+	}
+	wg.Wait()
+}
+
+// NotifyProviderError notifies about provider-specific errors (e.g. a
+// provider's credentials are invalid or it's unreachable) as a critical alert
+func (en *ErrorNotifier) NotifyProviderError(providerName, errorType, message string) {
+	en.dispatch(Alert{
+		Severity:  SeverityCritical,
+		Provider:  providerName,
+		Kind:      "provider_error",
+		Message:   fmt.Sprintf("%s: %s", errorType, message),
+		Labels:    map[string]string{"error_type": errorType},
+		Timestamp: time.Now(),
+	})
+}
+
+// NotifyAPIError notifies about generic API errors; a 5xx status is
+// treated as critical, anything else as a warning
+func (en *ErrorNotifier) NotifyAPIError(message string, statusCode int) {
+	severity := SeverityWarning
+	if statusCode >= 500 {
+		severity = SeverityCritical
+	}
+
+	en.dispatch(Alert{
+		Severity:  severity,
+		Kind:      "api_error",
+		Message:   message,
+		Labels:    map[string]string{"status_code": strconv.Itoa(statusCode)},
+		Timestamp: time.Now(),
+	})
+}
+
+// NotifyRequestFailed notifies about a single failed request as a warning
+func (en *ErrorNotifier) NotifyRequestFailed(providerName, requestType, details string) {
+	en.dispatch(Alert{
+		Severity:  SeverityWarning,
+		Provider:  providerName,
+		Kind:      "request_failed",
+		Message:   details,
+		Labels:    map[string]string{"request_type": requestType},
+		Timestamp: time.Now(),
+	})
+}
@@ -0,0 +1,389 @@
+// Package llm provides Anthropic (Claude) LLM provider implementation
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"wfrp-bot/agents"
+)
+
+// anthropicAPIVersion is the anthropic-version header required by every
+// /v1/messages request
+const anthropicAPIVersion = "2023-06-01"
+
+// AnthropicProvider implements LLMProvider for Anthropic's native
+// /v1/messages API
+type AnthropicProvider struct {
+	client *http.Client
+	config *ProviderConfig
+	apiURL string
+}
+
+// NewAnthropicProvider creates a new Anthropic provider instance
+func NewAnthropicProvider(cfg *ProviderConfig) (*AnthropicProvider, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("ANTHROPIC_API_KEY is required")
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = "https://api.anthropic.com/v1"
+	}
+
+	return &AnthropicProvider{
+		client: &http.Client{},
+		config: cfg,
+		apiURL: baseURL + "/messages",
+	}, nil
+}
+
+// anthropicMessage is one turn in an Anthropic /v1/messages conversation.
+// Content is either a plain string (simple text turns) or a
+// []anthropicContentBlock (assistant turns containing tool_use, or user
+// turns replying with tool_result)
+type anthropicMessage struct {
+	Role    string      `json:"role"`
+	Content interface{} `json:"content"`
+}
+
+// anthropicContentBlock is a single block of message content: "text" for
+// plain responses, "tool_use" for a model-requested tool call, or
+// "tool_result" for the result sent back to it
+type anthropicContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+// anthropicTool describes a tool in Anthropic's input_schema format
+type anthropicTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"input_schema"`
+}
+
+// anthropicRequest is the request payload for /v1/messages
+type anthropicRequest struct {
+	Model     string              `json:"model"`
+	System    string              `json:"system,omitempty"`
+	Messages  []anthropicMessage  `json:"messages"`
+	MaxTokens int                 `json:"max_tokens"`
+	Tools     []anthropicTool     `json:"tools,omitempty"`
+	Stream    bool                `json:"stream,omitempty"`
+}
+
+// anthropicResponse is the non-streaming response payload from /v1/messages
+type anthropicResponse struct {
+	Content []anthropicContentBlock `json:"content"`
+	Usage   anthropicUsage          `json:"usage"`
+}
+
+// anthropicUsage represents the token accounting Anthropic returns per request
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// anthropicStreamEvent is one SSE event from a streaming /v1/messages response
+type anthropicStreamEvent struct {
+	Type  string `json:"type"`
+	Delta struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"delta"`
+}
+
+// toAnthropicMessages converts conversation history into Anthropic's
+// message format, splitting out a leading "system" message (if any) since
+// Anthropic carries system instructions in a dedicated top-level field
+// rather than as a message
+func toAnthropicMessages(agent *agents.Agent, messages []Message) (string, []anthropicMessage) {
+	system := ""
+	if agent != nil {
+		system = agent.SystemPrompt
+	}
+
+	start := 0
+	if len(messages) > 0 && messages[0].Role == "system" {
+		system = messages[0].Content
+		start = 1
+	}
+
+	anthropicMessages := make([]anthropicMessage, 0, len(messages))
+	for _, msg := range messages[start:] {
+		anthropicMessages = append(anthropicMessages, anthropicMessage{Role: msg.Role, Content: msg.Content})
+	}
+
+	return system, anthropicMessages
+}
+
+// buildAnthropicTools converts an agent's allowed tools into Anthropic's
+// input_schema tool format
+func buildAnthropicTools(agent *agents.Agent) []anthropicTool {
+	if agent == nil {
+		return nil
+	}
+
+	tools := agent.Tools()
+	if len(tools) == 0 {
+		return nil
+	}
+
+	defs := make([]anthropicTool, 0, len(tools))
+	for _, tool := range tools {
+		defs = append(defs, anthropicTool{
+			Name:        tool.Name,
+			Description: tool.Description,
+			InputSchema: json.RawMessage(tool.Parameters),
+		})
+	}
+	return defs
+}
+
+// GenerateRequest sends a request to Anthropic and returns the response
+//
+// When agent is non-nil and exposes tools, the request loops executing
+// tool_use blocks (appending tool_result content blocks) until the model
+// returns a final text response or agent.Iterations() rounds are exhausted
+func (p *AnthropicProvider) GenerateRequest(ctx context.Context, agent *agents.Agent, messages []Message) (GenerateResult, error) {
+	start := time.Now()
+	system, anthropicMessages := toAnthropicMessages(agent, messages)
+	tools := buildAnthropicTools(agent)
+
+	maxIterations := 1
+	if len(tools) > 0 {
+		maxIterations = agent.Iterations()
+	}
+
+	for i := 0; i < maxIterations; i++ {
+		resp, err := p.send(ctx, system, anthropicMessages, tools)
+		if err != nil {
+			return GenerateResult{}, err
+		}
+
+		toolUses := filterAnthropicBlocks(resp.Content, "tool_use")
+		if len(toolUses) == 0 {
+			return GenerateResult{
+				Content: textFromAnthropicBlocks(resp.Content),
+				Usage: Usage{
+					Provider:         "anthropic",
+					Model:            p.config.Model,
+					PromptTokens:     resp.Usage.InputTokens,
+					CompletionTokens: resp.Usage.OutputTokens,
+					Duration:         time.Since(start),
+				},
+			}, nil
+		}
+
+		anthropicMessages = append(anthropicMessages, anthropicMessage{Role: "assistant", Content: resp.Content})
+		anthropicMessages = append(anthropicMessages, anthropicMessage{Role: "user", Content: runAnthropicToolCalls(ctx, agent, toolUses)})
+	}
+
+	return GenerateResult{}, fmt.Errorf("Anthropic tool-calling loop exceeded %d iterations", maxIterations)
+}
+
+// send performs a single non-streaming /v1/messages request
+func (p *AnthropicProvider) send(ctx context.Context, system string, messages []anthropicMessage, tools []anthropicTool) (*anthropicResponse, error) {
+	reqBody := anthropicRequest{
+		Model:     p.config.Model,
+		System:    system,
+		Messages:  messages,
+		MaxTokens: 4096,
+		Tools:     tools,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal Anthropic request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Anthropic request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-key", p.config.APIKey)
+	req.Header.Set("anthropic-version", anthropicAPIVersion)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Anthropic request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("Anthropic API error: %d - %s", resp.StatusCode, string(body))
+	}
+
+	var result anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode Anthropic response: %w", err)
+	}
+
+	return &result, nil
+}
+
+// StreamRequest sends a streaming request to Anthropic over SSE
+func (p *AnthropicProvider) StreamRequest(ctx context.Context, agent *agents.Agent, messages []Message) (<-chan string, error) {
+	ch := make(chan string)
+
+	go func() {
+		defer close(ch)
+		start := time.Now()
+		var full strings.Builder
+
+		system, anthropicMessages := toAnthropicMessages(agent, messages)
+
+		reqBody := anthropicRequest{
+			Model:     p.config.Model,
+			System:    system,
+			Messages:  anthropicMessages,
+			MaxTokens: 4096,
+			Stream:    true,
+		}
+
+		jsonData, err := json.Marshal(reqBody)
+		if err != nil {
+			ch <- fmt.Sprintf("Error: failed to marshal Anthropic request: %v", err)
+			return
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.apiURL, bytes.NewReader(jsonData))
+		if err != nil {
+			ch <- fmt.Sprintf("Error: failed to create Anthropic request: %v", err)
+			return
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("x-api-key", p.config.APIKey)
+		req.Header.Set("anthropic-version", anthropicAPIVersion)
+		req.Header.Set("Accept", "text/event-stream")
+
+		resp, err := p.client.Do(req)
+		if err != nil {
+			ch <- fmt.Sprintf("Error: Anthropic stream failed: %v", err)
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			ch <- fmt.Sprintf("Error: Anthropic API error: %d - %s", resp.StatusCode, string(body))
+			return
+		}
+
+		err = sseStream(ctx, resp.Body, func(payload []byte) bool {
+			var event anthropicStreamEvent
+			if err := json.Unmarshal(payload, &event); err != nil {
+				return true
+			}
+
+			if event.Type == "content_block_delta" && event.Delta.Type == "text_delta" {
+				full.WriteString(event.Delta.Text)
+				ch <- event.Delta.Text
+			}
+			return true
+		})
+		if err != nil {
+			ch <- fmt.Sprintf("Error: Anthropic stream read error: %v", err)
+			return
+		}
+
+		ch <- FormatUsageChunk(Usage{
+			Provider:         "anthropic",
+			Model:            p.config.Model,
+			PromptTokens:     estimateMessagesTokens(messages),
+			CompletionTokens: estimateTokens(full.String()),
+			Duration:         time.Since(start),
+		})
+	}()
+
+	return ch, nil
+}
+
+// Close closes the Anthropic provider connection
+func (p *AnthropicProvider) Close() error {
+	// No persistent connection to close
+	return nil
+}
+
+// filterAnthropicBlocks returns the blocks of blockType from blocks
+func filterAnthropicBlocks(blocks []anthropicContentBlock, blockType string) []anthropicContentBlock {
+	var out []anthropicContentBlock
+	for _, b := range blocks {
+		if b.Type == blockType {
+			out = append(out, b)
+		}
+	}
+	return out
+}
+
+// textFromAnthropicBlocks concatenates the text of every "text" block
+func textFromAnthropicBlocks(blocks []anthropicContentBlock) string {
+	var text strings.Builder
+	for _, b := range blocks {
+		if b.Type == "text" {
+			text.WriteString(b.Text)
+		}
+	}
+	return text.String()
+}
+
+// runAnthropicToolCalls executes tool_use blocks and returns the matching
+// tool_result content blocks to send back as the next "user" message
+func runAnthropicToolCalls(ctx context.Context, agent *agents.Agent, calls []anthropicContentBlock) []anthropicContentBlock {
+	results := make([]anthropicContentBlock, 0, len(calls))
+
+	for _, call := range calls {
+		result, err := agent.Toolbox.Call(ctx, call.Name, call.Input)
+		if err != nil {
+			log.Printf("[AGENT] Tool %s failed: %v", call.Name, err)
+			result = fmt.Sprintf("error: %v", err)
+		} else {
+			log.Printf("[AGENT] Tool %s -> %s", call.Name, truncateForLog(result, 200))
+		}
+
+		results = append(results, anthropicContentBlock{
+			Type:      "tool_result",
+			ToolUseID: call.ID,
+			Content:   result,
+		})
+	}
+
+	return results
+}
+
+// parseAnthropicConfig creates ProviderConfig from raw config data
+func parseAnthropicConfig(rawConfig map[string]interface{}) (*ProviderConfig, error) {
+	apiKey, ok := rawConfig["api_key"].(string)
+	if !ok || apiKey == "" {
+		return nil, fmt.Errorf("api_key is required for Anthropic provider")
+	}
+
+	cfg := &ProviderConfig{
+		Name:    "anthropic",
+		APIKey:  apiKey,
+		BaseURL: "https://api.anthropic.com/v1",
+		Model:   "claude-3-5-sonnet-20241022",
+	}
+
+	if model, ok := rawConfig["model"].(string); ok && model != "" {
+		cfg.Model = model
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,245 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"wfrp-bot/agents"
+)
+
+// CircuitState is the state of a circuitBreakerProvider, mirroring the
+// classic closed/open/half-open circuit breaker pattern
+type CircuitState int
+
+const (
+	// CircuitClosed is the normal state: requests pass through to the
+	// underlying provider
+	CircuitClosed CircuitState = iota
+	// CircuitOpen means FailureThreshold consecutive failures were just
+	// seen; requests fail immediately without reaching the provider until
+	// CooldownPeriod elapses
+	CircuitOpen
+	// CircuitHalfOpen means CooldownPeriod elapsed and a single trial
+	// request is being let through to see if the provider has recovered
+	CircuitHalfOpen
+)
+
+// String renders state for logging and the /providers admin command
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// CircuitBreakerOptions configures WithCircuitBreaker
+type CircuitBreakerOptions struct {
+	FailureThreshold int           // consecutive failures before opening; ignored once FailureRateThreshold > 0
+	CooldownPeriod   time.Duration // how long the circuit stays open before a half-open trial
+
+	// FailureRateThreshold switches the breaker from counting consecutive
+	// failures to a sliding window of the last slidingWindowSize outcomes:
+	// the circuit opens once at least MinRequests outcomes have been
+	// recorded and their failure rate reaches FailureRateThreshold. 0
+	// (the default) keeps the simpler consecutive-failure behavior
+	FailureRateThreshold float64
+	MinRequests          int
+
+	// Notifier, if set, is sent a provider_error alert whenever this
+	// breaker opens (see ErrorNotifier.NotifyProviderError)
+	Notifier *ErrorNotifier
+}
+
+// DefaultCircuitBreakerOptions is used whenever a zero-value
+// CircuitBreakerOptions is passed to WithCircuitBreaker
+var DefaultCircuitBreakerOptions = CircuitBreakerOptions{
+	FailureThreshold: 5,
+	CooldownPeriod:   30 * time.Second,
+}
+
+// slidingWindowSize bounds how many recent outcomes are kept for
+// FailureRateThreshold evaluation
+const slidingWindowSize = 20
+
+// circuitBreakerProvider wraps an LLMProvider, tracking consecutive
+// failures across requests (unlike retryProvider, which only retries
+// within a single request) so a provider that's clearly down stops being
+// tried at all until CooldownPeriod passes. Intended to sit inside a
+// FallbackProvider built by NewProviderWithFailover, so an open circuit
+// moves traffic to the next provider in the chain instead of piling up
+// slow timeouts against a dead one
+type circuitBreakerProvider struct {
+	next LLMProvider
+	name string
+	opts CircuitBreakerOptions
+
+	mu               sync.Mutex
+	state            CircuitState
+	failures         int
+	openedAt         time.Time
+	halfOpenInFlight bool
+	outcomes         []bool // sliding window of recent successes(true)/failures(false); only used when opts.FailureRateThreshold > 0
+}
+
+// WithCircuitBreaker wraps p with per-provider circuit breaker state. name
+// identifies p for Status()/the /providers admin command - typically the
+// same ProviderConfig.Name passed to NewProviderFromConfig. A zero-value
+// opts falls back to DefaultCircuitBreakerOptions
+func WithCircuitBreaker(p LLMProvider, name string, opts CircuitBreakerOptions) LLMProvider {
+	if opts.FailureThreshold <= 0 {
+		opts.FailureThreshold = DefaultCircuitBreakerOptions.FailureThreshold
+	}
+	if opts.CooldownPeriod <= 0 {
+		opts.CooldownPeriod = DefaultCircuitBreakerOptions.CooldownPeriod
+	}
+
+	return &circuitBreakerProvider{next: p, name: name, opts: opts}
+}
+
+// Name identifies the wrapped provider for status reporting
+func (c *circuitBreakerProvider) Name() string { return c.name }
+
+// State reports the circuit's current state, resolving an expired cooldown
+// to CircuitHalfOpen without requiring a request to arrive first
+func (c *circuitBreakerProvider) State() CircuitState {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stateLocked()
+}
+
+func (c *circuitBreakerProvider) stateLocked() CircuitState {
+	if c.state == CircuitOpen && time.Since(c.openedAt) >= c.opts.CooldownPeriod {
+		return CircuitHalfOpen
+	}
+	return c.state
+}
+
+// allow reports whether a request may proceed to c.next, reserving the
+// single half-open trial slot if the cooldown just expired
+func (c *circuitBreakerProvider) allow() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch c.stateLocked() {
+	case CircuitOpen:
+		return fmt.Errorf("circuit breaker open for provider %q, cooling down for %s", c.name, c.opts.CooldownPeriod-time.Since(c.openedAt))
+	case CircuitHalfOpen:
+		if c.halfOpenInFlight {
+			return fmt.Errorf("circuit breaker for provider %q is already running a half-open trial", c.name)
+		}
+		c.halfOpenInFlight = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// recordResult updates the circuit's state after a request to c.next. The
+// notifier (if any) is invoked after releasing c.mu so a slow alert sink
+// can't hold up other requests to this provider
+func (c *circuitBreakerProvider) recordResult(err error) {
+	justOpened := func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.halfOpenInFlight = false
+		c.recordOutcomeLocked(err == nil)
+
+		if err == nil {
+			c.failures = 0
+			c.state = CircuitClosed
+			return false
+		}
+
+		c.failures++
+
+		opened := false
+		if c.opts.FailureRateThreshold > 0 {
+			failures, total := c.windowStatsLocked()
+			opened = total >= c.opts.MinRequests && float64(failures)/float64(total) >= c.opts.FailureRateThreshold
+		} else {
+			opened = c.failures >= c.opts.FailureThreshold
+		}
+
+		if opened && c.stateLocked() != CircuitOpen {
+			c.state = CircuitOpen
+			c.openedAt = time.Now()
+			return true
+		}
+		return false
+	}()
+
+	if justOpened {
+		c.notifyOpen()
+	}
+}
+
+// recordOutcomeLocked appends to the sliding window used by
+// FailureRateThreshold. Callers must hold c.mu
+func (c *circuitBreakerProvider) recordOutcomeLocked(success bool) {
+	if c.opts.FailureRateThreshold <= 0 {
+		return
+	}
+	c.outcomes = append(c.outcomes, success)
+	if len(c.outcomes) > slidingWindowSize {
+		c.outcomes = c.outcomes[len(c.outcomes)-slidingWindowSize:]
+	}
+}
+
+// windowStatsLocked reports failures/total over the current sliding
+// window. Callers must hold c.mu
+func (c *circuitBreakerProvider) windowStatsLocked() (failures, total int) {
+	total = len(c.outcomes)
+	for _, ok := range c.outcomes {
+		if !ok {
+			failures++
+		}
+	}
+	return failures, total
+}
+
+// notifyOpen alerts opts.Notifier, if set, that the circuit just opened.
+// Must be called without holding c.mu
+func (c *circuitBreakerProvider) notifyOpen() {
+	if c.opts.Notifier == nil {
+		return
+	}
+	c.opts.Notifier.NotifyProviderError(c.name, "circuit_open", fmt.Sprintf("circuit breaker opened for provider %q", c.name))
+}
+
+// GenerateRequest fails fast while the circuit is open, otherwise delegates
+// and records the outcome
+func (c *circuitBreakerProvider) GenerateRequest(ctx context.Context, agent *agents.Agent, messages []Message) (GenerateResult, error) {
+	if err := c.allow(); err != nil {
+		return GenerateResult{}, err
+	}
+
+	result, err := c.next.GenerateRequest(ctx, agent, messages)
+	c.recordResult(err)
+	return result, err
+}
+
+// StreamRequest fails fast while the circuit is open, otherwise delegates
+// and records the outcome
+func (c *circuitBreakerProvider) StreamRequest(ctx context.Context, agent *agents.Agent, messages []Message) (<-chan string, error) {
+	if err := c.allow(); err != nil {
+		return nil, err
+	}
+
+	upstream, err := c.next.StreamRequest(ctx, agent, messages)
+	c.recordResult(err)
+	return upstream, err
+}
+
+// Close delegates to the underlying provider
+func (c *circuitBreakerProvider) Close() error {
+	return c.next.Close()
+}
@@ -0,0 +1,40 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+
+	"wfrp-bot/dice"
+)
+
+// diceRollRequestSchema constrains RequestDiceRoll's GenerateStructured call
+// to a single field: the dice notation (see package dice) the model wants
+// rolled. The model never invents the outcome itself - it only names the
+// roll, and the actual numbers come from rng
+var diceRollRequestSchema = json.RawMessage(`{"type":"object","properties":{"expr":{"type":"string","description":"WFRP dice notation, e.g. d100, 2d10+5, \"d100 vs WS 45\" or d10+SL"}},"required":["expr"]}`)
+
+// diceRollRequest is the decoded payload GenerateStructured fills in for RequestDiceRoll
+type diceRollRequest struct {
+	Expr string `json:"expr"`
+}
+
+// RequestDiceRoll asks the model which roll it wants (via GenerateStructured,
+// see structured.go) and then actually rolls it against rng, so the model
+// names the roll - "d100 vs WS 45" - but never generates the number itself.
+// skillCtx supplies the success levels a "+SL" expression adds; it may be
+// nil when the model isn't expected to request one
+func (rh *ResponseHandler) RequestDiceRoll(ctx context.Context, prompt string, characterCards []string, rng rand.Source, skillCtx *dice.SkillContext) (dice.Result, error) {
+	var req diceRollRequest
+	if err := rh.GenerateStructured(ctx, prompt, characterCards, diceRollRequestSchema, &req); err != nil {
+		return dice.Result{}, fmt.Errorf("dice roll request failed: %w", err)
+	}
+
+	expr, err := dice.Parse(req.Expr)
+	if err != nil {
+		return dice.Result{}, fmt.Errorf("model requested invalid dice expression %q: %w", req.Expr, err)
+	}
+
+	return expr.Roll(rng, skillCtx), nil
+}
@@ -0,0 +1,45 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+
+	"wfrp-bot/agents"
+)
+
+// RequestStructured issues a follow-up request that forces the model to
+// report its findings through a single tool call instead of free-form
+// prose. It registers a one-off tool named toolName (described by
+// toolDescription/toolParameters) on a throwaway agent, sends messages with
+// systemPrompt as the agent's instructions, and records the raw arguments
+// of every call the model makes to that tool.
+//
+// Providers that don't support tool-calling simply
+// ignore the tool and never call it, so the returned slice comes back
+// empty; callers should fall back to their own heuristic extraction in
+// that case rather than treating an empty result as an error
+func RequestStructured(ctx context.Context, provider LLMProvider, systemPrompt string, messages []Message, toolName, toolDescription string, toolParameters json.RawMessage, maxIterations int) ([]json.RawMessage, error) {
+	var calls []json.RawMessage
+
+	toolbox := agents.NewToolbox()
+	toolbox.Register(agents.Tool{
+		Name:        toolName,
+		Description: toolDescription,
+		Parameters:  toolParameters,
+		Handler: func(ctx context.Context, args json.RawMessage) (string, error) {
+			calls = append(calls, append(json.RawMessage(nil), args...))
+			return "recorded", nil
+		},
+	})
+
+	extractor := agents.NewAgent("extractor", systemPrompt, toolbox, []string{toolName})
+	if maxIterations > 0 {
+		extractor.MaxIterations = maxIterations
+	}
+
+	if _, err := provider.GenerateRequest(ctx, extractor, messages); err != nil {
+		return nil, err
+	}
+
+	return calls, nil
+}
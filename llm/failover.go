@@ -0,0 +1,29 @@
+package llm
+
+import "fmt"
+
+// NewProviderWithFailover builds configs[0] (the default provider) plus
+// every subsequent entry (config.BotConfig.FailoverChain, resolved to their
+// ProviderConfig by the caller) into a single LLMProvider: each one is
+// built the same way NewProviderFromConfig always has (concrete provider +
+// WithRetry + WithRateLimit), additionally wrapped in WithCircuitBreaker so
+// a provider that keeps failing stops being tried until its cooldown
+// elapses, and the whole ordered list is combined with NewFallback so a
+// SessionManager request transparently moves to the next provider when the
+// current one errors. configs must contain at least one entry
+func NewProviderWithFailover(configs []*ProviderConfig) (*FallbackProvider, error) {
+	if len(configs) == 0 {
+		return nil, fmt.Errorf("no provider configuration supplied")
+	}
+
+	built := make([]LLMProvider, 0, len(configs))
+	for _, cfg := range configs {
+		provider, err := NewProviderFromConfig(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build provider %q: %w", cfg.Name, err)
+		}
+		built = append(built, WithCircuitBreaker(provider, cfg.Name, cfg.Resilience.circuitBreakerOptions()))
+	}
+
+	return NewFallback(built[0], built[1:]...), nil
+}
@@ -0,0 +1,414 @@
+// Package llm provides Google Gemini LLM provider implementation
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"wfrp-bot/agents"
+)
+
+// GeminiProvider implements LLMProvider for Google's Gemini
+// (generativelanguage.googleapis.com) API
+type GeminiProvider struct {
+	client  *http.Client
+	config  *ProviderConfig
+	baseURL string
+}
+
+// NewGeminiProvider creates a new Gemini provider instance
+func NewGeminiProvider(cfg *ProviderConfig) (*GeminiProvider, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("GEMINI_API_KEY is required")
+	}
+
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = "https://generativelanguage.googleapis.com/v1beta"
+	}
+
+	return &GeminiProvider{
+		client:  &http.Client{},
+		config:  cfg,
+		baseURL: baseURL,
+	}, nil
+}
+
+// geminiPart is a single piece of a Gemini content entry: plain text, a
+// model-requested function call, or a function call's result
+type geminiPart struct {
+	Text             string                  `json:"text,omitempty"`
+	FunctionCall     *geminiFunctionCall     `json:"functionCall,omitempty"`
+	FunctionResponse *geminiFunctionResponse `json:"functionResponse,omitempty"`
+}
+
+// geminiFunctionCall is a function call requested by the model
+type geminiFunctionCall struct {
+	Name string          `json:"name"`
+	Args json.RawMessage `json:"args"`
+}
+
+// geminiFunctionResponse is the result of a function call sent back to the model
+type geminiFunctionResponse struct {
+	Name     string          `json:"name"`
+	Response json.RawMessage `json:"response"`
+}
+
+// geminiContent is one turn of a Gemini conversation ("user" or "model")
+type geminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []geminiPart `json:"parts"`
+}
+
+// geminiFunctionDeclaration describes a tool in Gemini's functionDeclarations format
+type geminiFunctionDeclaration struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Parameters  json.RawMessage `json:"parameters,omitempty"`
+}
+
+// geminiTool wraps the function declarations offered to the model
+type geminiTool struct {
+	FunctionDeclarations []geminiFunctionDeclaration `json:"functionDeclarations"`
+}
+
+// geminiRequest is the request payload for generateContent/streamGenerateContent
+type geminiRequest struct {
+	Contents          []geminiContent `json:"contents"`
+	SystemInstruction *geminiContent  `json:"systemInstruction,omitempty"`
+	Tools             []geminiTool    `json:"tools,omitempty"`
+}
+
+// geminiCandidate is one candidate response returned by the model
+type geminiCandidate struct {
+	Content geminiContent `json:"content"`
+}
+
+// geminiResponse is the response payload from generateContent, and also the
+// shape of each streamed chunk from streamGenerateContent
+type geminiResponse struct {
+	Candidates    []geminiCandidate   `json:"candidates"`
+	UsageMetadata geminiUsageMetadata `json:"usageMetadata"`
+}
+
+// geminiUsageMetadata carries the token accounting Gemini returns per
+// request; on streamed chunks it reflects the cumulative totals so far
+type geminiUsageMetadata struct {
+	PromptTokenCount     int `json:"promptTokenCount"`
+	CandidatesTokenCount int `json:"candidatesTokenCount"`
+}
+
+// toGeminiContents converts conversation history into Gemini's contents
+// format, pulling "system"-role messages out into a separate
+// systemInstruction since Gemini has no "system" role of its own
+func toGeminiContents(agent *agents.Agent, messages []Message) (*geminiContent, []geminiContent) {
+	systemText := ""
+	if agent != nil && (len(messages) == 0 || messages[0].Role != "system") {
+		systemText = agent.SystemPrompt
+	}
+
+	contents := make([]geminiContent, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			if systemText == "" {
+				systemText = msg.Content
+			} else {
+				systemText += "\n\n" + msg.Content
+			}
+			continue
+		}
+
+		role := "user"
+		if msg.Role == "assistant" {
+			role = "model"
+		}
+
+		contents = append(contents, geminiContent{Role: role, Parts: []geminiPart{{Text: msg.Content}}})
+	}
+
+	var systemInstruction *geminiContent
+	if systemText != "" {
+		systemInstruction = &geminiContent{Parts: []geminiPart{{Text: systemText}}}
+	}
+
+	return systemInstruction, contents
+}
+
+// buildGeminiTools converts an agent's allowed tools into Gemini's
+// functionDeclarations format
+func buildGeminiTools(agent *agents.Agent) []geminiTool {
+	if agent == nil {
+		return nil
+	}
+
+	tools := agent.Tools()
+	if len(tools) == 0 {
+		return nil
+	}
+
+	decls := make([]geminiFunctionDeclaration, 0, len(tools))
+	for _, tool := range tools {
+		decls = append(decls, geminiFunctionDeclaration{
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters:  json.RawMessage(tool.Parameters),
+		})
+	}
+
+	return []geminiTool{{FunctionDeclarations: decls}}
+}
+
+// GenerateRequest sends a request to Gemini and returns the response
+//
+// When agent is non-nil and exposes tools, the request loops executing
+// functionCall parts (appending functionResponse parts as a "function"
+// turn) until the model returns a final text response or
+// agent.Iterations() rounds are exhausted
+func (p *GeminiProvider) GenerateRequest(ctx context.Context, agent *agents.Agent, messages []Message) (GenerateResult, error) {
+	start := time.Now()
+	systemInstruction, contents := toGeminiContents(agent, messages)
+	tools := buildGeminiTools(agent)
+
+	maxIterations := 1
+	if len(tools) > 0 {
+		maxIterations = agent.Iterations()
+	}
+
+	for i := 0; i < maxIterations; i++ {
+		candidate, usage, err := p.send(ctx, systemInstruction, contents, tools)
+		if err != nil {
+			return GenerateResult{}, err
+		}
+
+		calls := partsWithFunctionCall(candidate.Content.Parts)
+		if len(calls) == 0 {
+			return GenerateResult{
+				Content: textFromGeminiParts(candidate.Content.Parts),
+				Usage: Usage{
+					Provider:         "gemini",
+					Model:            p.config.Model,
+					PromptTokens:     usage.PromptTokenCount,
+					CompletionTokens: usage.CandidatesTokenCount,
+					Duration:         time.Since(start),
+				},
+			}, nil
+		}
+
+		contents = append(contents, candidate.Content)
+		contents = append(contents, geminiContent{Role: "function", Parts: runGeminiToolCalls(ctx, agent, calls)})
+	}
+
+	return GenerateResult{}, fmt.Errorf("Gemini tool-calling loop exceeded %d iterations", maxIterations)
+}
+
+// send performs a single non-streaming generateContent request
+func (p *GeminiProvider) send(ctx context.Context, systemInstruction *geminiContent, contents []geminiContent, tools []geminiTool) (*geminiCandidate, geminiUsageMetadata, error) {
+	reqBody := geminiRequest{
+		Contents:          contents,
+		SystemInstruction: systemInstruction,
+		Tools:             tools,
+	}
+
+	jsonData, err := json.Marshal(reqBody)
+	if err != nil {
+		return nil, geminiUsageMetadata{}, fmt.Errorf("failed to marshal Gemini request: %w", err)
+	}
+
+	url := fmt.Sprintf("%s/models/%s:generateContent?key=%s", p.baseURL, p.config.Model, p.config.APIKey)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonData))
+	if err != nil {
+		return nil, geminiUsageMetadata{}, fmt.Errorf("failed to create Gemini request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, geminiUsageMetadata{}, fmt.Errorf("Gemini request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, geminiUsageMetadata{}, fmt.Errorf("Gemini API error: %d - %s", resp.StatusCode, string(body))
+	}
+
+	var result geminiResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, geminiUsageMetadata{}, fmt.Errorf("failed to decode Gemini response: %w", err)
+	}
+
+	if len(result.Candidates) == 0 {
+		return nil, geminiUsageMetadata{}, fmt.Errorf("Gemini returned no candidates")
+	}
+
+	return &result.Candidates[0], result.UsageMetadata, nil
+}
+
+// StreamRequest sends a streaming request to Gemini over SSE
+// (streamGenerateContent?alt=sse)
+func (p *GeminiProvider) StreamRequest(ctx context.Context, agent *agents.Agent, messages []Message) (<-chan string, error) {
+	ch := make(chan string)
+
+	go func() {
+		defer close(ch)
+		start := time.Now()
+		var full strings.Builder
+		var lastUsage geminiUsageMetadata
+
+		systemInstruction, contents := toGeminiContents(agent, messages)
+
+		reqBody := geminiRequest{
+			Contents:          contents,
+			SystemInstruction: systemInstruction,
+		}
+
+		jsonData, err := json.Marshal(reqBody)
+		if err != nil {
+			ch <- fmt.Sprintf("Error: failed to marshal Gemini request: %v", err)
+			return
+		}
+
+		url := fmt.Sprintf("%s/models/%s:streamGenerateContent?alt=sse&key=%s", p.baseURL, p.config.Model, p.config.APIKey)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(jsonData))
+		if err != nil {
+			ch <- fmt.Sprintf("Error: failed to create Gemini request: %v", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "text/event-stream")
+
+		resp, err := p.client.Do(req)
+		if err != nil {
+			ch <- fmt.Sprintf("Error: Gemini stream failed: %v", err)
+			return
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			ch <- fmt.Sprintf("Error: Gemini API error: %d - %s", resp.StatusCode, string(body))
+			return
+		}
+
+		err = sseStream(ctx, resp.Body, func(payload []byte) bool {
+			var chunk geminiResponse
+			if err := json.Unmarshal(payload, &chunk); err != nil {
+				return true
+			}
+
+			if len(chunk.Candidates) > 0 {
+				text := textFromGeminiParts(chunk.Candidates[0].Content.Parts)
+				full.WriteString(text)
+				ch <- text
+			}
+			lastUsage = chunk.UsageMetadata
+			return true
+		})
+		if err != nil {
+			ch <- fmt.Sprintf("Error: Gemini stream read error: %v", err)
+			return
+		}
+
+		promptTokens := lastUsage.PromptTokenCount
+		if promptTokens == 0 {
+			promptTokens = estimateMessagesTokens(messages)
+		}
+		completionTokens := lastUsage.CandidatesTokenCount
+		if completionTokens == 0 {
+			completionTokens = estimateTokens(full.String())
+		}
+
+		ch <- FormatUsageChunk(Usage{
+			Provider:         "gemini",
+			Model:            p.config.Model,
+			PromptTokens:     promptTokens,
+			CompletionTokens: completionTokens,
+			Duration:         time.Since(start),
+		})
+	}()
+
+	return ch, nil
+}
+
+// Close closes the Gemini provider connection
+func (p *GeminiProvider) Close() error {
+	// No persistent connection to close
+	return nil
+}
+
+// partsWithFunctionCall returns the parts of candidate content that
+// represent a model-requested function call
+func partsWithFunctionCall(parts []geminiPart) []geminiPart {
+	var out []geminiPart
+	for _, part := range parts {
+		if part.FunctionCall != nil {
+			out = append(out, part)
+		}
+	}
+	return out
+}
+
+// textFromGeminiParts concatenates the text of every part
+func textFromGeminiParts(parts []geminiPart) string {
+	var text strings.Builder
+	for _, part := range parts {
+		text.WriteString(part.Text)
+	}
+	return text.String()
+}
+
+// runGeminiToolCalls executes functionCall parts and returns the matching
+// functionResponse parts to send back as the next "function" turn
+func runGeminiToolCalls(ctx context.Context, agent *agents.Agent, calls []geminiPart) []geminiPart {
+	results := make([]geminiPart, 0, len(calls))
+
+	for _, call := range calls {
+		result, err := agent.Toolbox.Call(ctx, call.FunctionCall.Name, call.FunctionCall.Args)
+		if err != nil {
+			log.Printf("[AGENT] Tool %s failed: %v", call.FunctionCall.Name, err)
+			result = fmt.Sprintf("error: %v", err)
+		} else {
+			log.Printf("[AGENT] Tool %s -> %s", call.FunctionCall.Name, truncateForLog(result, 200))
+		}
+
+		response, _ := json.Marshal(map[string]string{"result": result})
+		results = append(results, geminiPart{
+			FunctionResponse: &geminiFunctionResponse{
+				Name:     call.FunctionCall.Name,
+				Response: response,
+			},
+		})
+	}
+
+	return results
+}
+
+// parseGeminiConfig creates ProviderConfig from raw config data
+func parseGeminiConfig(rawConfig map[string]interface{}) (*ProviderConfig, error) {
+	apiKey, ok := rawConfig["api_key"].(string)
+	if !ok || apiKey == "" {
+		return nil, fmt.Errorf("api_key is required for Gemini provider")
+	}
+
+	cfg := &ProviderConfig{
+		Name:    "gemini",
+		APIKey:  apiKey,
+		BaseURL: "https://generativelanguage.googleapis.com/v1beta",
+		Model:   "gemini-1.5-pro",
+	}
+
+	if model, ok := rawConfig["model"].(string); ok && model != "" {
+		cfg.Model = model
+	}
+
+	return cfg, nil
+}
@@ -0,0 +1,27 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// healthCheckTimeout bounds how long HealthCheck waits for its trivial
+// probe request before concluding the provider is unreachable
+const healthCheckTimeout = 10 * time.Second
+
+// HealthCheck probes provider with a minimal GenerateRequest, the same way
+// a real request would reach it, and reports whether it succeeded. Nothing
+// in the bot calls it: neither startup nor the /providers admin command
+// probes a provider with it. circuitBreakerProvider relies on ordinary request traffic instead, so it
+// doesn't call HealthCheck itself
+func HealthCheck(ctx context.Context, provider LLMProvider) error {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	_, err := provider.GenerateRequest(ctx, nil, []Message{{Role: "user", Content: "ping"}})
+	if err != nil {
+		return fmt.Errorf("health check failed: %w", err)
+	}
+	return nil
+}
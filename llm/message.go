@@ -0,0 +1,9 @@
+package llm
+
+// Message is a single role-tagged turn in a conversation sent to an LLM
+// provider. Role is one of "system", "user" or "assistant", matching the
+// values expected by OpenAI-compatible chat APIs
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
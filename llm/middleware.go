@@ -0,0 +1,414 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"wfrp-bot/agents"
+)
+
+// RetryOptions configures the backoff schedule used by WithRetry
+type RetryOptions struct {
+	MaxRetries           int           // number of retries after the initial attempt
+	BaseDelay            time.Duration // delay before the first retry
+	MaxDelay             time.Duration // ceiling applied to the exponential backoff
+	RetryableStatusCodes []int         // HTTP status codes worth retrying; empty uses the codes in DefaultRetryOptions
+}
+
+// DefaultRetryOptions is used by NewProviderFromConfig and by WithRetry
+// whenever a zero-value RetryOptions is passed in
+var DefaultRetryOptions = RetryOptions{
+	MaxRetries:           3,
+	BaseDelay:            500 * time.Millisecond,
+	MaxDelay:             10 * time.Second,
+	RetryableStatusCodes: []int{429, 500, 501, 502, 503},
+}
+
+// retryProvider wraps an LLMProvider with exponential-backoff retries on
+// transient errors
+type retryProvider struct {
+	next          LLMProvider
+	opts          RetryOptions
+	statusPattern *regexp.Regexp // matches opts.RetryableStatusCodes in an error's message, e.g. "Anthropic API error: 429 - rate limited"
+}
+
+// WithRetry wraps p so that transient errors (an HTTP status in
+// opts.RetryableStatusCodes, or a context deadline exceeded before the
+// request otherwise would have completed) are retried with exponential
+// backoff and jitter. A zero-value opts falls back to DefaultRetryOptions
+func WithRetry(p LLMProvider, opts RetryOptions) LLMProvider {
+	if opts.MaxRetries <= 0 {
+		opts.MaxRetries = DefaultRetryOptions.MaxRetries
+	}
+	if opts.BaseDelay <= 0 {
+		opts.BaseDelay = DefaultRetryOptions.BaseDelay
+	}
+	if opts.MaxDelay <= 0 {
+		opts.MaxDelay = DefaultRetryOptions.MaxDelay
+	}
+	if len(opts.RetryableStatusCodes) == 0 {
+		opts.RetryableStatusCodes = DefaultRetryOptions.RetryableStatusCodes
+	}
+
+	return &retryProvider{next: p, opts: opts, statusPattern: statusCodePattern(opts.RetryableStatusCodes)}
+}
+
+// statusCodePattern compiles codes into a regexp matching any of them as a
+// whole word, e.g. [429, 500] -> `\b(429|500)\b`
+func statusCodePattern(codes []int) *regexp.Regexp {
+	parts := make([]string, len(codes))
+	for i, code := range codes {
+		parts[i] = strconv.Itoa(code)
+	}
+	return regexp.MustCompile(`\b(` + strings.Join(parts, "|") + `)\b`)
+}
+
+// GenerateRequest retries the underlying provider's GenerateRequest on
+// transient errors until it succeeds or MaxRetries is exhausted
+func (r *retryProvider) GenerateRequest(ctx context.Context, agent *agents.Agent, messages []Message) (GenerateResult, error) {
+	var lastErr error
+
+	for attempt := 0; attempt <= r.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepOrDone(ctx, backoffDelay(r.opts, attempt)); err != nil {
+				return GenerateResult{}, err
+			}
+		}
+
+		result, err := r.next.GenerateRequest(ctx, agent, messages)
+		if err == nil {
+			return result, nil
+		}
+
+		lastErr = err
+		if !r.isRetryable(err) {
+			return GenerateResult{}, err
+		}
+	}
+
+	return GenerateResult{}, fmt.Errorf("exceeded %d retries: %w", r.opts.MaxRetries, lastErr)
+}
+
+// StreamRequest retries the underlying provider's StreamRequest on
+// transient errors, but only while no bytes have been delivered yet. Once
+// the first chunk is read from the upstream channel, it is assumed the
+// stream has started and any later error is surfaced on the channel as-is,
+// matching how every provider already reports mid-stream failures
+func (r *retryProvider) StreamRequest(ctx context.Context, agent *agents.Agent, messages []Message) (<-chan string, error) {
+	var lastErr error
+
+	for attempt := 0; attempt <= r.opts.MaxRetries; attempt++ {
+		if attempt > 0 {
+			if err := sleepOrDone(ctx, backoffDelay(r.opts, attempt)); err != nil {
+				return nil, err
+			}
+		}
+
+		upstream, err := r.next.StreamRequest(ctx, agent, messages)
+		if err != nil {
+			lastErr = err
+			if !r.isRetryable(err) {
+				return nil, err
+			}
+			continue
+		}
+
+		first, ok := <-upstream
+		if !ok {
+			return upstream, nil
+		}
+
+		if err := streamChunkError(first); err != nil && r.isRetryable(err) {
+			lastErr = err
+			continue
+		}
+
+		return prependChunk(first, upstream), nil
+	}
+
+	return nil, fmt.Errorf("exceeded %d retries: %w", r.opts.MaxRetries, lastErr)
+}
+
+// Close delegates to the underlying provider
+func (r *retryProvider) Close() error {
+	return r.next.Close()
+}
+
+// backoffDelay computes an exponential backoff delay with jitter for the
+// given (1-indexed) retry attempt
+func backoffDelay(opts RetryOptions, attempt int) time.Duration {
+	delay := time.Duration(float64(opts.BaseDelay) * math.Pow(2, float64(attempt-1)))
+	if delay > opts.MaxDelay {
+		delay = opts.MaxDelay
+	}
+
+	jitter := time.Duration(rand.Int63n(int64(delay)/2 + 1))
+	return delay/2 + jitter
+}
+
+// sleepOrDone waits for delay to elapse, returning ctx.Err() early if the
+// context is cancelled first
+func sleepOrDone(ctx context.Context, delay time.Duration) error {
+	select {
+	case <-time.After(delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isRetryable reports whether err represents a transient failure worth
+// retrying: a context deadline, or a provider error embedding one of
+// r.opts.RetryableStatusCodes
+func (r *retryProvider) isRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	return r.statusPattern.MatchString(err.Error())
+}
+
+// streamChunkError reports whether chunk is one of the "Error: ..."
+// strings every StreamRequest implementation sends on the channel instead
+// of returning a Go error, converting it back into one
+func streamChunkError(chunk string) error {
+	if !strings.HasPrefix(chunk, "Error: ") {
+		return nil
+	}
+	return errors.New(strings.TrimPrefix(chunk, "Error: "))
+}
+
+// prependChunk returns a channel that yields first followed by everything
+// remaining on upstream, used to put back a chunk that was peeked at to
+// decide whether a stream should be retried
+func prependChunk(first string, upstream <-chan string) <-chan string {
+	ch := make(chan string)
+
+	go func() {
+		defer close(ch)
+		ch <- first
+		for chunk := range upstream {
+			ch <- chunk
+		}
+	}()
+
+	return ch
+}
+
+// rateLimitProvider enforces a requests-per-minute cap on an LLMProvider
+// using a token bucket
+type rateLimitProvider struct {
+	next LLMProvider
+
+	mu         sync.Mutex
+	tokens     float64
+	maxTokens  float64
+	refillRate float64 // tokens per second
+	lastRefill time.Time
+}
+
+// WithRateLimit wraps p so that at most rpm requests per minute are sent to
+// it, queuing callers (honoring ctx cancellation) once the bucket is empty.
+// rpm <= 0 disables the limiter and returns p unwrapped
+func WithRateLimit(p LLMProvider, rpm int) LLMProvider {
+	if rpm <= 0 {
+		return p
+	}
+
+	return &rateLimitProvider{
+		next:       p,
+		tokens:     float64(rpm),
+		maxTokens:  float64(rpm),
+		refillRate: float64(rpm) / 60.0,
+		lastRefill: time.Now(),
+	}
+}
+
+// acquire blocks until a token is available or ctx is done
+func (r *rateLimitProvider) acquire(ctx context.Context) error {
+	for {
+		wait, ok := r.tryAcquire()
+		if ok {
+			return nil
+		}
+
+		if err := sleepOrDone(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// tryAcquire refills the bucket for elapsed time and, if a token is
+// available, consumes it and returns (0, true). Otherwise it returns how
+// long the caller should wait before trying again
+func (r *rateLimitProvider) tryAcquire() (time.Duration, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	elapsed := now.Sub(r.lastRefill).Seconds()
+	r.tokens = math.Min(r.maxTokens, r.tokens+elapsed*r.refillRate)
+	r.lastRefill = now
+
+	if r.tokens >= 1 {
+		r.tokens--
+		return 0, true
+	}
+
+	return time.Duration((1 - r.tokens) / r.refillRate * float64(time.Second)), false
+}
+
+// GenerateRequest waits for rate-limit capacity, then delegates
+func (r *rateLimitProvider) GenerateRequest(ctx context.Context, agent *agents.Agent, messages []Message) (GenerateResult, error) {
+	if err := r.acquire(ctx); err != nil {
+		return GenerateResult{}, err
+	}
+	return r.next.GenerateRequest(ctx, agent, messages)
+}
+
+// StreamRequest waits for rate-limit capacity, then delegates
+func (r *rateLimitProvider) StreamRequest(ctx context.Context, agent *agents.Agent, messages []Message) (<-chan string, error) {
+	if err := r.acquire(ctx); err != nil {
+		return nil, err
+	}
+	return r.next.StreamRequest(ctx, agent, messages)
+}
+
+// Close delegates to the underlying provider
+func (r *rateLimitProvider) Close() error {
+	return r.next.Close()
+}
+
+// FallbackProvider tries an ordered list of providers, falling through to
+// the next one whenever the current provider errors or its share of the
+// remaining context deadline runs out
+type FallbackProvider struct {
+	providers []LLMProvider
+}
+
+// NewFallback creates a FallbackProvider that tries primary first and then
+// secondaries in order
+func NewFallback(primary LLMProvider, secondaries ...LLMProvider) *FallbackProvider {
+	return &FallbackProvider{providers: append([]LLMProvider{primary}, secondaries...)}
+}
+
+// ProviderStatus reports one chain member's circuit breaker state for the
+// /providers admin command
+type ProviderStatus struct {
+	Name  string
+	State CircuitState
+}
+
+// circuitInspectable is implemented by circuitBreakerProvider; FallbackProvider
+// uses it to report Status() without depending on the concrete type
+type circuitInspectable interface {
+	Name() string
+	State() CircuitState
+}
+
+// Status reports the circuit breaker state of every chain member that has
+// one (see NewProviderWithFailover). Members not wrapped with
+// WithCircuitBreaker are omitted
+func (f *FallbackProvider) Status() []ProviderStatus {
+	var statuses []ProviderStatus
+	for _, p := range f.providers {
+		if insp, ok := p.(circuitInspectable); ok {
+			statuses = append(statuses, ProviderStatus{Name: insp.Name(), State: insp.State()})
+		}
+	}
+	return statuses
+}
+
+// GenerateRequest tries each provider in order until one succeeds
+func (f *FallbackProvider) GenerateRequest(ctx context.Context, agent *agents.Agent, messages []Message) (GenerateResult, error) {
+	var lastErr error
+
+	for i, p := range f.providers {
+		if ctx.Err() != nil {
+			return GenerateResult{}, ctx.Err()
+		}
+
+		attemptCtx, cancel := f.attemptContext(ctx, len(f.providers)-i)
+		result, err := p.GenerateRequest(attemptCtx, agent, messages)
+		cancel()
+		if err == nil {
+			return result, nil
+		}
+
+		lastErr = err
+	}
+
+	return GenerateResult{}, fmt.Errorf("all providers failed: %w", lastErr)
+}
+
+// StreamRequest tries each provider in order until one starts streaming
+// without an immediate error
+func (f *FallbackProvider) StreamRequest(ctx context.Context, agent *agents.Agent, messages []Message) (<-chan string, error) {
+	var lastErr error
+
+	for i, p := range f.providers {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+
+		attemptCtx, cancel := f.attemptContext(ctx, len(f.providers)-i)
+
+		upstream, err := p.StreamRequest(attemptCtx, agent, messages)
+		if err != nil {
+			cancel()
+			lastErr = err
+			continue
+		}
+
+		first, ok := <-upstream
+		if !ok {
+			cancel()
+			return upstream, nil
+		}
+
+		if streamErr := streamChunkError(first); streamErr != nil {
+			cancel()
+			lastErr = streamErr
+			continue
+		}
+
+		return prependChunk(first, upstream), nil
+	}
+
+	return nil, fmt.Errorf("all providers failed: %w", lastErr)
+}
+
+// Close closes every wrapped provider, returning the first error encountered
+func (f *FallbackProvider) Close() error {
+	var firstErr error
+	for _, p := range f.providers {
+		if err := p.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// attemptContext splits ctx's remaining deadline evenly across the
+// providers still left to try, so one slow or hung provider cannot consume
+// the entire budget before a fallback gets a chance to run. ctx is returned
+// unchanged when it has no deadline or only one provider remains
+func (f *FallbackProvider) attemptContext(ctx context.Context, remainingProviders int) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok || remainingProviders <= 1 {
+		return ctx, func() {}
+	}
+
+	budget := time.Until(deadline) / time.Duration(remainingProviders)
+	return context.WithTimeout(ctx, budget)
+}
@@ -7,7 +7,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"net/http"
+	"strings"
+	"time"
+
+	"wfrp-bot/agents"
 )
 
 // MinimaxProvider implements LLMProvider for minimax
@@ -36,63 +41,102 @@ func NewMinimaxProvider(cfg *ProviderConfig) (*MinimaxProvider, error) {
 }
 
 // GenerateRequest sends a request to minimax provider and returns response
-func (p *MinimaxProvider) GenerateRequest(ctx context.Context, prompt string, characterCards []string) (string, error) {
-	fullPrompt := p.buildPrompt(prompt, characterCards)
-
-	reqBody := minimaxRequest{
-		Model:    p.config.Model,
-		Messages: []message{{Role: "user", Content: fullPrompt}},
+//
+// Minimax's chat completions API is OpenAI-compatible, including tool/
+// function calling, so when agent exposes tools the request loops executing
+// tool calls (appending role:"tool" messages) until the model returns a
+// final answer or agent.Iterations() rounds are exhausted - mirroring
+// OpenAIProvider.GenerateRequest
+func (p *MinimaxProvider) GenerateRequest(ctx context.Context, agent *agents.Agent, messages []Message) (GenerateResult, error) {
+	start := time.Now()
+	minimaxMessages := toMinimaxMessages(agent, messages)
+
+	tools := buildMinimaxToolDefinitions(agent)
+	maxIterations := 1
+	if len(tools) > 0 {
+		maxIterations = agent.Iterations()
 	}
 
-	jsonData, err := json.Marshal(reqBody)
-	if err != nil {
-		return "", fmt.Errorf("failed to marshal minimax request: %w", err)
-	}
+	for i := 0; i < maxIterations; i++ {
+		reqBody := minimaxRequest{
+			Model:       p.config.Model,
+			Messages:    minimaxMessages,
+			Tools:       tools,
+			Temperature: p.config.Temperature,
+			MaxTokens:   p.config.MaxTokens,
+		}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", p.apiURL, bytes.NewReader(jsonData))
-	if err != nil {
-		return "", fmt.Errorf("failed to create minimax request: %w", err)
-	}
+		jsonData, err := json.Marshal(reqBody)
+		if err != nil {
+			return GenerateResult{}, fmt.Errorf("failed to marshal minimax request: %w", err)
+		}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
+		req, err := http.NewRequestWithContext(ctx, "POST", p.apiURL, bytes.NewReader(jsonData))
+		if err != nil {
+			return GenerateResult{}, fmt.Errorf("failed to create minimax request: %w", err)
+		}
 
-	resp, err := p.client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("minimax request failed: %w", err)
-	}
-	defer resp.Body.Close()
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Bearer "+p.config.APIKey)
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("minimax API error: %d - %s", resp.StatusCode, string(body))
-	}
+		resp, err := p.client.Do(req)
+		if err != nil {
+			return GenerateResult{}, fmt.Errorf("minimax request failed: %w", err)
+		}
 
-	var result minimaxResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return "", fmt.Errorf("failed to decode minimax response: %w", err)
-	}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			return GenerateResult{}, fmt.Errorf("minimax API error: %d - %s", resp.StatusCode, string(body))
+		}
 
-	if len(result.Choices) == 0 {
-		return "", fmt.Errorf("minimax returned empty response")
+		var result minimaxResponse
+		decodeErr := json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if decodeErr != nil {
+			return GenerateResult{}, fmt.Errorf("failed to decode minimax response: %w", decodeErr)
+		}
+
+		if len(result.Choices) == 0 {
+			return GenerateResult{}, fmt.Errorf("minimax returned empty response")
+		}
+
+		msg := result.Choices[0].Message
+		if len(msg.ToolCalls) == 0 {
+			return GenerateResult{
+				Content: msg.Content,
+				Usage: Usage{
+					Provider:         "minimax",
+					Model:            p.config.Model,
+					PromptTokens:     result.Usage.PromptTokens,
+					CompletionTokens: result.Usage.CompletionTokens,
+					Duration:         time.Since(start),
+				},
+			}, nil
+		}
+
+		minimaxMessages = append(minimaxMessages, msg)
+		minimaxMessages = append(minimaxMessages, runMinimaxToolCalls(ctx, agent, msg.ToolCalls)...)
 	}
 
-	return result.Choices[0].Message.Content, nil
+	return GenerateResult{}, fmt.Errorf("minimax tool-calling loop exceeded %d iterations", maxIterations)
 }
 
 // StreamRequest sends a streaming request to minimax provider
-func (p *MinimaxProvider) StreamRequest(ctx context.Context, prompt string, characterCards []string) (<-chan string, error) {
+func (p *MinimaxProvider) StreamRequest(ctx context.Context, agent *agents.Agent, messages []Message) (<-chan string, error) {
 	ch := make(chan string)
 
 	go func() {
 		defer close(ch)
-
-		fullPrompt := p.buildPrompt(prompt, characterCards)
+		start := time.Now()
+		var full strings.Builder
 
 		reqBody := minimaxRequest{
-			Model:    p.config.Model,
-			Messages: []message{{Role: "user", Content: fullPrompt}},
-			Stream:   true,
+			Model:       p.config.Model,
+			Messages:    toMinimaxMessages(agent, messages),
+			Stream:      true,
+			Temperature: p.config.Temperature,
+			MaxTokens:   p.config.MaxTokens,
 		}
 
 		jsonData, err := json.Marshal(reqBody)
@@ -123,21 +167,32 @@ func (p *MinimaxProvider) StreamRequest(ctx context.Context, prompt string, char
 			return
 		}
 
-		decoder := json.NewDecoder(resp.Body)
-		for {
+		err = sseStream(ctx, resp.Body, func(payload []byte) bool {
 			var chunk minimaxStreamChunk
-			if err := decoder.Decode(&chunk); err != nil {
-				if err == io.EOF {
-					break
-				}
-				ch <- fmt.Sprintf("Error: minimax stream decode error: %v", err)
-				return
+			if err := json.Unmarshal(payload, &chunk); err != nil {
+				// minimax, like OpenAI, occasionally sends non-JSON
+				// keep-alive payloads on the stream; skip rather than abort
+				return true
 			}
 
 			if len(chunk.Choices) > 0 && chunk.Choices[0].Delta.Content != "" {
+				full.WriteString(chunk.Choices[0].Delta.Content)
 				ch <- chunk.Choices[0].Delta.Content
 			}
+			return true
+		})
+		if err != nil {
+			ch <- fmt.Sprintf("Error: minimax stream read error: %v", err)
+			return
 		}
+
+		ch <- FormatUsageChunk(Usage{
+			Provider:         "minimax",
+			Model:            p.config.Model,
+			PromptTokens:     estimateMessagesTokens(messages),
+			CompletionTokens: estimateTokens(full.String()),
+			Duration:         time.Since(start),
+		})
 	}()
 
 	return ch, nil
@@ -148,37 +203,126 @@ func (p *MinimaxProvider) Close() error {
 	return nil
 }
 
-// buildPrompt combines the prompt with character card context
-func (p *MinimaxProvider) buildPrompt(prompt string, characterCards []string) string {
-	if len(characterCards) == 0 {
-		return prompt
+// toMinimaxMessages converts conversation history into minimax's message
+// format, prepending the agent's system prompt unless the caller already
+// supplied one as the first message
+func toMinimaxMessages(agent *agents.Agent, messages []Message) []message {
+	minimaxMessages := make([]message, 0, len(messages)+1)
+
+	if agent != nil && agent.SystemPrompt != "" && (len(messages) == 0 || messages[0].Role != "system") {
+		minimaxMessages = append(minimaxMessages, message{Role: "system", Content: agent.SystemPrompt})
 	}
 
-	contextStr := "--- CHARACTER CARDS ---\n"
-	for i, card := range characterCards {
-		contextStr += fmt.Sprintf("Character %d:\n%s\n\n", i+1, card)
+	for _, msg := range messages {
+		minimaxMessages = append(minimaxMessages, message{Role: msg.Role, Content: msg.Content})
+	}
+
+	return minimaxMessages
+}
+
+// buildMinimaxToolDefinitions converts an agent's allowed tools into
+// minimax's OpenAI-compatible tool schema
+func buildMinimaxToolDefinitions(agent *agents.Agent) []minimaxTool {
+	if agent == nil {
+		return nil
+	}
+
+	tools := agent.Tools()
+	if len(tools) == 0 {
+		return nil
+	}
+
+	defs := make([]minimaxTool, 0, len(tools))
+	for _, tool := range tools {
+		defs = append(defs, minimaxTool{
+			Type: "function",
+			Function: minimaxFunctionDef{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+	return defs
+}
+
+// runMinimaxToolCalls executes the tool calls requested by the model and
+// returns the resulting "tool" role messages to append to the conversation
+func runMinimaxToolCalls(ctx context.Context, agent *agents.Agent, calls []minimaxToolCall) []message {
+	messages := make([]message, 0, len(calls))
+
+	for _, call := range calls {
+		result, err := agent.Toolbox.Call(ctx, call.Function.Name, json.RawMessage(call.Function.Arguments))
+		if err != nil {
+			log.Printf("[MINIMAX] Tool %s failed: %v", call.Function.Name, err)
+			result = fmt.Sprintf("error: %v", err)
+		} else {
+			log.Printf("[MINIMAX] Tool %s -> %s", call.Function.Name, result)
+		}
+
+		messages = append(messages, message{
+			Role:       "tool",
+			Content:    result,
+			ToolCallID: call.ID,
+		})
 	}
-	contextStr += "--- END CHARACTER CARDS ---\n\n"
 
-	return contextStr + prompt
+	return messages
 }
 
 // minimaxRequest represents the request payload for minimax API
 type minimaxRequest struct {
-	Model    string    `json:"model"`
-	Messages []message `json:"messages"`
-	Stream   bool      `json:"stream,omitempty"`
+	Model       string        `json:"model"`
+	Messages    []message     `json:"messages"`
+	Stream      bool          `json:"stream,omitempty"`
+	Tools       []minimaxTool `json:"tools,omitempty"`
+	Temperature float64       `json:"temperature,omitempty"`
+	MaxTokens   int           `json:"max_tokens,omitempty"`
 }
 
 // message represents a chat message
 type message struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role       string            `json:"role"`
+	Content    string            `json:"content"`
+	ToolCalls  []minimaxToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string            `json:"tool_call_id,omitempty"`
+}
+
+// minimaxTool describes a function tool in minimax's OpenAI-compatible schema
+type minimaxTool struct {
+	Type     string             `json:"type"`
+	Function minimaxFunctionDef `json:"function"`
+}
+
+// minimaxFunctionDef is the function schema of a minimaxTool
+type minimaxFunctionDef struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  json.RawMessage `json:"parameters"`
+}
+
+// minimaxToolCall is a tool call requested by the model
+type minimaxToolCall struct {
+	ID       string                  `json:"id"`
+	Function minimaxToolCallFunction `json:"function"`
+}
+
+// minimaxToolCallFunction is the function payload of a minimaxToolCall
+type minimaxToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
 }
 
 // minimaxResponse represents the response from minimax API
 type minimaxResponse struct {
-	Choices []choice `json:"choices"`
+	Choices []choice     `json:"choices"`
+	Usage   minimaxUsage `json:"usage"`
+}
+
+// minimaxUsage represents token accounting returned by minimax API
+type minimaxUsage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
 }
 
 // minimaxStreamChunk represents a streaming chunk from minimax API
@@ -188,7 +332,7 @@ type minimaxStreamChunk struct {
 
 // choice represents a response choice
 type choice struct {
-	Message delta `json:"message"`
+	Message message `json:"message"`
 }
 
 // streamChoice represents a streaming choice
@@ -3,11 +3,14 @@ package llm
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/sashabaranov/go-openai"
+
+	"wfrp-bot/agents"
 )
 
 // OpenAIProvider implements LLMProvider for OpenAI-compatible APIs
@@ -34,44 +37,75 @@ func NewOpenAIProvider(cfg *ProviderConfig) (*OpenAIProvider, error) {
 }
 
 // GenerateRequest sends a request to OpenAI provider and returns response
-func (p *OpenAIProvider) GenerateRequest(ctx context.Context, prompt string, characterCards []string) (string, error) {
-	// Combine prompt with character cards context
-	fullPrompt := p.buildPrompt(prompt, characterCards)
-
-	req := openai.ChatCompletionRequest{
-		Model:       p.config.Model,
-		Messages:     []openai.ChatCompletionMessage{{Role: "user", Content: fullPrompt}},
-		MaxTokens:    4096,
-		Temperature:  0.7,
+//
+// When agent is non-nil and exposes tools, the request loops executing
+// tool calls (appending role:"tool" messages) until the model returns a
+// final answer or agent.Iterations() rounds are exhausted
+func (p *OpenAIProvider) GenerateRequest(ctx context.Context, agent *agents.Agent, messages []Message) (GenerateResult, error) {
+	start := time.Now()
+	chatMessages := toChatMessages(agent, messages)
+
+	tools := buildToolDefinitions(agent)
+	maxIterations := 1
+	if len(tools) > 0 {
+		maxIterations = agent.Iterations()
 	}
 
-	resp, err := p.client.CreateChatCompletion(ctx, req)
-	if err != nil {
-		return "", fmt.Errorf("OpenAI request failed: %w", err)
-	}
+	for i := 0; i < maxIterations; i++ {
+		req := openai.ChatCompletionRequest{
+			Model:       p.config.Model,
+			Messages:    chatMessages,
+			MaxTokens:   4096,
+			Temperature: 0.7,
+			Tools:       tools,
+		}
+
+		resp, err := p.client.CreateChatCompletion(ctx, req)
+		if err != nil {
+			return GenerateResult{}, fmt.Errorf("OpenAI request failed: %w", err)
+		}
+
+		if len(resp.Choices) == 0 {
+			return GenerateResult{}, fmt.Errorf("OpenAI returned empty response")
+		}
+
+		choice := resp.Choices[0]
+		if len(choice.Message.ToolCalls) == 0 {
+			return GenerateResult{
+				Content: choice.Message.Content,
+				Usage: Usage{
+					Provider:         "openai",
+					Model:            p.config.Model,
+					PromptTokens:     resp.Usage.PromptTokens,
+					CompletionTokens: resp.Usage.CompletionTokens,
+					Duration:         time.Since(start),
+				},
+			}, nil
+		}
 
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("OpenAI returned empty response")
+		chatMessages = append(chatMessages, choice.Message)
+		chatMessages = append(chatMessages, runToolCalls(ctx, agent, choice.Message.ToolCalls)...)
 	}
 
-	return resp.Choices[0].Message.Content, nil
+	return GenerateResult{}, fmt.Errorf("OpenAI tool-calling loop exceeded %d iterations", maxIterations)
 }
 
 // StreamRequest sends a streaming request to OpenAI provider
-func (p *OpenAIProvider) StreamRequest(ctx context.Context, prompt string, characterCards []string) (<-chan string, error) {
+func (p *OpenAIProvider) StreamRequest(ctx context.Context, agent *agents.Agent, messages []Message) (<-chan string, error) {
 	ch := make(chan string)
 
 	go func() {
 		defer close(ch)
-
-		fullPrompt := p.buildPrompt(prompt, characterCards)
+		start := time.Now()
+		var full strings.Builder
 
 		req := openai.ChatCompletionRequest{
 			Model:       p.config.Model,
-			Messages:     []openai.ChatCompletionMessage{{Role: "user", Content: fullPrompt}},
-			MaxTokens:    4096,
-			Temperature:  0.7,
+			Messages:    toChatMessages(agent, messages),
+			MaxTokens:   4096,
+			Temperature: 0.7,
 			Stream:      true,
+			Tools:       buildToolDefinitions(agent),
 		}
 
 		stream, err := p.client.CreateChatCompletionStream(ctx, req)
@@ -84,7 +118,7 @@ func (p *OpenAIProvider) StreamRequest(ctx context.Context, prompt string, chara
 			resp, err := stream.Recv()
 			if err != nil {
 				if err == io.EOF {
-					return
+					break
 				}
 				ch <- fmt.Sprintf("Error: OpenAI stream error: %v", err)
 				return
@@ -92,10 +126,19 @@ func (p *OpenAIProvider) StreamRequest(ctx context.Context, prompt string, chara
 
 			for _, choice := range resp.Choices {
 				if len(choice.Delta.Content) > 0 {
+					full.WriteString(choice.Delta.Content)
 					ch <- choice.Delta.Content
 				}
 			}
 		}
+
+		ch <- FormatUsageChunk(Usage{
+			Provider:         "openai",
+			Model:            p.config.Model,
+			PromptTokens:     estimateMessagesTokens(messages),
+			CompletionTokens: estimateTokens(full.String()),
+			Duration:         time.Since(start),
+		})
 	}()
 
 	return ch, nil
@@ -107,64 +150,32 @@ func (p *OpenAIProvider) Close() error {
 	return nil
 }
 
-// buildPrompt combines the prompt with character card context
-func (p *OpenAIProvider) buildPrompt(prompt string, characterCards []string) string {
-	if len(characterCards) == 0 {
-		return prompt
-	}
-
-	contextStr := "--- CHARACTER CARDS ---\n"
-	for i, card := range characterCards {
-		contextStr += fmt.Sprintf("Character %d:\n%s\n\n", i+1, card)
-	}
-	contextStr += "--- END CHARACTER CARDS ---\n\n"
-
-	return contextStr + prompt
-}
-
-// ConfigJSON represents OpenAI API configuration for JSON parsing
-type ConfigJSON struct {
-	APIKey  string `json:"api_key"`
-	BaseURL string `json:"base_url"`
-	Model   string `json:"model"`
-}
-
-// FromJSON creates ProviderConfig from JSON configuration
-func (c *ConfigJSON) FromJSON(data []byte) (*ProviderConfig, error) {
-	var cfg ConfigJSON
-	if err := json.Unmarshal(data, &cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse OpenAI config: %w", err)
-	}
-
-	pc := &ProviderConfig{
-		Name:   "openai",
-		APIKey: cfg.APIKey,
-		Model:   cfg.Model,
-	}
-
-	if cfg.BaseURL != "" {
-		pc.BaseURL = cfg.BaseURL
-	} else {
-		pc.BaseURL = "https://api.openai.com/v1"
-	}
-
-	if pc.Model == "" {
-		pc.Model = "gpt-4o"
-	}
-
-	return pc, nil
-}
-
-// NewProviderFromConfig creates an LLMProvider from ProviderConfig
+// NewProviderFromConfig creates an LLMProvider from ProviderConfig, layered
+// with retry and (when cfg.RequestsPerMinute is set) rate-limit middleware
 func NewProviderFromConfig(cfg *ProviderConfig) (LLMProvider, error) {
+	var provider LLMProvider
+	var err error
+
 	switch cfg.Name {
 	case "z.ai", "zai":
-		return NewZAIProvider(cfg)
+		provider, err = NewZAIProvider(cfg)
 	case "minimax":
-		return NewMinimaxProvider(cfg)
+		provider, err = NewMinimaxProvider(cfg)
+	case "anthropic":
+		provider, err = NewAnthropicProvider(cfg)
+	case "gemini":
+		provider, err = NewGeminiProvider(cfg)
 	case "openai", "custom":
-		return NewOpenAIProvider(cfg)
+		provider, err = NewOpenAIProvider(cfg)
 	default:
 		return nil, fmt.Errorf("unknown provider: %s", cfg.Name)
 	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider = WithRetry(provider, cfg.Resilience.retryOptions())
+	provider = WithRateLimit(provider, cfg.RequestsPerMinute)
+
+	return provider, nil
 }
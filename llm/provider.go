@@ -3,6 +3,9 @@ package llm
 
 import (
 	"context"
+	"time"
+
+	"wfrp-bot/agents"
 )
 
 // LLMProvider определяет интерфейс для интеграции LLM провайдеров
@@ -14,17 +17,23 @@ import (
 type LLMProvider interface {
 	// GenerateRequest отправляет запрос к LLM провайдеру и возвращает полный ответ
 	// - ctx: контекст для отмены запроса
-	// - prompt: промпт с инструкциями для AI
-	// - characterCards: массив карточек персонажей для контекста
-	// Возвращает текстовый ответ или ошибку
-	GenerateRequest(ctx context.Context, prompt string, characterCards []string) (string, error)
+	// - agent: агент, ограничивающий набор доступных инструментов (может быть nil)
+	// - messages: упорядоченная история диалога (system/user/assistant), включая
+	//   карточки персонажей и предыдущие реплики как отдельные сообщения
+	// Если agent задан и провайдер поддерживает tool-calling, модель может
+	// вызывать инструменты из agent.Tools() до получения финального ответа
+	// (не более agent.Iterations() раундов)
+	// Возвращает GenerateResult (текст ответа + учёт токенов) или ошибку
+	GenerateRequest(ctx context.Context, agent *agents.Agent, messages []Message) (GenerateResult, error)
 
 	// StreamRequest отправляет потоковый запрос к LLM провайдеру
 	// - ctx: контекст для отмены запроса
-	// - prompt: промпт с инструкциями для AI
-	// - characterCards: массив карточек персонажей для контекста
-	// Возвращает канал для получения фрагментов ответа
-	StreamRequest(ctx context.Context, prompt string, characterCards []string) (<-chan string, error)
+	// - agent: агент, ограничивающий набор доступных инструментов (может быть nil)
+	// - messages: упорядоченная история диалога (system/user/assistant)
+	// Возвращает канал для получения фрагментов ответа; последним значением
+	// перед закрытием канала отправляется сводка использования токенов,
+	// закодированная через FormatUsageChunk (см. ParseUsageChunk)
+	StreamRequest(ctx context.Context, agent *agents.Agent, messages []Message) (<-chan string, error)
 
 	// Close закрывает соединения и освобождает ресурсы провайдера
 	Close() error
@@ -48,8 +57,59 @@ type Response struct {
 
 // ProviderConfig хранит конфигурацию для LLM провайдеров
 type ProviderConfig struct {
-	Name    string // Имя провайдера (z.ai, minimax, openai, custom)
-	APIKey  string // API ключ провайдера
-	BaseURL string // Базовый URL API провайдера
-	Model   string // Модель для использования
+	Name              string      // Имя провайдера (z.ai/zai, minimax, anthropic, gemini, openai, custom)
+	APIKey            string      // API ключ провайдера
+	BaseURL           string      // Базовый URL API провайдера
+	Model             string      // Модель для использования
+	RequestsPerMinute int         // Ограничение частоты запросов (0 отключает лимит)
+	Temperature       float64     // Температура генерации (0 оставляет провайдеру значение по умолчанию)
+	MaxTokens         int         // Максимум токенов в ответе (0 оставляет провайдеру значение по умолчанию)
+	Priority          int         // Порядок в пуле failover (см. llm.PoolConfig): меньше - выше приоритет, 0 - самый высокий
+	Weight            int         // Относительный вес при равном Priority (см. llm.PoolConfig); <=0 трактуется как 1
+	Resilience        *Resilience // Настройка retry/circuit breaker для этого провайдера; nil использует значения по умолчанию
+}
+
+// Resilience configures the retry and circuit-breaker policy WithRetry and
+// WithCircuitBreaker apply to one ProviderConfig (see
+// ProviderConfig.Resilience). A nil Resilience falls back to
+// DefaultRetryOptions/DefaultCircuitBreakerOptions, the same zero-value
+// convention every other option struct in this package follows
+type Resilience struct {
+	MaxRetries           int           // see RetryOptions.MaxRetries
+	InitialBackoff       time.Duration // see RetryOptions.BaseDelay
+	MaxBackoff           time.Duration // see RetryOptions.MaxDelay
+	RetryableStatusCodes []int         // see RetryOptions.RetryableStatusCodes
+
+	FailureRateThreshold float64       // fraction of the sliding window that must fail to open the circuit (e.g. 0.5); 0 falls back to CircuitBreakerOptions.FailureThreshold's consecutive-failure mode
+	MinRequests          int           // minimum outcomes in the sliding window before FailureRateThreshold is evaluated
+	OpenStateDuration    time.Duration // see CircuitBreakerOptions.CooldownPeriod
+
+	Notifier *ErrorNotifier // if set, notified when the circuit breaker opens for this provider
+}
+
+// retryOptions converts r to a RetryOptions, or DefaultRetryOptions if r is nil
+func (r *Resilience) retryOptions() RetryOptions {
+	if r == nil {
+		return DefaultRetryOptions
+	}
+	return RetryOptions{
+		MaxRetries:           r.MaxRetries,
+		BaseDelay:            r.InitialBackoff,
+		MaxDelay:             r.MaxBackoff,
+		RetryableStatusCodes: r.RetryableStatusCodes,
+	}
+}
+
+// circuitBreakerOptions converts r to a CircuitBreakerOptions, or
+// DefaultCircuitBreakerOptions if r is nil
+func (r *Resilience) circuitBreakerOptions() CircuitBreakerOptions {
+	if r == nil {
+		return DefaultCircuitBreakerOptions
+	}
+	return CircuitBreakerOptions{
+		CooldownPeriod:       r.OpenStateDuration,
+		FailureRateThreshold: r.FailureRateThreshold,
+		MinRequests:          r.MinRequests,
+		Notifier:             r.Notifier,
+	}
 }
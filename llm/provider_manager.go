@@ -6,60 +6,262 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math/rand"
+	"sort"
 	"sync"
 	"time"
+
+	"wfrp-bot/agents"
 )
 
-// ProviderManager manages LLM providers with hot-reload capability
+// PoolConfig holds an ordered set of provider configurations for
+// NewProviderManagerPool to build into a single failover-aware
+// ProviderManager. See ProviderConfig.Priority/Weight for how pool order is
+// derived from it
+type PoolConfig struct {
+	Providers []*ProviderConfig
+}
+
+// ProviderMetrics counts requests/failures seen by one pool member, for the
+// /providers admin command (see ProviderManager.Metrics)
+type ProviderMetrics struct {
+	Requests int
+	Failures int
+}
+
+// metricsProvider wraps an LLMProvider, counting requests/failures per pool
+// member independently of circuitBreakerProvider's consecutive-failure (or
+// sliding-window)/cooldown bookkeeping, which only looks at recent outcomes
+type metricsProvider struct {
+	next LLMProvider
+	name string
+
+	mu      sync.Mutex
+	metrics ProviderMetrics
+}
+
+func withMetrics(p LLMProvider, name string) *metricsProvider {
+	return &metricsProvider{next: p, name: name}
+}
+
+func (m *metricsProvider) record(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.metrics.Requests++
+	if err != nil {
+		m.metrics.Failures++
+	}
+}
+
+func (m *metricsProvider) snapshot() ProviderMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.metrics
+}
+
+func (m *metricsProvider) GenerateRequest(ctx context.Context, agent *agents.Agent, messages []Message) (GenerateResult, error) {
+	result, err := m.next.GenerateRequest(ctx, agent, messages)
+	m.record(err)
+	return result, err
+}
+
+func (m *metricsProvider) StreamRequest(ctx context.Context, agent *agents.Agent, messages []Message) (<-chan string, error) {
+	upstream, err := m.next.StreamRequest(ctx, agent, messages)
+	m.record(err)
+	return upstream, err
+}
+
+func (m *metricsProvider) Close() error { return m.next.Close() }
+
+// ProviderManager manages an ordered, health-tracked pool of LLM providers
+// with hot-reload capability. A single-provider manager (see
+// NewProviderManager) behaves exactly as before; NewProviderManagerPool
+// builds on top of NewProviderWithFailover's circuit-breaker-wrapped chain
+// (see FallbackProvider) so GenerateRequest/StreamRequest transparently
+// move to the next provider in priority order whenever the current one
+// errors, times out, or is already in cooldown
 type ProviderManager struct {
-	currentProvider  LLMProvider
-	config           *ProviderConfig
-	configReloadChan chan struct{}
 	mu               sync.Mutex
+	fallback         *FallbackProvider
+	configs          []*ProviderConfig // pool members, in build order - parallel to fallback.providers and metricsProviders
+	metricsProviders []*metricsProvider
+	configReloadChan chan struct{}
 }
 
-// NewProviderManager creates a new provider manager
+// NewProviderManager creates a single-provider manager, equivalent to
+// NewProviderManagerPool with a one-entry pool
 func NewProviderManager(cfg *ProviderConfig) (*ProviderManager, error) {
-	provider, err := NewProviderFromConfig(cfg)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create provider: %w", err)
+	return NewProviderManagerPool(PoolConfig{Providers: []*ProviderConfig{cfg}})
+}
+
+// NewProviderManagerPool builds one LLMProvider per entry in pool.Providers
+// - each wrapped with request/failure metrics and circuit-breaker cooldown
+// - ordered by ascending ProviderConfig.Priority (ties broken by a
+// weighted-random draw on ProviderConfig.Weight), and combines them into a
+// single FallbackProvider
+func NewProviderManagerPool(pool PoolConfig) (*ProviderManager, error) {
+	if len(pool.Providers) == 0 {
+		return nil, fmt.Errorf("provider pool must have at least one entry")
+	}
+
+	ordered := orderPool(pool.Providers)
+
+	built := make([]LLMProvider, 0, len(ordered))
+	metricsProviders := make([]*metricsProvider, 0, len(ordered))
+	for _, cfg := range ordered {
+		provider, err := NewProviderFromConfig(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create provider %q: %w", cfg.Name, err)
+		}
+
+		mp := withMetrics(provider, cfg.Name)
+		metricsProviders = append(metricsProviders, mp)
+		built = append(built, WithCircuitBreaker(mp, cfg.Name, cfg.Resilience.circuitBreakerOptions()))
 	}
 
 	return &ProviderManager{
-		currentProvider:  provider,
-		config:           cfg,
+		fallback:         NewFallback(built[0], built[1:]...),
+		configs:          ordered,
+		metricsProviders: metricsProviders,
 		configReloadChan: make(chan struct{}, 1),
-		mu:               sync.Mutex{},
 	}, nil
 }
 
-// GetCurrentProvider returns the current provider
-func (pm *ProviderManager) GetCurrentProvider() LLMProvider {
+// orderPool sorts configs by ascending Priority, breaking ties within a
+// priority tier with a weighted-random draw on Weight (see weightedShuffle)
+func orderPool(configs []*ProviderConfig) []*ProviderConfig {
+	tiers := make(map[int][]*ProviderConfig)
+	var priorities []int
+	for _, cfg := range configs {
+		if _, seen := tiers[cfg.Priority]; !seen {
+			priorities = append(priorities, cfg.Priority)
+		}
+		tiers[cfg.Priority] = append(tiers[cfg.Priority], cfg)
+	}
+	sort.Ints(priorities)
+
+	ordered := make([]*ProviderConfig, 0, len(configs))
+	for _, priority := range priorities {
+		ordered = append(ordered, weightedShuffle(tiers[priority])...)
+	}
+	return ordered
+}
+
+// weightedShuffle repeatedly draws (without replacement) from tier using
+// each entry's Weight (<=0 treated as 1) as its relative probability, so a
+// higher-weight provider is more likely - but not guaranteed - to be tried
+// before its same-priority siblings
+func weightedShuffle(tier []*ProviderConfig) []*ProviderConfig {
+	remaining := append([]*ProviderConfig{}, tier...)
+	result := make([]*ProviderConfig, 0, len(tier))
+
+	for len(remaining) > 0 {
+		total := 0
+		for _, cfg := range remaining {
+			total += providerWeight(cfg)
+		}
+
+		pick := rand.Intn(total)
+		for i, cfg := range remaining {
+			pick -= providerWeight(cfg)
+			if pick < 0 {
+				result = append(result, cfg)
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+		}
+	}
+
+	return result
+}
+
+func providerWeight(cfg *ProviderConfig) int {
+	if cfg.Weight <= 0 {
+		return 1
+	}
+	return cfg.Weight
+}
+
+// GetActiveProvider returns the pool's highest-priority provider that isn't
+// currently in an open circuit-breaker cooldown, or the first provider in
+// the pool if every provider is cooling down - so callers always get
+// something to try rather than nil
+func (pm *ProviderManager) GetActiveProvider() LLMProvider {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
-	return pm.currentProvider
+	return pm.fallback.providers[pm.activeIndexLocked()]
+}
+
+// activeIndexLocked returns the index (into fallback.providers/configs/
+// metricsProviders) of the first pool member not in an open circuit.
+// Callers must hold pm.mu
+func (pm *ProviderManager) activeIndexLocked() int {
+	for i, p := range pm.fallback.providers {
+		if insp, ok := p.(circuitInspectable); !ok || insp.State() != CircuitOpen {
+			return i
+		}
+	}
+	return 0
+}
+
+// GetCurrentProvider returns the pool's current active provider (see
+// GetActiveProvider); kept alongside it for callers that predate the pool
+func (pm *ProviderManager) GetCurrentProvider() LLMProvider {
+	return pm.GetActiveProvider()
 }
 
-// GetCurrentConfig returns the current provider config
+// GetCurrentConfig returns the config of the pool's current active provider
 func (pm *ProviderManager) GetCurrentConfig() *ProviderConfig {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
-	return pm.config
+	return pm.configs[pm.activeIndexLocked()]
 }
 
-// ReloadProvider reloads the provider configuration without restarting the bot
-func (pm *ProviderManager) ReloadProvider(newConfig *ProviderConfig) error {
+// Metrics returns request/failure counts for every pool member, keyed by
+// ProviderConfig.Name, for the /providers admin command
+func (pm *ProviderManager) Metrics() map[string]ProviderMetrics {
 	pm.mu.Lock()
 	defer pm.mu.Unlock()
 
+	out := make(map[string]ProviderMetrics, len(pm.metricsProviders))
+	for i, mp := range pm.metricsProviders {
+		out[pm.configs[i].Name] = mp.snapshot()
+	}
+	return out
+}
+
+// ReloadProvider rebuilds and swaps in the pool member named newConfig.Name
+// without restarting the bot. For a single-provider manager (see
+// NewProviderManager) the lone entry is replaced regardless of name,
+// matching the manager's pre-pool behavior
+func (pm *ProviderManager) ReloadProvider(newConfig *ProviderConfig) error {
 	provider, err := NewProviderFromConfig(newConfig)
 	if err != nil {
 		log.Printf("Failed to create new provider: %v", err)
 		return fmt.Errorf("failed to create new provider: %w", err)
 	}
 
-	pm.currentProvider = provider
-	pm.config = newConfig
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+
+	idx := 0
+	if len(pm.configs) > 1 {
+		idx = -1
+		for i, cfg := range pm.configs {
+			if cfg.Name == newConfig.Name {
+				idx = i
+				break
+			}
+		}
+		if idx == -1 {
+			return fmt.Errorf("no pool entry named %q to reload", newConfig.Name)
+		}
+	}
+
+	mp := withMetrics(provider, newConfig.Name)
+	pm.metricsProviders[idx] = mp
+	pm.fallback.providers[idx] = WithCircuitBreaker(mp, newConfig.Name, newConfig.Resilience.circuitBreakerOptions())
+	pm.configs[idx] = newConfig
 
 	log.Printf("Provider successfully reloaded: %s", newConfig.Name)
 
@@ -101,21 +303,22 @@ func NewResponseHandler(pm *ProviderManager) *ResponseHandler {
 	return &ResponseHandler{pm: pm}
 }
 
-// BuildRequest builds a request with context and character cards
-func (rh *ResponseHandler) BuildRequest(prompt string, characterCards []string) string {
+// BuildRequest builds a system message carrying character card context
+func (rh *ResponseHandler) BuildRequest(characterCards []string) Message {
 	rh.pm.mu.Lock()
 	rh.pm.mu.Unlock()
 
-	contextStr := ""
-	if len(characterCards) > 0 {
-		contextStr = "--- CHARACTER CARDS ---\n"
-		for i, card := range characterCards {
-			contextStr += fmt.Sprintf("Character %d:\n%s\n\n", i+1, card)
-		}
-		contextStr += "--- END CHARACTER CARDS ---\n\n"
+	if len(characterCards) == 0 {
+		return Message{}
+	}
+
+	contextStr := "--- CHARACTER CARDS ---\n"
+	for i, card := range characterCards {
+		contextStr += fmt.Sprintf("Character %d:\n%s\n\n", i+1, card)
 	}
+	contextStr += "--- END CHARACTER CARDS ---\n"
 
-	return contextStr + prompt
+	return Message{Role: "system", Content: contextStr}
 }
 
 // HandleResponse processes LLM responses and can format them as JSON or text
@@ -134,38 +337,36 @@ func (rh *ResponseHandler) HandleResponse(response string, format string) (inter
 	}
 }
 
-// GenerateRequest sends a request to the current provider with error handling
-func (rh *ResponseHandler) GenerateRequest(ctx context.Context, prompt string, characterCards []string) (string, error) {
-	rh.pm.mu.Lock()
-	provider := rh.pm.currentProvider
-	cfg := rh.pm.config
-	rh.pm.mu.Unlock()
+// GenerateRequest sends a request through the provider pool with error
+// handling. The pool's FallbackProvider (see ProviderManager) transparently
+// retries on the next provider if the active one errors or is cooling down,
+// so cfg here just names whichever provider was active when the call began
+func (rh *ResponseHandler) GenerateRequest(ctx context.Context, agent *agents.Agent, messages []Message) (GenerateResult, error) {
+	cfg := rh.pm.GetCurrentConfig()
 
 	log.Printf("Making request to %s provider", cfg.Name)
 
 	startTime := time.Now()
-	response, err := provider.GenerateRequest(ctx, prompt, characterCards)
+	result, err := rh.pm.fallback.GenerateRequest(ctx, agent, messages)
 	duration := time.Since(startTime)
 
 	if err != nil {
 		log.Printf("Request to %s failed: %v (duration: %v)", cfg.Name, err, duration)
-		return "", fmt.Errorf("provider %s failed: %w", cfg.Name, err)
+		return GenerateResult{}, fmt.Errorf("provider %s failed: %w", cfg.Name, err)
 	}
 
 	log.Printf("Request to %s succeeded (duration: %v)", cfg.Name, duration)
-	return response, nil
+	return result, nil
 }
 
-// StreamRequest sends a streaming request with error handling
-func (rh *ResponseHandler) StreamRequest(ctx context.Context, prompt string, characterCards []string) (<-chan string, error) {
-	rh.pm.mu.Lock()
-	provider := rh.pm.currentProvider
-	cfg := rh.pm.config
-	rh.pm.mu.Unlock()
+// StreamRequest sends a streaming request through the provider pool with
+// error handling; see GenerateRequest for the failover behavior
+func (rh *ResponseHandler) StreamRequest(ctx context.Context, agent *agents.Agent, messages []Message) (<-chan string, error) {
+	cfg := rh.pm.GetCurrentConfig()
 
 	log.Printf("Making streaming request to %s provider", cfg.Name)
 
-	stream, err := provider.StreamRequest(ctx, prompt, characterCards)
+	stream, err := rh.pm.fallback.StreamRequest(ctx, agent, messages)
 	if err != nil {
 		log.Printf("Failed to create streaming request to %s: %v", cfg.Name, err)
 		return nil, fmt.Errorf("failed to create streaming request: %w", err)
@@ -174,46 +375,3 @@ func (rh *ResponseHandler) StreamRequest(ctx context.Context, prompt string, cha
 	log.Printf("Streaming request to %s started", cfg.Name)
 	return stream, nil
 }
-
-// ErrorNotifier handles and notifies about API errors
-type ErrorNotifier struct {
-	adminChatID string
-}
-
-// NewErrorNotifier creates a new error notifier
-func NewErrorNotifier(chatID string) *ErrorNotifier {
-	return &ErrorNotifier{adminChatID: chatID}
-}
-
-// NotifyProviderError notifies about provider-specific errors
-func (en *ErrorNotifier) NotifyProviderError(providerName, errorType, message string) {
-	errMsg := fmt.Sprintf("⚠️ **%s Provider Error** ⚠️\n\n**Error Type:** %s\n**Message:** %s\n\nPlease check your configuration.", providerName, errorType, message)
-
-	log.Printf("Provider error notification: %s", errMsg)
-
-	if en.adminChatID != "" {
-		log.Printf("Sending notification to admin chat %s: %s", en.adminChatID, errMsg)
-	}
-}
-
-// NotifyAPIError notifies about generic API errors
-func (en *ErrorNotifier) NotifyAPIError(message string, statusCode int) {
-	errMsg := fmt.Sprintf("⚠️ **API Error** ⚠️\n\n**Status Code:** %d\n**Message:** %s", statusCode, message)
-
-	log.Printf("API error notification: %s", errMsg)
-
-	if en.adminChatID != "" {
-		log.Printf("Sending notification to admin chat %s: %s", en.adminChatID, errMsg)
-	}
-}
-
-// NotifyRequestFailed notifies about request failures
-func (en *ErrorNotifier) NotifyRequestFailed(providerName, requestType, details string) {
-	errMsg := fmt.Sprintf("⚠️ **%s Request Failed** ⚠️\n\n**Provider:** %s\n**Request Type:** %s\n**Details:** %s", requestType, providerName, requestType, details)
-
-	log.Printf("Request failed notification: %s", errMsg)
-
-	if en.adminChatID != "" {
-		log.Printf("Sending notification to admin chat %s: %s", en.adminChatID, errMsg)
-	}
-}
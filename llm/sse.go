@@ -0,0 +1,67 @@
+package llm
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"strings"
+)
+
+// sseStream reads body as an OpenAI/Minimax/Anthropic/Gemini-style
+// text/event-stream: events are lines of the form "data: <payload>",
+// terminated (for OpenAI-compatible APIs) by a literal "data: [DONE]" line.
+// Blank lines and SSE comment lines (starting with ":") are skipped.
+// onEvent is called with each payload's raw bytes; returning false stops
+// the scan early. The scan also stops as soon as ctx is cancelled - so a
+// StreamRequest goroutine reading a slow/stalled response can unblock
+// promptly when /stop cancels the session context - even though the
+// underlying read only actually returns once the caller closes resp.Body
+func sseStream(ctx context.Context, body io.Reader, onEvent func(payload []byte) bool) error {
+	type scanResult struct {
+		line string
+		err  error
+	}
+	lines := make(chan scanResult)
+
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(body)
+		for scanner.Scan() {
+			lines <- scanResult{line: scanner.Text()}
+		}
+		if err := scanner.Err(); err != nil {
+			lines <- scanResult{err: err}
+		}
+	}()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case result, ok := <-lines:
+			if !ok {
+				return nil
+			}
+			if result.err != nil {
+				return result.err
+			}
+
+			line := result.line
+			if line == "" || strings.HasPrefix(line, ":") {
+				continue
+			}
+
+			payload, isData := strings.CutPrefix(line, "data: ")
+			if !isData {
+				continue
+			}
+			if payload == "[DONE]" {
+				return nil
+			}
+
+			if !onEvent([]byte(payload)) {
+				return nil
+			}
+		}
+	}
+}
@@ -0,0 +1,229 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// defaultStructuredRepairAttempts is how many times GenerateStructured
+// re-prompts the model with validator errors before giving up
+const defaultStructuredRepairAttempts = 3
+
+// structuredSchemaInstructionTemplate is appended to the caller's prompt so
+// every provider - even one without tool-calling support (see
+// RequestStructured for the tool-calling alternative) - knows to answer
+// with JSON matching schema
+const structuredSchemaInstructionTemplate = "\n\nОтветь ТОЛЬКО валидным JSON, соответствующим следующей JSON Schema (draft-07), без пояснений и markdown-разметки:\n%s"
+
+// GenerateStructured asks provider for a response matching schema (a JSON
+// Schema draft-07 document describing the expected object) and decodes it
+// into out, which must be a pointer. It strips markdown code fences,
+// recovers the JSON object from any surrounding prose, and drops trailing
+// commas before validating. On a parse or validation failure it re-prompts
+// the model with the specific errors, up to defaultStructuredRepairAttempts
+// times, before giving up
+func (rh *ResponseHandler) GenerateStructured(ctx context.Context, prompt string, characterCards []string, schema json.RawMessage, out any) error {
+	messages := []Message{{Role: "system", Content: prompt + fmt.Sprintf(structuredSchemaInstructionTemplate, string(schema))}}
+	if cardsMsg := rh.BuildRequest(characterCards); cardsMsg.Content != "" {
+		messages = append(messages, cardsMsg)
+	}
+	messages = append(messages, Message{Role: "user", Content: "Сгенерируй ответ согласно схеме."})
+
+	var lastErrs []string
+	for attempt := 0; attempt <= defaultStructuredRepairAttempts; attempt++ {
+		if attempt > 0 {
+			messages = append(messages, Message{
+				Role:    "user",
+				Content: fmt.Sprintf("Предыдущий ответ не прошёл проверку:\n- %s\nИсправь и пришли снова ТОЛЬКО JSON.", strings.Join(lastErrs, "\n- ")),
+			})
+		}
+
+		result, err := rh.GenerateRequest(ctx, nil, messages)
+		if err != nil {
+			return fmt.Errorf("structured generation request failed: %w", err)
+		}
+		messages = append(messages, Message{Role: "assistant", Content: result.Content})
+
+		candidate := extractJSON(result.Content)
+
+		var decoded any
+		if err := json.Unmarshal([]byte(candidate), &decoded); err != nil {
+			lastErrs = []string{fmt.Sprintf("invalid JSON: %v", err)}
+			continue
+		}
+
+		if errs := validateSchema(schema, decoded); len(errs) > 0 {
+			lastErrs = errs
+			continue
+		}
+
+		if err := json.Unmarshal([]byte(candidate), out); err != nil {
+			return fmt.Errorf("failed to decode structured response into target type: %w", err)
+		}
+		return nil
+	}
+
+	return fmt.Errorf("structured generation did not validate after %d attempts: %s", defaultStructuredRepairAttempts+1, strings.Join(lastErrs, "; "))
+}
+
+// jsonFencePattern matches a ```json ... ``` or bare ``` ... ``` code fence
+var jsonFencePattern = regexp.MustCompile("(?s)```(?:json)?\\s*(.*?)\\s*```")
+
+// trailingCommaPattern matches a comma immediately before a closing } or ]
+var trailingCommaPattern = regexp.MustCompile(`,(\s*[}\]])`)
+
+// extractJSON recovers a JSON object/array out of text: it strips a
+// markdown code fence if present, trims any prose before the first { or [
+// and after the matching last } or ], and drops trailing commas the model
+// sometimes leaves behind
+func extractJSON(text string) string {
+	if m := jsonFencePattern.FindStringSubmatch(text); m != nil {
+		text = m[1]
+	}
+	text = strings.TrimSpace(text)
+
+	if start := strings.IndexAny(text, "{["); start > 0 {
+		text = text[start:]
+	}
+	if end := strings.LastIndexAny(text, "}]"); end >= 0 && end < len(text)-1 {
+		text = text[:end+1]
+	}
+
+	return trailingCommaPattern.ReplaceAllString(text, "$1")
+}
+
+// validateSchema validates data (as decoded by json.Unmarshal into `any`)
+// against the draft-07 subset schema supports: type, properties, required,
+// items, enum, minimum/maximum, minLength/maxLength. It collects every
+// violation rather than stopping at the first, so GenerateStructured can
+// report them all in one repair prompt
+func validateSchema(schemaRaw json.RawMessage, data any) []string {
+	var schema map[string]any
+	if err := json.Unmarshal(schemaRaw, &schema); err != nil {
+		return []string{fmt.Sprintf("invalid schema: %v", err)}
+	}
+
+	var errs []string
+	validateNode(schema, data, "$", &errs)
+	return errs
+}
+
+func validateNode(schema map[string]any, data any, path string, errs *[]string) {
+	if t, ok := schema["type"].(string); ok && !matchesType(t, data) {
+		*errs = append(*errs, fmt.Sprintf("%s: expected type %s, got %s", path, t, jsonTypeName(data)))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]any); ok && !enumContains(enum, data) {
+		*errs = append(*errs, fmt.Sprintf("%s: value %v is not one of the allowed enum values", path, data))
+	}
+
+	switch v := data.(type) {
+	case map[string]any:
+		if required, ok := schema["required"].([]any); ok {
+			for _, r := range required {
+				name, _ := r.(string)
+				if _, present := v[name]; !present {
+					*errs = append(*errs, fmt.Sprintf("%s: missing required property %q", path, name))
+				}
+			}
+		}
+		if props, ok := schema["properties"].(map[string]any); ok {
+			for name, propSchemaRaw := range props {
+				propSchema, ok := propSchemaRaw.(map[string]any)
+				if !ok {
+					continue
+				}
+				if val, present := v[name]; present {
+					validateNode(propSchema, val, path+"."+name, errs)
+				}
+			}
+		}
+
+	case []any:
+		if itemSchema, ok := schema["items"].(map[string]any); ok {
+			for i, item := range v {
+				validateNode(itemSchema, item, fmt.Sprintf("%s[%d]", path, i), errs)
+			}
+		}
+
+	case string:
+		if minLen, ok := schema["minLength"].(float64); ok && float64(len(v)) < minLen {
+			*errs = append(*errs, fmt.Sprintf("%s: length %d is below minLength %v", path, len(v), minLen))
+		}
+		if maxLen, ok := schema["maxLength"].(float64); ok && float64(len(v)) > maxLen {
+			*errs = append(*errs, fmt.Sprintf("%s: length %d is above maxLength %v", path, len(v), maxLen))
+		}
+
+	case float64:
+		if minimum, ok := schema["minimum"].(float64); ok && v < minimum {
+			*errs = append(*errs, fmt.Sprintf("%s: value %v is below minimum %v", path, v, minimum))
+		}
+		if maximum, ok := schema["maximum"].(float64); ok && v > maximum {
+			*errs = append(*errs, fmt.Sprintf("%s: value %v is above maximum %v", path, v, maximum))
+		}
+	}
+}
+
+// matchesType reports whether data's decoded JSON type matches t
+func matchesType(t string, data any) bool {
+	switch t {
+	case "object":
+		_, ok := data.(map[string]any)
+		return ok
+	case "array":
+		_, ok := data.([]any)
+		return ok
+	case "string":
+		_, ok := data.(string)
+		return ok
+	case "boolean":
+		_, ok := data.(bool)
+		return ok
+	case "integer":
+		n, ok := data.(float64)
+		return ok && n == math.Trunc(n)
+	case "number":
+		_, ok := data.(float64)
+		return ok
+	case "null":
+		return data == nil
+	default:
+		return true
+	}
+}
+
+// jsonTypeName names data's decoded JSON type for error messages
+func jsonTypeName(data any) string {
+	switch data.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case nil:
+		return "null"
+	default:
+		return "unknown"
+	}
+}
+
+// enumContains reports whether data equals one of enum's values
+func enumContains(enum []any, data any) bool {
+	for _, e := range enum {
+		if reflect.DeepEqual(e, data) {
+			return true
+		}
+	}
+	return false
+}
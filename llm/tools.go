@@ -0,0 +1,87 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/sashabaranov/go-openai"
+
+	"wfrp-bot/agents"
+)
+
+// buildToolDefinitions converts an agent's allowed tools into the
+// openai.Tool schema accepted by ChatCompletionRequest.Tools
+func buildToolDefinitions(agent *agents.Agent) []openai.Tool {
+	if agent == nil {
+		return nil
+	}
+
+	tools := agent.Tools()
+	if len(tools) == 0 {
+		return nil
+	}
+
+	defs := make([]openai.Tool, 0, len(tools))
+	for _, tool := range tools {
+		defs = append(defs, openai.Tool{
+			Type: openai.ToolTypeFunction,
+			Function: &openai.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  json.RawMessage(tool.Parameters),
+			},
+		})
+	}
+	return defs
+}
+
+// toChatMessages converts conversation history into the OpenAI chat message
+// format, prepending the agent's system prompt unless the caller already
+// supplied one as the first message
+func toChatMessages(agent *agents.Agent, messages []Message) []openai.ChatCompletionMessage {
+	chatMessages := make([]openai.ChatCompletionMessage, 0, len(messages)+1)
+
+	if agent != nil && agent.SystemPrompt != "" && (len(messages) == 0 || messages[0].Role != openai.ChatMessageRoleSystem) {
+		chatMessages = append(chatMessages, openai.ChatCompletionMessage{Role: openai.ChatMessageRoleSystem, Content: agent.SystemPrompt})
+	}
+
+	for _, msg := range messages {
+		chatMessages = append(chatMessages, openai.ChatCompletionMessage{Role: msg.Role, Content: msg.Content})
+	}
+
+	return chatMessages
+}
+
+// runToolCalls executes the tool calls requested by the model and returns
+// the resulting "tool" role messages to append to the conversation
+func runToolCalls(ctx context.Context, agent *agents.Agent, calls []openai.ToolCall) []openai.ChatCompletionMessage {
+	messages := make([]openai.ChatCompletionMessage, 0, len(calls))
+
+	for _, call := range calls {
+		result, err := agent.Toolbox.Call(ctx, call.Function.Name, json.RawMessage(call.Function.Arguments))
+		if err != nil {
+			log.Printf("[AGENT] Tool %s failed: %v", call.Function.Name, err)
+			result = fmt.Sprintf("error: %v", err)
+		} else {
+			log.Printf("[AGENT] Tool %s -> %s", call.Function.Name, truncateForLog(result, 200))
+		}
+
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:       openai.ChatMessageRoleTool,
+			Content:    result,
+			ToolCallID: call.ID,
+		})
+	}
+
+	return messages
+}
+
+// truncateForLog shortens a string for logging purposes
+func truncateForLog(s string, maxLen int) string {
+	if len(s) <= maxLen {
+		return s
+	}
+	return s[:maxLen] + "..."
+}
@@ -0,0 +1,78 @@
+package llm
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// usageChunkPrefix marks the final value a StreamRequest channel sends
+// before closing, carrying the accumulated usage for the just-completed
+// stream. It mirrors the "Error: " sentinel convention every provider
+// already uses to report streaming failures in-band
+const usageChunkPrefix = "Usage: "
+
+// Usage carries token accounting for a single request: which provider/model
+// served it, how many tokens it used, and how long it took. GenerateRequest
+// returns it directly via GenerateResult; StreamRequest encodes it as the
+// final value on its channel (see FormatUsageChunk/ParseUsageChunk)
+type Usage struct {
+	Provider         string
+	Model            string
+	PromptTokens     int
+	CompletionTokens int
+	Duration         time.Duration
+}
+
+// GenerateResult is the structured response from GenerateRequest: the
+// generated text plus the usage accounting needed for cost tracking
+type GenerateResult struct {
+	Content string
+	Usage   Usage
+}
+
+// FormatUsageChunk encodes u as the sentinel value StreamRequest sends on
+// its channel right before closing it
+func FormatUsageChunk(u Usage) string {
+	data, err := json.Marshal(u)
+	if err != nil {
+		// Usage is always a plain value type; this only fails if json
+		// itself is broken, so there is nothing useful to fall back to
+		return usageChunkPrefix + "{}"
+	}
+	return usageChunkPrefix + string(data)
+}
+
+// ParseUsageChunk reports whether chunk is a usage sentinel emitted by
+// FormatUsageChunk, decoding it if so
+func ParseUsageChunk(chunk string) (Usage, bool) {
+	if !strings.HasPrefix(chunk, usageChunkPrefix) {
+		return Usage{}, false
+	}
+
+	var u Usage
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(chunk, usageChunkPrefix)), &u); err != nil {
+		return Usage{}, false
+	}
+
+	return u, true
+}
+
+// estimateTokens approximates a token count from raw text for providers
+// whose streaming APIs don't report usage incrementally. It is a rough
+// count of whitespace-separated words, not a real tokenizer, and is only used
+// as a fallback so StreamRequest can still emit a usage chunk
+func estimateTokens(text string) int {
+	return len(strings.Fields(text))
+}
+
+// estimateMessagesTokens sums estimateTokens over every message's content,
+// used as the prompt-token estimate when a provider's streaming API doesn't
+// report real usage
+func estimateMessagesTokens(messages []Message) int {
+	total := 0
+	for _, msg := range messages {
+		total += estimateTokens(msg.Content)
+	}
+	return total
+}
@@ -5,8 +5,12 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"strings"
+	"time"
 
 	"github.com/sashabaranov/go-openai"
+
+	"wfrp-bot/agents"
 )
 
 // ZAIProvider implements LLMProvider for z.ai (Claude API)
@@ -31,44 +35,75 @@ func NewZAIProvider(cfg *ProviderConfig) (*ZAIProvider, error) {
 }
 
 // GenerateRequest sends a request to z.ai provider and returns response
-func (p *ZAIProvider) GenerateRequest(ctx context.Context, prompt string, characterCards []string) (string, error) {
-	// Combine prompt with character cards context
-	fullPrompt := p.buildPrompt(prompt, characterCards)
-
-	req := openai.ChatCompletionRequest{
-		Model:       p.config.Model,
-		Messages:    []openai.ChatCompletionMessage{{Role: "user", Content: fullPrompt}},
-		MaxTokens:   4096,
-		Temperature: 0.7,
+//
+// When agent is non-nil and exposes tools, the request loops executing
+// tool calls (appending role:"tool" messages) until the model returns a
+// final answer or agent.Iterations() rounds are exhausted
+func (p *ZAIProvider) GenerateRequest(ctx context.Context, agent *agents.Agent, messages []Message) (GenerateResult, error) {
+	start := time.Now()
+	chatMessages := toChatMessages(agent, messages)
+
+	tools := buildToolDefinitions(agent)
+	maxIterations := 1
+	if len(tools) > 0 {
+		maxIterations = agent.Iterations()
 	}
 
-	resp, err := p.client.CreateChatCompletion(ctx, req)
-	if err != nil {
-		return "", fmt.Errorf("z.ai request failed: %w", err)
-	}
+	for i := 0; i < maxIterations; i++ {
+		req := openai.ChatCompletionRequest{
+			Model:       p.config.Model,
+			Messages:    chatMessages,
+			MaxTokens:   4096,
+			Temperature: 0.7,
+			Tools:       tools,
+		}
+
+		resp, err := p.client.CreateChatCompletion(ctx, req)
+		if err != nil {
+			return GenerateResult{}, fmt.Errorf("z.ai request failed: %w", err)
+		}
+
+		if len(resp.Choices) == 0 {
+			return GenerateResult{}, fmt.Errorf("z.ai returned empty response")
+		}
+
+		choice := resp.Choices[0]
+		if len(choice.Message.ToolCalls) == 0 {
+			return GenerateResult{
+				Content: choice.Message.Content,
+				Usage: Usage{
+					Provider:         "z.ai",
+					Model:            p.config.Model,
+					PromptTokens:     resp.Usage.PromptTokens,
+					CompletionTokens: resp.Usage.CompletionTokens,
+					Duration:         time.Since(start),
+				},
+			}, nil
+		}
 
-	if len(resp.Choices) == 0 {
-		return "", fmt.Errorf("z.ai returned empty response")
+		chatMessages = append(chatMessages, choice.Message)
+		chatMessages = append(chatMessages, runToolCalls(ctx, agent, choice.Message.ToolCalls)...)
 	}
 
-	return resp.Choices[0].Message.Content, nil
+	return GenerateResult{}, fmt.Errorf("z.ai tool-calling loop exceeded %d iterations", maxIterations)
 }
 
 // StreamRequest sends a streaming request to z.ai provider
-func (p *ZAIProvider) StreamRequest(ctx context.Context, prompt string, characterCards []string) (<-chan string, error) {
+func (p *ZAIProvider) StreamRequest(ctx context.Context, agent *agents.Agent, messages []Message) (<-chan string, error) {
 	ch := make(chan string)
 
 	go func() {
 		defer close(ch)
-
-		fullPrompt := p.buildPrompt(prompt, characterCards)
+		start := time.Now()
+		var full strings.Builder
 
 		req := openai.ChatCompletionRequest{
 			Model:       p.config.Model,
-			Messages:    []openai.ChatCompletionMessage{{Role: "user", Content: fullPrompt}},
+			Messages:    toChatMessages(agent, messages),
 			MaxTokens:   4096,
 			Temperature: 0.7,
 			Stream:      true,
+			Tools:       buildToolDefinitions(agent),
 		}
 
 		stream, err := p.client.CreateChatCompletionStream(ctx, req)
@@ -81,7 +116,7 @@ func (p *ZAIProvider) StreamRequest(ctx context.Context, prompt string, characte
 			resp, err := stream.Recv()
 			if err != nil {
 				if err == io.EOF {
-					return
+					break
 				}
 				ch <- fmt.Sprintf("Error: z.ai stream error: %v", err)
 				return
@@ -89,10 +124,19 @@ func (p *ZAIProvider) StreamRequest(ctx context.Context, prompt string, characte
 
 			for _, choice := range resp.Choices {
 				if len(choice.Delta.Content) > 0 {
+					full.WriteString(choice.Delta.Content)
 					ch <- choice.Delta.Content
 				}
 			}
 		}
+
+		ch <- FormatUsageChunk(Usage{
+			Provider:         "z.ai",
+			Model:            p.config.Model,
+			PromptTokens:     estimateMessagesTokens(messages),
+			CompletionTokens: estimateTokens(full.String()),
+			Duration:         time.Since(start),
+		})
 	}()
 
 	return ch, nil
@@ -104,21 +148,6 @@ func (p *ZAIProvider) Close() error {
 	return nil
 }
 
-// buildPrompt combines the prompt with character card context
-func (p *ZAIProvider) buildPrompt(prompt string, characterCards []string) string {
-	if len(characterCards) == 0 {
-		return prompt
-	}
-
-	contextStr := "--- CHARACTER CARDS ---\n"
-	for i, card := range characterCards {
-		contextStr += fmt.Sprintf("Character %d:\n%s\n\n", i+1, card)
-	}
-	contextStr += "--- END CHARACTER CARDS ---\n\n"
-
-	return contextStr + prompt
-}
-
 // parseConfig creates ProviderConfig from raw config data
 func parseZAIConfig(rawConfig map[string]interface{}) (*ProviderConfig, error) {
 	apiKey, ok := rawConfig["api_key"].(string)
@@ -1,17 +1,28 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
+	"strconv"
+	"sync"
 	"syscall"
 	"time"
 
 	"wfrp-bot/config"
 	"wfrp-bot/game"
+	"wfrp-bot/game/content"
 	"wfrp-bot/llm"
+	"wfrp-bot/rag"
+	"wfrp-bot/scheduler"
 	"wfrp-bot/storage"
 	"wfrp-bot/telegram"
+	"wfrp-bot/workqueue"
+	"wfrp-bot/xmpp"
 )
 
 // WFRP Game Master Bot - Telegram бот для ведения игр Warhammer Fantasy Roleplay 4th Edition
@@ -41,55 +52,209 @@ func main() {
 		basePath = "./storage"
 	}
 
-	// Инициализация LLM провайдера
-	_, err = llm.NewProviderFromConfig(&llm.ProviderConfig{
-		Name:    cfg.DefaultProvider,
-		APIKey:  cfg.Providers[cfg.DefaultProvider].APIKey,
-		BaseURL: cfg.Providers[cfg.DefaultProvider].BaseURL,
-		Model:   cfg.Providers[cfg.DefaultProvider].Model,
-	})
+	// Инициализация LLM провайдера с автоматическим переключением на
+	// cfg.FailoverChain, если основной провайдер недоступен (см.
+	// llm.NewProviderWithFailover и config.BotConfig.FailoverChain)
+	defaultProvider, err := buildFailoverProvider(cfg)
 	if err != nil {
 		log.Fatalf("Failed to create LLM provider: %v", err)
 	}
-	log.Printf("LLM provider initialized: %s", cfg.DefaultProvider)
+	if len(cfg.FailoverChain) > 0 {
+		log.Printf("LLM provider initialized: %s (failover chain: %v)", cfg.DefaultProvider, cfg.FailoverChain)
+	} else {
+		log.Printf("LLM provider initialized: %s", cfg.DefaultProvider)
+	}
 
-	// Создание LLM менеджера
-	_, err = llm.NewProviderManager(&llm.ProviderConfig{
-		Name:    cfg.DefaultProvider,
-		APIKey:  cfg.Providers[cfg.DefaultProvider].APIKey,
-		BaseURL: cfg.Providers[cfg.DefaultProvider].BaseURL,
-		Model:   cfg.Providers[cfg.DefaultProvider].Model,
-	})
-	if err != nil {
-		log.Fatalf("Failed to create provider manager: %v", err)
+	// Реестр менеджеров провайдеров, по одному на каждый настроенный
+	// провайдер, чтобы SIGHUP мог пересоздавать только изменившиеся клиенты
+	// (см. watchConfigReload) вместо полного перезапуска бота
+	providerManagers := make(map[string]*llm.ProviderManager)
+	var providerManagersMu sync.Mutex
+	for name, provider := range cfg.Providers {
+		pm, err := llm.NewProviderManager(&llm.ProviderConfig{
+			Name:              provider.Name,
+			APIKey:            provider.APIKey,
+			BaseURL:           provider.BaseURL,
+			Model:             provider.Model,
+			RequestsPerMinute: provider.RequestsPerMinute,
+		})
+		if err != nil {
+			log.Fatalf("Failed to create provider manager for %s: %v", name, err)
+		}
+		providerManagers[name] = pm
 	}
 
+	// Горячая перезагрузка учётных данных провайдеров по SIGHUP: меняются
+	// только провайдеры, отличающиеся в cfg.Diff, sessionManager и активные
+	// game.Session при этом не трогаются
+	watchConfigReload(cfg, providerManagers, &providerManagersMu)
+
 	// Инициализация Telegram бота
 	bot, err := telegram.NewBot(cfg.TelegramToken)
 	if err != nil {
 		log.Fatalf("Failed to create Telegram bot: %v", err)
 	}
 
-	// Создание rate limiter
-	limiter := telegram.NewRateLimiter(1 * time.Second)
+	// Создание rate limiter'ов: по пользователю и по чату - с запасом на
+	// всплески, общий - под лимит Telegram API (~30 сообщений/сек)
+	perUserLimiter := telegram.NewRateLimiter(1, 3)
+	perChatLimiter := telegram.NewRateLimiter(5, 15)
+	globalLimiter := telegram.NewRateLimiter(25, 30)
+
+	// Очередь исходящих сообщений: приоритеты, ограничение скорости
+	// (глобальное и по чату), персистентность на диске и отдельный
+	// поток, перегоняющий её до остановки бота
+	outboundQueue, err := workqueue.NewQueue(basePath)
+	if err != nil {
+		log.Fatalf("Failed to create outbound queue: %v", err)
+	}
+	if restored, err := outboundQueue.LoadPersisted(); err != nil {
+		log.Printf("Failed to load persisted outbound jobs: %v", err)
+	} else if restored > 0 {
+		log.Printf("Restored %d persisted outbound job(s)", restored)
+	}
+	queueStopChan := make(chan struct{})
+	go outboundQueue.Run(queueStopChan)
+
+	// Стример для длинных сообщений, делегирующий доставку каждого
+	// фрагмента outboundQueue вместо прямой отправки; ещё не подключён ни
+	// к одному обработчику команд, но регистрирует свой Handler сразу
+	_ = telegram.NewStreamer(bot, outboundQueue)
 
-	// Создание менеджера сессий
-	sessionManager := game.NewSessionManager()
+	// Экспорт метрик очереди для мониторинга
+	metricsAddr := os.Getenv("METRICS_ADDR")
+	if metricsAddr == "" {
+		metricsAddr = ":9090"
+	}
+	http.HandleFunc("/metrics", outboundQueue.MetricsHandler())
+	go func() {
+		if err := http.ListenAndServe(metricsAddr, nil); err != nil {
+			log.Printf("Metrics server stopped: %v", err)
+		}
+	}()
+
+	// Создание менеджера сессий (сохраняет сессии под basePath/sessions,
+	// переживает перезапуск бота)
+	sessionManager := game.NewSessionManager(basePath)
 
 	// Передача sessionManager в bot для обработки сообщений игроков
 	bot.SetSessionManager(sessionManager)
 
+	// Периодическая очистка сессий, простаивающих дольше суток
+	go sweepIdleSessions(sessionManager, 24*time.Hour)
+
 	// Создание менеджера персонажей
 	characterManager := game.NewCharacterManager(basePath)
+	if err := characterManager.WatchForChanges(); err != nil {
+		log.Printf("Character file watcher disabled: %v", err)
+	}
 
 	// Создание менеджера кампаний
 	campaignManager := storage.NewCampaignManager(basePath)
 
-	// Создание менеджера истории
-	_ = storage.NewHistoryManager(basePath)
+	// Подключение RAG-индексации заметок кампании (опционально, требует Qdrant)
+	if qdrantAddr := os.Getenv("QDRANT_ADDR"); qdrantAddr != "" {
+		if indexer, err := newRAGIndexer(cfg, qdrantAddr); err != nil {
+			log.Printf("Failed to initialize RAG indexer: %v", err)
+		} else {
+			campaignManager.SetIndexer(indexer)
+			log.Printf("RAG indexing enabled (Qdrant at %s)", qdrantAddr)
+		}
+	}
+
+	// Подключение векторного поиска правил (опционально): даёт
+	// RuleChecker.SearchRules реальные оценки похожести вместо
+	// фиксированной уверенности совпадения по шаблону
+	if ruleIndex, err := newRuleRAGIndex(cfg); err != nil {
+		log.Printf("Failed to initialize rule RAG index: %v", err)
+	} else if ruleIndex != nil {
+		sessionManager.SetRuleIndex(ruleIndex)
+		log.Printf("Rule RAG index enabled (%s)", os.Getenv("RULE_RAG_PROVIDER"))
+	}
+
+	// Подключение ретривера свода правил (опционально, требует тот же
+	// индексатор, что и RAG заметок кампании выше): вместо статического
+	// списка PromptBuilder.AddRule в системный промпт попадают только
+	// фрагменты, релевантные текущему действию игрока
+	if rulebookDir := os.Getenv("RULEBOOK_DIR"); rulebookDir != "" {
+		if campaignManager.Indexer() == nil {
+			log.Printf("RULEBOOK_DIR set but no RAG indexer is configured (set QDRANT_ADDR)")
+		} else if retriever, err := newRulebookRetriever(campaignManager.Indexer(), rulebookDir); err != nil {
+			log.Printf("Failed to initialize rulebook retriever: %v", err)
+		} else {
+			sessionManager.SetRulesRetriever(retriever)
+			log.Printf("Rulebook retrieval enabled (%s)", rulebookDir)
+		}
+	}
+
+	// Подключение XMPP-шлюза (опционально), чтобы к кампании могли
+	// присоединяться игроки не из Telegram через MUC-комнату
+	if xmppAddr := os.Getenv("XMPP_COMPONENT_ADDR"); xmppAddr != "" {
+		if err := setupXMPPGateway(xmppAddr, sessionManager); err != nil {
+			log.Printf("XMPP gateway disabled: %v", err)
+		}
+	}
+
+	// Периодическое резервное копирование кампании в .tar.gz (опционально,
+	// см. storage.CampaignManager.StartSnapshotScheduler)
+	if backupDir := os.Getenv("BACKUP_DIR"); backupDir != "" {
+		if backupCampaign := os.Getenv("BACKUP_CAMPAIGN"); backupCampaign != "" {
+			interval := 6 * time.Hour
+			if hours := os.Getenv("BACKUP_INTERVAL_HOURS"); hours != "" {
+				if n, err := strconv.Atoi(hours); err == nil && n > 0 {
+					interval = time.Duration(n) * time.Hour
+				}
+			}
+			campaignManager.StartSnapshotScheduler(backupCampaign, backupDir, interval)
+			log.Printf("Scheduled backups for campaign %q every %s to %s", backupCampaign, interval, backupDir)
+		}
+	}
+
+	// Восстановление игровых сессий, сохранённых до перезапуска
+	if restored, err := sessionManager.Rehydrate(context.Background(), defaultProvider, campaignManager); err != nil {
+		log.Printf("Failed to rehydrate persisted sessions: %v", err)
+	} else if len(restored) > 0 {
+		log.Printf("Rehydrated %d persisted session(s): %v", len(restored), restored)
+	}
+
+	// Создание менеджера истории (кэш над markdown-файлами сессий в SQLite)
+	if historyManager, err := storage.NewHistoryManager(basePath); err != nil {
+		log.Printf("Failed to initialize history manager: %v", err)
+	} else if err := historyManager.IndexSessions(); err != nil {
+		log.Printf("Failed to index session history: %v", err)
+	}
+
+	// Загрузка расы/карьеры для создания персонажей
+	contentPath := os.Getenv("CONTENT_PATH")
+	if contentPath == "" {
+		contentPath = "./game/content/data"
+	}
+	contentProvider, err := content.NewFileContentLoader().Load(contentPath)
+	if err != nil {
+		log.Fatalf("Failed to load content data: %v", err)
+	}
 
 	// Создание обработчиков команд
-	handlers := telegram.NewCommandHandlers(bot, sessionManager, characterManager, campaignManager)
+	handlers := telegram.NewCommandHandlers(bot, sessionManager, characterManager, campaignManager, contentProvider)
+
+	// Лобби матчмейкинга drop-in столов: игроки встают в очередь через
+	// /queue вместо заранее собранной Telegram-группы, см. game.Lobby и
+	// telegram.RegisterLobbyHandlers
+	lobby := game.NewLobby(context.Background(), defaultProvider, campaignManager, sessionManager)
+	lobby.StartMatching()
+	handlers.RegisterLobbyHandlers(lobby)
+
+	// Планировщик фоновых задач (напоминания, затухание состояний и т.д.),
+	// см. scheduler.Scheduler и telegram.RegisterSchedulerHandlers
+	jobScheduler := scheduler.New(basePath)
+	handlers.RegisterSchedulerHandlers(jobScheduler)
+	if restored, err := jobScheduler.LoadPersisted(); err != nil {
+		log.Printf("Failed to load persisted scheduled jobs: %v", err)
+	} else if restored > 0 {
+		log.Printf("Restored %d persisted scheduled job(s)", restored)
+	}
+	schedulerStopChan := make(chan struct{})
+	go jobScheduler.Run(schedulerStopChan)
 
 	// Регистрация всех обработчиков
 	handlers.RegisterAllHandlers()
@@ -97,9 +262,15 @@ func main() {
 	// Передача обработчиков в бота для обработки создания персонажей
 	bot.SetCommandHandlers(handlers)
 
+	// Восстановление незавершённых созданий персонажа после перезапуска
+	handlers.RestoreOutstandingCreations("./characters")
+
+	// Периодическая очистка брошенных созданий персонажа (старше 7 дней)
+	go sweepChargenStates("./characters", 7*24*time.Hour)
+
 	// Добавление middleware для логирования, ограничений и работы только в группе
 	bot.AddMiddleware(telegram.LoggingMiddleware)
-	bot.AddMiddleware(telegram.RateLimitMiddleware(limiter))
+	bot.AddMiddleware(telegram.RateLimitMiddleware(bot, perUserLimiter, perChatLimiter, globalLimiter))
 	bot.AddMiddleware(telegram.GroupOnlyMiddleware(cfg.GroupID))
 
 	// Запуск бота
@@ -118,5 +289,309 @@ func main() {
 
 	log.Println("Shutting down bot...")
 	bot.Stop()
+	campaignManager.StopSnapshotScheduler()
+	close(schedulerStopChan)
+	close(queueStopChan)
+	if err := characterManager.Close(); err != nil {
+		log.Printf("Failed to close character manager cleanly: %v", err)
+	}
 	log.Println("Bot stopped")
 }
+
+// buildFailoverProvider builds cfg.DefaultProvider plus cfg.FailoverChain as
+// a single llm.LLMProvider: each provider in the chain is wrapped with a
+// circuit breaker, and the whole ordered list falls through on error via
+// llm.NewProviderWithFailover, so a request against DefaultProvider
+// transparently moves to the next configured provider instead of failing
+func buildFailoverProvider(cfg config.BotConfig) (llm.LLMProvider, error) {
+	chain := cfg.ResolveProviderChain()
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no configuration found for provider %q", cfg.DefaultProvider)
+	}
+
+	configs := make([]*llm.ProviderConfig, 0, len(chain))
+	for _, pc := range chain {
+		configs = append(configs, &llm.ProviderConfig{
+			Name:              pc.Name,
+			APIKey:            pc.APIKey,
+			BaseURL:           pc.BaseURL,
+			Model:             pc.Model,
+			RequestsPerMinute: pc.RequestsPerMinute,
+		})
+	}
+
+	return llm.NewProviderWithFailover(configs)
+}
+
+// sweepChargenStates removes in-progress character creations older than
+// maxAge from basePath/chargen once at startup and then once a day, so
+// players who never came back to /resume don't leave files behind forever
+func sweepChargenStates(basePath string, maxAge time.Duration) {
+	sweep := func() {
+		removed, err := game.SweepExpiredChargenStates(basePath, maxAge)
+		if err != nil {
+			log.Printf("[CHARGEN] Sweep failed: %v", err)
+			return
+		}
+		if removed > 0 {
+			log.Printf("[CHARGEN] Swept %d expired in-progress character creation(s)", removed)
+		}
+	}
+
+	sweep()
+
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		sweep()
+	}
+}
+
+// sweepIdleSessions prunes (and un-persists) sessions idle longer than
+// maxIdle once at startup and then once a day, mirroring sweepChargenStates
+func sweepIdleSessions(sessionManager *game.SessionManager, maxIdle time.Duration) {
+	sweep := func() {
+		pruned := sessionManager.PruneIdle(maxIdle)
+		if len(pruned) > 0 {
+			log.Printf("[SESSION] Pruned %d idle session(s): %v", len(pruned), pruned)
+		}
+	}
+
+	sweep()
+
+	ticker := time.NewTicker(24 * time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		sweep()
+	}
+}
+
+// watchConfigReload registers the SIGHUP handler that rotates LLM provider
+// credentials at runtime. On each signal it reloads config.BotConfig,
+// diffs it against the last-known config, and only rebuilds the provider
+// managers that actually changed (added/changed) or tears down ones that
+// were removed - sessionManager and any in-progress game.Session are never
+// touched, so players keep their place in the conversation across a rotate
+func watchConfigReload(initial config.BotConfig, providerManagers map[string]*llm.ProviderManager, mu *sync.Mutex) {
+	current := initial
+
+	config.SetupConfigReload(func() error {
+		newCfg, err := config.ReloadConfig()
+		if err != nil {
+			return err
+		}
+
+		diffs := newCfg.Diff(current)
+		if len(diffs) == 0 {
+			log.Println("Config reloaded, no provider changes")
+			current = newCfg
+			return nil
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, diff := range diffs {
+			switch {
+			case diff.Removed:
+				delete(providerManagers, diff.Name)
+				log.Printf("Provider %s removed", diff.Name)
+
+			case diff.Added:
+				provider := newCfg.Providers[diff.Name]
+				pm, err := llm.NewProviderManager(&llm.ProviderConfig{
+					Name:              provider.Name,
+					APIKey:            provider.APIKey,
+					BaseURL:           provider.BaseURL,
+					Model:             provider.Model,
+					RequestsPerMinute: provider.RequestsPerMinute,
+				})
+				if err != nil {
+					log.Printf("Failed to initialize new provider %s: %v", diff.Name, err)
+					continue
+				}
+				providerManagers[diff.Name] = pm
+				log.Printf("Provider %s added", diff.Name)
+
+			case diff.Changed:
+				pm, ok := providerManagers[diff.Name]
+				if !ok {
+					continue
+				}
+				provider := newCfg.Providers[diff.Name]
+				if err := pm.ReloadProvider(&llm.ProviderConfig{
+					Name:              provider.Name,
+					APIKey:            provider.APIKey,
+					BaseURL:           provider.BaseURL,
+					Model:             provider.Model,
+					RequestsPerMinute: provider.RequestsPerMinute,
+				}); err != nil {
+					log.Printf("Failed to reload provider %s: %v", diff.Name, err)
+				}
+			}
+		}
+
+		current = newCfg
+		return nil
+	})
+}
+
+// setupXMPPGateway connects the optional XEP-0114 component gateway at
+// addr (XMPP_COMPONENT_JID and XMPP_COMPONENT_SECRET must also be set),
+// and, if XMPP_GAME_ROOM names a MUC room, joins it and attaches the
+// resulting xmpp.Bot to that room's game.Session via AttachTransport -
+// XMPP_COMPONENT_NICK picks the nick it joins under, defaulting to "GM"
+func setupXMPPGateway(addr string, sessionManager *game.SessionManager) error {
+	jid := os.Getenv("XMPP_COMPONENT_JID")
+	secret := os.Getenv("XMPP_COMPONENT_SECRET")
+	if jid == "" || secret == "" {
+		return fmt.Errorf("XMPP_COMPONENT_JID and XMPP_COMPONENT_SECRET are required")
+	}
+
+	component, err := xmpp.Dial(addr, jid, secret)
+	if err != nil {
+		return err
+	}
+
+	nick := os.Getenv("XMPP_COMPONENT_NICK")
+	if nick == "" {
+		nick = "GM"
+	}
+
+	bot := xmpp.NewBot(component, nick)
+	bot.SetSessionManager(sessionManager)
+	bot.Start()
+
+	if room := os.Getenv("XMPP_GAME_ROOM"); room != "" {
+		chatID := xmpp.RoomChatID(room)
+		if err := bot.JoinRoom(room, chatID); err != nil {
+			return err
+		}
+		sessionManager.AttachTransport(chatID, bot)
+		log.Printf("XMPP gateway joined %s (chat %d)", room, chatID)
+	}
+
+	return nil
+}
+
+// newRAGIndexer builds a rag.Indexer from environment configuration:
+// RAG_EMBEDDER selects "openai" (default, using the openai provider's API
+// key) or "ollama" (using OLLAMA_BASE_URL / OLLAMA_EMBED_MODEL), and qdrantAddr
+// is the Qdrant gRPC address (host:port) to store embeddings in
+func newRAGIndexer(cfg config.BotConfig, qdrantAddr string) (*rag.Indexer, error) {
+	var embedder rag.Embedder
+
+	switch os.Getenv("RAG_EMBEDDER") {
+	case "ollama":
+		embedder = rag.NewOllamaEmbedder(os.Getenv("OLLAMA_BASE_URL"), os.Getenv("OLLAMA_EMBED_MODEL"), 0)
+	default:
+		apiKey := cfg.Providers["openai"].APIKey
+		if apiKey == "" {
+			apiKey = os.Getenv("OPENAI_API_KEY")
+		}
+
+		openaiEmbedder, err := rag.NewOpenAIEmbedder(apiKey)
+		if err != nil {
+			return nil, err
+		}
+		embedder = openaiEmbedder
+	}
+
+	store, err := rag.NewStore(qdrantAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	return rag.NewIndexer(embedder, store), nil
+}
+
+// newRuleRAGIndex builds a game.RuleIndex from environment configuration,
+// or returns (nil, nil) when RULE_RAG_PROVIDER is unset - this index is
+// opt-in, separate from newRAGIndexer's campaign-notes Qdrant index, so it
+// uses its own RULE_RAG_* env vars rather than RAG_EMBEDDER/OLLAMA_*/
+// QDRANT_ADDR. RULE_RAG_PROVIDER selects "openai" (using RULE_RAG_API_KEY,
+// falling back to the openai provider's key), "ollama" (using
+// RULE_RAG_API_BASE as Ollama's base URL) or "local" (the dependency-free
+// hashing fallback, see game.LocalRAGClient). RULE_RAG_MODEL, RULE_RAG_TOP_K
+// and RULE_RAG_INDEX_PATH configure the model name, recall size and where
+// embeddings are persisted; if RULE_RAG_RULEBOOK_DIR is also set, every
+// .md file under it is ingested on startup
+func newRuleRAGIndex(cfg config.BotConfig) (*game.RuleIndex, error) {
+	provider := os.Getenv("RULE_RAG_PROVIDER")
+	if provider == "" {
+		return nil, nil
+	}
+
+	model := os.Getenv("RULE_RAG_MODEL")
+	apiBase := os.Getenv("RULE_RAG_API_BASE")
+
+	var client game.RAGClient
+	switch provider {
+	case "openai":
+		apiKey := os.Getenv("RULE_RAG_API_KEY")
+		if apiKey == "" {
+			apiKey = cfg.Providers["openai"].APIKey
+		}
+
+		openaiClient, err := game.NewOpenAIRAGClient(apiKey, apiBase, model)
+		if err != nil {
+			return nil, err
+		}
+		client = openaiClient
+	case "ollama":
+		client = game.NewOllamaRAGClient(apiBase, model)
+	case "local":
+		client = game.NewLocalRAGClient()
+	default:
+		return nil, fmt.Errorf("unknown RULE_RAG_PROVIDER %q", provider)
+	}
+
+	topK := 0
+	if n, err := strconv.Atoi(os.Getenv("RULE_RAG_TOP_K")); err == nil {
+		topK = n
+	}
+	indexPath := os.Getenv("RULE_RAG_INDEX_PATH")
+
+	idx := game.NewRuleIndex(client, indexPath, topK)
+
+	if rulebookDir := os.Getenv("RULE_RAG_RULEBOOK_DIR"); rulebookDir != "" {
+		files, err := filepath.Glob(filepath.Join(rulebookDir, "*.md"))
+		if err != nil {
+			return nil, fmt.Errorf("listing rulebook files in %s: %w", rulebookDir, err)
+		}
+		for _, file := range files {
+			if err := idx.Ingest(context.Background(), file); err != nil {
+				log.Printf("Failed to ingest rulebook file %s: %v", file, err)
+			}
+		}
+	}
+
+	return idx, nil
+}
+
+// newRulebookRetriever builds a game.RulebookRetriever over indexer and
+// ingests every .md file under rulebookDir into it, so Session can splice
+// the specific rulebook snippets a player's turn needs into the system
+// prompt (see game.Session.SetRulesRetriever) instead of always dumping
+// the static rule list
+func newRulebookRetriever(indexer *rag.Indexer, rulebookDir string) (*game.RulebookRetriever, error) {
+	retriever := game.NewRulebookRetriever(indexer)
+
+	files, err := filepath.Glob(filepath.Join(rulebookDir, "*.md"))
+	if err != nil {
+		return nil, fmt.Errorf("listing rulebook files in %s: %w", rulebookDir, err)
+	}
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			log.Printf("Failed to read rulebook file %s: %v", file, err)
+			continue
+		}
+		if err := retriever.IndexRulebook(context.Background(), file, string(content)); err != nil {
+			log.Printf("Failed to index rulebook file %s: %v", file, err)
+		}
+	}
+
+	return retriever, nil
+}
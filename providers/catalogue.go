@@ -0,0 +1,52 @@
+// Package providers is a small, dependency-free registry of the LLM
+// providers this bot knows how to talk to out of the box. It holds pure
+// data (default BaseURL/Model per provider name) so config.loadConfigFrom
+// can look defaults up instead of hard-coding a block per provider; it
+// deliberately doesn't import llm or config itself, so either can depend
+// on it without a cycle
+package providers
+
+// Defaults holds the fallback BaseURL/Model used when an operator sets
+// {NAME}_API_KEY but not the matching {NAME}_BASE_URL/{NAME}_MODEL
+type Defaults struct {
+	// DisplayName is the value stored in ProviderConfig.Name, which
+	// llm.NewProviderFromConfig switches on to pick the concrete provider
+	// implementation (e.g. "z.ai", not the env-var-derived key "zai")
+	DisplayName string
+	BaseURL     string
+	Model       string
+}
+
+// Catalogue returns the built-in providers' defaults, keyed by the
+// lowercase name used in their {NAME}_API_KEY env var. Providers not
+// listed here (operator-defined via CUSTOM_PROVIDERS, or "custom" itself)
+// fall back to the OpenAI-compatible defaults in config.loadConfigFrom
+func Catalogue() map[string]Defaults {
+	return map[string]Defaults{
+		"zai": {
+			DisplayName: "z.ai",
+			BaseURL:     "https://api.z.ai/v1",
+			Model:       "claude-3-5-sonnet-20240228",
+		},
+		"minimax": {
+			DisplayName: "minimax",
+			BaseURL:     "https://api.minimax.chat/v1",
+			Model:       "minimax-text",
+		},
+		"anthropic": {
+			DisplayName: "anthropic",
+			BaseURL:     "https://api.anthropic.com/v1",
+			Model:       "claude-3-5-sonnet-20241022",
+		},
+		"gemini": {
+			DisplayName: "gemini",
+			BaseURL:     "https://generativelanguage.googleapis.com/v1beta",
+			Model:       "gemini-1.5-pro",
+		},
+		"openai": {
+			DisplayName: "openai",
+			BaseURL:     "https://api.openai.com/v1",
+			Model:       "gpt-4o",
+		},
+	}
+}
@@ -0,0 +1,52 @@
+package rag
+
+import "strings"
+
+// Chunk is a piece of source text ready to be embedded and indexed, together
+// with enough metadata to show the GM where it came from
+type Chunk struct {
+	Source  string // file path the chunk was extracted from
+	Heading string // nearest preceding markdown heading, if any
+	Text    string
+}
+
+// ChunkMarkdown splits markdown content into chunks along heading and
+// paragraph boundaries, attaching the nearest heading to each chunk so
+// retrieved results can be attributed to a section
+func ChunkMarkdown(source, content string) []Chunk {
+	var chunks []Chunk
+
+	heading := ""
+	var paragraph strings.Builder
+
+	flush := func() {
+		text := strings.TrimSpace(paragraph.String())
+		if text != "" {
+			chunks = append(chunks, Chunk{Source: source, Heading: heading, Text: text})
+		}
+		paragraph.Reset()
+	}
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "#") {
+			flush()
+			heading = strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			continue
+		}
+
+		if trimmed == "" {
+			flush()
+			continue
+		}
+
+		if paragraph.Len() > 0 {
+			paragraph.WriteString(" ")
+		}
+		paragraph.WriteString(trimmed)
+	}
+	flush()
+
+	return chunks
+}
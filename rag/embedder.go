@@ -0,0 +1,161 @@
+// Package rag provides retrieval-augmented generation over campaign notes:
+// chunking markdown files, embedding the chunks and storing/searching them
+// in a vector database
+package rag
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/sashabaranov/go-openai"
+)
+
+// Embedder turns text chunks into vector embeddings for similarity search
+type Embedder interface {
+	// Embed returns one embedding vector per input text, in the same order
+	// as texts
+	Embed(ctx context.Context, texts []string) ([][]float32, error)
+
+	// Dimensions returns the size of vectors produced by this embedder
+	Dimensions() int
+}
+
+// OpenAIEmbedder embeds text using OpenAI's text-embedding-3-small model
+type OpenAIEmbedder struct {
+	client *openai.Client
+	model  openai.EmbeddingModel
+	dims   int
+}
+
+// NewOpenAIEmbedder creates an embedder backed by the OpenAI embeddings API
+func NewOpenAIEmbedder(apiKey string) (*OpenAIEmbedder, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("API key is required for OpenAI embedder")
+	}
+
+	return &OpenAIEmbedder{
+		client: openai.NewClient(apiKey),
+		model:  openai.SmallEmbedding3,
+		dims:   1536,
+	}, nil
+}
+
+// Embed sends texts to the OpenAI embeddings API and returns one vector per input
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	if len(texts) == 0 {
+		return nil, nil
+	}
+
+	resp, err := e.client.CreateEmbeddings(ctx, openai.EmbeddingRequestStrings{
+		Input: texts,
+		Model: e.model,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("OpenAI embedding request failed: %w", err)
+	}
+
+	vectors := make([][]float32, len(resp.Data))
+	for i, d := range resp.Data {
+		vectors[i] = d.Embedding
+	}
+
+	return vectors, nil
+}
+
+// Dimensions returns the size of vectors produced by text-embedding-3-small
+func (e *OpenAIEmbedder) Dimensions() int {
+	return e.dims
+}
+
+// OllamaEmbedder embeds text using a locally running Ollama embedding model
+type OllamaEmbedder struct {
+	baseURL string
+	model   string
+	dims    int
+	http    *http.Client
+}
+
+// NewOllamaEmbedder creates an embedder backed by a local Ollama instance.
+// baseURL defaults to http://localhost:11434 and model to "nomic-embed-text"
+// when empty
+func NewOllamaEmbedder(baseURL, model string, dims int) *OllamaEmbedder {
+	if baseURL == "" {
+		baseURL = "http://localhost:11434"
+	}
+	if model == "" {
+		model = "nomic-embed-text"
+	}
+	if dims == 0 {
+		dims = 768
+	}
+
+	return &OllamaEmbedder{
+		baseURL: baseURL,
+		model:   model,
+		dims:    dims,
+		http:    &http.Client{},
+	}
+}
+
+type ollamaEmbedRequest struct {
+	Model  string `json:"model"`
+	Prompt string `json:"prompt"`
+}
+
+type ollamaEmbedResponse struct {
+	Embedding []float32 `json:"embedding"`
+}
+
+// Embed calls Ollama's /api/embeddings endpoint once per text, since Ollama
+// does not support batched embedding requests
+func (e *OllamaEmbedder) Embed(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, 0, len(texts))
+
+	for _, text := range texts {
+		vector, err := e.embedOne(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, vector)
+	}
+
+	return vectors, nil
+}
+
+func (e *OllamaEmbedder) embedOne(ctx context.Context, text string) ([]float32, error) {
+	body, err := json.Marshal(ollamaEmbedRequest{Model: e.model, Prompt: text})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode Ollama embedding request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.baseURL+"/api/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build Ollama embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := e.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("Ollama embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Ollama embedding request returned status %d", resp.StatusCode)
+	}
+
+	var result ollamaEmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to parse Ollama embedding response: %w", err)
+	}
+
+	return result.Embedding, nil
+}
+
+// Dimensions returns the configured vector size for this Ollama model
+func (e *OllamaEmbedder) Dimensions() int {
+	return e.dims
+}
@@ -0,0 +1,97 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+)
+
+// Indexer embeds and stores campaign markdown so it can later be retrieved
+// by similarity search. One Qdrant collection is used per campaign
+type Indexer struct {
+	embedder Embedder
+	store    *Store
+}
+
+// NewIndexer creates an Indexer backed by embedder and store
+func NewIndexer(embedder Embedder, store *Store) *Indexer {
+	return &Indexer{embedder: embedder, store: store}
+}
+
+// collectionName returns the Qdrant collection used for a campaign's index
+func collectionName(campaign string) string {
+	return fmt.Sprintf("campaign-%s", campaign)
+}
+
+// IndexFile chunks a markdown file, embeds each chunk and upserts it into
+// the campaign's collection. Chunk IDs come from the path and the chunk's
+// position, so re-indexing overwrites the earlier chunks at the same
+// positions; chunks beyond the new count (or all of them, when the file now
+// yields no chunks) are not removed
+func (idx *Indexer) IndexFile(ctx context.Context, campaign, path, content string) error {
+	chunks := ChunkMarkdown(path, content)
+	if len(chunks) == 0 {
+		return nil
+	}
+
+	collection := collectionName(campaign)
+	if err := idx.store.EnsureCollection(ctx, collection, idx.embedder.Dimensions()); err != nil {
+		return err
+	}
+
+	texts := make([]string, len(chunks))
+	for i, chunk := range chunks {
+		texts[i] = chunk.Text
+	}
+
+	vectors, err := idx.embedder.Embed(ctx, texts)
+	if err != nil {
+		return fmt.Errorf("failed to embed chunks from %s: %w", path, err)
+	}
+
+	points := make([]Point, len(chunks))
+	for i, chunk := range chunks {
+		points[i] = Point{
+			ID:     chunkID(path, i),
+			Vector: vectors[i],
+			Payload: map[string]string{
+				"source":  chunk.Source,
+				"heading": chunk.Heading,
+				"text":    chunk.Text,
+			},
+		}
+	}
+
+	return idx.store.Upsert(ctx, collection, points)
+}
+
+// Search embeds query and returns the topK most relevant chunks previously
+// indexed for campaign
+func (idx *Indexer) Search(ctx context.Context, campaign, query string, topK int) ([]SearchResult, error) {
+	vectors, err := idx.embedder.Embed(ctx, []string{query})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed query: %w", err)
+	}
+	if len(vectors) == 0 {
+		return nil, nil
+	}
+
+	return idx.store.Search(ctx, collectionName(campaign), vectors[0], topK)
+}
+
+// chunkID derives a stable point ID for the i-th chunk of path, so
+// re-indexing the same file overwrites its previous chunks rather than
+// accumulating duplicates
+func chunkID(path string, i int) uint64 {
+	return hashPath(path)*1000 + uint64(i)
+}
+
+// hashPath returns a stable, non-cryptographic hash of path, used to build
+// deterministic point IDs
+func hashPath(path string) uint64 {
+	var h uint64 = 14695981039346656037
+	for i := 0; i < len(path); i++ {
+		h ^= uint64(path[i])
+		h *= 1099511628211
+	}
+	return h % 1000000000000
+}
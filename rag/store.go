@@ -0,0 +1,130 @@
+package rag
+
+import (
+	"context"
+	"fmt"
+
+	qdrant "github.com/qdrant/go-client/qdrant"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+)
+
+// Point is a single embedded chunk ready to be upserted into the vector store
+type Point struct {
+	ID      uint64
+	Vector  []float32
+	Payload map[string]string
+}
+
+// SearchResult is a chunk retrieved by similarity search, together with its
+// relevance score
+type SearchResult struct {
+	Score   float32
+	Payload map[string]string
+}
+
+// Store is a thin wrapper around the Qdrant gRPC API used to persist and
+// search campaign note embeddings
+type Store struct {
+	conn        *grpc.ClientConn
+	points      qdrant.PointsClient
+	collections qdrant.CollectionsClient
+}
+
+// NewStore dials a Qdrant instance at addr (host:port of its gRPC port,
+// typically 6334)
+func NewStore(addr string) (*Store, error) {
+	conn, err := grpc.Dial(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Qdrant at %s: %w", addr, err)
+	}
+
+	return &Store{
+		conn:        conn,
+		points:      qdrant.NewPointsClient(conn),
+		collections: qdrant.NewCollectionsClient(conn),
+	}, nil
+}
+
+// EnsureCollection creates a collection with the given vector size if it
+// doesn't already exist
+func (s *Store) EnsureCollection(ctx context.Context, collection string, dims int) error {
+	_, err := s.collections.Create(ctx, &qdrant.CreateCollection{
+		CollectionName: collection,
+		VectorsConfig: &qdrant.VectorsConfig{
+			Config: &qdrant.VectorsConfig_Params{
+				Params: &qdrant.VectorParams{
+					Size:     uint64(dims),
+					Distance: qdrant.Distance_Cosine,
+				},
+			},
+		},
+	})
+	if err != nil && status.Code(err) != codes.AlreadyExists {
+		return fmt.Errorf("failed to create Qdrant collection %s: %w", collection, err)
+	}
+
+	return nil
+}
+
+// Upsert inserts or overwrites points in a collection
+func (s *Store) Upsert(ctx context.Context, collection string, points []Point) error {
+	if len(points) == 0 {
+		return nil
+	}
+
+	pbPoints := make([]*qdrant.PointStruct, len(points))
+	for i, p := range points {
+		payload := make(map[string]*qdrant.Value, len(p.Payload))
+		for key, value := range p.Payload {
+			payload[key] = &qdrant.Value{Kind: &qdrant.Value_StringValue{StringValue: value}}
+		}
+
+		pbPoints[i] = &qdrant.PointStruct{
+			Id:      &qdrant.PointId{PointIdOptions: &qdrant.PointId_Num{Num: p.ID}},
+			Vectors: &qdrant.Vectors{VectorsOptions: &qdrant.Vectors_Vector{Vector: &qdrant.Vector{Data: p.Vector}}},
+			Payload: payload,
+		}
+	}
+
+	_, err := s.points.Upsert(ctx, &qdrant.UpsertPoints{
+		CollectionName: collection,
+		Points:         pbPoints,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upsert points into %s: %w", collection, err)
+	}
+
+	return nil
+}
+
+// Search returns the topK points most similar to vector
+func (s *Store) Search(ctx context.Context, collection string, vector []float32, topK int) ([]SearchResult, error) {
+	resp, err := s.points.Search(ctx, &qdrant.SearchPoints{
+		CollectionName: collection,
+		Vector:         vector,
+		Limit:          uint64(topK),
+		WithPayload:    &qdrant.WithPayloadSelector{SelectorOptions: &qdrant.WithPayloadSelector_Enable{Enable: true}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to search %s: %w", collection, err)
+	}
+
+	results := make([]SearchResult, len(resp.Result))
+	for i, scored := range resp.Result {
+		payload := make(map[string]string, len(scored.Payload))
+		for key, value := range scored.Payload {
+			payload[key] = value.GetStringValue()
+		}
+		results[i] = SearchResult{Score: scored.Score, Payload: payload}
+	}
+
+	return results, nil
+}
+
+// Close closes the underlying gRPC connection
+func (s *Store) Close() error {
+	return s.conn.Close()
+}
@@ -0,0 +1,44 @@
+package scheduler
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// jobHeap is a container/heap.Interface ordering Jobs by FireAt, so the
+// earliest-due job is always at index 0
+type jobHeap []Job
+
+func (h jobHeap) Len() int            { return len(h) }
+func (h jobHeap) Less(i, j int) bool  { return h[i].FireAt.Before(h[j].FireAt) }
+func (h jobHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *jobHeap) Push(x interface{}) { *h = append(*h, x.(Job)) }
+func (h *jobHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	job := old[n-1]
+	*h = old[:n-1]
+	return job
+}
+
+var _ heap.Interface = (*jobHeap)(nil)
+
+// heapPush, heapPop and heapRemove adapt container/heap's free functions to
+// jobHeap's element type, so Scheduler's methods read like plain heap
+// operations instead of threading interface{} casts through every call site
+func heapPush(h *jobHeap, job Job) {
+	heap.Push(h, job)
+}
+
+func heapPop(h *jobHeap) Job {
+	return heap.Pop(h).(Job)
+}
+
+func heapRemove(h *jobHeap, index int) Job {
+	return heap.Remove(h, index).(Job)
+}
+
+// sortByFireAt sorts jobs soonest-first, for Scheduler.Pending
+func sortByFireAt(jobs []Job) {
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].FireAt.Before(jobs[j].FireAt) })
+}
@@ -0,0 +1,295 @@
+// Package scheduler provides a persistent, time-ordered job queue for
+// asynchronous GM behavior - player reminders, atmospheric scene ticks,
+// condition expiries, random encounters - that fire on their own instead
+// of only in reaction to a command. Jobs are persisted as JSON under the
+// owning campaign's directory (basePath/<campaign>/reminders), so they
+// survive a restart, and a single goroutine drains them in FireAt order
+// off a min-heap (see Scheduler.Run)
+package scheduler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// JobType selects which registered Handler executes a Job
+type JobType string
+
+const (
+	// JobReminder fires a plain message back into the chat it was
+	// scheduled from, for /remind
+	JobReminder JobType = "reminder"
+	// JobSceneTick asks the GM to narrate an atmospheric beat, then
+	// reschedules itself for another interval later
+	JobSceneTick JobType = "scene_tick"
+	// JobConditionExpiry removes a status effect from a character after
+	// its duration has passed
+	JobConditionExpiry JobType = "condition_expiry"
+	// JobRandomEncounter rolls on a random encounter table at some point
+	// within a GM-chosen window
+	JobRandomEncounter JobType = "random_encounter"
+)
+
+// Job is one unit of scheduled GM work, serializable to JSON so it
+// survives a restart. Type selects which registered Handler executes it;
+// Payload carries whatever that Handler needs (e.g. the reminder text, or
+// the character and condition to expire)
+type Job struct {
+	ID        string
+	ChatID    int64
+	Campaign  string
+	Type      JobType
+	FireAt    time.Time
+	Payload   map[string]string
+	CreatedAt time.Time
+}
+
+// Handler executes one due Job. A non-nil error is logged and the job is
+// still dropped - Scheduler has no retry policy, since a missed reminder
+// or scene tick is harmless to skip rather than replay late
+type Handler func(job Job) error
+
+// Scheduler is a min-heap of Jobs ordered by FireAt, persisted per-campaign
+// under basePath
+type Scheduler struct {
+	basePath string
+
+	mu       sync.Mutex
+	handlers map[JobType]Handler
+	pending  jobHeap
+	byID     map[string]int // job ID -> index into pending, for Cancel
+
+	wakeup chan struct{}
+}
+
+// New creates a Scheduler persisting jobs under basePath/<campaign>/reminders
+func New(basePath string) *Scheduler {
+	return &Scheduler{
+		basePath: basePath,
+		handlers: make(map[JobType]Handler),
+		byID:     make(map[string]int),
+		wakeup:   make(chan struct{}, 1),
+	}
+}
+
+// RegisterHandler associates jobType with the function that executes Jobs
+// of that type. A Job whose type has no registered handler is logged and
+// dropped when it comes due
+func (s *Scheduler) RegisterHandler(jobType JobType, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[jobType] = handler
+}
+
+func (s *Scheduler) jobPath(job Job) string {
+	return filepath.Join(s.basePath, job.Campaign, "reminders", job.ID+".json")
+}
+
+// Schedule persists job to disk and adds it to the heap, returning it with
+// ID and CreatedAt filled in if they weren't already set
+func (s *Scheduler) Schedule(job Job) (Job, error) {
+	if job.ID == "" {
+		job.ID = fmt.Sprintf("%d-%d", time.Now().UnixNano(), job.ChatID)
+	}
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now()
+	}
+
+	if err := s.persist(job); err != nil {
+		return Job{}, err
+	}
+
+	s.push(job)
+	return job, nil
+}
+
+func (s *Scheduler) persist(job Job) error {
+	dir := filepath.Join(s.basePath, job.Campaign, "reminders")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create reminders directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode job %s: %w", job.ID, err)
+	}
+	if err := os.WriteFile(s.jobPath(job), data, 0644); err != nil {
+		return fmt.Errorf("failed to persist job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+func (s *Scheduler) push(job Job) {
+	s.mu.Lock()
+	heapPush(&s.pending, job)
+	s.reindexLocked()
+	s.mu.Unlock()
+
+	select {
+	case s.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// reindexLocked rebuilds byID after the heap shape changes; callers must
+// hold s.mu. The heap is small (pending reminders for a chat, not a
+// high-throughput queue), so this is simpler than threading index updates
+// through every heap.Fix/Push/Pop call site
+func (s *Scheduler) reindexLocked() {
+	s.byID = make(map[string]int, len(s.pending))
+	for i, job := range s.pending {
+		s.byID[job.ID] = i
+	}
+}
+
+// Cancel removes a pending job by ID, deleting its persisted file. It
+// reports false if no pending job has that ID
+func (s *Scheduler) Cancel(id string) bool {
+	s.mu.Lock()
+	idx, ok := s.byID[id]
+	if !ok {
+		s.mu.Unlock()
+		return false
+	}
+	job := s.pending[idx]
+	heapRemove(&s.pending, idx)
+	s.reindexLocked()
+	s.mu.Unlock()
+
+	if err := os.Remove(s.jobPath(job)); err != nil && !os.IsNotExist(err) {
+		log.Printf("[SCHEDULER] Failed to remove cancelled job %s: %v", id, err)
+	}
+	return true
+}
+
+// Pending returns every job currently scheduled for chatID, soonest first
+func (s *Scheduler) Pending(chatID int64) []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	jobs := make([]Job, 0, len(s.pending))
+	for _, job := range s.pending {
+		if job.ChatID == chatID {
+			jobs = append(jobs, job)
+		}
+	}
+	sortByFireAt(jobs)
+	return jobs
+}
+
+// LoadPersisted re-enqueues every job left on disk from before a crash or
+// restart, across every campaign directory under basePath. Call once at
+// startup, before Run
+func (s *Scheduler) LoadPersisted() (int, error) {
+	matches, err := filepath.Glob(filepath.Join(s.basePath, "*", "reminders", "*.json"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to list persisted jobs: %w", err)
+	}
+
+	count := 0
+	for _, path := range matches {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			log.Printf("[SCHEDULER] Failed to read persisted job %s: %v", path, err)
+			continue
+		}
+
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil {
+			log.Printf("[SCHEDULER] Failed to parse persisted job %s: %v", path, err)
+			continue
+		}
+
+		s.push(job)
+		count++
+	}
+	return count, nil
+}
+
+// Run polls the heap until stop is closed, dispatching each job to its
+// registered Handler as soon as it comes due. Intended to run in its own
+// goroutine
+func (s *Scheduler) Run(stop <-chan struct{}) {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+
+	for {
+		s.resetTimer(timer)
+
+		select {
+		case <-stop:
+			return
+		case <-s.wakeup:
+		case <-timer.C:
+		}
+		s.drainDue()
+	}
+}
+
+// resetTimer fires timer at the next job's FireAt, or leaves it at a
+// coarse fallback interval if the heap is empty
+func (s *Scheduler) resetTimer(timer *time.Timer) {
+	if !timer.Stop() {
+		select {
+		case <-timer.C:
+		default:
+		}
+	}
+
+	s.mu.Lock()
+	wait := time.Hour
+	if len(s.pending) > 0 {
+		wait = time.Until(s.pending[0].FireAt)
+		if wait < 0 {
+			wait = 0
+		}
+	}
+	s.mu.Unlock()
+
+	timer.Reset(wait)
+}
+
+// drainDue dispatches every job whose FireAt has passed
+func (s *Scheduler) drainDue() {
+	for {
+		job, ok := s.popDue()
+		if !ok {
+			return
+		}
+		s.dispatch(job)
+	}
+}
+
+func (s *Scheduler) popDue() (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.pending) == 0 || s.pending[0].FireAt.After(time.Now()) {
+		return Job{}, false
+	}
+
+	job := heapPop(&s.pending)
+	s.reindexLocked()
+	return job, true
+}
+
+func (s *Scheduler) dispatch(job Job) {
+	s.mu.Lock()
+	handler, ok := s.handlers[job.Type]
+	s.mu.Unlock()
+
+	if !ok {
+		log.Printf("[SCHEDULER] No handler registered for job type %q, dropping %s", job.Type, job.ID)
+	} else if err := handler(job); err != nil {
+		log.Printf("[SCHEDULER] Job %s (%s) failed: %v", job.ID, job.Type, err)
+	}
+
+	if err := os.Remove(s.jobPath(job)); err != nil && !os.IsNotExist(err) {
+		log.Printf("[SCHEDULER] Failed to remove completed job %s: %v", job.ID, err)
+	}
+}
@@ -2,13 +2,20 @@
 package storage
 
 import (
+	"context"
 	"fmt"
+	"io/fs"
+	"log"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
 	"time"
+
+	"wfrp-bot/llm"
+	"wfrp-bot/rag"
+	"wfrp-bot/usage"
 )
 
 // CampaignManager manages WFRP campaigns
@@ -16,7 +23,30 @@ type CampaignManager struct {
 	basePath  string
 	parser    *MarkdownParser
 	campaigns map[string]*Campaign
+	indexer   *rag.Indexer
+	ledger    *usage.Ledger
 	mu        sync.RWMutex
+
+	snapshotStop     chan struct{}
+	snapshotStopOnce sync.Once
+}
+
+// SetIndexer attaches a RAG indexer used to keep campaign notes searchable.
+// Passing nil (the default) disables indexing entirely
+func (cm *CampaignManager) SetIndexer(indexer *rag.Indexer) {
+	cm.mu.Lock()
+	defer cm.mu.Unlock()
+
+	cm.indexer = indexer
+}
+
+// Indexer returns the RAG indexer attached via SetIndexer, or nil if none
+// has been configured
+func (cm *CampaignManager) Indexer() *rag.Indexer {
+	cm.mu.RLock()
+	defer cm.mu.RUnlock()
+
+	return cm.indexer
 }
 
 // NewCampaignManager creates a new campaign manager
@@ -25,9 +55,36 @@ func NewCampaignManager(basePath string) *CampaignManager {
 		basePath:  basePath,
 		parser:    NewMarkdownParser(basePath),
 		campaigns: make(map[string]*Campaign),
+		ledger:    usage.NewLedger(basePath),
 	}
 }
 
+// GetUsage returns the accumulated LLM token/cost usage recorded for a
+// campaign
+func (cm *CampaignManager) GetUsage(name string) usage.Totals {
+	return cm.ledger.Totals(name)
+}
+
+// SetBudget sets the USD budget for a campaign; CheckBudget and
+// RecordUsage-consulting callers will reject further requests once it is
+// spent. A zero maxUSD disables budget enforcement
+func (cm *CampaignManager) SetBudget(name string, maxUSD float64) {
+	cm.ledger.SetBudget(name, maxUSD)
+}
+
+// CheckBudget returns usage.ErrBudgetExceeded if the campaign has a budget
+// set and has already spent at least that much. Callers should check this
+// before dispatching an LLM request
+func (cm *CampaignManager) CheckBudget(name string) error {
+	return cm.ledger.CheckBudget(name)
+}
+
+// RecordUsage records the token accounting for a completed LLM request
+// against a campaign's usage ledger
+func (cm *CampaignManager) RecordUsage(name string, u llm.Usage) error {
+	return cm.ledger.Record(name, u.Provider, u.Model, u.PromptTokens, u.CompletionTokens)
+}
+
 // Campaign represents a WFRP campaign
 type Campaign struct {
 	Name         string
@@ -226,6 +283,13 @@ func (cm *CampaignManager) Refresh() error {
 	cm.campaigns = newCampaigns
 	cm.mu.Unlock()
 
+	// Re-index every campaign so that notes changed on disk since the last
+	// Refresh (character sheets edited by hand, session logs added, etc.)
+	// are reflected in search results
+	for _, campInfo := range newCampaigns {
+		cm.indexCampaign(campInfo.Path, campInfo)
+	}
+
 	return nil
 }
 
@@ -295,10 +359,56 @@ func (cm *CampaignManager) saveCampaignInfo(campPath string, camp *Campaign) err
 	return cm.parser.WriteFile(infoPath, builder.String())
 }
 
-// indexCampaign adds campaign to search index
+// indexCampaign (re-)embeds a campaign's markdown notes into the RAG index,
+// so later similarity search finds its latest content. It is a no-op when
+// no indexer has been attached via SetIndexer. Indexing is best-effort: a
+// failure to reach the embedder or vector store is logged, not returned,
+// since it must never block campaign creation or saves
 func (cm *CampaignManager) indexCampaign(campPath string, camp *Campaign) {
-	// Placeholder for future search functionality
-	// Could integrate with Qdrant or other vector DB
+	cm.mu.RLock()
+	indexer := cm.indexer
+	cm.mu.RUnlock()
+
+	if indexer == nil {
+		return
+	}
+
+	ctx := context.Background()
+
+	for _, relPath := range campaignNotePaths(campPath, camp) {
+		content, err := cm.parser.ReadFile(relPath)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			log.Printf("[RAG] Failed to read %s for indexing: %v", relPath, err)
+			continue
+		}
+
+		if err := indexer.IndexFile(ctx, camp.Name, relPath, content); err != nil {
+			log.Printf("[RAG] Failed to index %s: %v", relPath, err)
+		}
+	}
+}
+
+// campaignNotePaths lists the markdown files that make up a campaign's
+// searchable notes: its info file, party summary, character sheets and
+// session logs
+func campaignNotePaths(campPath string, camp *Campaign) []string {
+	paths := []string{
+		filepath.Join(campPath, "campaign.md"),
+		filepath.Join(campPath, "party_summary.md"),
+	}
+
+	for _, character := range camp.Characters {
+		paths = append(paths, filepath.Join(campPath, "characters", character))
+	}
+
+	for _, session := range camp.Sessions {
+		paths = append(paths, filepath.Join(campPath, "sessions", session))
+	}
+
+	return paths
 }
 
 // isValidCampaignName validates campaign name
@@ -319,6 +429,71 @@ func isValidCampaignName(name string) bool {
 	return true
 }
 
+// ListCampaignFiles walks a campaign's directory on disk and returns every
+// regular file's path relative to the campaign root (e.g.
+// "characters/alice.md", "sessions/session1_scenes.md"), for tools like the
+// GM agent's list_campaign_files
+func (cm *CampaignManager) ListCampaignFiles(name string) ([]string, error) {
+	cm.mu.RLock()
+	camp, exists := cm.campaigns[name]
+	cm.mu.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("campaign not found: %s", name)
+	}
+
+	var files []string
+	err := filepath.WalkDir(camp.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, relErr := filepath.Rel(camp.Path, path)
+		if relErr != nil {
+			return relErr
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list campaign files: %w", err)
+	}
+
+	return files, nil
+}
+
+// sceneNotesPath returns the path to a session's persisted scene notes
+// file. It's a flat "<sessionID>_scenes.md" under sessions/, like the rest
+// of campaignNotePaths' flat .md convention, so it's picked up by
+// ListSessions/indexCampaign on the next Refresh without extra wiring
+func (cm *CampaignManager) sceneNotesPath(campaign, sessionID string) string {
+	return filepath.Join(cm.basePath, campaign, "sessions", sessionID+"_scenes.md")
+}
+
+// AppendSceneNote appends a timestamped scene note to sessionID's notes
+// file under campaign, creating it if necessary. Unlike a Session's
+// in-memory Notes (see Session.AddNote in package game), this survives a
+// bot restart and becomes part of the campaign's searchable notes
+func (cm *CampaignManager) AppendSceneNote(campaign, sessionID, text string) error {
+	path := cm.sceneNotesPath(campaign, sessionID)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create scene notes directory: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open scene notes file: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := fmt.Fprintf(file, "- %s: %s\n", time.Now().Format(time.RFC3339), text); err != nil {
+		return fmt.Errorf("failed to write scene note: %w", err)
+	}
+	return nil
+}
+
 // GetCampaignPath returns the full path to a campaign
 func (cm *CampaignManager) GetCampaignPath(name string) string {
 	cm.mu.RLock()
@@ -360,5 +535,11 @@ func (cm *CampaignManager) SavePartySummary(campaign, summary string) error {
 
 	summaryPath := filepath.Join(camp.Path, "party_summary.md")
 
-	return cm.parser.WriteFile(summaryPath, summary)
+	if err := cm.parser.WriteFile(summaryPath, summary); err != nil {
+		return err
+	}
+
+	cm.indexCampaign(camp.Path, camp)
+
+	return nil
 }
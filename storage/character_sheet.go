@@ -0,0 +1,42 @@
+package storage
+
+// CharacterSheet is the canonical WFRP character-sheet schema decoded from
+// a character file's YAML frontmatter (see MarkdownParser.ParseCharacterSheet).
+// Extra captures any frontmatter keys this schema doesn't know about yet, so
+// BuildCharacterSheet can round-trip a sheet without silently dropping them
+type CharacterSheet struct {
+	Name            string         `yaml:"name"`
+	Characteristics map[string]int `yaml:"characteristics"` // WS, BS, S, T, I, Ag, Dex, Int, WP, Fel
+	Skills          []SkillAdvance `yaml:"skills"`
+	Talents         []SkillAdvance `yaml:"talents"`
+	Career          []CareerEntry  `yaml:"career"`
+	Trappings       []string       `yaml:"trappings"`
+	Wounds          Wounds         `yaml:"wounds"`
+	Fate            int            `yaml:"fate"`
+	Resilience      int            `yaml:"resilience"`
+	Resolve         int            `yaml:"resolve"`
+	XPSpent         int            `yaml:"xp_spent"`
+	XPTotal         int            `yaml:"xp_total"`
+	Extra           map[string]any `yaml:",inline"`
+}
+
+// SkillAdvance is one entry in CharacterSheet.Skills or .Talents: a name
+// plus the number of advances bought (talents use Advances as a times-taken
+// counter when the talent can be taken more than once)
+type SkillAdvance struct {
+	Name     string `yaml:"name"`
+	Advances int    `yaml:"advances"`
+}
+
+// CareerEntry is one entry in CharacterSheet.Career, in chronological order
+type CareerEntry struct {
+	Name   string `yaml:"name"`
+	Tier   int    `yaml:"tier"`
+	Status string `yaml:"status,omitempty"` // e.g. "current", "completed"
+}
+
+// Wounds holds a character's current and maximum wounds
+type Wounds struct {
+	Current int `yaml:"current"`
+	Max     int `yaml:"max"`
+}
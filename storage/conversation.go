@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ConversationMessage is a single persisted turn in a session's conversation
+//
+// ParentID forms a tree rather than a flat list: a GM can append new
+// messages onto any earlier message (via ForkFrom) to explore an alternate
+// narrative path without losing the original branch
+type ConversationMessage struct {
+	ID        string    `json:"id"`
+	ParentID  string    `json:"parent_id,omitempty"`
+	Role      string    `json:"role"`
+	Content   string    `json:"content"`
+	Timestamp time.Time `json:"timestamp"`
+	Model     string    `json:"model,omitempty"` // LLM model that generated this message; empty for player turns
+}
+
+// Conversation is the full message tree for one game session
+type Conversation struct {
+	Campaign string
+	Session  string
+	Path     string
+	Messages []ConversationMessage
+}
+
+// Find returns the message with the given ID
+func (c *Conversation) Find(id string) (ConversationMessage, bool) {
+	for _, msg := range c.Messages {
+		if msg.ID == id {
+			return msg, true
+		}
+	}
+	return ConversationMessage{}, false
+}
+
+// Branches returns the ID of every message with no children, i.e. the tip
+// of each distinct branch in the conversation
+func (c *Conversation) Branches() []string {
+	hasChild := make(map[string]bool, len(c.Messages))
+	for _, msg := range c.Messages {
+		if msg.ParentID != "" {
+			hasChild[msg.ParentID] = true
+		}
+	}
+
+	var tips []string
+	for _, msg := range c.Messages {
+		if !hasChild[msg.ID] {
+			tips = append(tips, msg.ID)
+		}
+	}
+	return tips
+}
+
+// Chain returns the linear chain of messages from the root down to tipID,
+// root-first, by following parent pointers
+func (c *Conversation) Chain(tipID string) []ConversationMessage {
+	byID := make(map[string]ConversationMessage, len(c.Messages))
+	for _, msg := range c.Messages {
+		byID[msg.ID] = msg
+	}
+
+	var chain []ConversationMessage
+	for id := tipID; id != ""; {
+		msg, ok := byID[id]
+		if !ok {
+			break
+		}
+		chain = append([]ConversationMessage{msg}, chain...)
+		id = msg.ParentID
+	}
+	return chain
+}
+
+// conversationPath returns the path to a session's message log
+func (cm *CampaignManager) conversationPath(campaign, sessionID string) string {
+	return filepath.Join(cm.basePath, campaign, "sessions", sessionID, "messages.jsonl")
+}
+
+// NewConversation creates the on-disk message log for a session, or loads
+// it if one was already started
+func (cm *CampaignManager) NewConversation(campaign, sessionID string) (*Conversation, error) {
+	path := cm.conversationPath(campaign, sessionID)
+
+	if _, err := os.Stat(path); err == nil {
+		return cm.LoadConversation(campaign, sessionID)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create conversation directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		return nil, fmt.Errorf("failed to create conversation log: %w", err)
+	}
+
+	return &Conversation{Campaign: campaign, Session: sessionID, Path: path}, nil
+}
+
+// LoadConversation reads the full message tree for a session from disk
+func (cm *CampaignManager) LoadConversation(campaign, sessionID string) (*Conversation, error) {
+	path := cm.conversationPath(campaign, sessionID)
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open conversation log: %w", err)
+	}
+	defer file.Close()
+
+	conv := &Conversation{Campaign: campaign, Session: sessionID, Path: path}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var msg ConversationMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			return nil, fmt.Errorf("failed to parse conversation message: %w", err)
+		}
+		conv.Messages = append(conv.Messages, msg)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read conversation log: %w", err)
+	}
+
+	return conv, nil
+}
+
+// AppendMessage appends a new message onto parentID (pass "" to start a root
+// message) and persists it to the session's message log. model identifies
+// the LLM that generated content and should be left "" for player turns
+func (cm *CampaignManager) AppendMessage(campaign, sessionID, parentID, role, content, model string) (ConversationMessage, error) {
+	path := cm.conversationPath(campaign, sessionID)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return ConversationMessage{}, fmt.Errorf("failed to create conversation directory: %w", err)
+	}
+
+	msg := ConversationMessage{
+		ID:        fmt.Sprintf("m%d", time.Now().UnixNano()),
+		ParentID:  parentID,
+		Role:      role,
+		Content:   content,
+		Timestamp: time.Now(),
+		Model:     model,
+	}
+
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return ConversationMessage{}, fmt.Errorf("failed to encode conversation message: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return ConversationMessage{}, fmt.Errorf("failed to open conversation log: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return ConversationMessage{}, fmt.Errorf("failed to write conversation message: %w", err)
+	}
+
+	return msg, nil
+}
+
+// ForkFrom validates that messageID exists in the conversation and returns
+// its current state; the caller continues the new branch by passing
+// messageID as the parentID to subsequent AppendMessage calls, leaving the
+// original chain after messageID untouched. An empty messageID is always
+// valid and forks from the root of the conversation
+func (cm *CampaignManager) ForkFrom(campaign, sessionID, messageID string) (*Conversation, error) {
+	conv, err := cm.LoadConversation(campaign, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if messageID != "" {
+		if _, ok := conv.Find(messageID); !ok {
+			return nil, fmt.Errorf("message not found: %s", messageID)
+		}
+	}
+
+	return conv, nil
+}
+
+// ListBranches returns the tip message ID of every branch in a session's
+// conversation
+func (cm *CampaignManager) ListBranches(campaign, sessionID string) ([]string, error) {
+	conv, err := cm.LoadConversation(campaign, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return conv.Branches(), nil
+}
@@ -2,41 +2,163 @@
 package storage
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"path/filepath"
-	"sort"
 	"strings"
 	"time"
+
+	_ "modernc.org/sqlite"
 )
 
-// HistoryManager manages session history storage
+// HistoryManager manages session history storage. Markdown files under
+// basePath remain the source of truth; db is a cache over them - an
+// embedded, pure-Go SQLite database (see migrate) that makes searching and
+// filtering thousands of session logs feasible without scanning every file
+// on every query. IndexSessions keeps the cache in sync by comparing each
+// file's mtime against the value stored the last time it was indexed
 type HistoryManager struct {
-	basePath  string
-	parser    *MarkdownParser
-	sessions   map[string]*SessionRecord
-	mu         sync.RWMutex
+	basePath string
+	parser   *MarkdownParser
+	db       *sql.DB
 }
 
-// NewHistoryManager creates a new history manager
-func NewHistoryManager(basePath string) *HistoryManager {
-	return &HistoryManager{
-		basePath: basePath,
-		parser:    NewMarkdownParser(basePath),
-		sessions:   make(map[string]*SessionRecord),
+// NewHistoryManager creates a history manager backed by a SQLite database
+// at basePath/history.db, creating its schema if this is the first run.
+// Call IndexSessions afterwards to populate (or refresh) the cache from
+// the markdown files already on disk
+func NewHistoryManager(basePath string) (*HistoryManager, error) {
+	if err := os.MkdirAll(basePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create history directory %s: %w", basePath, err)
+	}
+
+	db, err := sql.Open("sqlite", filepath.Join(basePath, "history.db"))
+	if err != nil {
+		return nil, fmt.Errorf("opening history database: %w", err)
+	}
+
+	hm := &HistoryManager{basePath: basePath, parser: NewMarkdownParser(basePath), db: db}
+	if err := hm.migrate(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating history database: %w", err)
 	}
+
+	return hm, nil
+}
+
+// migrate creates hm's schema if it doesn't already exist: sessions,
+// session_characters and session_events tables, plus an FTS5 virtual table
+// over each session's title/summary/body for SearchSessions
+func (hm *HistoryManager) migrate() error {
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS sessions (
+			id TEXT PRIMARY KEY,
+			campaign TEXT NOT NULL,
+			title TEXT,
+			summary TEXT,
+			body TEXT,
+			date INTEGER NOT NULL,
+			path TEXT NOT NULL,
+			mtime INTEGER NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS session_characters (
+			session_id TEXT NOT NULL,
+			character TEXT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_session_characters_session ON session_characters(session_id)`,
+		`CREATE TABLE IF NOT EXISTS session_events (
+			session_id TEXT NOT NULL,
+			timestamp INTEGER NOT NULL,
+			actor TEXT,
+			body TEXT
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_session_events_session ON session_events(session_id)`,
+		`CREATE VIRTUAL TABLE IF NOT EXISTS sessions_fts USING fts5(id UNINDEXED, title, summary, body)`,
+	}
+
+	for _, stmt := range statements {
+		if _, err := hm.db.Exec(stmt); err != nil {
+			return fmt.Errorf("executing schema statement: %w", err)
+		}
+	}
+
+	return nil
 }
 
 // SessionRecord represents a saved session
 type SessionRecord struct {
-	ID          string
-	Date        time.Time
-	Title       string
+	ID         string
+	Date       time.Time
+	Title      string
+	Summary    string
+	Campaign   string
+	Characters []string
+	Path       string
+}
+
+// parsedSessionFile is what parseSessionMarkdown extracts from a session's
+// markdown body, ready to upsert into the cache
+type parsedSessionFile struct {
+	Title      string
+	Date       time.Time
 	Summary    string
-	Campaign    string
-	Characters  []string
-	Path        string
+	Characters []string
+}
+
+// parseSessionMarkdown extracts the title, date, summary and participant
+// list from a session file's markdown content (see writeSessionFile for
+// the format this reads back)
+func parseSessionMarkdown(content string) parsedSessionFile {
+	var parsed parsedSessionFile
+	section := ""
+
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		switch {
+		case strings.HasPrefix(trimmed, "# "):
+			parsed.Title = strings.TrimSpace(strings.TrimPrefix(trimmed, "#"))
+			section = ""
+		case strings.HasPrefix(trimmed, "## Дата:") || strings.HasPrefix(trimmed, "## Date:"):
+			if _, value, ok := strings.Cut(trimmed, ":"); ok {
+				if date, err := time.Parse("2006-01-02 15:04", strings.TrimSpace(value)); err == nil {
+					parsed.Date = date
+				}
+			}
+			section = ""
+		case strings.HasPrefix(trimmed, "## Итог") || strings.HasPrefix(trimmed, "## Summary"):
+			section = "summary"
+		case strings.HasPrefix(trimmed, "## Участники") || strings.HasPrefix(trimmed, "## Participants"):
+			section = "characters"
+		case strings.HasPrefix(trimmed, "## "):
+			section = ""
+		case section == "summary" && trimmed != "":
+			if parsed.Summary != "" {
+				parsed.Summary += " "
+			}
+			parsed.Summary += trimmed
+		case section == "characters" && strings.HasPrefix(trimmed, "-"):
+			parsed.Characters = append(parsed.Characters, strings.TrimSpace(strings.TrimPrefix(trimmed, "-")))
+		}
+	}
+
+	return parsed
+}
+
+// sanitizeFilename replaces characters that aren't safe in a filename with
+// an underscore
+func sanitizeFilename(title string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '?', '*', '<', '>', '|', '"':
+			return '_'
+		}
+		return r
+	}, title)
 }
 
 // CreateSession creates a new session record
@@ -46,21 +168,12 @@ func (hm *HistoryManager) CreateSession(campaign, title string) (*SessionRecord,
 	// Generate session ID (YYYY-MM-DD_HH-MM)
 	sessionID := now.Format("2006-01-02_15-04")
 	if title != "" {
-		sessionID += "_" + strings.Map(func(r rune) rune {
-			for _, ch := range []string{":", "?", "*", "<", ">", "|", "\""} {
-				if r == rune(ch[0]) {
-					return '_'
-				}
-			}
-			return r
-		}, title)
+		sessionID += "_" + sanitizeFilename(title)
 	}
 
-	// Create session filename
 	filename := sessionID + ".md"
 	path := filepath.Join(hm.basePath, campaign, filename)
 
-	// Create session file with header
 	session := &SessionRecord{
 		ID:         sessionID,
 		Date:       now,
@@ -70,151 +183,115 @@ func (hm *HistoryManager) CreateSession(campaign, title string) (*SessionRecord,
 		Path:       path,
 	}
 
-	// Write initial session file
 	if err := hm.writeSessionFile(path, session); err != nil {
 		return nil, err
 	}
 
-	// Add to manager
-	hm.mu.Lock()
-	hm.sessions[sessionID] = session
-	hm.mu.Unlock()
+	if err := hm.upsertFromFile(sessionID, campaign, path); err != nil {
+		return nil, err
+	}
 
 	return session, nil
 }
 
-// GetSession retrieves a session by ID
+// GetSession retrieves a session by ID from the cache
 func (hm *HistoryManager) GetSession(sessionID string) (*SessionRecord, error) {
-	hm.mu.RLock()
-	defer hm.mu.RUnlock()
+	row := hm.db.QueryRow(`SELECT id, campaign, title, summary, date, path FROM sessions WHERE id = ?`, sessionID)
 
-	session, exists := hm.sessions[sessionID]
-	if !exists {
-		// Try to load from disk
-		return hm.loadSession(sessionID)
+	var session SessionRecord
+	var dateUnix int64
+	if err := row.Scan(&session.ID, &session.Campaign, &session.Title, &session.Summary, &dateUnix, &session.Path); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("session not found: %s", sessionID)
+		}
+		return nil, fmt.Errorf("loading session %s: %w", sessionID, err)
 	}
+	session.Date = time.Unix(dateUnix, 0)
 
-	return session, nil
+	characters, err := hm.loadCharacters(sessionID)
+	if err != nil {
+		return nil, err
+	}
+	session.Characters = characters
+
+	return &session, nil
 }
 
-// ListSessions returns all sessions for a campaign
+// ListSessions returns all sessions for a campaign, newest first
 func (hm *HistoryManager) ListSessions(campaign string) ([]*SessionRecord, error) {
-	hm.mu.RLock()
-	defer hm.mu.RUnlock()
-
-	var sessions []*SessionRecord
-
-	for _, session := range hm.sessions {
-		if session.Campaign == campaign {
-			sessions = append(sessions, session)
-		}
+	rows, err := hm.db.Query(`SELECT id, campaign, title, summary, date, path FROM sessions WHERE campaign = ? ORDER BY date DESC`, campaign)
+	if err != nil {
+		return nil, fmt.Errorf("listing sessions for campaign %s: %w", campaign, err)
 	}
+	defer rows.Close()
 
-	// Sort by date (newest first)
-	sort.Slice(sessions, func(i, j int) bool {
-		return sessions[j].Date.After(sessions[i].Date)
-	})
-
-	return sessions, nil
+	return hm.scanSessionRows(rows)
 }
 
-// AppendToSession adds content to an existing session
-func (hm *HistoryManager) AppendToSession(sessionID, content string) error {
-	// Resolve session path
+// AppendToSession adds content to an existing session's markdown file and
+// records a session_events row for it (timestamp, actor, body), so a
+// session's timeline can be reconstructed later
+func (hm *HistoryManager) AppendToSession(sessionID, actor, content string) error {
 	path, err := hm.resolveSessionPath(sessionID)
 	if err != nil {
 		return err
 	}
 
-	// Append content to file
-	file, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
-	if err != nil {
-		return fmt.Errorf("failed to open session file: %w", err)
+	if err := hm.parser.AppendFile(path, "\n"+content); err != nil {
+		return err
 	}
-	defer file.Close()
 
-	if _, err := file.WriteString("\n" + content); err != nil {
-		return fmt.Errorf("failed to write to session file: %w", err)
+	if _, err := hm.db.Exec(`INSERT INTO session_events (session_id, timestamp, actor, body) VALUES (?, ?, ?, ?)`,
+		sessionID, time.Now().Unix(), actor, content); err != nil {
+		return fmt.Errorf("recording event for session %s: %w", sessionID, err)
 	}
 
-	return nil
+	campaign, err := hm.sessionCampaign(sessionID)
+	if err != nil {
+		return err
+	}
+	return hm.upsertFromFile(sessionID, campaign, path)
 }
 
 // UpdateSessionSummary updates the summary of a session
 func (hm *HistoryManager) UpdateSessionSummary(sessionID, summary string) error {
-	// Load session
 	session, err := hm.GetSession(sessionID)
 	if err != nil {
 		return err
 	}
 
 	session.Summary = summary
+	if err := hm.writeSessionFile(session.Path, session); err != nil {
+		return err
+	}
 
-	// Write updated session
-	return hm.writeSessionFile(session.Path, session)
+	return hm.upsertFromFile(session.ID, session.Campaign, session.Path)
 }
 
-// loadSession loads a session from disk
-func (hm *HistoryManager) loadSession(sessionID string) (*SessionRecord, error) {
-	path, err := hm.resolveSessionPath(sessionID)
-	if err != nil {
-		return nil, err
+// resolveSessionPath looks up the file path cached for sessionID
+func (hm *HistoryManager) resolveSessionPath(sessionID string) (string, error) {
+	var path string
+	err := hm.db.QueryRow(`SELECT path FROM sessions WHERE id = ?`, sessionID).Scan(&path)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("session not found: %s", sessionID)
 	}
-
-	content, err := hm.parser.ReadFile(path)
 	if err != nil {
-		return nil, err
+		return "", fmt.Errorf("resolving path for session %s: %w", sessionID, err)
 	}
-
-	// Parse session file
-	session := &SessionRecord{
-		ID:   sessionID,
-		Path: path,
-	}
-
-	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		trimmed := strings.TrimSpace(line)
-
-		if strings.HasPrefix(trimmed, "#") {
-			session.Title = strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
-		} else if strings.HasPrefix(trimmed, "Дата:") || strings.HasPrefix(trimmed, "Date:") {
-			if date, err := time.Parse("2006-01-02 15:04", strings.TrimSpace(strings.Split(trimmed, ":")[1])); err == nil {
-				session.Date = date
-			}
-		} else if strings.HasPrefix(trimmed, "Итог:") || strings.HasPrefix(trimmed, "Summary:") {
-			session.Summary = strings.TrimSpace(strings.Split(trimmed, ":")[1])
-		}
-	}
-
-	// Add to cache
-	hm.mu.Lock()
-	hm.sessions[sessionID] = session
-	hm.mu.Unlock()
-
-	return session, nil
+	return path, nil
 }
 
-// resolveSessionPath finds the full path to a session file
-func (hm *HistoryManager) resolveSessionPath(sessionID string) (string, error) {
-	// Try to find session file in base path
-	entries, err := os.ReadDir(hm.basePath)
-	if err != nil {
-		return "", err
+// sessionCampaign looks up the campaign cached for sessionID
+func (hm *HistoryManager) sessionCampaign(sessionID string) (string, error) {
+	var campaign string
+	err := hm.db.QueryRow(`SELECT campaign FROM sessions WHERE id = ?`, sessionID).Scan(&campaign)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("session not found: %s", sessionID)
 	}
-
-	for _, entry := range entries {
-		if entry.IsDir() {
-			// Check inside campaign directories
-			continue
-		}
-
-		if strings.HasPrefix(entry.Name(), sessionID) && strings.HasSuffix(entry.Name(), ".md") {
-			return filepath.Join(hm.basePath, entry.Name())
-		}
+	if err != nil {
+		return "", fmt.Errorf("resolving campaign for session %s: %w", sessionID, err)
 	}
-
-	return "", fmt.Errorf("session not found: %s", sessionID)
+	return campaign, nil
 }
 
 // writeSessionFile writes a session record to file
@@ -246,71 +323,64 @@ func (hm *HistoryManager) writeSessionFile(path string, session *SessionRecord)
 	return hm.parser.WriteFile(path, builder.String())
 }
 
-// GetLatestSessions returns recent sessions across all campaigns
+// GetLatestSessions returns the limit most recent sessions across all
+// campaigns (limit<=0 returns every session)
 func (hm *HistoryManager) GetLatestSessions(limit int) ([]*SessionRecord, error) {
-	hm.mu.RLock()
-	defer hm.mu.RUnlock()
-
-	var allSessions []*SessionRecord
-
-	for _, session := range hm.sessions {
-		allSessions = append(allSessions, session)
+	query := `SELECT id, campaign, title, summary, date, path FROM sessions ORDER BY date DESC`
+	var args []interface{}
+	if limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, limit)
 	}
 
-	// Sort by date (newest first)
-	sort.Slice(allSessions, func(i, j int) bool {
-		return allSessions[j].Date.After(allSessions[i].Date)
-	})
-
-	// Limit results
-	if limit > 0 && len(allSessions) > limit {
-		allSessions = allSessions[:limit]
+	rows, err := hm.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("listing latest sessions: %w", err)
 	}
+	defer rows.Close()
 
-	return allSessions, nil
+	return hm.scanSessionRows(rows)
 }
 
-// SearchSessions searches for sessions matching a query
+// SearchSessions searches title/summary/body for query using the FTS5
+// index, returning sessions ordered by bm25 relevance (best match first)
 func (hm *HistoryManager) SearchSessions(query string) ([]*SessionRecord, error) {
-	hm.mu.RLock()
-	defer hm.mu.RUnlock()
-
-	var results []*SessionRecord
-
-	queryLower := strings.ToLower(query)
-
-	for _, session := range hm.sessions {
-		// Search in title and summary
-		titleMatch := strings.Contains(strings.ToLower(session.Title), queryLower)
-		summaryMatch := strings.Contains(strings.ToLower(session.Summary), queryLower)
-
-		if titleMatch || summaryMatch {
-			results = append(results, session)
-		}
+	rows, err := hm.db.Query(`
+		SELECT s.id, s.campaign, s.title, s.summary, s.date, s.path
+		FROM sessions_fts f
+		JOIN sessions s ON s.id = f.id
+		WHERE f MATCH ?
+		ORDER BY bm25(f)
+	`, query)
+	if err != nil {
+		return nil, fmt.Errorf("searching sessions for %q: %w", query, err)
 	}
+	defer rows.Close()
 
-	return results, nil
+	return hm.scanSessionRows(rows)
 }
 
-// DeleteSession removes a session record
+// DeleteSession removes a session record and its file
 func (hm *HistoryManager) DeleteSession(sessionID string) error {
-	hm.mu.RLock()
-	session, exists := hm.sessions[sessionID]
-	hm.mu.RUnlock()
-
-	if !exists {
-		return fmt.Errorf("session not found: %s", sessionID)
+	path, err := hm.resolveSessionPath(sessionID)
+	if err != nil {
+		return err
 	}
 
-	// Delete file
-	if err := os.Remove(session.Path); err != nil {
+	if err := os.Remove(path); err != nil {
 		return err
 	}
 
-	// Remove from cache
-	hm.mu.Lock()
-	delete(hm.sessions, sessionID)
-	hm.mu.Unlock()
+	for _, stmt := range []string{
+		`DELETE FROM sessions WHERE id = ?`,
+		`DELETE FROM session_characters WHERE session_id = ?`,
+		`DELETE FROM session_events WHERE session_id = ?`,
+		`DELETE FROM sessions_fts WHERE id = ?`,
+	} {
+		if _, err := hm.db.Exec(stmt, sessionID); err != nil {
+			return fmt.Errorf("removing cached session %s: %w", sessionID, err)
+		}
+	}
 
 	return nil
 }
@@ -334,86 +404,179 @@ func (hm *HistoryManager) ArchiveSession(sessionID string) error {
 		return err
 	}
 
+	if _, err := hm.db.Exec(`UPDATE sessions SET path = ? WHERE id = ?`, archivePath, sessionID); err != nil {
+		return fmt.Errorf("updating cached path for archived session %s: %w", sessionID, err)
+	}
+
 	return nil
 }
 
-// IndexSessions rebuilds the session index
+// IndexSessions walks basePath and upserts any session file whose mtime
+// differs from the value stored in the cache, treating the markdown files
+// as the source of truth and the database as a cache over them
 func (hm *HistoryManager) IndexSessions() error {
-	hm.mu.Lock()
-	defer hm.mu.Unlock()
-
-	// Scan all session files
-	hm.sessions = make(map[string]*SessionRecord)
+	return filepath.WalkDir(hm.basePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(d.Name(), ".md") {
+			return nil
+		}
 
-	entries, err := os.ReadDir(hm.basePath)
-	if err != nil {
-		return err
-	}
+		rel, err := filepath.Rel(hm.basePath, path)
+		if err != nil {
+			return err
+		}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			// Skip directories, scan them recursively
-			if err := hm.indexDirectory(filepath.Join(hm.basePath, entry.Name())); err != nil {
-				log.Printf("[HISTORY] Failed to index directory %s: %v", entry.Name(), err)
-			}
-			continue
+		campaign := ""
+		if dir := filepath.Dir(rel); dir != "." {
+			campaign = strings.SplitN(dir, string(filepath.Separator), 2)[0]
 		}
 
-		if !strings.HasSuffix(entry.Name(), ".md") {
-			continue
+		sessionID := strings.TrimSuffix(d.Name(), ".md")
+
+		info, err := d.Info()
+		if err != nil {
+			return err
 		}
 
-		// Try to parse session ID from filename
-		sessionID := strings.TrimSuffix(entry.Name(), ".md")
-		if strings.Contains(sessionID, "_") {
-			// Format: YYYY-MM-DD_HH-MM_description.md
-			// Use full filename as ID
-			sessionID = entry.Name()
+		stored, err := hm.storedMtime(sessionID)
+		if err != nil {
+			return err
+		}
+		if stored == info.ModTime().Unix() {
+			return nil // cache already current for this file
 		}
 
-		path := filepath.Join(hm.basePath, entry.Name())
-		session := &SessionRecord{
-			ID:  sessionID,
-			Path: path,
+		if err := hm.upsertFromFile(sessionID, campaign, path); err != nil {
+			log.Printf("[HISTORY] Failed to index %s: %v", path, err)
 		}
+		return nil
+	})
+}
 
-		hm.sessions[sessionID] = session
+// storedMtime returns the mtime IndexSessions last cached for sessionID,
+// or 0 if it isn't cached yet
+func (hm *HistoryManager) storedMtime(sessionID string) (int64, error) {
+	var mtime int64
+	err := hm.db.QueryRow(`SELECT mtime FROM sessions WHERE id = ?`, sessionID).Scan(&mtime)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
 	}
-
-	return nil
+	if err != nil {
+		return 0, fmt.Errorf("reading cached mtime for session %s: %w", sessionID, err)
+	}
+	return mtime, nil
 }
 
-// indexDirectory recursively indexes session files
-func (hm *HistoryManager) indexDirectory(dir string) error {
-	entries, err := os.ReadDir(dir)
+// upsertFromFile reads path, parses it and upserts it into the cache under
+// sessionID/campaign
+func (hm *HistoryManager) upsertFromFile(sessionID, campaign, path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat session file %s: %w", path, err)
+	}
+
+	content, err := hm.parser.ReadFile(path)
 	if err != nil {
 		return err
 	}
 
-	for _, entry := range entries {
-		if entry.IsDir() {
-			if err := hm.indexDirectory(filepath.Join(dir, entry.Name())); err != nil {
-				return err
-			}
-			continue
+	parsed := parseSessionMarkdown(content)
+	if parsed.Title == "" {
+		parsed.Title = sessionID
+	}
+	if parsed.Date.IsZero() {
+		parsed.Date = info.ModTime()
+	}
+
+	return hm.upsertSession(sessionID, campaign, path, parsed, content, info.ModTime())
+}
+
+// upsertSession writes sessionID's row, its characters and its FTS entry
+// in a single transaction
+func (hm *HistoryManager) upsertSession(sessionID, campaign, path string, parsed parsedSessionFile, body string, mtime time.Time) error {
+	tx, err := hm.db.Begin()
+	if err != nil {
+		return fmt.Errorf("beginning transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT INTO sessions (id, campaign, title, summary, body, date, path, mtime)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			campaign = excluded.campaign,
+			title = excluded.title,
+			summary = excluded.summary,
+			body = excluded.body,
+			date = excluded.date,
+			path = excluded.path,
+			mtime = excluded.mtime
+	`, sessionID, campaign, parsed.Title, parsed.Summary, body, parsed.Date.Unix(), path, mtime.Unix()); err != nil {
+		return fmt.Errorf("upserting session %s: %w", sessionID, err)
+	}
+
+	if _, err := tx.Exec(`DELETE FROM session_characters WHERE session_id = ?`, sessionID); err != nil {
+		return fmt.Errorf("clearing characters for session %s: %w", sessionID, err)
+	}
+	for _, char := range parsed.Characters {
+		if _, err := tx.Exec(`INSERT INTO session_characters (session_id, character) VALUES (?, ?)`, sessionID, char); err != nil {
+			return fmt.Errorf("inserting character for session %s: %w", sessionID, err)
 		}
+	}
 
-		if !strings.HasSuffix(entry.Name(), ".md") {
-			continue
+	if _, err := tx.Exec(`DELETE FROM sessions_fts WHERE id = ?`, sessionID); err != nil {
+		return fmt.Errorf("clearing search index for session %s: %w", sessionID, err)
+	}
+	if _, err := tx.Exec(`INSERT INTO sessions_fts (id, title, summary, body) VALUES (?, ?, ?, ?)`, sessionID, parsed.Title, parsed.Summary, body); err != nil {
+		return fmt.Errorf("indexing session %s for search: %w", sessionID, err)
+	}
+
+	return tx.Commit()
+}
+
+// loadCharacters returns the characters cached for sessionID, alphabetically
+func (hm *HistoryManager) loadCharacters(sessionID string) ([]string, error) {
+	rows, err := hm.db.Query(`SELECT character FROM session_characters WHERE session_id = ? ORDER BY character`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("loading characters for session %s: %w", sessionID, err)
+	}
+	defer rows.Close()
+
+	var characters []string
+	for rows.Next() {
+		var character string
+		if err := rows.Scan(&character); err != nil {
+			return nil, fmt.Errorf("scanning character for session %s: %w", sessionID, err)
 		}
+		characters = append(characters, character)
+	}
+	return characters, rows.Err()
+}
 
-		path := filepath.Join(dir, entry.Name())
-		sessionID := strings.TrimSuffix(entry.Name(), ".md")
+// scanSessionRows builds SessionRecords from a query selecting
+// id, campaign, title, summary, date, path in that order, loading each
+// row's characters separately
+func (hm *HistoryManager) scanSessionRows(rows *sql.Rows) ([]*SessionRecord, error) {
+	var sessions []*SessionRecord
+	for rows.Next() {
+		var session SessionRecord
+		var dateUnix int64
+		if err := rows.Scan(&session.ID, &session.Campaign, &session.Title, &session.Summary, &dateUnix, &session.Path); err != nil {
+			return nil, fmt.Errorf("scanning session row: %w", err)
+		}
+		session.Date = time.Unix(dateUnix, 0)
 
-		session := &SessionRecord{
-			ID:   sessionID,
-			Path: path,
+		characters, err := hm.loadCharacters(session.ID)
+		if err != nil {
+			return nil, err
 		}
+		session.Characters = characters
 
-		hm.sessions[sessionID] = session
+		sessions = append(sessions, &session)
 	}
-
-	return nil
+	return sessions, rows.Err()
 }
 
 // SessionFilter filters session queries
@@ -423,59 +586,114 @@ type SessionFilter struct {
 	EndDate    *time.Time
 	MinDate    *time.Time
 	MaxDate    *time.Time
-	Characters  []string
+	Characters []string
 }
 
-// FilterSessions applies filters to session list
+// FilterSessions applies filter entirely in SQL: campaign and date bounds
+// become WHERE clauses, and Characters (a session must include every one
+// listed) becomes a join against session_characters with a matching
+// HAVING COUNT
 func (hm *HistoryManager) FilterSessions(filter SessionFilter) ([]*SessionRecord, error) {
-	hm.mu.RLock()
-	defer hm.mu.RUnlock()
+	var b strings.Builder
+	b.WriteString("SELECT s.id, s.campaign, s.title, s.summary, s.date, s.path FROM sessions s")
 
-	var results []*SessionRecord
+	var args []interface{}
+	if len(filter.Characters) > 0 {
+		b.WriteString(" JOIN session_characters sc ON sc.session_id = s.id")
+	}
 
-	for _, session := range hm.sessions {
-		if !hm.matchesFilter(session, filter) {
-			continue
+	var conditions []string
+	if filter.Campaign != "" {
+		conditions = append(conditions, "s.campaign = ?")
+		args = append(args, filter.Campaign)
+	}
+	if filter.StartDate != nil {
+		conditions = append(conditions, "s.date >= ?")
+		args = append(args, filter.StartDate.Unix())
+	}
+	if filter.EndDate != nil {
+		conditions = append(conditions, "s.date <= ?")
+		args = append(args, filter.EndDate.Unix())
+	}
+	if filter.MinDate != nil {
+		conditions = append(conditions, "s.date >= ?")
+		args = append(args, filter.MinDate.Unix())
+	}
+	if filter.MaxDate != nil {
+		conditions = append(conditions, "s.date <= ?")
+		args = append(args, filter.MaxDate.Unix())
+	}
+	if len(filter.Characters) > 0 {
+		placeholders := make([]string, len(filter.Characters))
+		for i, char := range filter.Characters {
+			placeholders[i] = "?"
+			args = append(args, char)
 		}
-		results = append(results, session)
+		conditions = append(conditions, fmt.Sprintf("sc.character IN (%s)", strings.Join(placeholders, ",")))
 	}
 
-	return results, nil
-}
-
-// matchesFilter checks if a session matches the filter criteria
-func (hm *HistoryManager) matchesFilter(session *SessionRecord, filter SessionFilter) bool {
-	// Filter by campaign
-	if filter.Campaign != "" && session.Campaign != filter.Campaign {
-		return false
+	if len(conditions) > 0 {
+		b.WriteString(" WHERE ")
+		b.WriteString(strings.Join(conditions, " AND "))
 	}
 
-	// Filter by date range
-	if filter.StartDate != nil && session.Date.Before(*filter.StartDate) {
-		return false
+	if len(filter.Characters) > 0 {
+		b.WriteString(" GROUP BY s.id HAVING COUNT(DISTINCT sc.character) = ?")
+		args = append(args, len(filter.Characters))
 	}
 
-	if filter.EndDate != nil && session.Date.After(*filter.EndDate) {
-		return false
-	}
+	b.WriteString(" ORDER BY s.date DESC")
 
-	if filter.MinDate != nil && session.Date.Before(*filter.MinDate) {
-		return false
+	rows, err := hm.db.Query(b.String(), args...)
+	if err != nil {
+		return nil, fmt.Errorf("filtering sessions: %w", err)
 	}
+	defer rows.Close()
 
-	if filter.MaxDate != nil && session.Date.After(*filter.MaxDate) {
-		return false
+	return hm.scanSessionRows(rows)
+}
+
+// SessionListing is one row of the "history --long" style listing (see
+// nushell's sqlite history command): enough summary detail to scan
+// thousands of sessions without opening each one
+type SessionListing struct {
+	ID             string
+	Campaign       string
+	Date           time.Time
+	Duration       time.Duration // between the first and last recorded event
+	CharacterCount int
+}
+
+// LongListing returns every session as a SessionListing, newest first
+func (hm *HistoryManager) LongListing() ([]SessionListing, error) {
+	rows, err := hm.db.Query(`
+		SELECT s.id, s.campaign, s.date,
+		       COALESCE(MIN(e.timestamp), 0), COALESCE(MAX(e.timestamp), 0),
+		       (SELECT COUNT(*) FROM session_characters sc WHERE sc.session_id = s.id)
+		FROM sessions s
+		LEFT JOIN session_events e ON e.session_id = s.id
+		GROUP BY s.id
+		ORDER BY s.date DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("querying session listing: %w", err)
 	}
+	defer rows.Close()
 
-	// Filter by characters
-	if len(filter.Characters) > 0 {
-		sessionChars := strings.Join(session.Characters, ",")
-		for _, char := range filter.Characters {
-			if !strings.Contains(sessionChars, char) {
-				return false
-			}
+	var listings []SessionListing
+	for rows.Next() {
+		var listing SessionListing
+		var dateUnix, firstUnix, lastUnix int64
+		if err := rows.Scan(&listing.ID, &listing.Campaign, &dateUnix, &firstUnix, &lastUnix, &listing.CharacterCount); err != nil {
+			return nil, fmt.Errorf("scanning session listing row: %w", err)
 		}
-	}
 
-	return true
+		listing.Date = time.Unix(dateUnix, 0)
+		if lastUnix > firstUnix {
+			listing.Duration = time.Duration(lastUnix-firstUnix) * time.Second
+		}
+
+		listings = append(listings, listing)
+	}
+	return listings, rows.Err()
 }
@@ -7,6 +7,10 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"wfrp-bot/dice"
 )
 
 // MarkdownParser handles parsing of WFRP markdown files
@@ -49,22 +53,74 @@ func (mp *MarkdownParser) WriteFile(path, content string) error {
 	return nil
 }
 
-// ParseCharacterSheet parses a character sheet from markdown
+// AppendFile appends content to a markdown file, creating it (and any
+// missing parent directories) if it does not already exist
+func (mp *MarkdownParser) AppendFile(path, content string) error {
+	fullPath := mp.resolvePath(path)
+
+	dir := filepath.Dir(fullPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create directory %s: %w", dir, err)
+	}
+
+	f, err := os.OpenFile(fullPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %w", fullPath, err)
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(content); err != nil {
+		return fmt.Errorf("failed to append to file %s: %w", fullPath, err)
+	}
+
+	return nil
+}
+
+// ParseCharacterSheet parses a character sheet from markdown. If content
+// starts with a "---" delimited YAML frontmatter block that decodes
+// cleanly into a CharacterSheet, the result's Sheet field is populated and
+// the markdown body is scanned for any remaining free-form sections/lists.
+// Otherwise (no frontmatter, or a decode error - nested maps the old
+// SplitN(":", 2) parser used to mangle are the usual culprit) it falls back
+// to the original line-heuristic parser; only a decode error is recorded
+// in Warnings
 func (mp *MarkdownParser) ParseCharacterSheet(content string) (*ParsedCharacter, error) {
+	frontmatter, body, hasFrontmatter := splitFrontmatter(content)
+	if !hasFrontmatter {
+		return parseCharacterSheetHeuristic(content, nil), nil
+	}
+
+	var sheet CharacterSheet
+	if err := yaml.Unmarshal([]byte(frontmatter), &sheet); err != nil {
+		warning := fmt.Sprintf("YAML frontmatter decode failed, falling back to heuristic parser: %v", err)
+		return parseCharacterSheetHeuristic(content, []string{warning}), nil
+	}
+
+	char := parseCharacterSheetHeuristic(body, nil)
+	char.Sheet = &sheet
+	if sheet.Name != "" {
+		char.Name = sheet.Name
+	}
+
+	return char, nil
+}
+
+// parseCharacterSheetHeuristic is the original section/key-value/list-item
+// line scanner, used both as ParseCharacterSheet's sole path for files with
+// no frontmatter and as its fallback when frontmatter fails to decode
+func parseCharacterSheetHeuristic(content string, warnings []string) *ParsedCharacter {
 	char := &ParsedCharacter{
-		Fields: make(map[string]string),
+		Fields:   make(map[string]string),
+		Warnings: warnings,
 	}
 
-	// Parse using regex patterns
 	lines := strings.Split(content, "\n")
-	currentSection := ""
 
 	for _, line := range lines {
 		trimmed := strings.TrimSpace(line)
 
 		// Detect sections
 		if strings.HasPrefix(trimmed, "#") {
-			currentSection = strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
 			continue
 		}
 
@@ -92,7 +148,7 @@ func (mp *MarkdownParser) ParseCharacterSheet(content string) (*ParsedCharacter,
 		char.Name = name
 	}
 
-	return char, nil
+	return char
 }
 
 // ParseSessionLog parses a session log from markdown
@@ -128,6 +184,8 @@ type ParsedCharacter struct {
 	Fields    map[string]string
 	ListItems []string
 	Sections  map[string][]string
+	Sheet     *CharacterSheet // populated when YAML frontmatter decoded cleanly; nil when the heuristic fallback parser was used
+	Warnings  []string        // non-fatal issues encountered while parsing, e.g. a frontmatter decode failure that triggered the heuristic fallback
 }
 
 // SessionLog represents a parsed session log
@@ -145,16 +203,7 @@ type LogEntry struct {
 	Type      string // "action", "dialogue", "system"
 	Actor     string
 	Content   string
-	Roll      *DiceRoll
-}
-
-// DiceRoll represents a dice roll result
-type DiceRoll struct {
-	Type     string // "d100", "d10", "2d10"
-	Characteristic string
-	Skill     string
-	Result    int
-	Modifier  int
+	Roll      *dice.Result
 }
 
 // parseSessionMetadata extracts metadata from log lines
@@ -196,18 +245,22 @@ func (mp *MarkdownParser) parseLogEntry(line string) *LogEntry {
 		entry.Timestamp = matches[1]
 	}
 
-	// Parse dice rolls
-	dicePattern := regexp.MustCompile(`d(\d+)|(\d+)d(\d+)`)
-	if diceMatches := dicePattern.FindAllString(line, -1); len(diceMatches) > 0 {
-		entry.Roll = &DiceRoll{
-			Type:    diceMatches[0],
-			Result:   mp.extractRollResult(line),
+	// Parse dice rolls: the core NdM notation plus an optional "vs [Char] Target"
+	// clause, e.g. "d100 vs WS 45" in "Walter rolls d100 vs WS 45 = 32"
+	if matches := logDicePattern.FindStringSubmatch(line); matches != nil {
+		if expr, err := dice.Parse(matches[1]); err == nil {
+			result := expr.Resolve(mp.extractRollResult(line))
+			entry.Roll = &result
 		}
 	}
 
 	return entry
 }
 
+// logDicePattern recognizes a dice expression embedded in free-form log
+// text, loosely enough to feed straight into dice.Parse
+var logDicePattern = regexp.MustCompile(`(?i)(\d*d\d+(?:[+-]\d+|\+sl)?(?:\s+vs\s+(?:[a-zа-я]+\s+)?\d+)?)`)
+
 // extractRollResult extracts dice roll result from line
 func (mp *MarkdownParser) extractRollResult(line string) int {
 	// Find result after roll
@@ -228,35 +281,66 @@ func parseResult(s string) (int, error) {
 	return result, err
 }
 
-// ExtractFrontmatter extracts YAML frontmatter from markdown
+// ExtractFrontmatter extracts YAML frontmatter from markdown, decoding it
+// with a real YAML parser (nested maps, lists, quoted and multiline values,
+// UTF-8 keys like "Характеристики" all decode correctly, unlike the old
+// line-by-line SplitN(":", 2) scanner). Nested values are flattened to
+// dotted keys (e.g. "characteristics.ws") and lists are joined with ", ",
+// since callers expect a flat map[string]string; use ParseCharacterSheet
+// for the typed schema instead if the structure matters
 func (mp *MarkdownParser) ExtractFrontmatter(content string) (map[string]string, string) {
-	// Check for --- delimiters
-	if !strings.HasPrefix(content, "---") {
+	frontmatter, body, ok := splitFrontmatter(content)
+	if !ok {
 		return make(map[string]string), content
 	}
 
+	var raw map[string]any
+	if err := yaml.Unmarshal([]byte(frontmatter), &raw); err != nil {
+		return make(map[string]string), body
+	}
+
+	metadata := make(map[string]string, len(raw))
+	flattenYAML("", raw, metadata)
+	return metadata, body
+}
+
+// splitFrontmatter splits content into its "---" delimited YAML
+// frontmatter block and the remaining body. ok is false if content has no
+// such block
+func splitFrontmatter(content string) (frontmatter, body string, ok bool) {
+	if !strings.HasPrefix(content, "---") {
+		return "", content, false
+	}
+
 	parts := strings.SplitN(content, "---", 3)
 	if len(parts) < 3 {
-		return make(map[string]string), content
+		return "", content, false
 	}
 
-	frontmatter := parts[1]
-	body := parts[2]
+	return parts[1], parts[2], true
+}
 
-	// Parse simple key: value pairs
-	metadata := make(map[string]string)
-	for _, line := range strings.Split(frontmatter, "\n") {
-		if strings.Contains(line, ":") {
-			kv := strings.SplitN(line, ":", 2)
-			if len(kv) == 2 {
-				key := strings.TrimSpace(kv[0])
-				value := strings.TrimSpace(kv[1])
-				metadata[key] = value
+// flattenYAML stringifies a yaml.Unmarshal'd value into out, joining nested
+// map keys with "." and list items with ", ", e.g.
+// {"characteristics": {"ws": 45}} becomes {"characteristics.ws": "45"}
+func flattenYAML(prefix string, value any, out map[string]string) {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, val := range v {
+			if prefix != "" {
+				key = prefix + "." + key
 			}
+			flattenYAML(key, val, out)
 		}
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fmt.Sprintf("%v", item)
+		}
+		out[prefix] = strings.Join(parts, ", ")
+	default:
+		out[prefix] = fmt.Sprintf("%v", v)
 	}
-
-	return metadata, body
 }
 
 // BuildSessionLog creates a session log from entries
@@ -285,10 +369,31 @@ func (mp *MarkdownParser) BuildSessionLog(date, title, summary string, entries [
 	return builder.String(), nil
 }
 
-// BuildCharacterSheet creates a character sheet from parsed data
+// BuildCharacterSheet creates a character sheet from parsed data, the
+// symmetric counterpart to ParseCharacterSheet. If char.Sheet is set, it is
+// marshaled back to YAML as the file's "---" delimited frontmatter block
+// (CharacterSheet.Extra preserves any unknown keys from the original
+// decode, so a parse/build round-trip doesn't drop them) before the
+// markdown body
 func (mp *MarkdownParser) BuildCharacterSheet(char *ParsedCharacter) (string, error) {
 	var builder strings.Builder
 
+	if char.Sheet != nil {
+		sheet := *char.Sheet
+		if sheet.Name == "" {
+			sheet.Name = char.Name
+		}
+
+		yamlBytes, err := yaml.Marshal(sheet)
+		if err != nil {
+			return "", fmt.Errorf("failed to marshal character sheet frontmatter: %w", err)
+		}
+
+		builder.WriteString("---\n")
+		builder.Write(yamlBytes)
+		builder.WriteString("---\n\n")
+	}
+
 	// Header
 	builder.WriteString(fmt.Sprintf("# %s\n\n", char.Name))
 
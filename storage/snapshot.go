@@ -0,0 +1,272 @@
+// Package storage provides campaign snapshot export/import for WFRP bot
+package storage
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// SnapshotSchemaVersion is incremented whenever the manifest or archive
+// layout produced by ExportSnapshot changes in a way ImportSnapshot needs
+// to know about
+const SnapshotSchemaVersion = 1
+
+// SnapshotManifest describes one archive produced by ExportSnapshot: the
+// schema version guards ImportSnapshot against reading a future format,
+// and the per-file sha256 sums let it detect a truncated or tampered
+// upload before anything is written to disk
+type SnapshotManifest struct {
+	Version   int               `json:"version"`
+	Campaign  string            `json:"campaign"`
+	CreatedAt time.Time         `json:"created_at"`
+	Files     map[string]string `json:"files"` // path relative to the campaign dir -> sha256 hex
+}
+
+// ExportSnapshot packages every file under campaign's directory - character
+// sheets (with their current HP/XP), session conversation logs and scene
+// notes - into a gzip-compressed tar archive with a manifest.json entry,
+// for /backup_export and scheduled backups (see StartSnapshotScheduler)
+func (cm *CampaignManager) ExportSnapshot(campaign string) ([]byte, error) {
+	campPath := filepath.Join(cm.basePath, campaign)
+
+	var files []string
+	err := filepath.WalkDir(campPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, relErr := filepath.Rel(campPath, path)
+		if relErr != nil {
+			return relErr
+		}
+		files = append(files, rel)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list campaign files: %w", err)
+	}
+	sort.Strings(files)
+
+	manifest := SnapshotManifest{
+		Version:   SnapshotSchemaVersion,
+		Campaign:  campaign,
+		CreatedAt: time.Now(),
+		Files:     make(map[string]string, len(files)),
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, rel := range files {
+		data, err := os.ReadFile(filepath.Join(campPath, rel))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", rel, err)
+		}
+		sum := sha256.Sum256(data)
+		manifest.Files[rel] = hex.EncodeToString(sum[:])
+
+		if err := tw.WriteHeader(&tar.Header{Name: rel, Mode: 0644, Size: int64(len(data))}); err != nil {
+			return nil, fmt.Errorf("failed to write tar header for %s: %w", rel, err)
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, fmt.Errorf("failed to write %s to archive: %w", rel, err)
+		}
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: "manifest.json", Mode: 0644, Size: int64(len(manifestData))}); err != nil {
+		return nil, fmt.Errorf("failed to write manifest header: %w", err)
+	}
+	if _, err := tw.Write(manifestData); err != nil {
+		return nil, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close archive: %w", err)
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close archive: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// ImportSnapshot validates and restores a snapshot produced by
+// ExportSnapshot over campaign's directory, via a staging directory that
+// is renamed into place only once every file has checked out against the
+// manifest. The existing directory is removed just before that rename, so
+// the swap is two steps, not one atomic operation. Unless force is set, it refuses to touch a campaign directory
+// that already exists, since a caller with a session running against it
+// (see CommandHandlers.BackupImportCommand) would otherwise have its
+// conversation log and character sheets pulled out from under it
+func (cm *CampaignManager) ImportSnapshot(campaign string, data []byte, force bool) (*SnapshotManifest, error) {
+	campPath := filepath.Join(cm.basePath, campaign)
+
+	if !force {
+		if _, err := os.Stat(campPath); err == nil {
+			return nil, fmt.Errorf("campaign %q already exists; pass force to overwrite", campaign)
+		}
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("not a gzip archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	var manifest *SnapshotManifest
+	extracted := make(map[string][]byte)
+
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("corrupt archive: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s from archive: %w", hdr.Name, err)
+		}
+
+		if hdr.Name == "manifest.json" {
+			var m SnapshotManifest
+			if err := json.Unmarshal(content, &m); err != nil {
+				return nil, fmt.Errorf("invalid manifest: %w", err)
+			}
+			manifest = &m
+			continue
+		}
+		extracted[hdr.Name] = content
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("archive has no manifest.json")
+	}
+	if manifest.Version != SnapshotSchemaVersion {
+		return nil, fmt.Errorf("unsupported snapshot schema version %d (expected %d)", manifest.Version, SnapshotSchemaVersion)
+	}
+
+	stagePath := campPath + ".restoring"
+	if err := os.RemoveAll(stagePath); err != nil {
+		return nil, fmt.Errorf("failed to clear staging directory: %w", err)
+	}
+	if err := os.MkdirAll(stagePath, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer os.RemoveAll(stagePath)
+
+	for rel, sum := range manifest.Files {
+		content, ok := extracted[rel]
+		if !ok {
+			return nil, fmt.Errorf("manifest references missing file %s", rel)
+		}
+		got := sha256.Sum256(content)
+		if hex.EncodeToString(got[:]) != sum {
+			return nil, fmt.Errorf("checksum mismatch for %s", rel)
+		}
+
+		dest := filepath.Join(stagePath, rel)
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return nil, fmt.Errorf("failed to create directory for %s: %w", rel, err)
+		}
+		if err := os.WriteFile(dest, content, 0644); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", rel, err)
+		}
+	}
+
+	if err := os.RemoveAll(campPath); err != nil {
+		return nil, fmt.Errorf("failed to remove existing campaign directory: %w", err)
+	}
+	if err := os.Rename(stagePath, campPath); err != nil {
+		return nil, fmt.Errorf("failed to install restored campaign: %w", err)
+	}
+
+	cm.mu.Lock()
+	delete(cm.campaigns, campaign)
+	cm.mu.Unlock()
+	if err := cm.Refresh(); err != nil {
+		return nil, fmt.Errorf("restored files but failed to reload campaign: %w", err)
+	}
+
+	return manifest, nil
+}
+
+// StartSnapshotScheduler launches a background goroutine that exports a
+// snapshot of campaign to destDir every interval, named
+// "<campaign>-<unix timestamp>.tar.gz" so runs never clobber each other,
+// giving GMs painless recovery from a bad LLM edit or a lost character
+// without needing to remember to run /backup_export themselves. Stop via
+// StopSnapshotScheduler
+func (cm *CampaignManager) StartSnapshotScheduler(campaign, destDir string, interval time.Duration) {
+	cm.snapshotStop = make(chan struct{})
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-cm.snapshotStop:
+				return
+			case <-ticker.C:
+				if err := cm.snapshotToDir(campaign, destDir); err != nil {
+					log.Printf("[SNAPSHOT] Scheduled backup of %s failed: %v", campaign, err)
+				}
+			}
+		}
+	}()
+}
+
+// StopSnapshotScheduler shuts down the background scheduler started by
+// StartSnapshotScheduler
+func (cm *CampaignManager) StopSnapshotScheduler() {
+	cm.snapshotStopOnce.Do(func() {
+		if cm.snapshotStop != nil {
+			close(cm.snapshotStop)
+		}
+	})
+}
+
+// snapshotToDir exports campaign and writes it under destDir
+func (cm *CampaignManager) snapshotToDir(campaign, destDir string) error {
+	data, err := cm.ExportSnapshot(campaign)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		return fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	path := filepath.Join(destDir, fmt.Sprintf("%s-%d.tar.gz", campaign, time.Now().Unix()))
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write scheduled backup: %w", err)
+	}
+
+	log.Printf("[SNAPSHOT] Wrote scheduled backup of %s to %s", campaign, path)
+	return nil
+}
@@ -2,16 +2,24 @@
 package telegram
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net/http"
 	"strings"
 	"sync"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"wfrp-bot/chat"
 	"wfrp-bot/game"
 )
 
+// Bot implements chat.Transport, so a game.Session can route GM narration
+// to it alongside any other attached transport (see xmpp.Bot)
+var _ chat.Transport = (*Bot)(nil)
+
 // CommandHandler handles bot commands
 type CommandHandler func(update *tgbotapi.Update, args []string) error
 
@@ -28,11 +36,12 @@ type Bot struct {
 	wg              sync.WaitGroup
 	mu              sync.RWMutex
 	sessionManager  *game.SessionManager
-	commandHandlers interface{} // Reference to CommandHandlers for character creation
+	commandHandlers *CommandHandlers // dispatches messages into active conversational flows
 }
 
-// SetCommandHandlers sets reference to command handlers for character creation
-func (b *Bot) SetCommandHandlers(h interface{}) {
+// SetCommandHandlers sets the reference to command handlers used to
+// dispatch messages into active conversational flows (see FlowManager)
+func (b *Bot) SetCommandHandlers(h *CommandHandlers) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	b.commandHandlers = h
@@ -108,6 +117,11 @@ func (b *Bot) HandleUpdate(update *tgbotapi.Update) error {
 	for _, mw := range b.middleware {
 		cont, err := mw(update)
 		if err != nil {
+			var limited *ErrLimited
+			if errors.As(err, &limited) && update.Message != nil {
+				return b.SendMessage(update.Message.Chat.ID, fmt.Sprintf(
+					"Слишком много сообщений, подождите %s и попробуйте снова.", limited.RetryAfter.Round(time.Second)))
+			}
 			log.Printf("Middleware error: %v", err)
 			return err
 		}
@@ -121,6 +135,11 @@ func (b *Bot) HandleUpdate(update *tgbotapi.Update) error {
 		return b.handleCommand(update)
 	}
 
+	// Handle file uploads (e.g. the tar.gz dropped for /backup_import)
+	if update.Message != nil && update.Message.Document != nil {
+		return b.handleDocumentMessage(update)
+	}
+
 	// Handle regular messages from players
 	if update.Message != nil && update.Message.Text != "" {
 		return b.handlePlayerMessage(update)
@@ -163,11 +182,11 @@ func (b *Bot) handlePlayerMessage(update *tgbotapi.Update) error {
 
 	log.Printf("[MSG] Player %s: %s", userID, text)
 
-	// Check if there's an active character creation
-	if ch, ok := b.commandHandlers.(*CommandHandlers); ok {
-		if _, exists := ch.characterCreators[chatID]; exists {
-			return ch.ProcessCharacterCreation(chatID, text)
-		}
+	// Dispatch into whatever conversational flow is active for this chat
+	// (character creation, statblock import, backup import, ...) before
+	// falling through to normal session handling
+	if b.commandHandlers != nil && b.commandHandlers.flows.IsActive(chatID) {
+		return b.commandHandlers.processFlowMessage(chatID, text)
 	}
 
 	if b.sessionManager == nil {
@@ -187,6 +206,56 @@ func (b *Bot) handlePlayerMessage(update *tgbotapi.Update) error {
 	return nil
 }
 
+// handleDocumentMessage routes a file upload into whatever flow is active
+// for the chat, via FlowManager.ProcessDocument. Documents arriving
+// outside a flow are silently ignored like any other update
+// handlePlayerMessage wouldn't know what to do with; if the active flow
+// doesn't accept one, the player is told it expects a text message
+func (b *Bot) handleDocumentMessage(update *tgbotapi.Update) error {
+	chatID := update.Message.Chat.ID
+
+	if b.commandHandlers == nil || !b.commandHandlers.flows.IsActive(chatID) {
+		return nil
+	}
+
+	data, err := b.downloadDocument(update.Message.Document.FileID)
+	if err != nil {
+		log.Printf("[DOC] Failed to download document for chat %d: %v", chatID, err)
+		return b.SendMessage(chatID, fmt.Sprintf("Не удалось скачать файл: %v", err))
+	}
+
+	result, handled, err := b.commandHandlers.flows.ProcessDocument(chatID, data, update.Message.Document.FileName)
+	if !handled {
+		return b.SendMessage(chatID, "Эта команда ожидает текстовое сообщение, а не файл.")
+	}
+	if err != nil {
+		log.Printf("[FLOW] Document processing error for chat %d: %v", chatID, err)
+	}
+
+	return b.SendMessage(chatID, result.Reply)
+}
+
+// downloadDocument fetches the full contents of a Telegram-hosted file by
+// its file ID
+func (b *Bot) downloadDocument(fileID string) ([]byte, error) {
+	file, err := b.api.GetFile(tgbotapi.FileConfig{FileID: fileID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve file: %w", err)
+	}
+
+	resp, err := http.Get(file.Link(b.api.Token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download file: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read file: %w", err)
+	}
+	return data, nil
+}
+
 // handleCallbackQuery processes callback button presses
 func (b *Bot) handleCallbackQuery(update *tgbotapi.Update) error {
 	userID := update.CallbackQuery.From.ID
@@ -194,11 +263,23 @@ func (b *Bot) handleCallbackQuery(update *tgbotapi.Update) error {
 
 	log.Printf("[CALLBACK] User %d: %s", userID, data)
 
-	// Handle callback actions
-	// This would be integrated with game session for button interactions
+	// Route the callback's data into the same FlowManager state machine as
+	// a regular text reply, so a future inline keyboard can drive a flow
+	// (e.g. race/career selection) without any changes here
+	if update.CallbackQuery.Message == nil || b.commandHandlers == nil {
+		return nil
+	}
+	chatID := update.CallbackQuery.Message.Chat.ID
+	if b.commandHandlers.flows.IsActive(chatID) {
+		return b.commandHandlers.processFlowMessage(chatID, data)
+	}
+
 	return nil
 }
 
+// Name identifies this transport for chat.Transport
+func (b *Bot) Name() string { return "telegram" }
+
 // SendMessage sends a text message to a chat
 func (b *Bot) SendMessage(chatID int64, text string) error {
 	msg := tgbotapi.NewMessage(chatID, text)
@@ -230,6 +311,39 @@ func (b *Bot) SendReply(messageID int, chatID int64, text string) error {
 	return nil
 }
 
+// SendDocument uploads data as a file named filename to a chat, with an
+// optional caption
+func (b *Bot) SendDocument(chatID int64, filename string, data []byte, caption string) error {
+	doc := tgbotapi.NewDocument(chatID, tgbotapi.FileBytes{Name: filename, Bytes: data})
+	doc.Caption = caption
+
+	_, err := b.api.Send(doc)
+	if err != nil {
+		log.Printf("[SEND] Failed to send document %s to %d: %v", filename, chatID, err)
+		return err
+	}
+
+	log.Printf("[SEND] Document %s sent to %d", filename, chatID)
+	return nil
+}
+
+// SendMessageWithID sends a text message and returns its Telegram message
+// ID, so callers that need to edit it afterwards (see Streamer.StreamFromChan)
+// don't have to guess it
+func (b *Bot) SendMessageWithID(chatID int64, text string) (int, error) {
+	msg := tgbotapi.NewMessage(chatID, text)
+	msg.ParseMode = "Markdown"
+
+	sent, err := b.api.Send(msg)
+	if err != nil {
+		log.Printf("[SEND] Failed to send message to %d: %v", chatID, err)
+		return 0, err
+	}
+
+	log.Printf("[SEND] Message sent to %d: %q", chatID, truncateText(text, 50))
+	return sent.MessageID, nil
+}
+
 // SendEdit edits an existing message
 func (b *Bot) SendEdit(messageID int, chatID int64, text string) error {
 	msg := tgbotapi.NewEditMessageText(chatID, int(messageID), text)
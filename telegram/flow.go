@@ -0,0 +1,225 @@
+package telegram
+
+import (
+	"sync"
+	"time"
+)
+
+// ConversationFlow drives one multi-turn conversation in a single chat:
+// Prompt returns what to show the player next, Process consumes their
+// reply and reports whether the conversation is finished, and Cancel
+// returns the message to show if the player aborts with /cancel.
+// Complex state machines (see characterCreationFlow) implement this
+// directly; simpler ones can be built from an ordered []Step via NewFlow
+type ConversationFlow interface {
+	Prompt() string
+	Process(input string) (reply string, done bool, err error)
+	Cancel() string
+}
+
+// DocumentFlow is implemented by flows that can consume a file upload
+// instead of typed text, such as backupImportFlow accepting the tar.gz
+// produced by /backup_export
+type DocumentFlow interface {
+	ConversationFlow
+	ProcessDocument(data []byte, filename string) (reply string, done bool, err error)
+}
+
+// Step is one question in a linear Flow: Validate rejects malformed input
+// without advancing, Parse extracts the answer, and Next picks the
+// following step index from the parsed value (or -1 to finish the flow)
+type Step struct {
+	Prompt   string
+	Validate func(input string) error
+	Parse    func(input string) (interface{}, error)
+	Next     func(value interface{}) int
+}
+
+// Flow drives a fixed sequence of Steps, collecting each parsed answer and
+// calling onComplete once the last step's Next returns a negative index.
+// It implements ConversationFlow, so it can be registered with a
+// FlowManager exactly like characterCreationFlow - use this for new
+// multi-step conversations (quest setup, encounter builder, dice-roll
+// wizards, ...) that fit a fixed question sequence
+type Flow struct {
+	steps      []Step
+	current    int
+	cancelMsg  string
+	answers    []interface{}
+	onComplete func(answers []interface{}) (string, error)
+}
+
+// NewFlow builds a ConversationFlow from an ordered list of Steps
+func NewFlow(steps []Step, cancelMsg string, onComplete func(answers []interface{}) (string, error)) *Flow {
+	return &Flow{steps: steps, cancelMsg: cancelMsg, onComplete: onComplete}
+}
+
+func (f *Flow) Prompt() string {
+	return f.steps[f.current].Prompt
+}
+
+func (f *Flow) Cancel() string {
+	return f.cancelMsg
+}
+
+func (f *Flow) Process(input string) (string, bool, error) {
+	step := f.steps[f.current]
+
+	if step.Validate != nil {
+		if err := step.Validate(input); err != nil {
+			return err.Error(), false, nil
+		}
+	}
+
+	value, err := step.Parse(input)
+	if err != nil {
+		return err.Error(), false, nil
+	}
+	f.answers = append(f.answers, value)
+
+	next := step.Next(value)
+	if next < 0 {
+		reply, err := f.onComplete(f.answers)
+		return reply, true, err
+	}
+
+	f.current = next
+	return f.steps[f.current].Prompt, false, nil
+}
+
+// flowEntry is the bookkeeping FlowManager keeps per active conversation
+type flowEntry struct {
+	flow         ConversationFlow
+	userID       string
+	lastActivity time.Time
+}
+
+// FlowManager tracks which chat is mid-conversation in a ConversationFlow
+// (character creation, statblock import, backup import, ...), replacing
+// the ad-hoc per-feature awaiting*/*Creators maps and the
+// commandHandlers interface{} type assertion Bot.handlePlayerMessage used
+// to need to reach them. Flows are keyed by chatID, matching
+// game.SessionManager and the rest of this package, since a WFRP group
+// shares one conversation per chat rather than one per player
+type FlowManager struct {
+	mu      sync.Mutex
+	active  map[int64]*flowEntry
+	idleMax time.Duration
+}
+
+// NewFlowManager creates a FlowManager that abandons a flow if idleMax
+// passes between messages (0 disables the timeout)
+func NewFlowManager(idleMax time.Duration) *FlowManager {
+	return &FlowManager{active: make(map[int64]*flowEntry), idleMax: idleMax}
+}
+
+// Start registers flow as the active conversation for chatID and returns
+// its opening prompt, replacing whatever flow (if any) was already
+// running there - callers that want to refuse that should check IsActive
+// first
+func (fm *FlowManager) Start(chatID int64, userID string, flow ConversationFlow) string {
+	fm.mu.Lock()
+	fm.active[chatID] = &flowEntry{flow: flow, userID: userID, lastActivity: time.Now()}
+	fm.mu.Unlock()
+	return flow.Prompt()
+}
+
+// IsActive reports whether chatID has a conversation in progress
+func (fm *FlowManager) IsActive(chatID int64) bool {
+	fm.mu.Lock()
+	defer fm.mu.Unlock()
+	_, exists := fm.active[chatID]
+	return exists
+}
+
+// FlowResult is what Process reports back about the flow it just drove
+type FlowResult struct {
+	Reply string
+	Done  bool
+	Flow  ConversationFlow
+}
+
+// Process feeds text into the flow active for chatID. handled is false if
+// no flow was active, in which case callers should fall through to normal
+// message handling. The flow's own Process runs without FlowManager's
+// lock held, so a flow is free to call Start/Cancel on this same
+// FlowManager as part of handling its last step (see statblockImportFlow,
+// which hands off to a characterCreationFlow this way)
+func (fm *FlowManager) Process(chatID int64, text string) (result FlowResult, handled bool, err error) {
+	fm.mu.Lock()
+	entry, exists := fm.active[chatID]
+	fm.mu.Unlock()
+	if !exists {
+		return FlowResult{}, false, nil
+	}
+
+	if fm.idleMax > 0 && time.Since(entry.lastActivity) > fm.idleMax {
+		fm.mu.Lock()
+		delete(fm.active, chatID)
+		fm.mu.Unlock()
+		return FlowResult{Reply: entry.flow.Cancel() + " (время ожидания истекло)", Done: true, Flow: entry.flow}, true, nil
+	}
+
+	reply, done, err := entry.flow.Process(text)
+
+	fm.mu.Lock()
+	if current, stillSame := fm.active[chatID]; stillSame && current == entry {
+		if done {
+			delete(fm.active, chatID)
+		} else {
+			entry.lastActivity = time.Now()
+		}
+	}
+	fm.mu.Unlock()
+
+	return FlowResult{Reply: reply, Done: done, Flow: entry.flow}, true, err
+}
+
+// ProcessDocument feeds a downloaded file into the flow active for chatID,
+// if that flow implements DocumentFlow. handled is false if no flow is
+// active or the active one doesn't accept documents, in which case
+// callers should tell the player to send text instead
+func (fm *FlowManager) ProcessDocument(chatID int64, data []byte, filename string) (result FlowResult, handled bool, err error) {
+	fm.mu.Lock()
+	entry, exists := fm.active[chatID]
+	fm.mu.Unlock()
+	if !exists {
+		return FlowResult{}, false, nil
+	}
+
+	docFlow, ok := entry.flow.(DocumentFlow)
+	if !ok {
+		return FlowResult{}, false, nil
+	}
+
+	reply, done, err := docFlow.ProcessDocument(data, filename)
+
+	fm.mu.Lock()
+	if current, stillSame := fm.active[chatID]; stillSame && current == entry {
+		if done {
+			delete(fm.active, chatID)
+		} else {
+			entry.lastActivity = time.Now()
+		}
+	}
+	fm.mu.Unlock()
+
+	return FlowResult{Reply: reply, Done: done, Flow: entry.flow}, true, err
+}
+
+// Cancel aborts chatID's active flow, if any, returning its cancel message
+// and the flow itself (so callers can run feature-specific cleanup, e.g.
+// CommandHandlers.CancelCommand removing a saved chargen state file)
+func (fm *FlowManager) Cancel(chatID int64) (message string, ok bool, flow ConversationFlow) {
+	fm.mu.Lock()
+	entry, exists := fm.active[chatID]
+	if exists {
+		delete(fm.active, chatID)
+	}
+	fm.mu.Unlock()
+
+	if !exists {
+		return "", false, nil
+	}
+	return entry.flow.Cancel(), true, entry.flow
+}
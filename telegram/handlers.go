@@ -15,30 +15,177 @@ import (
 
 	"wfrp-bot/config"
 	"wfrp-bot/game"
+	"wfrp-bot/game/content"
+	"wfrp-bot/game/importer"
 	"wfrp-bot/llm"
+	"wfrp-bot/scheduler"
 	"wfrp-bot/storage"
 )
 
 // Command handlers for WFRP bot
 type CommandHandlers struct {
-	bot               *Bot
-	sessionMgr        *game.SessionManager
-	charMgr           *game.CharacterManager
-	storageMgr        *storage.CampaignManager
-	characterCreators map[int64]*game.CharacterCreator
+	bot         *Bot
+	sessionMgr  *game.SessionManager
+	charMgr     *game.CharacterManager
+	storageMgr  *storage.CampaignManager
+	content     content.ContentProvider
+	flows       *FlowManager
+	lastCreated map[int64]*game.CharacterCreator
+	sched       *scheduler.Scheduler // optional; set via RegisterSchedulerHandlers
+	lobby       *game.Lobby          // optional; set via RegisterLobbyHandlers
+
+	runtimeConfigs map[int64]*config.RuntimeConfig // chatID -> /config state, see runtimeConfigFor
 }
 
-// NewCommandHandlers creates a new command handlers instance
-func NewCommandHandlers(bot *Bot, sessionMgr *game.SessionManager, charMgr *game.CharacterManager, storageMgr *storage.CampaignManager) *CommandHandlers {
+// NewCommandHandlers creates a new command handlers instance. provider
+// supplies the races and careers available during character creation
+func NewCommandHandlers(bot *Bot, sessionMgr *game.SessionManager, charMgr *game.CharacterManager, storageMgr *storage.CampaignManager, provider content.ContentProvider) *CommandHandlers {
 	return &CommandHandlers{
-		bot:               bot,
-		sessionMgr:        sessionMgr,
-		charMgr:           charMgr,
-		storageMgr:        storageMgr,
-		characterCreators: make(map[int64]*game.CharacterCreator),
+		bot:         bot,
+		sessionMgr:  sessionMgr,
+		charMgr:     charMgr,
+		storageMgr:  storageMgr,
+		content:     provider,
+		flows:       NewFlowManager(30 * time.Minute),
+		lastCreated: make(map[int64]*game.CharacterCreator),
+
+		runtimeConfigs: make(map[int64]*config.RuntimeConfig),
 	}
 }
 
+// characterCreationFlow adapts *game.CharacterCreator - which already runs
+// its own branching question sequence (race, career, stats, ...) - onto
+// ConversationFlow, so it can be driven by a FlowManager like any other flow
+type characterCreationFlow struct {
+	creator *game.CharacterCreator
+}
+
+func (f *characterCreationFlow) Prompt() string {
+	return f.creator.GetPrompt()
+}
+
+func (f *characterCreationFlow) Cancel() string {
+	return "❌ Создание персонажа отменено."
+}
+
+func (f *characterCreationFlow) Process(input string) (string, bool, error) {
+	reply, isComplete := f.creator.ProcessInput(input)
+	return reply, isComplete && f.creator.IsComplete(), nil
+}
+
+// statblockImportFlow is a single-message flow started by /import: the
+// next message is parsed as a pasted character sheet and, on success,
+// hands off to a characterCreationFlow starting at CC_Review so the
+// player can confirm and save it like any other character
+type statblockImportFlow struct {
+	h      *CommandHandlers
+	chatID int64
+	userID string
+}
+
+func (f *statblockImportFlow) Prompt() string {
+	return "Вставь текст листа персонажа (свой или сгенерированный ботом ранее следующим сообщением). Я распознаю имя, расу, карьеру, статус, характеристики, навыки, таланты, снаряжение и деньги."
+}
+
+func (f *statblockImportFlow) Cancel() string {
+	return "Импорт отменён."
+}
+
+func (f *statblockImportFlow) Process(input string) (string, bool, error) {
+	data, warnings, err := importer.ParseStatblock(input)
+	if err != nil {
+		return fmt.Sprintf("Не удалось разобрать лист персонажа: %v\nПопробуй /import ещё раз.", err), true, nil
+	}
+	data.BasePath = "./characters"
+
+	creator := game.NewCharacterCreator("./characters", f.h.content)
+	creator.Data = data
+	creator.State = game.CC_Review
+	creator.UserID = f.userID
+
+	msg := fmt.Sprintf("Персонаж %s импортирован.\n\n%s", creator.Data.Name, creator.GetPrompt())
+	if len(warnings) > 0 {
+		msg += fmt.Sprintf("\n\n⚠️ %d строк не распознано при импорте.", len(warnings))
+	}
+
+	f.h.flows.Start(f.chatID, f.userID, &characterCreationFlow{creator: creator})
+
+	return msg, true, nil
+}
+
+// backupImportFlow is a single-message flow started by /backup_import: the
+// next message is expected to be the tar.gz snapshot produced by
+// /backup_export, which is unpacked over the flow's target campaign (see
+// storage.CampaignManager.ImportSnapshot). It implements DocumentFlow, not
+// just ConversationFlow, since a snapshot arrives as a file upload rather
+// than text
+type backupImportFlow struct {
+	h        *CommandHandlers
+	campaign string
+	force    bool
+}
+
+func (f *backupImportFlow) Prompt() string {
+	return fmt.Sprintf("Пришли файлом резервную копию (.tar.gz из /backup_export) для кампании %q следующим сообщением.", f.campaign)
+}
+
+func (f *backupImportFlow) Cancel() string {
+	return "Импорт резервной копии отменён."
+}
+
+func (f *backupImportFlow) Process(input string) (string, bool, error) {
+	return "Нужен файл, а не текст. Пришли .tar.gz, полученный от /backup_export, или /cancel для отмены.", false, nil
+}
+
+func (f *backupImportFlow) ProcessDocument(data []byte, filename string) (string, bool, error) {
+	manifest, err := f.h.storageMgr.ImportSnapshot(f.campaign, data, f.force)
+	if err != nil {
+		return fmt.Sprintf("Не удалось импортировать резервную копию: %v\nПопробуй /backup_import ещё раз.", err), true, nil
+	}
+	return fmt.Sprintf("Кампания %q восстановлена из резервной копии от %s (%d файлов).", f.campaign, manifest.CreatedAt.Format("2006-01-02 15:04:05"), len(manifest.Files)), true, nil
+}
+
+// processFlowMessage feeds text into the flow active for chatID (character
+// creation, statblock import, backup import, ...). It is a no-op if no
+// flow is active
+func (h *CommandHandlers) processFlowMessage(chatID int64, text string) error {
+	result, handled, err := h.flows.Process(chatID, text)
+	if !handled {
+		return nil
+	}
+	if err != nil {
+		return h.bot.SendMessage(chatID, fmt.Sprintf("Ошибка: %v", err))
+	}
+
+	if err := h.bot.SendMessage(chatID, result.Reply); err != nil {
+		return err
+	}
+
+	if result.Done {
+		h.onFlowComplete(chatID, result.Flow)
+	}
+	return nil
+}
+
+// onFlowComplete runs feature-specific follow-up once a flow finishes.
+// Character creation needs to save the finished character and keep it
+// around for /export; other flows (statblock import, backup import) do
+// everything they need inside Process or ProcessDocument themselves
+func (h *CommandHandlers) onFlowComplete(chatID int64, flow ConversationFlow) {
+	cc, ok := flow.(*characterCreationFlow)
+	if !ok {
+		return
+	}
+
+	creator := cc.creator
+	if err := creator.SaveToFile("./characters"); err != nil {
+		log.Printf("[NEWCHAR] Failed to save character: %v", err)
+	} else {
+		h.bot.SendMessage(chatID, fmt.Sprintf("✅ Персонаж %s сохранён в characters/", creator.Data.Name))
+	}
+	h.lastCreated[chatID] = creator
+}
+
 // StartCommand starts a new game session
 func (h *CommandHandlers) StartCommand(update *tgbotapi.Update, args []string) error {
 	if update.Message == nil {
@@ -52,6 +199,7 @@ func (h *CommandHandlers) StartCommand(update *tgbotapi.Update, args []string) e
 	if len(args) > 0 {
 		campaign = args[0]
 	}
+	agentName := parseAgentFlag(args)
 
 	if campaign == "" {
 		// List available campaigns
@@ -78,19 +226,17 @@ func (h *CommandHandlers) StartCommand(update *tgbotapi.Update, args []string) e
 		return h.bot.SendMessage(chatID, fmt.Sprintf("Ошибка загрузки конфигурации: %v", err))
 	}
 
-	// Create LLM provider
-	provider, err := llm.NewProviderFromConfig(&llm.ProviderConfig{
-		Name:    cfg.DefaultProvider,
-		APIKey:  cfg.Providers[cfg.DefaultProvider].APIKey,
-		BaseURL: cfg.Providers[cfg.DefaultProvider].BaseURL,
-		Model:   cfg.Providers[cfg.DefaultProvider].Model,
-	})
+	// Create LLM provider, with automatic failover to cfg.FailoverChain
+	provider, err := buildConfiguredProvider(cfg)
 	if err != nil {
 		return h.bot.SendMessage(chatID, fmt.Sprintf("Ошибка инициализации LLM провайдера: %v", err))
 	}
 
 	// Create new session for campaign
-	session := game.NewSession(context.Background(), chatID, campaign, provider)
+	session := game.NewSession(context.Background(), chatID, campaign, provider, h.storageMgr)
+	if agentName != "" {
+		session.SetAgent(agentName)
+	}
 	session.Start()
 
 	h.sessionMgr.AddSession(chatID, session)
@@ -98,6 +244,18 @@ func (h *CommandHandlers) StartCommand(update *tgbotapi.Update, args []string) e
 	return h.bot.SendMessage(chatID, fmt.Sprintf("✅ Игровая сессия запущена для кампании: %s\n\nGM готов принимать команды.", campaign))
 }
 
+// parseAgentFlag scans /start's arguments for "--agent <name>" and returns
+// name, or "" if the flag wasn't present (in which case the session keeps
+// its default GM agent)
+func parseAgentFlag(args []string) string {
+	for i, arg := range args {
+		if arg == "--agent" && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
 // HelpCommand displays help information
 func (h *CommandHandlers) HelpCommand(update *tgbotapi.Update, args []string) error {
 	if update.Message == nil {
@@ -107,7 +265,7 @@ func (h *CommandHandlers) HelpCommand(update *tgbotapi.Update, args []string) er
 	helpText := `🎮 **WFRP Game Master Bot** - Справка по командам
 
 📋 **Основные команды:**
-/start <кампания> - Запустить новую игру или сессию
+/start <кампания> [--agent <имя>] - Запустить новую игру или сессию (необязательный агент, например wfrp-character-builder)
 /stop - Остановить текущую сессию
 /status - Показать статус текущей сессии
 
@@ -124,7 +282,22 @@ func (h *CommandHandlers) HelpCommand(update *tgbotapi.Update, args []string) er
 🎲 **Утилиты:**
 /roll <формула> - Бросить кубы (например: d100, 2d10+5)
 /scene <описание> - Описать сцену
-/reload - Перезагрузить конфигурацию
+/config - Показать текущие настройки LLM
+/config get <ключ> - Показать значение ключа
+/config set <ключ> <значение> - Изменить настройку активной сессии (provider, model, base_url, temperature, max_tokens, system_prompt)
+
+🌿 **История и ветки сессии:**
+/convhistory [n] - Показать последние n ходов диалога (по умолчанию 10)
+/edit <msgID> <текст> - Переписать ход и пересгенерировать ответ ГМа от этой точки
+/rewind [n] - Откатить указатель сессии на n ходов назад
+/branches - Показать все ветки диалога сессии
+/backup_export <кампания> - Выгрузить резервную копию кампании (.tar.gz)
+/backup_import <кампания> [--force] - Восстановить кампанию из резервной копии
+
+⏰ **Напоминания:**
+/remind <10m|2h30m|завтра 20:00> <текст> - Напомнить в чате в указанное время
+/reminders - Показать ожидающие напоминания
+/unremind <id> - Отменить напоминание
 /help - Показать эту справку
 
 📚 **Доступные кампании:`
@@ -218,7 +391,7 @@ func (h *CommandHandlers) CharacterCommand(update *tgbotapi.Update, args []strin
 	}
 
 	// Check if already creating a character
-	if _, exists := h.characterCreators[chatID]; exists {
+	if h.flows.IsActive(chatID) {
 		return h.bot.SendMessage(chatID, "Создание персонажа уже начато! Ответь на текущий вопрос или напиши /cancel для отмены.")
 	}
 
@@ -238,7 +411,7 @@ func (h *CommandHandlers) CharacterCommand(update *tgbotapi.Update, args []strin
 	}
 
 	// Start new character creation
-	creator := game.NewCharacterCreator("./characters")
+	creator := game.NewCharacterCreator("./characters", h.content)
 	creator.Data.Name = charName
 
 	// Try to get LLM provider from session
@@ -260,9 +433,10 @@ func (h *CommandHandlers) CharacterCommand(update *tgbotapi.Update, args []strin
 		}
 	}
 
-	h.characterCreators[chatID] = creator
+	userID := fmt.Sprintf("%d", update.Message.From.ID)
+	prompt := h.flows.Start(chatID, userID, &characterCreationFlow{creator: creator})
 
-	return h.bot.SendMessage(chatID, fmt.Sprintf("🎭 **Создание персонажа: %s**\n\n%s", charName, creator.GetPrompt()))
+	return h.bot.SendMessage(chatID, fmt.Sprintf("🎭 **Создание персонажа: %s**\n\n%s", charName, prompt))
 }
 
 // CharactersCommand displays list of all characters
@@ -366,19 +540,134 @@ func (h *CommandHandlers) formatCharacterCard(char *game.Character) string {
 	return builder.String()
 }
 
-// ReloadCommand reloads configuration
-func (h *CommandHandlers) ReloadCommand(update *tgbotapi.Update, args []string) error {
+// runtimeConfigFor returns chatID's RuntimeConfig, building it from the
+// current BotConfig plus any persisted overlay (see config.LoadOverlay) the
+// first time a chat is seen, and caching it afterwards so /config set
+// changes stick for the rest of the process's lifetime
+func (h *CommandHandlers) runtimeConfigFor(chatID int64) (*config.RuntimeConfig, error) {
+	if rc, ok := h.runtimeConfigs[chatID]; ok {
+		return rc, nil
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	rc := config.NewRuntimeConfig(cfg)
+	if overlay, err := config.LoadOverlay(config.OverlayPath()); err != nil {
+		log.Printf("[CONFIG] Failed to load config overlay: %v", err)
+	} else {
+		rc = rc.ApplyOverlay(overlay)
+	}
+
+	h.runtimeConfigs[chatID] = &rc
+	return &rc, nil
+}
+
+// ConfigCommand shows or hot-swaps the active session's LLM settings:
+//   - /config - show every key with its current value
+//   - /config get <key> - show one key's value
+//   - /config set <key> <value> - change a key and, if the chat has an
+//     active session, rebuild its LLM provider immediately (see
+//     applyRuntimeConfig) and persist the change to the config overlay file
+//     so it survives a restart
+func (h *CommandHandlers) ConfigCommand(update *tgbotapi.Update, args []string) error {
 	if update.Message == nil {
 		return fmt.Errorf("no message in update")
 	}
-
 	chatID := update.Message.Chat.ID
 
-	// Reload configuration from environment
-	// Note: This is a placeholder - actual implementation would re-read .env
-	log.Printf("[RELOAD] Configuration reload requested by user %d", update.Message.From.ID)
+	rc, err := h.runtimeConfigFor(chatID)
+	if err != nil {
+		return h.bot.SendMessage(chatID, fmt.Sprintf("Ошибка загрузки конфигурации: %v", err))
+	}
+
+	if len(args) == 0 {
+		var builder strings.Builder
+		builder.WriteString("⚙️ **Текущие настройки:**\n\n")
+		for _, key := range config.RuntimeConfigKeys {
+			value, _ := rc.Get(key)
+			builder.WriteString(fmt.Sprintf("`%s` = %s\n", key, value))
+		}
+		return h.bot.SendMessage(chatID, builder.String())
+	}
 
-	return h.bot.SendMessage(chatID, "⚙️ Конфигурация перезагружена.")
+	switch args[0] {
+	case "get":
+		if len(args) < 2 {
+			return h.bot.SendMessage(chatID, "Использование: /config get <ключ>")
+		}
+		value, ok := rc.Get(args[1])
+		if !ok {
+			return h.bot.SendMessage(chatID, fmt.Sprintf("Неизвестный ключ %q.", args[1]))
+		}
+		return h.bot.SendMessage(chatID, fmt.Sprintf("`%s` = %s", args[1], value))
+
+	case "set":
+		if len(args) < 3 {
+			return h.bot.SendMessage(chatID, "Использование: /config set <ключ> <значение>")
+		}
+		key := args[1]
+		value := strings.Join(args[2:], " ")
+
+		if err := rc.Set(key, value); err != nil {
+			return h.bot.SendMessage(chatID, fmt.Sprintf("Ошибка: %v", err))
+		}
+
+		if err := h.applyRuntimeConfig(chatID, *rc); err != nil {
+			return h.bot.SendMessage(chatID, fmt.Sprintf("Настройка сохранена, но не удалось применить к активной сессии: %v", err))
+		}
+
+		if err := config.SaveOverlay(config.OverlayPath(), rc.ToOverlay()); err != nil {
+			log.Printf("[CONFIG] Failed to persist config overlay: %v", err)
+		}
+
+		return h.bot.SendMessage(chatID, fmt.Sprintf("✅ `%s` установлен в %q.", key, value))
+
+	default:
+		return h.bot.SendMessage(chatID, "Использование: /config, /config get <ключ>, /config set <ключ> <значение>")
+	}
+}
+
+// applyRuntimeConfig rebuilds rc's LLM provider via llm.NewProviderFromConfig
+// and swaps it into chatID's active session (see Session.SetLLMProvider),
+// and applies rc.SystemPrompt as the session's prompt override. It is a
+// no-op if no session is currently running for chatID. A session started
+// later by /start (StartCommand) gets its provider from the BotConfig via
+// buildConfiguredProvider and does not read these settings
+func (h *CommandHandlers) applyRuntimeConfig(chatID int64, rc config.RuntimeConfig) error {
+	session, exists := h.sessionMgr.GetSession(chatID)
+	if !exists {
+		return nil
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	providerCfg, ok := cfg.Providers[rc.Provider]
+	if !ok {
+		return fmt.Errorf("no configuration found for provider %q", rc.Provider)
+	}
+
+	provider, err := llm.NewProviderFromConfig(&llm.ProviderConfig{
+		Name:              providerCfg.Name,
+		APIKey:            providerCfg.APIKey,
+		BaseURL:           rc.BaseURL,
+		Model:             rc.Model,
+		RequestsPerMinute: providerCfg.RequestsPerMinute,
+		Temperature:       rc.Temperature,
+		MaxTokens:         rc.MaxTokens,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to build LLM provider: %w", err)
+	}
+
+	session.SetLLMProvider(provider)
+	session.SetSystemPromptOverride(rc.SystemPrompt)
+	return nil
 }
 
 // StopCommand stops the current session
@@ -409,12 +698,14 @@ func (h *CommandHandlers) NewCharCommand(update *tgbotapi.Update, args []string)
 	chatID := update.Message.Chat.ID
 
 	// Check if already creating a character
-	if _, exists := h.characterCreators[chatID]; exists {
+	if h.flows.IsActive(chatID) {
 		return h.bot.SendMessage(chatID, "Создание персонажа уже начато! Ответь на текущий вопрос или напиши /cancel для отмены.")
 	}
 
 	// Create character creator with LLM provider
-	creator := game.NewCharacterCreator("./characters")
+	creator := game.NewCharacterCreator("./characters", h.content)
+	userID := fmt.Sprintf("%d", update.Message.From.ID)
+	creator.UserID = userID
 
 	// Try to get LLM provider from session
 	if session, exists := h.sessionMgr.GetSession(chatID); exists {
@@ -443,52 +734,306 @@ func (h *CommandHandlers) NewCharCommand(update *tgbotapi.Update, args []string)
 		}
 	}
 
-	h.characterCreators[chatID] = creator
+	prompt := h.flows.Start(chatID, userID, &characterCreationFlow{creator: creator})
 
-	return h.bot.SendMessage(chatID, "🎭 **Создание персонажа WFRP 4E**\n\n"+creator.GetPrompt())
+	return h.bot.SendMessage(chatID, "🎭 **Создание персонажа WFRP 4E**\n\n"+prompt)
 }
 
-// ProcessCharacterCreation handles ongoing character creation
-func (h *CommandHandlers) ProcessCharacterCreation(chatID int64, text string) error {
-	creator, exists := h.characterCreators[chatID]
-	if !exists {
-		return nil
+// CancelCommand aborts whatever flow (character creation, statblock
+// import, backup import, ...) is currently active in this chat
+func (h *CommandHandlers) CancelCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
 	}
 
-	response, isComplete := creator.ProcessInput(text)
+	chatID := update.Message.Chat.ID
 
-	if err := h.bot.SendMessage(chatID, response); err != nil {
-		return err
+	msg, active, flow := h.flows.Cancel(chatID)
+	if !active {
+		return h.bot.SendMessage(chatID, "Нет активного процесса для отмены.")
 	}
 
-	if isComplete && creator.IsComplete() {
-		// Save character to file
-		if err := creator.SaveToFile("./characters"); err != nil {
-			log.Printf("[NEWCHAR] Failed to save character: %v", err)
-		} else {
-			h.bot.SendMessage(chatID, fmt.Sprintf("✅ Персонаж %s сохранён в characters/", creator.Data.Name))
+	if cc, ok := flow.(*characterCreationFlow); ok && cc.creator.UserID != "" {
+		basePath := cc.creator.Data.BasePath
+		if basePath == "" {
+			basePath = "./characters"
+		}
+		if err := game.DeleteStateFile(basePath, cc.creator.UserID); err != nil {
+			log.Printf("[CANCEL] Failed to remove saved state for %s: %v", cc.creator.UserID, err)
 		}
-		// Remove from active creators
-		delete(h.characterCreators, chatID)
 	}
 
-	return nil
+	return h.bot.SendMessage(chatID, msg)
+}
+
+// ResumeCommand restores an in-progress character creation saved by a
+// previous run of the bot (or an earlier session in this chat), picking up
+// at exactly the prompt the player left off at
+func (h *CommandHandlers) ResumeCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
+	}
+	chatID := update.Message.Chat.ID
+	userID := fmt.Sprintf("%d", update.Message.From.ID)
+
+	if h.flows.IsActive(chatID) {
+		return h.bot.SendMessage(chatID, "Создание персонажа уже начато! Ответь на текущий вопрос или напиши /cancel для отмены.")
+	}
+
+	creator, err := game.LoadStateFromFile("./characters", userID, h.content)
+	if err != nil {
+		return h.bot.SendMessage(chatID, "Нет сохранённого незавершённого персонажа. Напиши /newchar, чтобы начать.")
+	}
+	creator.UserID = userID
+
+	if session, exists := h.sessionMgr.GetSession(chatID); exists {
+		creator.SetLLMProvider(session.GetLLMProvider())
+	} else {
+		creator.SetLLMProvider(h.resolveLLMProvider(chatID))
+	}
+
+	prompt := h.flows.Start(chatID, userID, &characterCreationFlow{creator: creator})
+
+	return h.bot.SendMessage(chatID, "▶️ Продолжаем создание персонажа.\n\n"+prompt)
+}
+
+// ExportCommand sends the last character created in this chat as a file.
+// Usage: /export <json|markdown|foundry>, defaulting to markdown
+func (h *CommandHandlers) ExportCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
+	}
+	chatID := update.Message.Chat.ID
+
+	creator, exists := h.lastCreated[chatID]
+	if !exists {
+		return h.bot.SendMessage(chatID, "Нет готового персонажа для экспорта. Сначала создай его через /newchar.")
+	}
+
+	format := "markdown"
+	if len(args) > 0 {
+		format = strings.ToLower(args[0])
+	}
+
+	data, err := creator.Export(format)
+	if err != nil {
+		return h.bot.SendMessage(chatID, fmt.Sprintf("Ошибка экспорта: %v", err))
+	}
+
+	ext := "json"
+	if format == "markdown" {
+		ext = "md"
+	}
+	filename := fmt.Sprintf("%s_%s.%s", creator.Data.Name, format, ext)
+
+	return h.bot.SendDocument(chatID, filename, data, fmt.Sprintf("Экспорт персонажа %s (%s)", creator.Data.Name, format))
+}
+
+// ImportCommand starts the statblock import flow: the next message from
+// this chat is parsed as a pasted character sheet
+func (h *CommandHandlers) ImportCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
+	}
+	chatID := update.Message.Chat.ID
+
+	if h.flows.IsActive(chatID) {
+		return h.bot.SendMessage(chatID, "Создание персонажа уже начато! Напиши /cancel для отмены.")
+	}
+
+	userID := fmt.Sprintf("%d", update.Message.From.ID)
+	prompt := h.flows.Start(chatID, userID, &statblockImportFlow{h: h, chatID: chatID, userID: userID})
+
+	return h.bot.SendMessage(chatID, prompt)
+}
+
+// BackupExportCommand packages a campaign - character sheets, session
+// conversation logs, scene notes - into a versioned tar.gz snapshot (see
+// storage.CampaignManager.ExportSnapshot) and sends it back as a document,
+// so a GM can migrate a campaign between deployments or recover from a bad
+// LLM edit (see BackupImportCommand)
+func (h *CommandHandlers) BackupExportCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
+	}
+	chatID := update.Message.Chat.ID
+
+	if len(args) < 1 {
+		return h.bot.SendMessage(chatID, "Использование: /backup_export <кампания>")
+	}
+	campaign := args[0]
+
+	data, err := h.storageMgr.ExportSnapshot(campaign)
+	if err != nil {
+		return h.bot.SendMessage(chatID, fmt.Sprintf("Ошибка экспорта резервной копии: %v", err))
+	}
+
+	filename := fmt.Sprintf("%s_backup.tar.gz", campaign)
+	return h.bot.SendDocument(chatID, filename, data, fmt.Sprintf("Резервная копия кампании %q", campaign))
 }
 
-// CancelCharacterCreation cancels ongoing character creation
-func (h *CommandHandlers) CancelCharacterCreation(update *tgbotapi.Update, args []string) error {
+// BackupImportCommand starts a backup import for campaign: the next
+// message is expected to be the tar.gz document produced by
+// /backup_export (see backupImportFlow). It refuses to run if a session is
+// currently active for that campaign, since a restore would pull the
+// conversation log and character sheets out from under it - pass --force
+// to override
+func (h *CommandHandlers) BackupImportCommand(update *tgbotapi.Update, args []string) error {
 	if update.Message == nil {
 		return fmt.Errorf("no message in update")
 	}
+	chatID := update.Message.Chat.ID
 
+	if len(args) < 1 {
+		return h.bot.SendMessage(chatID, "Использование: /backup_import <кампания> [--force]")
+	}
+	campaign := args[0]
+	force := false
+	for _, a := range args[1:] {
+		if a == "--force" {
+			force = true
+		}
+	}
+
+	if !force {
+		for _, session := range h.sessionMgr.GetAllSessions() {
+			if session.Campaign == campaign {
+				return h.bot.SendMessage(chatID, fmt.Sprintf("⚠️ Кампания %q используется активной сессией. Повтори команду с --force, чтобы перезаписать её.", campaign))
+			}
+		}
+	}
+
+	userID := fmt.Sprintf("%d", update.Message.From.ID)
+	prompt := h.flows.Start(chatID, userID, &backupImportFlow{h: h, campaign: campaign, force: force})
+
+	return h.bot.SendMessage(chatID, prompt)
+}
+
+// RestoreOutstandingCreations logs every in-progress character creation left
+// over from before a restart, found under basePath/chargen. Each one is
+// already reachable via /resume (LoadStateFromFile only needs the userID,
+// not the chat it was started in), so there is nothing to re-inject here -
+// this just gives the operator visibility into how many are waiting
+func (h *CommandHandlers) RestoreOutstandingCreations(basePath string) {
+	userIDs, err := game.OutstandingChargenUsers(basePath)
+	if err != nil {
+		log.Printf("[CHARGEN] Failed to list outstanding creations: %v", err)
+		return
+	}
+	if len(userIDs) == 0 {
+		return
+	}
+	log.Printf("[CHARGEN] %d in-progress character creation(s) waiting for /resume: %v", len(userIDs), userIDs)
+}
+
+// NPCsCommand generates a batch of NPCs for a GM. Usage:
+//
+//	/npcs <count> [kind=warrior,ranger] [status=Серебряный] [minage=N] [maxage=N]
+//
+// It replies with a Markdown summary table, then a zip of one JSON file per
+// NPC (reusing the same export format /export uses for player characters)
+func (h *CommandHandlers) NPCsCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
+	}
 	chatID := update.Message.Chat.ID
 
-	if _, exists := h.characterCreators[chatID]; exists {
-		delete(h.characterCreators, chatID)
-		return h.bot.SendMessage(chatID, "❌ Создание персонажа отменено.")
+	if len(args) == 0 {
+		return h.bot.SendMessage(chatID, "Использование: /npcs <количество> [kind=класс,класс] [status=Статус] [minage=N] [maxage=N]")
+	}
+
+	count, err := strconv.Atoi(args[0])
+	if err != nil || count <= 0 {
+		return h.bot.SendMessage(chatID, "Количество должно быть положительным числом.")
+	}
+	if count > 20 {
+		return h.bot.SendMessage(chatID, "Не больше 20 NPC за раз.")
+	}
+
+	opts := game.NPCOptions{}
+	for _, arg := range args[1:] {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			continue
+		}
+		switch strings.ToLower(key) {
+		case "kind", "class":
+			opts.Classes = strings.Split(value, ",")
+		case "status":
+			opts.Status = value
+		case "minage":
+			if n, err := strconv.Atoi(value); err == nil {
+				opts.MinAge = n
+			}
+		case "maxage":
+			if n, err := strconv.Atoi(value); err == nil {
+				opts.MaxAge = n
+			}
+		}
+		// Unrecognized keys (e.g. region=) are accepted but don't constrain anything
+	}
+
+	npcs, err := game.GenerateNPCs(context.Background(), h.content, h.resolveLLMProvider(chatID), count, opts)
+	if err != nil {
+		return h.bot.SendMessage(chatID, fmt.Sprintf("Ошибка генерации NPC: %v", err))
 	}
 
-	return h.bot.SendMessage(chatID, "Нет активного создания персонажа.")
+	if err := h.bot.SendMessage(chatID, game.NPCsMarkdownTable(npcs)); err != nil {
+		return err
+	}
+
+	zipData, err := game.NPCsJSONZip(npcs)
+	if err != nil {
+		return h.bot.SendMessage(chatID, fmt.Sprintf("Ошибка упаковки NPC: %v", err))
+	}
+
+	return h.bot.SendDocument(chatID, "npcs.zip", zipData, fmt.Sprintf("%d NPC", len(npcs)))
+}
+
+// resolveLLMProvider returns the chat's session LLM provider when a session
+// is running, or builds a temporary failover-aware one from config
+// otherwise (see buildConfiguredProvider)
+func (h *CommandHandlers) resolveLLMProvider(chatID int64) llm.LLMProvider {
+	if session, exists := h.sessionMgr.GetSession(chatID); exists {
+		return session.GetLLMProvider()
+	}
+
+	cfg, err := config.LoadConfig()
+	if err != nil {
+		log.Printf("[NPCS] Failed to load config: %v", err)
+		return nil
+	}
+	provider, err := buildConfiguredProvider(cfg)
+	if err != nil {
+		log.Printf("[NPCS] Failed to create provider: %v", err)
+		return nil
+	}
+	return provider
+}
+
+// buildConfiguredProvider builds cfg.DefaultProvider plus cfg.FailoverChain
+// as a single failover-aware llm.LLMProvider (see llm.NewProviderWithFailover),
+// so both a fresh /start session (StartCommand) and resolveLLMProvider's
+// temporary NPC-generation provider transparently move to the next
+// configured provider instead of only ever trying the default one
+func buildConfiguredProvider(cfg config.BotConfig) (llm.LLMProvider, error) {
+	chain := cfg.ResolveProviderChain()
+	if len(chain) == 0 {
+		return nil, fmt.Errorf("no configuration found for provider %q", cfg.DefaultProvider)
+	}
+
+	configs := make([]*llm.ProviderConfig, 0, len(chain))
+	for _, pc := range chain {
+		configs = append(configs, &llm.ProviderConfig{
+			Name:              pc.Name,
+			APIKey:            pc.APIKey,
+			BaseURL:           pc.BaseURL,
+			Model:             pc.Model,
+			RequestsPerMinute: pc.RequestsPerMinute,
+		})
+	}
+
+	return llm.NewProviderWithFailover(configs)
 }
 
 // RegisterAllHandlers registers all command handlers with the bot
@@ -499,20 +1044,87 @@ func (h *CommandHandlers) RegisterAllHandlers() {
 	h.bot.AddCommand("status", h.StatusCommand)
 	h.bot.AddCommand("character", h.CharacterCommand)
 	h.bot.AddCommand("characters", h.CharactersCommand)
-	h.bot.AddCommand("reload", h.ReloadCommand)
+	h.bot.AddCommand("config", h.ConfigCommand)
 	h.bot.AddCommand("stop", h.StopCommand)
 
 	// Register character creation
 	h.bot.AddCommand("newchar", h.NewCharCommand)
-	h.bot.AddCommand("cancel", h.CancelCharacterCreation)
+	h.bot.AddCommand("cancel", h.CancelCommand)
+	h.bot.AddCommand("import", h.ImportCommand)
+	h.bot.AddCommand("npcs", h.NPCsCommand)
+	h.bot.AddCommand("resume", h.ResumeCommand)
 
 	// Register additional game commands
 	h.bot.AddCommand("roll", h.RollCommand)
 	h.bot.AddCommand("scene", h.SceneCommand)
 
+	// Register character history commands
+	h.bot.AddCommand("history", h.HistoryCommand)
+	h.bot.AddCommand("diff", h.DiffCommand)
+	h.bot.AddCommand("undo", h.UndoCommand)
+
+	// Register character export
+	h.bot.AddCommand("export", h.ExportCommand)
+
+	// Register session backup/restore
+	h.bot.AddCommand("backup_export", h.BackupExportCommand)
+	h.bot.AddCommand("backup_import", h.BackupImportCommand)
+
+	// Register LLM provider status
+	h.bot.AddCommand("providers", h.ProvidersCommand)
+
+	// Register conversation branching commands
+	h.bot.AddCommand("convhistory", h.ConvHistoryCommand)
+	h.bot.AddCommand("edit", h.EditCommand)
+	h.bot.AddCommand("rewind", h.RewindCommand)
+	h.bot.AddCommand("branches", h.BranchesCommand)
+
+	// Register scheduled reminder commands (see RegisterSchedulerHandlers)
+	h.bot.AddCommand("remind", h.RemindCommand)
+	h.bot.AddCommand("reminders", h.RemindersCommand)
+	h.bot.AddCommand("unremind", h.UnremindCommand)
+
+	// Register lobby matchmaking commands (see RegisterLobbyHandlers)
+	h.bot.AddCommand("queue", h.QueueCommand)
+
 	log.Println("[COMMANDS] All command handlers registered")
 }
 
+// ProvidersCommand reports the live circuit breaker status of the chat's
+// configured LLM provider chain (DefaultProvider + FailoverChain), so a GM
+// can see which providers are currently being skipped without digging
+// through logs
+func (h *CommandHandlers) ProvidersCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
+	}
+	chatID := update.Message.Chat.ID
+
+	provider := h.resolveLLMProvider(chatID)
+	if provider == nil {
+		return h.bot.SendMessage(chatID, "Не удалось определить текущего LLM провайдера.")
+	}
+
+	fallback, ok := provider.(*llm.FallbackProvider)
+	if !ok {
+		return h.bot.SendMessage(chatID, "Переключение между провайдерами не настроено (FAILOVER_CHAIN пуст).")
+	}
+
+	var builder strings.Builder
+	builder.WriteString("🔌 **Статус провайдеров:**\n\n")
+	for i, status := range fallback.Status() {
+		marker := "✅"
+		switch status.State {
+		case llm.CircuitOpen:
+			marker = "⛔"
+		case llm.CircuitHalfOpen:
+			marker = "⚠️"
+		}
+		builder.WriteString(fmt.Sprintf("%d. %s %s — %s\n", i+1, marker, status.Name, status.State))
+	}
+	return h.bot.SendMessage(chatID, builder.String())
+}
+
 // RollCommand handles dice rolls
 func (h *CommandHandlers) RollCommand(update *tgbotapi.Update, args []string) error {
 	if update.Message == nil || len(args) == 0 {
@@ -581,3 +1193,258 @@ func (h *CommandHandlers) SceneCommand(update *tgbotapi.Update, args []string) e
 
 	return h.bot.SendMessage(update.Message.Chat.ID, fmt.Sprintf("🏰 **Сцена:**\n\n%s", scene))
 }
+
+// ConvHistoryCommand lists the last n turns of the session's conversation
+// (default 10). This is the GM conversation log, distinct from /history,
+// which lists a character sheet's revision history
+func (h *CommandHandlers) ConvHistoryCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
+	}
+
+	chatID := update.Message.Chat.ID
+	session, exists := h.sessionMgr.GetSession(chatID)
+	if !exists {
+		return h.bot.SendMessage(chatID, "Нет активной игровой сессии.")
+	}
+
+	n := 10
+	if len(args) > 0 {
+		if parsed, err := strconv.Atoi(args[0]); err == nil && parsed > 0 {
+			n = parsed
+		}
+	}
+
+	turns, err := session.History(n)
+	if err != nil {
+		return h.bot.SendMessage(chatID, fmt.Sprintf("Ошибка загрузки истории: %v", err))
+	}
+	if len(turns) == 0 {
+		return h.bot.SendMessage(chatID, "История сессии пуста.")
+	}
+
+	var builder strings.Builder
+	builder.WriteString("🕒 **История сессии:**\n\n")
+	for _, turn := range turns {
+		builder.WriteString(fmt.Sprintf("`%s` [%s] %s\n\n", turn.ID, turn.Role, turn.Content))
+	}
+
+	return h.bot.SendMessage(chatID, builder.String())
+}
+
+// EditCommand rewrites an earlier message in the session's conversation and
+// re-generates the GM's response from that point, leaving the original
+// branch intact on disk
+func (h *CommandHandlers) EditCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
+	}
+
+	chatID := update.Message.Chat.ID
+	session, exists := h.sessionMgr.GetSession(chatID)
+	if !exists {
+		return h.bot.SendMessage(chatID, "Нет активной игровой сессии.")
+	}
+
+	if len(args) < 2 {
+		return h.bot.SendMessage(chatID, "Использование: /edit <msgID> <новый текст>")
+	}
+
+	msgID := args[0]
+	newText := strings.Join(args[1:], " ")
+
+	output, err := session.EditMessage(msgID, newText)
+	if err != nil {
+		return h.bot.SendMessage(chatID, fmt.Sprintf("Ошибка редактирования: %v", err))
+	}
+
+	return h.bot.SendMessage(chatID, output.Content)
+}
+
+// RewindCommand moves the session's active branch pointer back n turns
+func (h *CommandHandlers) RewindCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
+	}
+
+	chatID := update.Message.Chat.ID
+	session, exists := h.sessionMgr.GetSession(chatID)
+	if !exists {
+		return h.bot.SendMessage(chatID, "Нет активной игровой сессии.")
+	}
+
+	n := 1
+	if len(args) > 0 {
+		if parsed, err := strconv.Atoi(args[0]); err == nil && parsed > 0 {
+			n = parsed
+		}
+	}
+
+	if err := session.Rewind(n); err != nil {
+		return h.bot.SendMessage(chatID, fmt.Sprintf("Ошибка отката: %v", err))
+	}
+
+	return h.bot.SendMessage(chatID, fmt.Sprintf("⏪ Откат на %d ход(ов) выполнен.", n))
+}
+
+// BranchesCommand lists the tip of every branch in the session's
+// conversation, marking the one currently active
+func (h *CommandHandlers) BranchesCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
+	}
+
+	chatID := update.Message.Chat.ID
+	session, exists := h.sessionMgr.GetSession(chatID)
+	if !exists {
+		return h.bot.SendMessage(chatID, "Нет активной игровой сессии.")
+	}
+
+	branches, err := session.Branches()
+	if err != nil {
+		return h.bot.SendMessage(chatID, fmt.Sprintf("Ошибка загрузки веток: %v", err))
+	}
+	if len(branches) == 0 {
+		return h.bot.SendMessage(chatID, "У сессии пока нет веток.")
+	}
+
+	var builder strings.Builder
+	builder.WriteString("🌿 **Ветки сессии:**\n\n")
+	for _, b := range branches {
+		marker := ""
+		if b.Active {
+			marker = " (текущая)"
+		}
+		builder.WriteString(fmt.Sprintf("`%s`%s — %s\n", b.TipID, marker, b.Preview))
+	}
+
+	return h.bot.SendMessage(chatID, builder.String())
+}
+
+// HistoryCommand lists a character's sheet revision history
+func (h *CommandHandlers) HistoryCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
+	}
+
+	chatID := update.Message.Chat.ID
+	playerID := fmt.Sprintf("%d", update.Message.From.ID)
+
+	entries, err := h.charMgr.History(playerID)
+	if err != nil || len(entries) == 0 {
+		return h.bot.SendMessage(chatID, "История персонажа пуста.")
+	}
+
+	var builder strings.Builder
+	builder.WriteString("🕒 **История персонажа:**\n\n")
+	for i, entry := range entries {
+		summary := "исходная версия"
+		if entry.Update != nil {
+			summary = summarizeUpdate(*entry.Update)
+		}
+		builder.WriteString(fmt.Sprintf("%d. `%s` %s — %s\n", i+1, shortRev(entry.Rev), entry.Timestamp.Format("2006-01-02 15:04"), summary))
+	}
+
+	return h.bot.SendMessage(chatID, builder.String())
+}
+
+// summarizeUpdate renders a short human-readable summary of a CharacterUpdate
+func summarizeUpdate(update game.CharacterUpdate) string {
+	var parts []string
+	if update.HPChange != 0 {
+		parts = append(parts, fmt.Sprintf("HP %+d", update.HPChange))
+	}
+	if update.MaxHPChange != 0 {
+		parts = append(parts, fmt.Sprintf("макс. HP %+d", update.MaxHPChange))
+	}
+	if update.XPChange != 0 {
+		parts = append(parts, fmt.Sprintf("опыт %+d", update.XPChange))
+	}
+	if len(update.SkillsAdded) > 0 {
+		parts = append(parts, "новые навыки: "+strings.Join(update.SkillsAdded, ", "))
+	}
+	if len(update.Conditions) > 0 {
+		parts = append(parts, "состояния: "+strings.Join(update.Conditions, ", "))
+	}
+	if len(parts) == 0 {
+		return "без изменений"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// shortRev returns an 8-character prefix of rev for display
+func shortRev(rev string) string {
+	if len(rev) <= 8 {
+		return rev
+	}
+	return rev[:8]
+}
+
+// DiffCommand shows a diff between two revisions of a character's sheet.
+// With no arguments, it diffs the most recent change; with one revision,
+// it diffs that revision against its parent; with two, it diffs them
+// directly
+func (h *CommandHandlers) DiffCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
+	}
+
+	chatID := update.Message.Chat.ID
+	playerID := fmt.Sprintf("%d", update.Message.From.ID)
+
+	entries, err := h.charMgr.History(playerID)
+	if err != nil || len(entries) == 0 {
+		return h.bot.SendMessage(chatID, "История персонажа пуста.")
+	}
+
+	var fromRev, toRev string
+	switch len(args) {
+	case 0:
+		last := entries[len(entries)-1]
+		fromRev, toRev = last.ParentRev, last.Rev
+	case 1:
+		toRev = args[0]
+		for _, entry := range entries {
+			if strings.HasPrefix(entry.Rev, toRev) {
+				fromRev, toRev = entry.ParentRev, entry.Rev
+				break
+			}
+		}
+	default:
+		fromRev, toRev = args[0], args[1]
+	}
+
+	diff, err := h.charMgr.DiffRevisions(playerID, fromRev, toRev)
+	if err != nil {
+		return h.bot.SendMessage(chatID, fmt.Sprintf("Не удалось построить diff: %v", err))
+	}
+
+	return h.bot.SendMessage(chatID, fmt.Sprintf("```\n%s```", diff))
+}
+
+// UndoCommand reverts a character to its previous sheet revision, or to an
+// explicit revision if one is given
+func (h *CommandHandlers) UndoCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
+	}
+
+	chatID := update.Message.Chat.ID
+	playerID := fmt.Sprintf("%d", update.Message.From.ID)
+
+	entries, err := h.charMgr.History(playerID)
+	if err != nil || len(entries) < 2 {
+		return h.bot.SendMessage(chatID, "Нет предыдущей версии персонажа для отката.")
+	}
+
+	targetRev := entries[len(entries)-2].Rev
+	if len(args) > 0 {
+		targetRev = args[0]
+	}
+
+	if err := h.charMgr.Undo(playerID, targetRev); err != nil {
+		return h.bot.SendMessage(chatID, fmt.Sprintf("Не удалось откатить персонажа: %v", err))
+	}
+
+	return h.bot.SendMessage(chatID, fmt.Sprintf("✅ Персонаж откачен к версии `%s`", shortRev(targetRev)))
+}
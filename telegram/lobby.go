@@ -0,0 +1,65 @@
+package telegram
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"wfrp-bot/game"
+)
+
+// RegisterLobbyHandlers attaches lobby to h, so /queue has somewhere to
+// enqueue into (see game.Lobby). Call once during setup, alongside
+// lobby.StartMatching
+func (h *CommandHandlers) RegisterLobbyHandlers(lobby *game.Lobby) {
+	h.lobby = lobby
+}
+
+// QueueCommand queues the calling player for drop-in matchmaking instead
+// of a pre-formed Telegram group: /queue <campaign> [party_size]. The
+// result - a started session or an eviction after waiting too long - is
+// delivered asynchronously back to the chat /queue was sent from, once
+// game.Lobby's background matcher resolves it (see game.Lobby.Enqueue)
+func (h *CommandHandlers) QueueCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
+	}
+	chatID := update.Message.Chat.ID
+
+	if h.lobby == nil {
+		return h.bot.SendMessage(chatID, "Лобби матчмейкинга недоступно.")
+	}
+	if len(args) == 0 {
+		return h.bot.SendMessage(chatID, "Использование: /queue <кампания> [размер группы]")
+	}
+
+	campaign := args[0]
+	prefs := game.LobbyPrefs{}
+	if len(args) > 1 {
+		partySize, err := strconv.Atoi(args[1])
+		if err != nil || partySize <= 0 {
+			return h.bot.SendMessage(chatID, "Размер группы должен быть положительным числом")
+		}
+		prefs.PartySize = partySize
+	}
+
+	playerID := fmt.Sprintf("%d", update.Message.From.ID)
+	result := h.lobby.Enqueue(playerID, campaign, prefs)
+
+	go func() {
+		res := <-result
+		var err error
+		if res.Err != nil {
+			err = h.bot.SendMessage(chatID, fmt.Sprintf("❌ Очередь для кампании %s: %v", campaign, res.Err))
+		} else {
+			err = h.bot.SendMessage(chatID, fmt.Sprintf("✅ Сессия начата для кампании %s (ID: %s)", campaign, res.SessionID))
+		}
+		if err != nil {
+			log.Printf("[LOBBY] Failed to notify chat %d of queue result: %v", chatID, err)
+		}
+	}()
+
+	return h.bot.SendMessage(chatID, fmt.Sprintf("🎲 Вы в очереди на кампанию %s. Ждите уведомления о начале сессии.", campaign))
+}
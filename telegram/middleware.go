@@ -3,44 +3,11 @@ package telegram
 
 import (
 	"log"
-	"sync"
-	"time"
+	"strconv"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
 )
 
-// RateLimiter implements rate limiting per user
-type RateLimiter struct {
-	mu        sync.Mutex
-	lastSeen  map[int64]time.Time
-	threshold time.Duration
-}
-
-// NewRateLimiter creates a new rate limiter
-func NewRateLimiter(threshold time.Duration) *RateLimiter {
-	return &RateLimiter{
-		lastSeen:  make(map[int64]time.Time),
-		threshold: threshold,
-	}
-}
-
-// Allow checks if user is within rate limit
-func (rl *RateLimiter) Allow(userID int64) bool {
-	rl.mu.Lock()
-	defer rl.mu.Unlock()
-
-	now := time.Now()
-	lastTime, exists := rl.lastSeen[userID]
-
-	if !exists || now.Sub(lastTime) > rl.threshold {
-		rl.lastSeen[userID] = now
-		return true
-	}
-
-	log.Printf("Rate limit triggered for user %d", userID)
-	return false
-}
-
 // LoggingMiddleware logs all incoming updates
 func LoggingMiddleware(update *tgbotapi.Update) (bool, error) {
 	if update.Message != nil {
@@ -60,22 +27,45 @@ func LoggingMiddleware(update *tgbotapi.Update) (bool, error) {
 	return true, nil // Continue processing
 }
 
-// RateLimitMiddleware implements rate limiting
-func RateLimitMiddleware(limiter *RateLimiter) Middleware {
+// RateLimitMiddleware composes three token-bucket RateLimiters - perUser,
+// perChat, and a single global bucket sized for Telegram's ~30 msg/sec
+// outgoing cap - and rejects an update if any of them is exhausted,
+// checking global first, then perChat, then perUser. A rejected update isn't dropped silently: it's
+// re-queued on a bounded delayQueue and redelivered through bot once the
+// limiter that rejected it should have a token again, and the middleware
+// returns an *ErrLimited so HandleUpdate can tell the player to slow down
+// instead of just logging it
+func RateLimitMiddleware(bot *Bot, perUser, perChat, global *RateLimiter) Middleware {
+	queue := newDelayQueue(rateLimitQueueCapacity)
+
 	return func(update *tgbotapi.Update) (bool, error) {
 		if update.Message == nil {
 			return true, nil // Only limit messages
 		}
 
-		userID := update.Message.From.ID
-		if !limiter.Allow(userID) {
-			// Send rate limit message
-			// Note: This would need bot API access, which creates circular dependency
-			// For now, just log and continue
-			log.Printf("Rate limited user %d, but processing anyway", userID)
+		userKey := strconv.FormatInt(update.Message.From.ID, 10)
+		chatKey := strconv.FormatInt(update.Message.Chat.ID, 10)
+
+		var scope string
+		var limiter *RateLimiter
+		var key string
+		switch {
+		case !global.Allow(globalBucketKey):
+			scope, limiter, key = "global", global, globalBucketKey
+		case !perChat.Allow(chatKey):
+			scope, limiter, key = "chat", perChat, chatKey
+		case !perUser.Allow(userKey):
+			scope, limiter, key = "user", perUser, userKey
+		default:
+			return true, nil
 		}
 
-		return true, nil
+		retryAfter := limiter.RetryAfter(key)
+		if !queue.schedule(retryAfter, func() { bot.HandleUpdate(update) }) {
+			log.Printf("Rate limit queue full, dropping update from chat %d", update.Message.Chat.ID)
+		}
+
+		return false, &ErrLimited{Scope: scope, RetryAfter: retryAfter}
 	}
 }
 
@@ -0,0 +1,170 @@
+package telegram
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// globalBucketKey is the single key RateLimitMiddleware's global bucket is
+// keyed under - there's only ever one global bucket, but RateLimiter is
+// keyed by string so it can be shared with the per-user/per-chat cases
+const globalBucketKey = "*"
+
+// rateLimiterGCAge is how long a bucket can go unseen before RateLimiter
+// evicts it, bounding memory on a long-running bot
+const rateLimiterGCAge = time.Hour
+
+// rateLimiterGCInterval caps how often Allow bothers scanning the bucket
+// map for eviction
+const rateLimiterGCInterval = 10 * time.Minute
+
+// tokenBucket is a single key's bucket: up to burst tokens refill
+// continuously at RateLimiter.rate tokens/sec
+type tokenBucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// RateLimiter is a token-bucket limiter keyed by an arbitrary string - a
+// user ID, a chat ID, or a fixed key for a single global bucket. Buckets
+// unseen for more than rateLimiterGCAge are evicted lazily, on a later
+// Allow call, so a long-running bot doesn't keep one bucket per user/chat
+// forever
+type RateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	rate    float64 // tokens refilled per second
+	burst   int     // bucket capacity
+
+	lastGC time.Time
+}
+
+// NewRateLimiter creates a limiter refilling at rate tokens/sec up to a
+// capacity of burst tokens per key
+func NewRateLimiter(rate float64, burst int) *RateLimiter {
+	return &RateLimiter{
+		buckets: make(map[string]*tokenBucket),
+		rate:    rate,
+		burst:   burst,
+		lastGC:  time.Now(),
+	}
+}
+
+// Allow consumes one token for key if one is available
+func (rl *RateLimiter) Allow(key string) bool {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	rl.gcLocked()
+	b := rl.refillLocked(key)
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// RetryAfter estimates how long until key next has a token available. It's
+// meant to be called right after Allow(key) returned false, to report back
+// how long the caller should wait
+func (rl *RateLimiter) RetryAfter(key string) time.Duration {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	b, ok := rl.buckets[key]
+	if !ok || b.tokens >= 1 || rl.rate <= 0 {
+		return 0
+	}
+
+	needed := 1 - b.tokens
+	return time.Duration(needed / rl.rate * float64(time.Second))
+}
+
+// refillLocked returns key's bucket, creating it at full capacity if new
+// and topping it up for elapsed time since it was last seen. Callers must
+// hold rl.mu
+func (rl *RateLimiter) refillLocked(key string) *tokenBucket {
+	now := time.Now()
+
+	b, ok := rl.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(rl.burst), lastSeen: now}
+		rl.buckets[key] = b
+		return b
+	}
+
+	elapsed := now.Sub(b.lastSeen).Seconds()
+	b.tokens += elapsed * rl.rate
+	if b.tokens > float64(rl.burst) {
+		b.tokens = float64(rl.burst)
+	}
+	b.lastSeen = now
+
+	return b
+}
+
+// gcLocked evicts buckets unseen for more than rateLimiterGCAge, at most
+// once per rateLimiterGCInterval so Allow doesn't pay a full map scan on
+// every call. Callers must hold rl.mu
+func (rl *RateLimiter) gcLocked() {
+	now := time.Now()
+	if now.Sub(rl.lastGC) < rateLimiterGCInterval {
+		return
+	}
+	rl.lastGC = now
+
+	for key, b := range rl.buckets {
+		if now.Sub(b.lastSeen) > rateLimiterGCAge {
+			delete(rl.buckets, key)
+		}
+	}
+}
+
+// ErrLimited is returned by RateLimitMiddleware when an update is rejected
+// for exhausting one of its RateLimiters. Scope names which bucket
+// rejected it ("user", "chat" or "global"); RetryAfter estimates how long
+// until that bucket has a token again
+type ErrLimited struct {
+	Scope      string
+	RetryAfter time.Duration
+}
+
+func (e *ErrLimited) Error() string {
+	return fmt.Sprintf("rate limited (%s), retry after %s", e.Scope, e.RetryAfter.Round(time.Second))
+}
+
+// rateLimitQueueCapacity bounds delayQueue's in-flight redeliveries for
+// RateLimitMiddleware
+const rateLimitQueueCapacity = 200
+
+// delayQueue bounds how many deferred redeliveries can be in flight at
+// once: schedule reserves a slot up front and reports false without
+// scheduling anything if the queue is already full, so a sustained flood
+// of rejected updates can't pile up goroutines unboundedly
+type delayQueue struct {
+	slots chan struct{}
+}
+
+// newDelayQueue creates a delayQueue that allows up to capacity scheduled
+// redeliveries to be pending at once
+func newDelayQueue(capacity int) *delayQueue {
+	return &delayQueue{slots: make(chan struct{}, capacity)}
+}
+
+// schedule runs redeliver after delay and reports true, or reports false
+// without scheduling anything if the queue is already at capacity
+func (q *delayQueue) schedule(delay time.Duration, redeliver func()) bool {
+	select {
+	case q.slots <- struct{}{}:
+	default:
+		return false
+	}
+
+	time.AfterFunc(delay, func() {
+		defer func() { <-q.slots }()
+		redeliver()
+	})
+	return true
+}
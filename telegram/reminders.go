@@ -0,0 +1,229 @@
+package telegram
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+
+	"wfrp-bot/game"
+	"wfrp-bot/scheduler"
+)
+
+// RegisterSchedulerHandlers attaches sched to h and wires every
+// scheduler.JobType it knows about to its handler, so /remind and the
+// GM-oriented job types (scene_tick, condition_expiry, random_encounter)
+// have something to actually fire. Call once during setup, alongside
+// sched.LoadPersisted and go sched.Run(stop)
+func (h *CommandHandlers) RegisterSchedulerHandlers(sched *scheduler.Scheduler) {
+	h.sched = sched
+
+	sched.RegisterHandler(scheduler.JobReminder, h.handleReminderJob)
+	sched.RegisterHandler(scheduler.JobSceneTick, h.handleSceneTickJob)
+	sched.RegisterHandler(scheduler.JobConditionExpiry, h.handleConditionExpiryJob)
+	sched.RegisterHandler(scheduler.JobRandomEncounter, h.handleRandomEncounterJob)
+}
+
+// handleReminderJob delivers a /remind job's message back to the chat it
+// was scheduled from
+func (h *CommandHandlers) handleReminderJob(job scheduler.Job) error {
+	return h.bot.SendMessage(job.ChatID, fmt.Sprintf("⏰ Напоминание: %s", job.Payload["message"]))
+}
+
+// handleSceneTickJob asks the session's GM agent to narrate an atmospheric
+// beat via the normal ProcessInput pipeline - the same path a player
+// message takes - then reschedules itself for another interval later, for
+// as long as the session is still active
+func (h *CommandHandlers) handleSceneTickJob(job scheduler.Job) error {
+	session, exists := h.sessionMgr.GetSession(job.ChatID)
+	if !exists {
+		return nil // session ended; let the recurring tick lapse
+	}
+
+	output, err := session.ProcessInput(game.InputData{
+		Source:    "system",
+		Content:   "Опиши краткий атмосферный момент, чтобы оживить текущую сцену - звук, деталь окружения, реакцию NPC. Не продвигай сюжет и не жди ответа игроков.",
+		Timestamp: time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("scene tick generation failed: %w", err)
+	}
+
+	if err := h.bot.SendMessage(job.ChatID, output.Content); err != nil {
+		return err
+	}
+
+	interval, err := time.ParseDuration(job.Payload["interval"])
+	if err != nil {
+		return fmt.Errorf("invalid scene tick interval %q: %w", job.Payload["interval"], err)
+	}
+
+	_, err = h.sched.Schedule(scheduler.Job{
+		ChatID:   job.ChatID,
+		Campaign: job.Campaign,
+		Type:     scheduler.JobSceneTick,
+		FireAt:   time.Now().Add(interval),
+		Payload:  job.Payload,
+	})
+	return err
+}
+
+// handleConditionExpiryJob removes a status effect from a character after
+// its duration has passed (see Session.ExpireCondition)
+func (h *CommandHandlers) handleConditionExpiryJob(job scheduler.Job) error {
+	session, exists := h.sessionMgr.GetSession(job.ChatID)
+	if !exists {
+		return nil
+	}
+
+	name, ok := session.ExpireCondition(job.Payload["player_id"], game.Condition(job.Payload["condition"]))
+	if !ok {
+		return fmt.Errorf("character not found for player %s", job.Payload["player_id"])
+	}
+
+	return h.bot.SendMessage(job.ChatID, fmt.Sprintf("⏳ %s: состояние «%s» закончилось.", name, job.Payload["condition"]))
+}
+
+// randomEncounterTable is the built-in fallback roll table for
+// random_encounter jobs; campaigns with richer content can replace this by
+// registering their own handler after RegisterSchedulerHandlers is called,
+// since a later RegisterHandler for the same job type takes its place
+var randomEncounterTable = []string{
+	"Отряд мародёров-зверолюдов замечен на дороге впереди.",
+	"Бродячий торговец предлагает сомнительные товары.",
+	"Странный запах гнили доносится из ближайшего переулка.",
+	"Патруль городской стражи требует предъявить документы.",
+	"Стая гигантских крыс выскакивает из канализации.",
+	"Путники находят брошенную повозку со следами борьбы.",
+}
+
+// handleRandomEncounterJob rolls on randomEncounterTable and narrates the
+// result into the chat
+func (h *CommandHandlers) handleRandomEncounterJob(job scheduler.Job) error {
+	encounter := randomEncounterTable[rand.Intn(len(randomEncounterTable))]
+	return h.bot.SendMessage(job.ChatID, fmt.Sprintf("🎲 Случайная встреча: %s", encounter))
+}
+
+// RemindCommand schedules a one-off message back into the chat at a given
+// time, parsed either as a duration ("10m", "2h30m") or an absolute time
+// ("20:00" for the next occurrence, "завтра 20:00" for tomorrow) - see
+// parseReminderWhen
+func (h *CommandHandlers) RemindCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
+	}
+	chatID := update.Message.Chat.ID
+
+	if h.sched == nil {
+		return h.bot.SendMessage(chatID, "Планировщик напоминаний не настроен.")
+	}
+
+	session, exists := h.sessionMgr.GetSession(chatID)
+	if !exists {
+		return h.bot.SendMessage(chatID, "Нет активной игровой сессии.")
+	}
+
+	fireAt, rest, err := parseReminderWhen(args)
+	if err != nil || len(rest) == 0 {
+		return h.bot.SendMessage(chatID, "Использование: /remind <10m|2h30m|завтра 20:00> <текст>")
+	}
+
+	job, err := h.sched.Schedule(scheduler.Job{
+		ChatID:   chatID,
+		Campaign: session.Campaign,
+		Type:     scheduler.JobReminder,
+		FireAt:   fireAt,
+		Payload:  map[string]string{"message": strings.Join(rest, " ")},
+	})
+	if err != nil {
+		return h.bot.SendMessage(chatID, fmt.Sprintf("Не удалось создать напоминание: %v", err))
+	}
+
+	return h.bot.SendMessage(chatID, fmt.Sprintf("⏰ Напоминание `%s` установлено на %s.", job.ID, fireAt.Format("2006-01-02 15:04")))
+}
+
+// RemindersCommand lists every job pending for the chat, not just reminders
+func (h *CommandHandlers) RemindersCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
+	}
+	chatID := update.Message.Chat.ID
+
+	if h.sched == nil {
+		return h.bot.SendMessage(chatID, "Планировщик напоминаний не настроен.")
+	}
+
+	jobs := h.sched.Pending(chatID)
+	if len(jobs) == 0 {
+		return h.bot.SendMessage(chatID, "Нет ожидающих напоминаний.")
+	}
+
+	var builder strings.Builder
+	builder.WriteString("⏰ **Ожидающие напоминания:**\n\n")
+	for _, job := range jobs {
+		builder.WriteString(fmt.Sprintf("`%s` [%s] %s — %s\n", job.ID, job.Type, job.FireAt.Format("2006-01-02 15:04"), job.Payload["message"]))
+	}
+
+	return h.bot.SendMessage(chatID, builder.String())
+}
+
+// UnremindCommand cancels a pending job by ID (see RemindersCommand)
+func (h *CommandHandlers) UnremindCommand(update *tgbotapi.Update, args []string) error {
+	if update.Message == nil {
+		return fmt.Errorf("no message in update")
+	}
+	chatID := update.Message.Chat.ID
+
+	if h.sched == nil {
+		return h.bot.SendMessage(chatID, "Планировщик напоминаний не настроен.")
+	}
+	if len(args) < 1 {
+		return h.bot.SendMessage(chatID, "Использование: /unremind <id>")
+	}
+
+	if !h.sched.Cancel(args[0]) {
+		return h.bot.SendMessage(chatID, fmt.Sprintf("Напоминание `%s` не найдено.", args[0]))
+	}
+
+	return h.bot.SendMessage(chatID, fmt.Sprintf("Напоминание `%s` отменено.", args[0]))
+}
+
+// parseReminderWhen consumes the leading time specifier from args - a
+// time.ParseDuration-compatible string ("10m", "2h30m"), an absolute
+// "HH:MM" for the next occurrence, or "завтра HH:MM" for tomorrow - and
+// returns when it resolves to plus whatever args are left over as the
+// reminder text
+func parseReminderWhen(args []string) (fireAt time.Time, rest []string, err error) {
+	if len(args) == 0 {
+		return time.Time{}, nil, fmt.Errorf("no time specifier given")
+	}
+
+	if d, perr := time.ParseDuration(args[0]); perr == nil {
+		if d <= 0 {
+			return time.Time{}, nil, fmt.Errorf("duration must be positive")
+		}
+		return time.Now().Add(d), args[1:], nil
+	}
+
+	if strings.EqualFold(args[0], "завтра") && len(args) >= 2 {
+		clock, perr := time.Parse("15:04", args[1])
+		if perr != nil {
+			return time.Time{}, nil, fmt.Errorf("invalid time %q, expected HH:MM: %w", args[1], perr)
+		}
+		now := time.Now()
+		return time.Date(now.Year(), now.Month(), now.Day()+1, clock.Hour(), clock.Minute(), 0, 0, now.Location()), args[2:], nil
+	}
+
+	if clock, perr := time.Parse("15:04", args[0]); perr == nil {
+		now := time.Now()
+		at := time.Date(now.Year(), now.Month(), now.Day(), clock.Hour(), clock.Minute(), 0, 0, now.Location())
+		if at.Before(now) {
+			at = at.Add(24 * time.Hour)
+		}
+		return at, args[1:], nil
+	}
+
+	return time.Time{}, nil, fmt.Errorf("could not parse time specifier %q", args[0])
+}
@@ -2,142 +2,182 @@
 package telegram
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
-	"sync"
 	"time"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"wfrp-bot/workqueue"
 )
 
-// Streamer handles sending long messages in chunks
+// telegramChunkJob is the workqueue.Job.Kind Streamer registers its chunk
+// delivery Handler under
+const telegramChunkJob = "telegram_chunk"
+
+// Streamer handles sending long messages in chunks, via a shared
+// workqueue.Queue rather than its own goroutine + channel, so outbound
+// narration is rate-limited and prioritized alongside every other chat's
+// traffic (see workqueue.Queue)
 type Streamer struct {
-	bot         *Bot
+	bot          *Bot
+	queue        *workqueue.Queue
 	maxLength    int
-	rateLimit    time.Duration
-	mu           sync.Mutex
-	queue        chan *streamJob
-	stopChan     chan struct{}
-	wg           sync.WaitGroup
+	editInterval time.Duration // minimum time between SendEdit calls for one StreamFromChan message
+	jobTimeout   time.Duration // how long Stream/StreamReply wait for a chunk to be delivered
 }
 
-// streamJob represents a streaming job
-type streamJob struct {
-	chatID  int64
-	text     string
-	replyTo  *int
-	callback func(int, error)
+// markdownState tracks which Markdown delimiters are left open (an odd
+// number of occurrences so far) in the text already sent to Telegram, so
+// StreamFromChan never splits a message inside a code fence or a
+// bold/italic run
+type markdownState struct {
+	codeFence bool
+	bold      bool
+	italic    bool
 }
 
-// NewStreamer creates a new message streamer
-func NewStreamer(bot *Bot) *Streamer {
-	return &Streamer{
-		bot:      bot,
-		maxLength: 4096, // Telegram message limit
-		rateLimit: 100 * time.Millisecond, // 10 messages per second
-		queue:     make(chan *streamJob, 100),
-		stopChan:  make(chan struct{}),
+// scanMarkdown scans text and toggles state for every delimiter run it
+// contains, returning the resulting state. Delimiters inside an open code
+// fence are treated as literal text, matching how Telegram itself renders them
+func scanMarkdown(state markdownState, text string) markdownState {
+	for i := 0; i < len(text); {
+		switch {
+		case strings.HasPrefix(text[i:], "```"):
+			state.codeFence = !state.codeFence
+			i += 3
+		case !state.codeFence && strings.HasPrefix(text[i:], "**"):
+			state.bold = !state.bold
+			i += 2
+		case !state.codeFence && (text[i] == '*' || text[i] == '_'):
+			state.italic = !state.italic
+			i++
+		default:
+			i++
+		}
 	}
+	return state
 }
 
-// Start begins processing the streaming queue
-func (s *Streamer) Start() {
-	s.wg.Add(1)
-	go s.processQueue()
+// closingSuffix returns the Markdown needed to close every delimiter left
+// open by state, so a message can be sent without ending mid-run
+func closingSuffix(state markdownState) string {
+	var b strings.Builder
+	if state.italic {
+		b.WriteString("_")
+	}
+	if state.bold {
+		b.WriteString("**")
+	}
+	if state.codeFence {
+		b.WriteString("\n```")
+	}
+	return b.String()
 }
 
-// Stop gracefully stops the streamer
-func (s *Streamer) Stop() {
-	close(s.stopChan)
-	s.wg.Wait()
+// openingPrefix returns the Markdown needed to reopen the delimiters
+// closingSuffix closed, so the next message continues the same run
+func openingPrefix(state markdownState) string {
+	var b strings.Builder
+	if state.codeFence {
+		b.WriteString("```\n")
+	}
+	if state.bold {
+		b.WriteString("**")
+	}
+	if state.italic {
+		b.WriteString("_")
+	}
+	return b.String()
 }
 
-// Stream sends a long message in chunks
-func (s *Streamer) Stream(chatID int64, text string) error {
-	resultChan := make(chan error, 1)
-	job := &streamJob{
-		chatID: chatID,
-		text:    text,
-		callback: func(part int, err error) {
-			resultChan <- err
-		},
+// NewStreamer creates a message streamer that submits chunk delivery to
+// queue instead of sending directly, so Telegram's rate limits are shared
+// with every other job (command replies, background work) on that chat.
+// It registers its own Handler under telegramChunkJob - queue.Run must
+// still be started separately (see main.go)
+func NewStreamer(bot *Bot, queue *workqueue.Queue) *Streamer {
+	s := &Streamer{
+		bot:          bot,
+		queue:        queue,
+		maxLength:    4096,            // Telegram message limit
+		editInterval: 1 * time.Second, // Telegram allows ~1 edit/sec/chat
+		jobTimeout:   30 * time.Second,
 	}
+	queue.RegisterHandler(telegramChunkJob, s.handleChunkJob)
+	return s
+}
 
-	select {
-	case s.queue <- job:
-	case <-time.After(5 * time.Second):
-		return ErrQueueFull
-	}
+// handleChunkJob delivers one chunk via the Bot, translating Telegram's
+// 429 Too Many Requests into a workqueue.RetryableError that honors its
+// retry_after rather than the queue's default flat backoff
+func (s *Streamer) handleChunkJob(job workqueue.Job) error {
+	text := job.Data["text"]
 
-	return <-resultChan
-}
+	var err error
+	if replyTo, ok := job.Data["reply_to"]; ok {
+		messageID, convErr := strconv.Atoi(replyTo)
+		if convErr != nil {
+			return fmt.Errorf("invalid reply_to %q: %w", replyTo, convErr)
+		}
+		err = s.bot.SendReply(messageID, job.ChatID, text)
+	} else {
+		err = s.bot.SendMessage(job.ChatID, text)
+	}
 
-// StreamReply sends a long reply in chunks
-func (s *Streamer) StreamReply(messageID int, chatID int64, text string) error {
-	resultChan := make(chan error, 1)
-	job := &streamJob{
-		chatID: chatID,
-		text:    text,
-		replyTo:  &messageID,
-		callback: func(part int, err error) {
-			resultChan <- err
-		},
+	if err == nil {
+		return nil
 	}
 
-	select {
-	case s.queue <- job:
-	case <-time.After(5 * time.Second):
-		return ErrQueueFull
+	var tgErr *tgbotapi.Error
+	if errors.As(err, &tgErr) && tgErr.RetryAfter > 0 {
+		return &workqueue.RetryableError{
+			Err:   err,
+			After: time.Duration(tgErr.RetryAfter) * time.Second,
+		}
 	}
 
-	return <-resultChan
+	return err
 }
 
-// processQueue handles streaming jobs
-func (s *Streamer) processQueue() {
-	defer s.wg.Done()
+// Stream sends a long message in chunks, waiting for each to be delivered
+// before sending the next, so chunks always arrive in order. If a chunk is
+// dropped after exhausting retries or isn't delivered within jobTimeout,
+// Stream returns that error and the remaining chunks are not sent
+func (s *Streamer) Stream(chatID int64, text string) error {
+	return s.streamChunks(chatID, nil, text)
+}
 
-	for {
-		select {
-		case <-s.stopChan:
-			return
-		case job, ok := <-s.queue:
-			if !ok {
-				return
-			}
-			s.processJob(job)
-		}
-	}
+// StreamReply sends a long reply in chunks, see Stream
+func (s *Streamer) StreamReply(messageID int, chatID int64, text string) error {
+	return s.streamChunks(chatID, &messageID, text)
 }
 
-// processJob processes a single streaming job
-func (s *Streamer) processJob(job *streamJob) {
-	// Split text into chunks
-	chunks := s.splitText(job.text)
+func (s *Streamer) streamChunks(chatID int64, replyTo *int, text string) error {
+	chunks := s.splitText(text)
 
 	for i, chunk := range chunks {
-		// Apply rate limiting
-		if i > 0 {
-			time.Sleep(s.rateLimit)
+		data := map[string]string{"text": chunk}
+		if replyTo != nil {
+			data["reply_to"] = strconv.Itoa(*replyTo)
 		}
 
-		var err error
-		if job.replyTo != nil {
-			err = s.bot.SendReply(*job.replyTo, job.chatID, chunk)
-		} else {
-			err = s.bot.SendMessage(job.chatID, chunk)
-		}
-
-		if job.callback != nil {
-			job.callback(i, err)
-		}
+		err := s.queue.EnqueueAndWait(workqueue.Job{
+			Kind:     telegramChunkJob,
+			Priority: workqueue.PriorityStreamChunk,
+			ChatID:   chatID,
+			Data:     data,
+		}, s.jobTimeout)
 
 		if err != nil {
 			log.Printf("Failed to send chunk %d/%d: %v", i+1, len(chunks), err)
-			return
+			return err
 		}
 	}
+
+	return nil
 }
 
 // splitText splits text into chunks that fit within max length
@@ -170,7 +210,7 @@ func (s *Streamer) findBestSplitPoint(text string) int {
 	// Priority order: period, newline, space
 	splitPoints := []struct {
 		pos  int
-		char  rune
+		char rune
 	}{
 		{strings.LastIndex(text, "."), '.'},
 		{strings.LastIndex(text, "\n"), '\n'},
@@ -201,7 +241,89 @@ func (s *Streamer) StreamMarkdownReply(messageID int, chatID int64, markdown str
 	return s.StreamReply(messageID, chatID, markdown)
 }
 
+// StreamFromChan renders an incremental LLM token stream into chatID as it
+// arrives, instead of splitting an already-complete string. It sends an
+// initial placeholder message, then appends buffered tokens with SendEdit
+// at most once per s.editInterval (Telegram allows roughly one edit per
+// second per chat), opening a new message whenever the running text would
+// exceed s.maxLength. Markdown delimiter state (code fences, bold, italic)
+// is tracked across edits via scanMarkdown, so a chunk boundary never
+// lands mid-run: any run left open is closed before a message is sent and
+// reopened at the start of the next one.
+//
+// If cancel is closed before tokens is, any buffered text is flushed with
+// a final edit and ErrStreamCancelled is returned without waiting for more
+// tokens. Nothing in the bot calls StreamFromChan, and StopCommand closes
+// no cancel channel, so /stop does not halt a stream through it
+func (s *Streamer) StreamFromChan(chatID int64, tokens <-chan string, cancel <-chan struct{}) error {
+	messageID, err := s.bot.SendMessageWithID(chatID, "…")
+	if err != nil {
+		return fmt.Errorf("failed to send placeholder message: %w", err)
+	}
+
+	var state markdownState
+	buffer := ""  // text already rendered into the current Telegram message
+	pending := "" // tokens received since the last edit
+	var lastEdit time.Time
+
+	flush := func(force bool) error {
+		if pending == "" {
+			return nil
+		}
+		if !force && time.Since(lastEdit) < s.editInterval {
+			return nil
+		}
+
+		newState := scanMarkdown(state, pending)
+		candidate := buffer + pending
+
+		if len(candidate)+len(closingSuffix(newState)) > s.maxLength {
+			// Current message is full: close its open delimiters, then
+			// start a new message that reopens them and carries the overflow
+			if err := s.bot.SendEdit(messageID, chatID, buffer+closingSuffix(state)); err != nil {
+				return err
+			}
+
+			prefix := openingPrefix(state)
+			newID, err := s.bot.SendMessageWithID(chatID, prefix+pending)
+			if err != nil {
+				return err
+			}
+
+			messageID = newID
+			buffer = prefix + pending
+			state = scanMarkdown(state, pending)
+		} else {
+			if err := s.bot.SendEdit(messageID, chatID, candidate+closingSuffix(newState)); err != nil {
+				return err
+			}
+
+			buffer = candidate
+			state = newState
+		}
+
+		pending = ""
+		lastEdit = time.Now()
+		return nil
+	}
+
+	for {
+		select {
+		case <-cancel:
+			_ = flush(true)
+			return ErrStreamCancelled
+
+		case token, ok := <-tokens:
+			if !ok {
+				return flush(true)
+			}
+			pending += token
+			if err := flush(false); err != nil {
+				return err
+			}
+		}
+	}
+}
+
 // Errors
-var (
-	ErrQueueFull = fmt.Errorf("streaming queue is full")
-)
+var ErrStreamCancelled = fmt.Errorf("stream cancelled")
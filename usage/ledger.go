@@ -0,0 +1,198 @@
+// Package usage tracks LLM token accounting and per-campaign cost budgets
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// ErrBudgetExceeded is returned by CheckBudget (and by callers that consult
+// it before dispatching a request) when a campaign has spent at least its
+// configured budget
+var ErrBudgetExceeded = errors.New("campaign budget exceeded")
+
+// Entry is a single recorded request, persisted as one line of
+// <campaign>/usage.jsonl
+type Entry struct {
+	Time             time.Time `json:"time"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	CostUSD          float64   `json:"cost_usd"`
+}
+
+// Totals summarizes the accumulated usage recorded for a campaign
+type Totals struct {
+	PromptTokens     int
+	CompletionTokens int
+	CostUSD          float64
+	BudgetUSD        float64 // 0 means no budget has been set
+}
+
+// Remaining returns how much of the campaign's budget is left, or a
+// negative value once it has been exceeded. It returns 0 when no budget has
+// been set
+func (t Totals) Remaining() float64 {
+	if t.BudgetUSD == 0 {
+		return 0
+	}
+	return t.BudgetUSD - t.CostUSD
+}
+
+// Ledger persists per-campaign token usage and cost totals to
+// <basePath>/<campaign>/usage.jsonl, and enforces optional per-campaign
+// USD budgets
+type Ledger struct {
+	basePath string
+
+	mu     sync.Mutex
+	totals map[string]Totals
+	loaded map[string]bool
+}
+
+// NewLedger creates a Ledger rooted at basePath, the same campaigns
+// directory used by storage.CampaignManager
+func NewLedger(basePath string) *Ledger {
+	return &Ledger{
+		basePath: basePath,
+		totals:   make(map[string]Totals),
+		loaded:   make(map[string]bool),
+	}
+}
+
+// SetBudget sets the USD budget for a campaign. A zero value disables
+// budget enforcement
+func (l *Ledger) SetBudget(campaign string, maxUSD float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.ensureLoaded(campaign)
+	t := l.totals[campaign]
+	t.BudgetUSD = maxUSD
+	l.totals[campaign] = t
+}
+
+// CheckBudget returns ErrBudgetExceeded if campaign has a budget set and
+// has already spent at least that much
+func (l *Ledger) CheckBudget(campaign string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.ensureLoaded(campaign)
+	t := l.totals[campaign]
+	if t.BudgetUSD > 0 && t.CostUSD >= t.BudgetUSD {
+		return fmt.Errorf("%w: campaign %s has spent $%.4f of its $%.4f budget", ErrBudgetExceeded, campaign, t.CostUSD, t.BudgetUSD)
+	}
+	return nil
+}
+
+// Totals returns the accumulated usage recorded for campaign
+func (l *Ledger) Totals(campaign string) Totals {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.ensureLoaded(campaign)
+	return l.totals[campaign]
+}
+
+// Record appends an entry for a completed request and updates campaign's
+// running totals
+func (l *Ledger) Record(campaign, provider, model string, promptTokens, completionTokens int) error {
+	cost := Cost(provider, model, promptTokens, completionTokens)
+
+	entry := Entry{
+		Time:             time.Now(),
+		Provider:         provider,
+		Model:            model,
+		PromptTokens:     promptTokens,
+		CompletionTokens: completionTokens,
+		CostUSD:          cost,
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.ensureLoaded(campaign)
+
+	if err := l.appendEntry(campaign, entry); err != nil {
+		return err
+	}
+
+	t := l.totals[campaign]
+	t.PromptTokens += promptTokens
+	t.CompletionTokens += completionTokens
+	t.CostUSD += cost
+	l.totals[campaign] = t
+
+	return nil
+}
+
+// appendEntry appends entry to <basePath>/<campaign>/usage.jsonl, creating
+// the campaign directory if it does not already exist
+func (l *Ledger) appendEntry(campaign string, entry Entry) error {
+	dir := filepath.Join(l.basePath, campaign)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create campaign directory %s: %w", dir, err)
+	}
+
+	path := l.path(campaign)
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open usage ledger %s: %w", path, err)
+	}
+	defer f.Close()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal usage entry: %w", err)
+	}
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to append usage entry to %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// ensureLoaded lazily replays campaign's usage.jsonl into l.totals the
+// first time it is referenced, so restarting the bot doesn't lose track of
+// previously spent budget. Must be called with l.mu held
+func (l *Ledger) ensureLoaded(campaign string) {
+	if l.loaded[campaign] {
+		return
+	}
+	l.loaded[campaign] = true
+
+	f, err := os.Open(l.path(campaign))
+	if err != nil {
+		return // No ledger yet is OK
+	}
+	defer f.Close()
+
+	t := l.totals[campaign]
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		t.PromptTokens += entry.PromptTokens
+		t.CompletionTokens += entry.CompletionTokens
+		t.CostUSD += entry.CostUSD
+	}
+
+	l.totals[campaign] = t
+}
+
+// path returns the usage.jsonl path for campaign
+func (l *Ledger) path(campaign string) string {
+	return filepath.Join(l.basePath, campaign, "usage.jsonl")
+}
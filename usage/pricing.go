@@ -0,0 +1,41 @@
+package usage
+
+import "fmt"
+
+// Price is the per-million-token cost for a (provider, model) pair
+type Price struct {
+	PromptPerMillion     float64
+	CompletionPerMillion float64
+}
+
+// pricingTable holds known (provider, model) prices, keyed by
+// "<provider>:<model>". Entries are approximate list prices in USD and are
+// meant for rough cost tracking, not billing reconciliation
+var pricingTable = map[string]Price{
+	"openai:gpt-4o":                        {PromptPerMillion: 2.50, CompletionPerMillion: 10.00},
+	"openai:gpt-4o-mini":                   {PromptPerMillion: 0.15, CompletionPerMillion: 0.60},
+	"z.ai:claude-3-5-sonnet-20240228":      {PromptPerMillion: 3.00, CompletionPerMillion: 15.00},
+	"anthropic:claude-3-5-sonnet-20241022": {PromptPerMillion: 3.00, CompletionPerMillion: 15.00},
+	"anthropic:claude-3-haiku-20240307":    {PromptPerMillion: 0.25, CompletionPerMillion: 1.25},
+	"gemini:gemini-1.5-pro":                {PromptPerMillion: 1.25, CompletionPerMillion: 5.00},
+	"gemini:gemini-1.5-flash":              {PromptPerMillion: 0.075, CompletionPerMillion: 0.30},
+	"minimax:abab6.5s-chat":                {PromptPerMillion: 0.50, CompletionPerMillion: 1.50},
+}
+
+// pricingKey builds the pricingTable lookup key for a (provider, model) pair
+func pricingKey(provider, model string) string {
+	return fmt.Sprintf("%s:%s", provider, model)
+}
+
+// Cost computes the USD cost of a request from its token counts. Unknown
+// (provider, model) pairs cost 0 rather than erroring, since a missing
+// price entry shouldn't block accounting for known ones
+func Cost(provider, model string, promptTokens, completionTokens int) float64 {
+	price, ok := pricingTable[pricingKey(provider, model)]
+	if !ok {
+		return 0
+	}
+
+	return float64(promptTokens)/1_000_000*price.PromptPerMillion +
+		float64(completionTokens)/1_000_000*price.CompletionPerMillion
+}
@@ -0,0 +1,42 @@
+package workqueue
+
+import "time"
+
+// tokenBucket is a simple token-bucket rate limiter: up to capacity
+// tokens refill continuously over refillEvery. It is not safe for
+// concurrent use on its own - Queue always calls it with its own mu held
+type tokenBucket struct {
+	capacity    float64
+	tokens      float64
+	refillEvery time.Duration
+	last        time.Time
+}
+
+func newTokenBucket(capacity int, refillEvery time.Duration) *tokenBucket {
+	return &tokenBucket{
+		capacity:    float64(capacity),
+		tokens:      float64(capacity),
+		refillEvery: refillEvery,
+		last:        time.Now(),
+	}
+}
+
+func (b *tokenBucket) refill() {
+	elapsed := time.Since(b.last)
+	b.tokens += elapsed.Seconds() / b.refillEvery.Seconds() * b.capacity
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+	b.last = time.Now()
+}
+
+// Peek reports whether a token is currently available, without spending it
+func (b *tokenBucket) Peek() bool {
+	b.refill()
+	return b.tokens >= 1
+}
+
+// Take spends one token; callers must have confirmed with Peek first
+func (b *tokenBucket) Take() {
+	b.tokens--
+}
@@ -0,0 +1,39 @@
+package workqueue
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// MetricsHandler renders queue depth per priority, drop count, and retry
+// count in Prometheus text exposition format, for main.go to mount at
+// /metrics. Hand-rolled rather than pulling in the prometheus client
+// library, since this is the only metric this bot exposes so far
+func (q *Queue) MetricsHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q.metrics.mu.Lock()
+		depth := make(map[Priority]int, len(q.metrics.depth))
+		for p, n := range q.metrics.depth {
+			depth[p] = n
+		}
+		dropped := q.metrics.dropped
+		retried := q.metrics.retried
+		q.metrics.mu.Unlock()
+
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+
+		fmt.Fprintln(w, "# HELP workqueue_depth Number of jobs waiting per priority level")
+		fmt.Fprintln(w, "# TYPE workqueue_depth gauge")
+		for _, p := range priorityOrder {
+			fmt.Fprintf(w, "workqueue_depth{priority=%q} %d\n", p.String(), depth[p])
+		}
+
+		fmt.Fprintln(w, "# HELP workqueue_dropped_total Jobs dropped after exhausting retries or missing a handler")
+		fmt.Fprintln(w, "# TYPE workqueue_dropped_total counter")
+		fmt.Fprintf(w, "workqueue_dropped_total %d\n", dropped)
+
+		fmt.Fprintln(w, "# HELP workqueue_retried_total Jobs retried after a handler error")
+		fmt.Fprintln(w, "# TYPE workqueue_retried_total counter")
+		fmt.Fprintf(w, "workqueue_retried_total %d\n", retried)
+	}
+}
@@ -0,0 +1,415 @@
+// Package workqueue provides a shared, priority-aware, rate-limited job
+// queue for outbound Telegram/LLM work (streamed narration chunks, command
+// replies, background maintenance), so callers don't each need their own
+// ad-hoc goroutine + channel (see the queue this replaces in
+// telegram.Streamer). Jobs are persisted to basePath/queue as JSON files,
+// so an in-flight GM narration survives a crash and is picked up again via
+// LoadPersisted
+package workqueue
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Priority orders jobs within the queue; lower values are drained first
+type Priority int
+
+const (
+	PriorityCommand     Priority = 0
+	PriorityStreamChunk Priority = 1
+	PriorityBackground  Priority = 2
+)
+
+// priorityOrder is the fixed drain order next() walks
+var priorityOrder = []Priority{PriorityCommand, PriorityStreamChunk, PriorityBackground}
+
+func (p Priority) String() string {
+	switch p {
+	case PriorityCommand:
+		return "command"
+	case PriorityStreamChunk:
+		return "stream_chunk"
+	case PriorityBackground:
+		return "background"
+	default:
+		return "unknown"
+	}
+}
+
+// Job is one unit of outbound work, serializable to JSON so it survives a
+// crash while queued. Kind selects which registered Handler executes it;
+// Data carries whatever that Handler needs (e.g. the message text)
+type Job struct {
+	ID        string
+	Kind      string
+	Priority  Priority
+	ChatID    int64
+	Data      map[string]string
+	CreatedAt time.Time
+	Attempts  int
+
+	// done and messageID let EnqueueAndWait/EnqueueAndWaitForMessage block
+	// on this specific job's outcome. Unexported, so they're skipped by
+	// JSON (de)serialization - a job reloaded via LoadPersisted after a
+	// crash has no waiter left to notify anyway
+	done      chan error
+	messageID *int
+}
+
+// SetMessageID records the Telegram message ID a "send" Handler created,
+// for EnqueueAndWaitForMessage callers that need to edit it afterwards.
+// A no-op for jobs enqueued without EnqueueAndWaitForMessage
+func (j Job) SetMessageID(id int) {
+	if j.messageID != nil {
+		*j.messageID = id
+	}
+}
+
+// Handler executes one Job. A non-nil error causes the job to be
+// retried - after its After if the error is a *RetryableError, after a
+// flat second otherwise - up to Queue's maxAttempts, after which the job
+// is dropped and counted in the workqueue_dropped_total metric (see
+// MetricsHandler)
+type Handler func(job Job) error
+
+// RetryableError marks a Handler error that should be retried after
+// exactly After, e.g. Telegram's 429 Too Many Requests honoring its
+// retry_after field, rather than the default flat backoff
+type RetryableError struct {
+	Err   error
+	After time.Duration
+}
+
+func (e *RetryableError) Error() string { return e.Err.Error() }
+func (e *RetryableError) Unwrap() error { return e.Err }
+
+// Metrics are the counters Queue.MetricsHandler exposes
+type Metrics struct {
+	mu      sync.Mutex
+	depth   map[Priority]int
+	dropped int
+	retried int
+}
+
+// Queue is a priority queue of Jobs, enforcing Telegram's global
+// (30 msg/sec) and per-chat (1 msg/sec) rate limits with token buckets
+type Queue struct {
+	basePath string
+	mu       sync.Mutex
+	handlers map[string]Handler
+	queues   map[Priority][]Job
+
+	global      *tokenBucket
+	perChat     map[int64]*tokenBucket
+	maxAttempts int
+
+	metrics Metrics
+	wakeup  chan struct{}
+}
+
+// NewQueue creates a Queue persisting pending jobs under basePath/queue
+func NewQueue(basePath string) (*Queue, error) {
+	dir := filepath.Join(basePath, "queue")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create workqueue directory: %w", err)
+	}
+
+	q := &Queue{
+		basePath:    basePath,
+		handlers:    make(map[string]Handler),
+		queues:      make(map[Priority][]Job),
+		global:      newTokenBucket(30, time.Second),
+		perChat:     make(map[int64]*tokenBucket),
+		maxAttempts: 5,
+		wakeup:      make(chan struct{}, 1),
+	}
+	q.metrics.depth = make(map[Priority]int)
+	return q, nil
+}
+
+// RegisterHandler associates kind with the function that executes Jobs of
+// that kind. Jobs enqueued with an unregistered Kind are dropped
+func (q *Queue) RegisterHandler(kind string, handler Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = handler
+}
+
+func (q *Queue) jobPath(id string) string {
+	return filepath.Join(q.basePath, "queue", id+".json")
+}
+
+// Enqueue persists job to disk and schedules it for delivery once its
+// priority's turn comes and its rate limit has a token available
+func (q *Queue) Enqueue(job Job) error {
+	if job.ID == "" {
+		job.ID = fmt.Sprintf("%d-%d", time.Now().UnixNano(), job.ChatID)
+	}
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now()
+	}
+
+	if err := q.persist(job); err != nil {
+		return err
+	}
+
+	q.enqueueLocal(job)
+	return nil
+}
+
+// ErrTimeout is returned by EnqueueAndWait/EnqueueAndWaitForMessage if the
+// job hasn't reached a terminal state (delivered, or dropped after
+// exhausting retries) within the given timeout
+var ErrTimeout = errors.New("workqueue: timed out waiting for job")
+
+// EnqueueAndWait behaves like Enqueue, but blocks up to timeout for the
+// job to reach a terminal state and returns its final error, if any. Use
+// for callers that need a synchronous result (e.g. editing a message in
+// place) rather than fire-and-forget
+func (q *Queue) EnqueueAndWait(job Job, timeout time.Duration) error {
+	done := make(chan error, 1)
+	job.done = done
+
+	if err := q.enqueueWaiting(job); err != nil {
+		return err
+	}
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(timeout):
+		return ErrTimeout
+	}
+}
+
+// EnqueueAndWaitForMessage behaves like EnqueueAndWait, but also returns
+// the Telegram message ID the Handler recorded via Job.SetMessageID - for
+// jobs (like a new Streamer message) whose caller needs to edit it later
+func (q *Queue) EnqueueAndWaitForMessage(job Job, timeout time.Duration) (int, error) {
+	done := make(chan error, 1)
+	var messageID int
+	job.done = done
+	job.messageID = &messageID
+
+	if err := q.enqueueWaiting(job); err != nil {
+		return 0, err
+	}
+
+	select {
+	case err := <-done:
+		return messageID, err
+	case <-time.After(timeout):
+		return 0, ErrTimeout
+	}
+}
+
+func (q *Queue) enqueueWaiting(job Job) error {
+	if job.ID == "" {
+		job.ID = fmt.Sprintf("%d-%d", time.Now().UnixNano(), job.ChatID)
+	}
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = time.Now()
+	}
+
+	if err := q.persist(job); err != nil {
+		return err
+	}
+
+	q.enqueueLocal(job)
+	return nil
+}
+
+func (q *Queue) persist(job Job) error {
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode job %s: %w", job.ID, err)
+	}
+	if err := os.WriteFile(q.jobPath(job.ID), data, 0644); err != nil {
+		return fmt.Errorf("failed to persist job %s: %w", job.ID, err)
+	}
+	return nil
+}
+
+func (q *Queue) enqueueLocal(job Job) {
+	q.mu.Lock()
+	q.queues[job.Priority] = append(q.queues[job.Priority], job)
+	q.mu.Unlock()
+
+	q.metrics.mu.Lock()
+	q.metrics.depth[job.Priority]++
+	q.metrics.mu.Unlock()
+
+	select {
+	case q.wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// LoadPersisted re-enqueues every job left on disk from before a crash or
+// restart. Call once at startup, before Run
+func (q *Queue) LoadPersisted() (int, error) {
+	entries, err := os.ReadDir(filepath.Join(q.basePath, "queue"))
+	if err != nil {
+		return 0, fmt.Errorf("failed to read workqueue directory: %w", err)
+	}
+
+	count := 0
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(q.basePath, "queue", entry.Name()))
+		if err != nil {
+			log.Printf("[WORKQUEUE] Failed to read persisted job %s: %v", entry.Name(), err)
+			continue
+		}
+
+		var job Job
+		if err := json.Unmarshal(data, &job); err != nil {
+			log.Printf("[WORKQUEUE] Failed to parse persisted job %s: %v", entry.Name(), err)
+			continue
+		}
+
+		q.enqueueLocal(job)
+		count++
+	}
+	return count, nil
+}
+
+// Run drains the queue until stop is closed, dispatching jobs to their
+// registered Handler in priority order while respecting the global and
+// per-chat rate limits. Intended to run in its own goroutine
+func (q *Queue) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-q.wakeup:
+		case <-ticker.C:
+		}
+		q.drain()
+	}
+}
+
+func (q *Queue) drain() {
+	for {
+		job, ok := q.next()
+		if !ok {
+			return
+		}
+		q.dispatch(job)
+	}
+}
+
+// next pops the highest-priority job whose chat and global rate limit
+// both have a token available, leaving lower-priority or rate-limited
+// jobs in the queue for the next tick
+func (q *Queue) next() (Job, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, p := range priorityOrder {
+		jobs := q.queues[p]
+		for i, job := range jobs {
+			chatBucket := q.chatBucketLocked(job.ChatID)
+			if !q.global.Peek() || !chatBucket.Peek() {
+				continue
+			}
+			q.global.Take()
+			chatBucket.Take()
+
+			q.queues[p] = append(append([]Job{}, jobs[:i]...), jobs[i+1:]...)
+			q.metrics.mu.Lock()
+			q.metrics.depth[p]--
+			q.metrics.mu.Unlock()
+			return job, true
+		}
+	}
+	return Job{}, false
+}
+
+func (q *Queue) chatBucketLocked(chatID int64) *tokenBucket {
+	b, ok := q.perChat[chatID]
+	if !ok {
+		b = newTokenBucket(1, time.Second)
+		q.perChat[chatID] = b
+	}
+	return b
+}
+
+func (q *Queue) dispatch(job Job) {
+	q.mu.Lock()
+	handler, ok := q.handlers[job.Kind]
+	q.mu.Unlock()
+
+	if !ok {
+		err := fmt.Errorf("no handler registered for job kind %q", job.Kind)
+		log.Printf("[WORKQUEUE] %v, dropping %s", err, job.ID)
+		q.drop(job, err)
+		return
+	}
+
+	err := handler(job)
+	if err == nil {
+		q.complete(job)
+		return
+	}
+
+	if job.Attempts >= q.maxAttempts {
+		log.Printf("[WORKQUEUE] Job %s (%s) failed permanently after %d attempts: %v", job.ID, job.Kind, job.Attempts, err)
+		q.drop(job, err)
+		return
+	}
+
+	var retryable *RetryableError
+	delay := time.Second
+	if errors.As(err, &retryable) {
+		delay = retryable.After
+	}
+
+	job.Attempts++
+	q.metrics.mu.Lock()
+	q.metrics.retried++
+	q.metrics.mu.Unlock()
+
+	if err := q.persist(job); err != nil {
+		log.Printf("[WORKQUEUE] Failed to persist job %s before retry: %v", job.ID, err)
+	}
+
+	time.AfterFunc(delay, func() {
+		q.enqueueLocal(job)
+	})
+}
+
+func (q *Queue) complete(job Job) {
+	if err := os.Remove(q.jobPath(job.ID)); err != nil && !os.IsNotExist(err) {
+		log.Printf("[WORKQUEUE] Failed to remove completed job %s: %v", job.ID, err)
+	}
+	if job.done != nil {
+		job.done <- nil
+	}
+}
+
+func (q *Queue) drop(job Job, cause error) {
+	q.metrics.mu.Lock()
+	q.metrics.dropped++
+	q.metrics.mu.Unlock()
+
+	if err := os.Remove(q.jobPath(job.ID)); err != nil && !os.IsNotExist(err) {
+		log.Printf("[WORKQUEUE] Failed to remove dropped job %s: %v", job.ID, err)
+	}
+	if job.done != nil {
+		job.done <- cause
+	}
+}
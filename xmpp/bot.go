@@ -0,0 +1,237 @@
+package xmpp
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"strings"
+	"sync"
+
+	"wfrp-bot/chat"
+	"wfrp-bot/game"
+)
+
+// CommandHandler handles a command typed in a joined MUC room, mirroring
+// telegram.CommandHandler
+type CommandHandler func(roomJID, nick string, args []string) error
+
+// Middleware processes an incoming room message before any handler or
+// session runs, mirroring telegram.Middleware
+type Middleware func(roomJID, nick, body string) (bool, error)
+
+// Bot is the XMPP-side mirror of telegram.Bot: it joins MUC rooms over a
+// XEP-0114 Component connection and implements chat.Transport, so a
+// game.Session already running on Telegram can also be followed from any
+// XMPP client via game.SessionManager.AttachTransport
+type Bot struct {
+	component      *Component
+	nick           string
+	handlers       map[string]CommandHandler
+	middleware     []Middleware
+	sessionManager *game.SessionManager
+	mu             sync.RWMutex
+	stopChan       chan struct{}
+	wg             sync.WaitGroup
+
+	rooms   map[string]int64 // roomJID -> chatID, populated by JoinRoom
+	roomsMu sync.RWMutex
+}
+
+var _ chat.Transport = (*Bot)(nil)
+
+// NewBot creates an XMPP bot around an already-handshaken Component
+func NewBot(component *Component, nick string) *Bot {
+	return &Bot{
+		component:  component,
+		nick:       nick,
+		handlers:   make(map[string]CommandHandler),
+		middleware: make([]Middleware, 0),
+		stopChan:   make(chan struct{}),
+		rooms:      make(map[string]int64),
+	}
+}
+
+// RoomChatID derives a deterministic chatID from a MUC room JID (e.g.
+// "table1@conference.example.org"), so the same room always maps to the
+// same game.Session across restarts
+func RoomChatID(roomJID string) int64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(roomJID))
+	id := int64(h.Sum64())
+	if id < 0 {
+		id = -id
+	}
+	return id
+}
+
+// Name identifies this transport for chat.Transport
+func (b *Bot) Name() string { return "xmpp" }
+
+// AddCommand registers a command handler, mirroring telegram.Bot.AddCommand
+func (b *Bot) AddCommand(name string, handler CommandHandler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers[name] = handler
+	log.Printf("[XMPP] Registered command: /%s", name)
+}
+
+// AddMiddleware adds middleware to the bot
+func (b *Bot) AddMiddleware(mw Middleware) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.middleware = append(b.middleware, mw)
+}
+
+// SetSessionManager sets the session manager used to process non-command
+// room messages, mirroring telegram.Bot.SetSessionManager
+func (b *Bot) SetSessionManager(sm *game.SessionManager) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.sessionManager = sm
+}
+
+// JoinRoom joins roomJID under b's nick and maps it to chatID. Pair this
+// with game.SessionManager.AttachTransport(chatID, b) so the room follows
+// that chat's GM narration
+func (b *Bot) JoinRoom(roomJID string, chatID int64) error {
+	if err := b.component.JoinRoom(roomJID, b.nick); err != nil {
+		return fmt.Errorf("failed to join MUC room %s: %w", roomJID, err)
+	}
+
+	b.roomsMu.Lock()
+	b.rooms[roomJID] = chatID
+	b.roomsMu.Unlock()
+
+	log.Printf("[XMPP] Joined room %s as %s (chat %d)", roomJID, b.nick, chatID)
+	return nil
+}
+
+// Start begins receiving stanzas from the component in its own goroutine
+func (b *Bot) Start() {
+	b.wg.Add(1)
+	go b.receiveLoop()
+}
+
+// Stop gracefully stops the bot
+func (b *Bot) Stop() {
+	close(b.stopChan)
+	_ = b.component.Close()
+	b.wg.Wait()
+}
+
+func (b *Bot) receiveLoop() {
+	defer b.wg.Done()
+
+	for {
+		select {
+		case <-b.stopChan:
+			return
+		default:
+		}
+
+		kind, from, _, body, err := b.component.Recv()
+		if err != nil {
+			log.Printf("[XMPP] Component connection closed: %v", err)
+			return
+		}
+		if kind != "message" || body == "" {
+			continue
+		}
+
+		roomJID, nick := splitOccupantJID(from)
+		if nick == b.nick {
+			continue // ignore our own reflected messages
+		}
+
+		if err := b.handleRoomMessage(roomJID, nick, body); err != nil {
+			log.Printf("[XMPP] Failed to handle message from %s: %v", from, err)
+		}
+	}
+}
+
+// splitOccupantJID splits a MUC occupant JID (room@service/nick) into its
+// room and nick parts
+func splitOccupantJID(occupant string) (room, nick string) {
+	room, nick, found := strings.Cut(occupant, "/")
+	if !found {
+		return occupant, ""
+	}
+	return room, nick
+}
+
+func (b *Bot) handleRoomMessage(roomJID, nick, body string) error {
+	b.mu.RLock()
+	middleware := append([]Middleware{}, b.middleware...)
+	b.mu.RUnlock()
+
+	for _, mw := range middleware {
+		cont, err := mw(roomJID, nick, body)
+		if err != nil {
+			return err
+		}
+		if !cont {
+			return nil
+		}
+	}
+
+	if strings.HasPrefix(body, "/") {
+		fields := strings.Fields(body[1:])
+		if len(fields) == 0 {
+			return nil
+		}
+		command := fields[0]
+		args := fields[1:]
+
+		b.mu.RLock()
+		handler, exists := b.handlers[command]
+		b.mu.RUnlock()
+
+		if !exists {
+			return nil
+		}
+		return handler(roomJID, nick, args)
+	}
+
+	b.roomsMu.RLock()
+	chatID, known := b.rooms[roomJID]
+	b.roomsMu.RUnlock()
+	if !known || b.sessionManager == nil {
+		return nil
+	}
+
+	output, err := b.sessionManager.ProcessPlayerMessage(chatID, nick, body)
+	if err != nil {
+		return b.SendMessage(chatID, fmt.Sprintf("Ошибка обработки сообщения: %v", err))
+	}
+	if output != nil {
+		return b.SendMessage(chatID, output.Content)
+	}
+	return nil
+}
+
+// SendMessage sends a groupchat message to the MUC room mapped to chatID
+func (b *Bot) SendMessage(chatID int64, text string) error {
+	roomJID, ok := b.roomJID(chatID)
+	if !ok {
+		return fmt.Errorf("no XMPP room joined for chat %d", chatID)
+	}
+	return b.component.SendMessage(roomJID, "groupchat", text)
+}
+
+// SendReply sends text to chatID's room. MUC groupchat has no native
+// threaded reply, so this behaves identically to SendMessage - messageID
+// is accepted only to satisfy chat.Transport
+func (b *Bot) SendReply(_ int, chatID int64, text string) error {
+	return b.SendMessage(chatID, text)
+}
+
+func (b *Bot) roomJID(chatID int64) (string, bool) {
+	b.roomsMu.RLock()
+	defer b.roomsMu.RUnlock()
+	for room, id := range b.rooms {
+		if id == chatID {
+			return room, true
+		}
+	}
+	return "", false
+}
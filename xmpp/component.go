@@ -0,0 +1,185 @@
+// Package xmpp provides a minimal XEP-0114 ("Jabber Component Protocol")
+// client, so a WFRP session can be joined from any XMPP client via a MUC
+// room, without pulling in a third-party XMPP library. It hand-rolls just
+// enough of the protocol - the component handshake and <message>/<presence>
+// stanzas - to mirror telegram.Bot's surface (see Bot)
+package xmpp
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+)
+
+// Component is a raw XEP-0114 component connection: the server hands it
+// a stream ID, it proves knowledge of sharedSecret by replying with the
+// SHA-1 digest of streamID+secret, and from then on can send and receive
+// arbitrary stanzas addressed to/from its componentJID
+type Component struct {
+	conn    net.Conn
+	decoder *xml.Decoder
+	jid     string
+	mu      sync.Mutex // guards writes to conn
+}
+
+// Dial connects to a jabber component port (typically 5347), completes
+// the XEP-0114 handshake using sharedSecret, and returns a ready Component
+func Dial(addr, componentJID, sharedSecret string) (*Component, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to XMPP component port: %w", err)
+	}
+
+	c := &Component{
+		conn:    conn,
+		jid:     componentJID,
+		decoder: xml.NewDecoder(bufio.NewReader(conn)),
+	}
+
+	if _, err := fmt.Fprintf(conn, "<stream:stream xmlns='jabber:component:accept' xmlns:stream='http://etherx.jabber.org/streams' to='%s'>", componentJID); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open component stream: %w", err)
+	}
+
+	streamID, err := c.readStreamID()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	digest := sha1.Sum([]byte(streamID + sharedSecret))
+	if _, err := fmt.Fprintf(conn, "<handshake>%s</handshake>", hex.EncodeToString(digest[:])); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send handshake: %w", err)
+	}
+
+	if err := c.expectHandshakeAck(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	log.Printf("[XMPP] Component %s connected to %s", componentJID, addr)
+	return c, nil
+}
+
+func (c *Component) readStreamID() (string, error) {
+	for {
+		tok, err := c.decoder.Token()
+		if err != nil {
+			return "", fmt.Errorf("failed to read stream header: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "stream" {
+			continue
+		}
+		for _, attr := range start.Attr {
+			if attr.Name.Local == "id" {
+				return attr.Value, nil
+			}
+		}
+		return "", fmt.Errorf("stream header missing id attribute")
+	}
+}
+
+func (c *Component) expectHandshakeAck() error {
+	for {
+		tok, err := c.decoder.Token()
+		if err != nil {
+			return fmt.Errorf("failed to read handshake ack: %w", err)
+		}
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if start.Name.Local != "handshake" {
+			return fmt.Errorf("unexpected element %q while waiting for handshake ack", start.Name.Local)
+		}
+		return c.decoder.Skip()
+	}
+}
+
+// Send writes a raw stanza to the stream
+func (c *Component) Send(stanza string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := io.WriteString(c.conn, stanza)
+	return err
+}
+
+// SendMessage sends a <message> stanza of msgType (e.g. "groupchat",
+// "chat") with body to "to", XML-escaping body
+func (c *Component) SendMessage(to, msgType, body string) error {
+	var escaped bytes.Buffer
+	if err := xml.EscapeText(&escaped, []byte(body)); err != nil {
+		return fmt.Errorf("failed to escape message body: %w", err)
+	}
+	return c.Send(fmt.Sprintf("<message from=%q to=%q type=%q><body>%s</body></message>",
+		c.jid, to, msgType, escaped.String()))
+}
+
+// JoinRoom sends the presence stanza that joins a MUC room under nick,
+// per XEP-0045
+func (c *Component) JoinRoom(roomJID, nick string) error {
+	return c.Send(fmt.Sprintf("<presence from=%q to=%q><x xmlns='http://jabber.org/protocol/muc'/></presence>",
+		c.jid, roomJID+"/"+nick))
+}
+
+// Recv blocks for the next top-level stanza and returns its element name
+// ("message", "presence", ...), the from/to JIDs, and message body (empty
+// for non-message stanzas)
+func (c *Component) Recv() (kind, from, to, body string, err error) {
+	for {
+		tok, err := c.decoder.Token()
+		if err != nil {
+			return "", "", "", "", err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+
+		switch start.Name.Local {
+		case "message":
+			var m struct {
+				From string `xml:"from,attr"`
+				To   string `xml:"to,attr"`
+				Body string `xml:"body"`
+			}
+			if err := c.decoder.DecodeElement(&m, &start); err != nil {
+				return "", "", "", "", fmt.Errorf("failed to decode message stanza: %w", err)
+			}
+			return "message", m.From, m.To, m.Body, nil
+
+		case "presence":
+			var p struct {
+				From string `xml:"from,attr"`
+				To   string `xml:"to,attr"`
+			}
+			if err := c.decoder.DecodeElement(&p, &start); err != nil {
+				return "", "", "", "", fmt.Errorf("failed to decode presence stanza: %w", err)
+			}
+			return "presence", p.From, p.To, "", nil
+
+		default:
+			if err := c.decoder.Skip(); err != nil {
+				return "", "", "", "", err
+			}
+		}
+	}
+}
+
+// Close closes the stream and underlying connection
+func (c *Component) Close() error {
+	c.mu.Lock()
+	_, _ = io.WriteString(c.conn, "</stream:stream>")
+	c.mu.Unlock()
+	return c.conn.Close()
+}